@@ -0,0 +1,98 @@
+package nfsv3driver
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"sync"
+
+	"code.cloudfoundry.org/goshims/ioutilshim"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+// MinAdminTokenLength keeps admin tokens out of reach of guessing
+const MinAdminTokenLength = 32
+
+type ReloadableAdminToken interface {
+	driveradmin.AdminToken
+	Reload(logger lager.Logger) error
+}
+
+type fileAdminToken struct {
+	ioutil ioutilshim.Ioutil
+	path   string
+
+	lock    sync.RWMutex
+	digests [][sha256.Size]byte
+}
+
+// NewFileAdminToken accepts the tokens in a file, one per line, so that a new token can be rolled out before the
+// old one is removed
+func NewFileAdminToken(logger lager.Logger, ioutil ioutilshim.Ioutil, path string) (ReloadableAdminToken, error) {
+	t := &fileAdminToken{
+		ioutil: ioutil,
+		path:   path,
+	}
+
+	if err := t.Reload(logger); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *fileAdminToken) Reload(logger lager.Logger) error {
+	logger = logger.Session("admin-token-reload", lager.Data{"path": t.path})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	contents, err := t.ioutil.ReadFile(t.path)
+	if err != nil {
+		logger.Error("failed-to-read", err)
+		return err
+	}
+
+	var digests [][sha256.Size]byte
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for line := 1; scanner.Scan(); line++ {
+		token := bytes.TrimSpace(scanner.Bytes())
+		if len(token) == 0 {
+			continue
+		}
+		if len(token) < MinAdminTokenLength {
+			err := fmt.Errorf("%s:%d: admin tokens must be at least %d characters long", t.path, line, MinAdminTokenLength)
+			logger.Error("failed-to-parse", err)
+			return err
+		}
+		digests = append(digests, sha256.Sum256(token))
+	}
+	if len(digests) == 0 {
+		err := fmt.Errorf("no admin tokens found in %s", t.path)
+		logger.Error("failed-to-parse", err)
+		return err
+	}
+
+	t.lock.Lock()
+	t.digests = digests
+	t.lock.Unlock()
+
+	logger.Info("loaded", lager.Data{"count": len(digests)})
+	return nil
+}
+
+// Matches compares digests, so that neither the comparison nor the tokens' lengths leak through timing
+func (t *fileAdminToken) Matches(token string) bool {
+	digest := sha256.Sum256([]byte(token))
+
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	matched := 0
+	for _, candidate := range t.digests {
+		matched |= subtle.ConstantTimeCompare(digest[:], candidate[:])
+	}
+	return matched == 1
+}
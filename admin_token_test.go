@@ -0,0 +1,116 @@
+package nfsv3driver_test
+
+import (
+	"errors"
+	"strings"
+
+	"code.cloudfoundry.org/goshims/ioutilshim/ioutil_fake"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("FileAdminToken", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeIoutil *ioutil_fake.FakeIoutil
+		contents   string
+		subject    nfsv3driver.ReloadableAdminToken
+		err        error
+
+		token      = strings.Repeat("a", nfsv3driver.MinAdminTokenLength)
+		otherToken = strings.Repeat("b", nfsv3driver.MinAdminTokenLength)
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("admin-token")
+		fakeIoutil = &ioutil_fake.FakeIoutil{}
+		fakeIoutil.ReadFileStub = func(string) ([]byte, error) {
+			return []byte(contents), nil
+		}
+		contents = token + "\n"
+	})
+
+	JustBeforeEach(func() {
+		subject, err = nfsv3driver.NewFileAdminToken(logger, fakeIoutil, "/var/vcap/jobs/nfsv3driver/config/admin_token")
+	})
+
+	It("reads the token file", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeIoutil.ReadFileArgsForCall(0)).To(Equal("/var/vcap/jobs/nfsv3driver/config/admin_token"))
+		Expect(logger).To(gbytes.Say(`admin-token-reload.loaded.*"count":1`))
+	})
+
+	It("matches its token", func() {
+		Expect(subject.Matches(token)).To(BeTrue())
+	})
+
+	It("does not match other tokens", func() {
+		Expect(subject.Matches(otherToken)).To(BeFalse())
+		Expect(subject.Matches(token[1:])).To(BeFalse())
+		Expect(subject.Matches("")).To(BeFalse())
+	})
+
+	It("does not log the token", func() {
+		Expect(logger.Buffer().Contents()).NotTo(ContainSubstring(token))
+	})
+
+	Context("when the file holds several tokens", func() {
+		BeforeEach(func() {
+			contents = "\n  " + token + "  \n\n" + otherToken
+		})
+
+		It("matches each of them", func() {
+			Expect(subject.Matches(token)).To(BeTrue())
+			Expect(subject.Matches(otherToken)).To(BeTrue())
+		})
+	})
+
+	Context("when the token is rotated", func() {
+		It("matches only the new token", func() {
+			contents = otherToken
+			Expect(subject.Reload(logger)).To(Succeed())
+			Expect(subject.Matches(token)).To(BeFalse())
+			Expect(subject.Matches(otherToken)).To(BeTrue())
+		})
+
+		It("keeps the previous token when the new file is invalid", func() {
+			contents = ""
+			Expect(subject.Reload(logger)).To(MatchError("no admin tokens found in /var/vcap/jobs/nfsv3driver/config/admin_token"))
+			Expect(subject.Matches(token)).To(BeTrue())
+		})
+	})
+
+	Context("when a token is too short", func() {
+		BeforeEach(func() {
+			contents = token + "\nshort\n"
+		})
+
+		It("fails", func() {
+			Expect(err).To(MatchError("/var/vcap/jobs/nfsv3driver/config/admin_token:2: admin tokens must be at least 32 characters long"))
+		})
+	})
+
+	Context("when the file is empty", func() {
+		BeforeEach(func() {
+			contents = "\n"
+		})
+
+		It("fails", func() {
+			Expect(err).To(MatchError("no admin tokens found in /var/vcap/jobs/nfsv3driver/config/admin_token"))
+		})
+	})
+
+	Context("when the file cannot be read", func() {
+		BeforeEach(func() {
+			fakeIoutil.ReadFileStub = nil
+			fakeIoutil.ReadFileReturns(nil, errors.New("permission denied"))
+		})
+
+		It("fails", func() {
+			Expect(err).To(MatchError("permission denied"))
+		})
+	})
+})
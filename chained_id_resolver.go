@@ -0,0 +1,69 @@
+package nfsv3driver
+
+import (
+	"fmt"
+	"regexp"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/lager/v3"
+)
+
+type IdResolverSource struct {
+	// Name identifies the source in logs
+	Name string
+	// Pattern restricts the source to matching usernames. A nil pattern matches every username.
+	Pattern *regexp.Regexp
+	// Authoritative sources end the chain when they do not know a username, instead of passing it on.
+	Authoritative bool
+	Resolver      IdResolver
+}
+
+type chainedIdResolver struct {
+	sources []IdResolverSource
+}
+
+// NewChainedIdResolver consults the sources in order. The first source that knows the username decides the
+// outcome: a wrong password there is not retried against later sources.
+func NewChainedIdResolver(sources []IdResolverSource) IdResolver {
+	return &chainedIdResolver{sources: sources}
+}
+
+func (c *chainedIdResolver) Resolve(env dockerdriver.Env, username string, password string) (uid string, gid string, err error) {
+	logger := env.Logger().Session("chained-resolve", lager.Data{"username": username})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	for _, source := range c.sources {
+		if source.Pattern != nil && !source.Pattern.MatchString(username) {
+			logger.Debug("source-skipped", lager.Data{"source": source.Name})
+			continue
+		}
+
+		uid, gid, err = source.Resolver.Resolve(env, username, password)
+		if err == nil {
+			logger.Info("resolved", lager.Data{"source": source.Name, "uid": uid, "gid": gid})
+			return uid, gid, nil
+		}
+
+		if err != (dockerdriver.SafeError{SafeDescription: UserNotFoundErrorMessage}) || source.Authoritative {
+			logger.Info("rejected", lager.Data{"source": source.Name, "reason": err.Error()})
+			return "", "", err
+		}
+
+		logger.Info("user-not-found", lager.Data{"source": source.Name})
+	}
+
+	return "", "", dockerdriver.SafeError{SafeDescription: UserNotFoundErrorMessage}
+}
+
+// CheckConnection checks every source that depends on a directory server
+func (c *chainedIdResolver) CheckConnection(env dockerdriver.Env) error {
+	for _, source := range c.sources {
+		if checker, ok := source.Resolver.(ConnectionChecker); ok {
+			if err := checker.CheckConnection(env); err != nil {
+				return fmt.Errorf("%s: %s", source.Name, err.Error())
+			}
+		}
+	}
+	return nil
+}
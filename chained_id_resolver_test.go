@@ -0,0 +1,154 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("ChainedIdResolver", func() {
+	var (
+		logger    *lagertest.TestLogger
+		env       dockerdriver.Env
+		static    *nfsdriverfakes.FakeIdResolver
+		primary   *nfsdriverfakes.FakeIdResolver
+		secondary *nfsdriverfakes.FakeIdResolver
+		sources   []nfsv3driver.IdResolverSource
+		username  string
+		uid, gid  string
+		err       error
+
+		notFound = dockerdriver.SafeError{SafeDescription: nfsv3driver.UserNotFoundErrorMessage}
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("chained-id-resolver")
+		env = driverhttp.NewHttpDriverEnv(logger, context.TODO())
+
+		static = &nfsdriverfakes.FakeIdResolver{}
+		static.ResolveReturns("", "", notFound)
+		primary = &nfsdriverfakes.FakeIdResolver{}
+		primary.ResolveReturns("", "", notFound)
+		secondary = &nfsdriverfakes.FakeIdResolver{}
+		secondary.ResolveReturns("", "", notFound)
+
+		sources = []nfsv3driver.IdResolverSource{
+			{Name: "static", Resolver: static},
+			{Name: "primary", Pattern: regexp.MustCompile(`^[^@]+$`), Resolver: primary},
+			{Name: "secondary", Pattern: regexp.MustCompile(`@partner\.example\.com$`), Resolver: secondary},
+		}
+		username = "alice"
+	})
+
+	JustBeforeEach(func() {
+		uid, gid, err = nfsv3driver.NewChainedIdResolver(sources).Resolve(env, username, "secret")
+	})
+
+	Context("when the first source knows the user", func() {
+		BeforeEach(func() {
+			static.ResolveReturns("1001", "2001", nil)
+		})
+
+		It("answers from it without consulting later sources", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uid).To(Equal("1001"))
+			Expect(gid).To(Equal("2001"))
+			Expect(primary.ResolveCallCount()).To(BeZero())
+			Expect(logger).To(gbytes.Say(`chained-resolve.resolved.*"source":"static"`))
+		})
+	})
+
+	Context("when only a later source knows the user", func() {
+		BeforeEach(func() {
+			primary.ResolveReturns("1002", "2002", nil)
+		})
+
+		It("falls through to it", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uid).To(Equal("1002"))
+			Expect(static.ResolveCallCount()).To(Equal(1))
+			_, user, password := primary.ResolveArgsForCall(0)
+			Expect(user).To(Equal("alice"))
+			Expect(password).To(Equal("secret"))
+			Expect(logger).To(gbytes.Say(`"source":"primary"`))
+		})
+	})
+
+	Context("when a source rejects the password", func() {
+		BeforeEach(func() {
+			static.ResolveReturns("", "", dockerdriver.SafeError{SafeDescription: nfsv3driver.InvalidCredentialsErrorMessage})
+			primary.ResolveReturns("1002", "2002", nil)
+		})
+
+		It("does not try later sources", func() {
+			Expect(err).To(MatchError(nfsv3driver.InvalidCredentialsErrorMessage))
+			Expect(primary.ResolveCallCount()).To(BeZero())
+		})
+	})
+
+	Context("when the username only matches a later source's pattern", func() {
+		BeforeEach(func() {
+			username = "bob@partner.example.com"
+			secondary.ResolveReturns("1003", "2003", nil)
+		})
+
+		It("skips sources whose pattern does not match", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uid).To(Equal("1003"))
+			Expect(primary.ResolveCallCount()).To(BeZero())
+			Expect(static.ResolveCallCount()).To(Equal(1))
+		})
+	})
+
+	Context("when an authoritative source does not know the user", func() {
+		BeforeEach(func() {
+			sources[0].Authoritative = true
+			primary.ResolveReturns("1002", "2002", nil)
+		})
+
+		It("ends the chain", func() {
+			Expect(err).To(Equal(notFound))
+			Expect(primary.ResolveCallCount()).To(BeZero())
+		})
+	})
+
+	Context("when no source knows the user", func() {
+		It("reports that the user does not exist", func() {
+			Expect(err).To(Equal(notFound))
+			Expect(static.ResolveCallCount()).To(Equal(1))
+			Expect(primary.ResolveCallCount()).To(Equal(1))
+			Expect(secondary.ResolveCallCount()).To(BeZero())
+		})
+	})
+
+	Describe("CheckConnection", func() {
+		var checker *nfsdriverfakes.FakeConnectionChecker
+
+		BeforeEach(func() {
+			checker = &nfsdriverfakes.FakeConnectionChecker{}
+			sources[1].Resolver = struct {
+				nfsv3driver.IdResolver
+				nfsv3driver.ConnectionChecker
+			}{primary, checker}
+		})
+
+		It("checks the sources with a directory server", func() {
+			Expect(nfsv3driver.NewChainedIdResolver(sources).(nfsv3driver.ConnectionChecker).CheckConnection(env)).To(Succeed())
+			Expect(checker.CheckConnectionCallCount()).To(Equal(1))
+		})
+
+		It("names the source that failed", func() {
+			checker.CheckConnectionReturns(errors.New("LDAP server could not be reached"))
+			Expect(nfsv3driver.NewChainedIdResolver(sources).(nfsv3driver.ConnectionChecker).CheckConnection(env)).To(MatchError("primary: LDAP server could not be reached"))
+		})
+	})
+})
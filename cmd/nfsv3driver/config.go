@@ -0,0 +1,843 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagerflags"
+	"code.cloudfoundry.org/nfsv3driver"
+	"gopkg.in/yaml.v3"
+)
+
+// config holds every setting of the driver. It starts from the command line flags and LDAP_* environment
+// variables; settings in the file named by -config take precedence over both.
+type config struct {
+	ListenAddr  string             `yaml:"listen_addr"`
+	AdminAddr   string             `yaml:"admin_addr"`
+	DriversPath string             `yaml:"drivers_path"`
+	Transport   string             `yaml:"transport"`
+	MapfsPath   string             `yaml:"mapfs_path"`
+	MountDir    string             `yaml:"mount_dir"`
+	LogLevel    string             `yaml:"log_level"`
+	TLS         tlsSettings        `yaml:"tls"`
+	UnixSocket  unixSocketSettings `yaml:"unix_socket"`
+	Admin       adminSettings      `yaml:"admin"`
+
+	// IdResolvers are consulted in order: "static", "ldap" and "ldap:<name>" for a directory in ldap_sources
+	IdResolvers []string `yaml:"id_resolvers"`
+	// AuthoritativeIdResolvers reject the usernames they do not know instead of passing them to the next resolver
+	AuthoritativeIdResolvers []string              `yaml:"authoritative_id_resolvers"`
+	ResolverHelperUser       string                `yaml:"resolver_helper_user"`
+	StaticUsers              staticUsersSettings   `yaml:"static_users"`
+	Ldap                     ldapSettings          `yaml:"ldap"`
+	LdapSources              ldapSources           `yaml:"ldap_sources"`
+	LoginThrottle            loginThrottleSettings `yaml:"login_throttle"`
+	IdPolicy                 idPolicySettings      `yaml:"id_policy"`
+	Tokens                   tokenSettings         `yaml:"tokens"`
+	PasswordKey              passwordKeySettings   `yaml:"password_key"`
+}
+
+type tlsSettings struct {
+	RequireSSL         bool   `yaml:"require_ssl"`
+	CAFile             string `yaml:"ca_file"`
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+	ClientCertFile     string `yaml:"client_cert_file"`
+	ClientKeyFile      string `yaml:"client_key_file"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+	// PollInterval is how often cert_file, key_file and ca_file are checked for rotated certificates
+	PollInterval time.Duration `yaml:"poll_interval"`
+}
+
+// unixSocketSettings apply to the socket at listen_addr when transport is unix, and to the admin socket
+type unixSocketSettings struct {
+	// Mode is octal, e.g. "0660"
+	Mode  string `yaml:"mode"`
+	Owner string `yaml:"owner"`
+	Group string `yaml:"group"`
+	// AllowedPeerUids are comma separated ids and ranges
+	AllowedPeerUids string `yaml:"allowed_peer_uids"`
+}
+
+// adminSettings protect the admin server at admin_addr, or at socket when it is set
+type adminSettings struct {
+	// RequireSSL serves the admin API with mutual TLS, using the certificates of the driver server
+	RequireSSL        bool          `yaml:"require_ssl"`
+	TokenFile         string        `yaml:"token_file"`
+	TokenPollInterval time.Duration `yaml:"token_poll_interval"`
+	// HealthCheckTimeout bounds each check of /health/live and /health/ready
+	HealthCheckTimeout time.Duration `yaml:"health_check_timeout"`
+	// EvacuationTimeout and EvacuationParallelism bound how long and how many volumes at once /evacuate unmounts
+	EvacuationTimeout     time.Duration `yaml:"evacuation_timeout"`
+	EvacuationParallelism int           `yaml:"evacuation_parallelism"`
+
+	Socket     string             `yaml:"socket"`
+	UnixSocket unixSocketSettings `yaml:"unix_socket"`
+}
+
+type staticUsersSettings struct {
+	File            string        `yaml:"file"`
+	PollInterval    time.Duration `yaml:"poll_interval"`
+	UsernamePattern string        `yaml:"username_pattern"`
+}
+
+type ldapSettings struct {
+	Host            string                     `yaml:"host"`
+	Port            int                        `yaml:"port"`
+	Proto           string                     `yaml:"proto"`
+	UserFqdn        string                     `yaml:"user_fqdn"`
+	Timeout         time.Duration              `yaml:"timeout"`
+	SvcUser         string                     `yaml:"svc_user"`
+	SvcPass         string                     `yaml:"svc_pass"`
+	CACert          string                     `yaml:"ca_cert"`
+	CredentialFiles ldapCredentialFileSettings `yaml:"credential_files"`
+	UsernamePattern string                     `yaml:"username_pattern"`
+	Domains         []nfsv3driver.LdapDomain   `yaml:"domains"`
+	IdMapping       idMappingSettings          `yaml:"id_mapping"`
+	RequiredGroups  []string                   `yaml:"required_groups"`
+	GroupBaseDN     string                     `yaml:"group_base_dn"`
+	GroupLookup     string                     `yaml:"group_lookup"`
+	PageSize        uint32                     `yaml:"page_size"`
+	ChaseReferrals  bool                       `yaml:"chase_referrals"`
+	MaxReferralHops int                        `yaml:"max_referral_hops"`
+	ReferralTLS     string                     `yaml:"referral_tls"`
+}
+
+// ldapSources are further directories by name. Unlike the ldap block they are not read from LDAP_* variables.
+type ldapSources map[string]ldapSettings
+
+type ldapCredentialFileSettings struct {
+	SvcUser      string        `yaml:"svc_user"`
+	SvcPass      string        `yaml:"svc_pass"`
+	CACert       string        `yaml:"ca_cert"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+}
+
+type idMappingSettings struct {
+	Enabled   bool   `yaml:"enabled"`
+	RangeMin  uint32 `yaml:"range_min"`
+	RangeMax  uint32 `yaml:"range_max"`
+	RangeSize uint32 `yaml:"range_size"`
+}
+
+type loginThrottleSettings struct {
+	MaxUserFailures   int           `yaml:"max_user_failures"`
+	Backoff           time.Duration `yaml:"backoff"`
+	MaxBackoff        time.Duration `yaml:"max_backoff"`
+	MaxGlobalFailures int           `yaml:"max_global_failures"`
+	GlobalWindow      time.Duration `yaml:"global_window"`
+	MaxConcurrent     int           `yaml:"max_concurrent"`
+	FailureCacheTTL   time.Duration `yaml:"failure_cache_ttl"`
+}
+
+// idPolicySettings are comma separated ids and ranges, e.g. "1000-59999,65534"
+type idPolicySettings struct {
+	AllowedUids string `yaml:"allowed_uids"`
+	DeniedUids  string `yaml:"denied_uids"`
+	AllowedGids string `yaml:"allowed_gids"`
+	DeniedGids  string `yaml:"denied_gids"`
+}
+
+// tokenSettings reject replayed tokens when ReplayCacheSize is positive
+type tokenSettings struct {
+	KeysFile        string        `yaml:"keys_file"`
+	PollInterval    time.Duration `yaml:"poll_interval"`
+	Audience        string        `yaml:"audience"`
+	Issuer          string        `yaml:"issuer"`
+	MaxAge          time.Duration `yaml:"max_age"`
+	Leeway          time.Duration `yaml:"leeway"`
+	ReplayCacheSize int           `yaml:"replay_cache_size"`
+}
+
+type passwordKeySettings struct {
+	File         string        `yaml:"file"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+}
+
+// configProblems lists every invalid setting, so that operators can fix them in one go
+type configProblems []string
+
+func (p configProblems) Error() string {
+	return "invalid configuration:\n  " + strings.Join(p, "\n  ")
+}
+
+func (p *configProblems) add(path string, format string, args ...interface{}) {
+	*p = append(*p, path+": "+fmt.Sprintf(format, args...))
+}
+
+var logLevels = map[string]lager.LogLevel{
+	lagerflags.DEBUG: lager.DEBUG,
+	lagerflags.INFO:  lager.INFO,
+	lagerflags.ERROR: lager.ERROR,
+	lagerflags.FATAL: lager.FATAL,
+}
+
+func loadConfig() (config, error) {
+	c := configFromFlags()
+
+	problems := c.applyEnvironment()
+	if *configFile != "" {
+		if err := c.applyFile(*configFile); err != nil {
+			// the settings are incomplete, so validating them would only add noise
+			return c, append(problems, err.Error())
+		}
+	}
+	problems = append(problems, c.validate()...)
+
+	if len(problems) > 0 {
+		return c, problems
+	}
+	return c, nil
+}
+
+func configFromFlags() config {
+	var resolvers []string
+	for _, name := range strings.Split(*idResolvers, ",") {
+		resolvers = append(resolvers, strings.TrimSpace(name))
+	}
+	var authoritativeResolvers []string
+	for _, name := range strings.Split(*authoritativeIdResolvers, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			authoritativeResolvers = append(authoritativeResolvers, name)
+		}
+	}
+
+	ldap := defaultLdapSettings()
+	ldap.UsernamePattern = *ldapUsernamePattern
+
+	return config{
+		ListenAddr:  *atAddress,
+		AdminAddr:   *adminAddress,
+		DriversPath: *driversPath,
+		Transport:   *transport,
+		MapfsPath:   *mapfsPath,
+		MountDir:    *mountDir,
+		LogLevel:    lagerflags.ConfigFromFlags().LogLevel,
+		TLS: tlsSettings{
+			RequireSSL:         *requireSSL,
+			CAFile:             *caFile,
+			CertFile:           *certFile,
+			KeyFile:            *keyFile,
+			ClientCertFile:     *clientCertFile,
+			ClientKeyFile:      *clientKeyFile,
+			InsecureSkipVerify: *insecureSkipVerify,
+			PollInterval:       *tlsPollInterval,
+		},
+		UnixSocket: unixSocketSettings{
+			Mode:            *unixSocketMode,
+			Owner:           *unixSocketOwner,
+			Group:           *unixSocketGroup,
+			AllowedPeerUids: *unixSocketAllowedPeerUids,
+		},
+		Admin: adminSettings{
+			RequireSSL:            *adminRequireSSL,
+			TokenFile:             *adminTokenFile,
+			TokenPollInterval:     *adminTokenPollInterval,
+			HealthCheckTimeout:    *adminHealthCheckTimeout,
+			EvacuationTimeout:     *evacuationTimeout,
+			EvacuationParallelism: *evacuationParallelism,
+			Socket:                *adminSocket,
+			UnixSocket: unixSocketSettings{
+				Mode:            *adminSocketMode,
+				Owner:           *adminSocketOwner,
+				Group:           *adminSocketGroup,
+				AllowedPeerUids: *adminSocketAllowedPeerUids,
+			},
+		},
+		IdResolvers:              resolvers,
+		AuthoritativeIdResolvers: authoritativeResolvers,
+		ResolverHelperUser:       *resolverHelperUser,
+		StaticUsers: staticUsersSettings{
+			File:            *staticUsersFile,
+			PollInterval:    *staticUsersPollInterval,
+			UsernamePattern: *staticUsernamePattern,
+		},
+		Ldap: ldap,
+		LoginThrottle: loginThrottleSettings{
+			MaxUserFailures:   *maxUserLoginFailures,
+			Backoff:           *loginFailureBackoff,
+			MaxBackoff:        *maxLoginFailureBackoff,
+			MaxGlobalFailures: *maxGlobalLoginFailures,
+			GlobalWindow:      *globalLoginFailureWindow,
+			MaxConcurrent:     *maxConcurrentLogins,
+			FailureCacheTTL:   *loginFailureCacheTTL,
+		},
+		IdPolicy: idPolicySettings{
+			AllowedUids: *allowedUids,
+			DeniedUids:  *deniedUids,
+			AllowedGids: *allowedGids,
+			DeniedGids:  *deniedGids,
+		},
+		Tokens: tokenSettings{
+			KeysFile:        *tokenKeysFile,
+			PollInterval:    *tokenKeysPollInterval,
+			Audience:        *tokenAudience,
+			Issuer:          *tokenIssuer,
+			MaxAge:          *tokenMaxAge,
+			Leeway:          *tokenLeeway,
+			ReplayCacheSize: *tokenReplayCacheSize,
+		},
+		PasswordKey: passwordKeySettings{
+			File:         *passwordKeyFile,
+			PollInterval: *passwordKeyPollInterval,
+		},
+	}
+}
+
+// defaultLdapSettings are where the ldap block and every block in ldap_sources start from
+func defaultLdapSettings() ldapSettings {
+	return ldapSettings{
+		Proto:   "tcp",
+		Timeout: 120 * time.Second,
+		CredentialFiles: ldapCredentialFileSettings{
+			PollInterval: *ldapCredentialsPollInterval,
+		},
+		IdMapping: idMappingSettings{
+			RangeMin:  nfsv3driver.DefaultIdMapRange.Min,
+			RangeMax:  nfsv3driver.DefaultIdMapRange.Max,
+			RangeSize: nfsv3driver.DefaultIdMapRange.Size,
+		},
+		GroupLookup:     string(nfsv3driver.GroupLookupInChain),
+		MaxReferralHops: 3,
+		ReferralTLS:     string(nfsv3driver.ReferralTLSRequired),
+	}
+}
+
+// applyEnvironment reads the LDAP_* variables. Unset and empty variables keep their defaults.
+func (c *config) applyEnvironment() configProblems {
+	var problems configProblems
+
+	for env, value := range map[string]*string{
+		"LDAP_SVC_USER":      &c.Ldap.SvcUser,
+		"LDAP_SVC_PASS":      &c.Ldap.SvcPass,
+		"LDAP_USER_FQDN":     &c.Ldap.UserFqdn,
+		"LDAP_HOST":          &c.Ldap.Host,
+		"LDAP_CA_CERT":       &c.Ldap.CACert,
+		"LDAP_SVC_USER_FILE": &c.Ldap.CredentialFiles.SvcUser,
+		"LDAP_SVC_PASS_FILE": &c.Ldap.CredentialFiles.SvcPass,
+		"LDAP_CA_CERT_FILE":  &c.Ldap.CredentialFiles.CACert,
+		"LDAP_PROTO":         &c.Ldap.Proto,
+		"LDAP_GROUP_BASE_DN": &c.Ldap.GroupBaseDN,
+		"LDAP_GROUP_LOOKUP":  &c.Ldap.GroupLookup,
+		"LDAP_REFERRAL_TLS":  &c.Ldap.ReferralTLS,
+	} {
+		if setting := os.Getenv(env); setting != "" {
+			*value = setting
+		}
+	}
+
+	for env, value := range map[string]*int{
+		"LDAP_PORT":              &c.Ldap.Port,
+		"LDAP_MAX_REFERRAL_HOPS": &c.Ldap.MaxReferralHops,
+	} {
+		if setting := os.Getenv(env); setting != "" {
+			parsed, err := strconv.Atoi(setting)
+			if err != nil {
+				problems.add(env, "'%s' is not a number", setting)
+				continue
+			}
+			*value = parsed
+		}
+	}
+
+	if timeout := os.Getenv("LDAP_TIMEOUT"); timeout != "" {
+		seconds, err := strconv.Atoi(timeout)
+		switch {
+		case err != nil:
+			problems.add("LDAP_TIMEOUT", "'%s' is not a number of seconds", timeout)
+		case seconds < 0:
+			problems.add("LDAP_TIMEOUT", "must not be negative")
+		case seconds > 0:
+			c.Ldap.Timeout = time.Duration(seconds) * time.Second
+		}
+	}
+
+	for env, value := range map[string]*uint32{
+		"LDAP_IDMAP_RANGE_MIN":  &c.Ldap.IdMapping.RangeMin,
+		"LDAP_IDMAP_RANGE_MAX":  &c.Ldap.IdMapping.RangeMax,
+		"LDAP_IDMAP_RANGE_SIZE": &c.Ldap.IdMapping.RangeSize,
+		"LDAP_PAGE_SIZE":        &c.Ldap.PageSize,
+	} {
+		if setting := os.Getenv(env); setting != "" {
+			parsed, err := strconv.ParseUint(setting, 10, 32)
+			if err != nil {
+				problems.add(env, "'%s' is not a valid number", setting)
+				continue
+			}
+			*value = uint32(parsed)
+		}
+	}
+
+	if domains := os.Getenv("LDAP_DOMAINS"); domains != "" {
+		parsed, err := nfsv3driver.ParseLdapDomains(domains)
+		if err != nil {
+			problems.add("LDAP_DOMAINS", "%s", err.Error())
+		}
+		c.Ldap.Domains = parsed
+	}
+
+	// Group DNs contain commas, so the list is separated by semicolons
+	for _, group := range strings.Split(os.Getenv("LDAP_REQUIRED_GROUPS"), ";") {
+		if group = strings.TrimSpace(group); group != "" {
+			c.Ldap.RequiredGroups = append(c.Ldap.RequiredGroups, group)
+		}
+	}
+
+	c.Ldap.IdMapping.Enabled = os.Getenv("LDAP_ID_MAPPING") == "true"
+	c.Ldap.ChaseReferrals = os.Getenv("LDAP_CHASE_REFERRALS") == "true"
+
+	return problems
+}
+
+// applyFile overlays the settings in a YAML or JSON file. Unknown settings are rejected, so that typos do not
+// silently fall back to defaults.
+func (c *config) applyFile(path string) error {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %s", err.Error())
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(contents))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %s", path, err.Error())
+	}
+	return nil
+}
+
+// UnmarshalYAML starts every source from defaultLdapSettings. yaml.Node.Decode does not reject unknown settings,
+// so each source is decoded again with a strict decoder.
+func (s *ldapSources) UnmarshalYAML(value *yaml.Node) error {
+	var nodes map[string]yaml.Node
+	if err := value.Decode(&nodes); err != nil {
+		return err
+	}
+
+	sources := ldapSources{}
+	for name, node := range nodes {
+		contents, err := yaml.Marshal(&node)
+		if err != nil {
+			return err
+		}
+
+		settings := defaultLdapSettings()
+		decoder := yaml.NewDecoder(bytes.NewReader(contents))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&settings); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("ldap_sources.%s: %s", name, err.Error())
+		}
+		sources[name] = settings
+	}
+
+	*s = sources
+	return nil
+}
+
+func (c config) validate() configProblems {
+	var problems configProblems
+
+	if c.ListenAddr == "" {
+		problems.add("listen_addr", "is required")
+	}
+	if c.AdminAddr == "" {
+		problems.add("admin_addr", "is required")
+	}
+	switch c.Transport {
+	case "tcp", "tcp-json", "unix":
+	default:
+		problems.add("transport", "unknown transport '%s', must be one of tcp, tcp-json or unix", c.Transport)
+	}
+	if _, ok := logLevels[c.LogLevel]; !ok {
+		problems.add("log_level", "unknown log level '%s', must be one of debug, info, error or fatal", c.LogLevel)
+	}
+	if c.TLS.RequireSSL {
+		for path, value := range map[string]string{"tls.ca_file": c.TLS.CAFile, "tls.cert_file": c.TLS.CertFile, "tls.key_file": c.TLS.KeyFile} {
+			if value == "" {
+				problems.add(path, "is required when tls.require_ssl is set")
+			}
+		}
+		if c.TLS.PollInterval <= 0 {
+			problems.add("tls.poll_interval", "must be positive")
+		}
+	}
+
+	if c.Transport == "unix" {
+		problems = append(problems, c.UnixSocket.validate("unix_socket")...)
+	}
+	if c.Admin.RequireSSL {
+		if !c.TLS.RequireSSL {
+			problems.add("admin.require_ssl", "requires tls.require_ssl, whose certificates the admin server uses")
+		}
+		if c.Admin.Socket != "" {
+			problems.add("admin.require_ssl", "is not supported with admin.socket")
+		}
+	}
+	if c.Admin.TokenFile != "" && c.Admin.TokenPollInterval <= 0 {
+		problems.add("admin.token_poll_interval", "must be positive")
+	}
+	if c.Admin.HealthCheckTimeout <= 0 {
+		problems.add("admin.health_check_timeout", "must be positive")
+	}
+	if c.Admin.EvacuationTimeout <= 0 {
+		problems.add("admin.evacuation_timeout", "must be positive")
+	}
+	if c.Admin.EvacuationParallelism < 1 {
+		problems.add("admin.evacuation_parallelism", "must be at least 1")
+	}
+	if c.Admin.Socket != "" {
+		problems = append(problems, c.Admin.UnixSocket.validate("admin.unix_socket")...)
+	}
+
+	for _, name := range c.IdResolvers {
+		switch {
+		case name == "static":
+			if c.StaticUsers.File == "" {
+				problems.add("static_users.file", "is required by the static id resolver")
+			}
+		case strings.HasPrefix(name, "ldap:"):
+			if _, ok := c.ldapSource(name); !ok {
+				problems.add("id_resolvers", "unknown ldap source '%s', it must be defined in ldap_sources", strings.TrimPrefix(name, "ldap:"))
+			}
+		case name != "ldap":
+			problems.add("id_resolvers", "unknown id resolver '%s'", name)
+		}
+	}
+	for _, name := range c.AuthoritativeIdResolvers {
+		if !slices.Contains(c.IdResolvers, name) {
+			problems.add("authoritative_id_resolvers", "'%s' is not one of the id_resolvers", name)
+		}
+	}
+	if c.ResolverHelperUser != "" {
+		if _, err := resolverHelperCredential(c.ResolverHelperUser); err != nil {
+			problems.add("resolver_helper_user", "%s", err.Error())
+		}
+	}
+	if c.StaticUsers.PollInterval <= 0 {
+		problems.add("static_users.poll_interval", "must be positive")
+	}
+	validatePattern(&problems, "static_users.username_pattern", c.StaticUsers.UsernamePattern)
+
+	problems = append(problems, c.Ldap.validate("ldap")...)
+	for name, source := range c.LdapSources {
+		section := "ldap_sources." + name
+		if source.Host == "" {
+			problems.add(section+".host", "is required")
+		}
+		problems = append(problems, source.validate(section)...)
+	}
+
+	for path, value := range map[string]int{
+		"login_throttle.max_user_failures":   c.LoginThrottle.MaxUserFailures,
+		"login_throttle.max_global_failures": c.LoginThrottle.MaxGlobalFailures,
+		"login_throttle.max_concurrent":      c.LoginThrottle.MaxConcurrent,
+	} {
+		if value < 0 {
+			problems.add(path, "must not be negative")
+		}
+	}
+	for path, value := range map[string]time.Duration{
+		"login_throttle.backoff":           c.LoginThrottle.Backoff,
+		"login_throttle.max_backoff":       c.LoginThrottle.MaxBackoff,
+		"login_throttle.global_window":     c.LoginThrottle.GlobalWindow,
+		"login_throttle.failure_cache_ttl": c.LoginThrottle.FailureCacheTTL,
+	} {
+		if value < 0 {
+			problems.add(path, "must not be negative")
+		}
+	}
+
+	for path, value := range map[string]string{
+		"id_policy.allowed_uids": c.IdPolicy.AllowedUids,
+		"id_policy.denied_uids":  c.IdPolicy.DeniedUids,
+		"id_policy.allowed_gids": c.IdPolicy.AllowedGids,
+		"id_policy.denied_gids":  c.IdPolicy.DeniedGids,
+	} {
+		if _, err := nfsv3driver.ParseIdRanges(value); err != nil {
+			problems.add(path, "%s", err.Error())
+		}
+	}
+
+	if c.Tokens.KeysFile != "" {
+		if c.Tokens.Audience == "" {
+			problems.add("tokens.audience", "is required when tokens.keys_file is set")
+		}
+		if c.Tokens.MaxAge <= 0 {
+			problems.add("tokens.max_age", "must be positive")
+		}
+		if c.Tokens.Leeway < 0 {
+			problems.add("tokens.leeway", "must not be negative")
+		}
+		if c.Tokens.ReplayCacheSize < 0 {
+			problems.add("tokens.replay_cache_size", "must not be negative")
+		}
+		if c.Tokens.PollInterval <= 0 {
+			problems.add("tokens.poll_interval", "must be positive")
+		}
+	}
+	if c.PasswordKey.File != "" && c.PasswordKey.PollInterval <= 0 {
+		problems.add("password_key.poll_interval", "must be positive")
+	}
+
+	// map iteration order is random
+	sort.Strings(problems)
+	return problems
+}
+
+// validate reports problems under section, since the ldap block and ldap_sources share these settings
+func (l ldapSettings) validate(section string) configProblems {
+	var problems configProblems
+
+	if l.Host != "" {
+		var missing []string
+		if l.SvcUser == "" && l.CredentialFiles.SvcUser == "" {
+			missing = append(missing, "svc_user")
+		}
+		if l.SvcPass == "" && l.CredentialFiles.SvcPass == "" {
+			missing = append(missing, "svc_pass")
+		}
+		if l.UserFqdn == "" {
+			missing = append(missing, "user_fqdn")
+		}
+		if l.Port == 0 {
+			missing = append(missing, "port")
+		}
+		if len(missing) > 0 {
+			problems.add(section, "LDAP is enabled but required LDAP parameters are not set: %s", strings.Join(missing, ", "))
+		}
+	}
+	if l.Port < 0 || l.Port > 65535 {
+		problems.add(section+".port", "must be between 1 and 65535")
+	}
+	if l.Timeout <= 0 {
+		problems.add(section+".timeout", "must be positive")
+	}
+	if l.CredentialFiles.PollInterval <= 0 {
+		problems.add(section+".credential_files.poll_interval", "must be positive")
+	}
+	validatePattern(&problems, section+".username_pattern", l.UsernamePattern)
+
+	if err := nfsv3driver.ValidateLdapDomains(l.Domains); err != nil {
+		problems.add(section+".domains", "%s", err.Error())
+	}
+	if idMapRange := l.idMapRange(); idMapRange != nil {
+		if err := idMapRange.Validate(); err != nil {
+			problems.add(section+".id_mapping", "%s", err.Error())
+		}
+	}
+	if err := l.groupMembershipPolicy().Validate(); err != nil {
+		path := section + ".required_groups"
+		if l.GroupLookup != string(nfsv3driver.GroupLookupInChain) && l.GroupLookup != string(nfsv3driver.GroupLookupRecursive) {
+			path = section + ".group_lookup"
+		}
+		problems.add(path, "%s", err.Error())
+	}
+	if err := l.searchOptions().Validate(); err != nil {
+		path := section + ".referral_tls"
+		if l.MaxReferralHops < 0 {
+			path = section + ".max_referral_hops"
+		}
+		problems.add(path, "%s", err.Error())
+	}
+
+	return problems
+}
+
+// validate reports problems under section, since the driver and the admin socket share these settings
+func (u unixSocketSettings) validate(section string) configProblems {
+	var problems configProblems
+
+	if _, err := u.mode(); err != nil {
+		problems.add(section+".mode", "%s", err.Error())
+	}
+	if _, err := lookupId(u.Owner, user.Lookup, func(u *user.User) string { return u.Uid }); err != nil {
+		problems.add(section+".owner", "%s", err.Error())
+	}
+	if _, err := lookupId(u.Group, user.LookupGroup, func(g *user.Group) string { return g.Gid }); err != nil {
+		problems.add(section+".group", "%s", err.Error())
+	}
+	if _, err := nfsv3driver.ParseIdRanges(u.AllowedPeerUids); err != nil {
+		problems.add(section+".allowed_peer_uids", "%s", err.Error())
+	}
+
+	return problems
+}
+
+func (u unixSocketSettings) mode() (os.FileMode, error) {
+	mode, err := strconv.ParseUint(u.Mode, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid mode '%s'", u.Mode)
+	}
+	return os.FileMode(mode), nil
+}
+
+func (u unixSocketSettings) socketConfig() (nfsv3driver.UnixSocketConfig, error) {
+	var config nfsv3driver.UnixSocketConfig
+	var err error
+
+	if config.Mode, err = u.mode(); err != nil {
+		return config, err
+	}
+	if config.Uid, err = lookupId(u.Owner, user.Lookup, func(u *user.User) string { return u.Uid }); err != nil {
+		return config, err
+	}
+	if config.Gid, err = lookupId(u.Group, user.LookupGroup, func(g *user.Group) string { return g.Gid }); err != nil {
+		return config, err
+	}
+	config.AllowedPeerUids, err = nfsv3driver.ParseIdRanges(u.AllowedPeerUids)
+	return config, err
+}
+
+// lookupId accepts a numeric id or a name, and returns -1 for an empty setting
+func lookupId[T any](nameOrId string, lookup func(string) (T, error), id func(T) string) (int, error) {
+	if nameOrId == "" {
+		return -1, nil
+	}
+	if parsed, err := strconv.ParseUint(nameOrId, 10, 32); err == nil {
+		return int(parsed), nil
+	}
+
+	found, err := lookup(nameOrId)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(id(found))
+}
+
+func validatePattern(problems *configProblems, path string, pattern string) {
+	if _, err := regexp.Compile(pattern); err != nil {
+		problems.add(path, "%s", err.Error())
+	}
+}
+
+// ldapSource returns the settings of an LDAP id resolver: "ldap" for the ldap block or "ldap:<name>" for a
+// block in ldap_sources
+func (c config) ldapSource(resolver string) (ldapSettings, bool) {
+	if resolver == "ldap" {
+		return c.Ldap, true
+	}
+	name, found := strings.CutPrefix(resolver, "ldap:")
+	if !found {
+		return ldapSettings{}, false
+	}
+	source, ok := c.LdapSources[name]
+	return source, ok
+}
+
+func (l ldapSettings) credentialFiles() nfsv3driver.LdapCredentialFiles {
+	return nfsv3driver.LdapCredentialFiles{
+		SvcUserFile: l.CredentialFiles.SvcUser,
+		SvcPassFile: l.CredentialFiles.SvcPass,
+		CACertFile:  l.CredentialFiles.CACert,
+	}
+}
+
+func (l ldapSettings) idMapRange() *nfsv3driver.IdMapRange {
+	if !l.IdMapping.Enabled {
+		return nil
+	}
+	return &nfsv3driver.IdMapRange{Min: l.IdMapping.RangeMin, Max: l.IdMapping.RangeMax, Size: l.IdMapping.RangeSize}
+}
+
+func (l ldapSettings) groupMembershipPolicy() nfsv3driver.GroupMembershipPolicy {
+	return nfsv3driver.GroupMembershipPolicy{
+		RequiredGroups: l.RequiredGroups,
+		Lookup:         nfsv3driver.GroupLookup(l.GroupLookup),
+		BaseDN:         l.GroupBaseDN,
+	}
+}
+
+func (l ldapSettings) searchOptions() nfsv3driver.LdapSearchOptions {
+	return nfsv3driver.LdapSearchOptions{
+		PageSize:        l.PageSize,
+		ChaseReferrals:  l.ChaseReferrals,
+		MaxReferralHops: l.MaxReferralHops,
+		ReferralTLS:     nfsv3driver.ReferralTLSPolicy(l.ReferralTLS),
+	}
+}
+
+func (p idPolicySettings) policy() (nfsv3driver.IdPolicy, error) {
+	var policy nfsv3driver.IdPolicy
+	for _, setting := range []struct {
+		value  string
+		ranges *[]nfsv3driver.IdRange
+	}{
+		{p.AllowedUids, &policy.AllowedUids},
+		{p.DeniedUids, &policy.DeniedUids},
+		{p.AllowedGids, &policy.AllowedGids},
+		{p.DeniedGids, &policy.DeniedGids},
+	} {
+		var err error
+		if *setting.ranges, err = nfsv3driver.ParseIdRanges(setting.value); err != nil {
+			return nfsv3driver.IdPolicy{}, err
+		}
+	}
+	return policy, nil
+}
+
+// withLiveSettings returns c with the settings that can change without a restart taken from other: the log
+// level, the id policy and the LDAP settings of the ldap block and ldap_sources.
+func (c config) withLiveSettings(other config) config {
+	c.LogLevel = other.LogLevel
+	c.IdPolicy = other.IdPolicy
+
+	c.Ldap = c.Ldap.withLiveSettings(other.Ldap)
+	var sources ldapSources
+	if other.LdapSources != nil {
+		sources = ldapSources{}
+		for name, source := range other.LdapSources {
+			if current, ok := c.LdapSources[name]; ok {
+				source = current.withLiveSettings(source)
+			}
+			sources[name] = source
+		}
+	}
+	c.LdapSources = sources
+
+	return c
+}
+
+// withLiveSettings returns l with every setting taken from other except the credential files. They are watched
+// from startup, so while they are configured the credentials they default to cannot change either.
+func (l ldapSettings) withLiveSettings(other ldapSettings) ldapSettings {
+	other.CredentialFiles = l.CredentialFiles
+	if len(l.credentialFiles().Paths()) > 0 {
+		other.SvcUser, other.SvcPass, other.CACert = l.SvcUser, l.SvcPass, l.CACert
+	}
+	return other
+}
+
+// changedSettings lists the paths of the settings that differ between two configurations
+func changedSettings(old config, new config) []string {
+	var changed []string
+	compareSettings("", reflect.ValueOf(old), reflect.ValueOf(new), &changed)
+	return changed
+}
+
+func compareSettings(path string, old reflect.Value, new reflect.Value, changed *[]string) {
+	if old.Kind() != reflect.Struct {
+		if !reflect.DeepEqual(old.Interface(), new.Interface()) {
+			*changed = append(*changed, path)
+		}
+		return
+	}
+
+	for i := 0; i < old.NumField(); i++ {
+		name := old.Type().Field(i).Tag.Get("yaml")
+		if path != "" {
+			name = path + "." + name
+		}
+		compareSettings(name, old.Field(i), new.Field(i), changed)
+	}
+}
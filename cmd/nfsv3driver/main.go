@@ -1,12 +1,19 @@
 package main
 
 import (
-	"code.cloudfoundry.org/tlsconfig"
 	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
 	"os"
+	"os/exec"
+	"os/user"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	cf_debug_server "code.cloudfoundry.org/debugserver"
@@ -22,6 +29,7 @@ import (
 	"code.cloudfoundry.org/lager/v3"
 	"code.cloudfoundry.org/lager/v3/lagerflags"
 	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
 	"code.cloudfoundry.org/nfsv3driver/driveradmin/driveradminhttp"
 	"code.cloudfoundry.org/nfsv3driver/driveradmin/driveradminlocal"
 	"code.cloudfoundry.org/volumedriver"
@@ -34,6 +42,12 @@ import (
 	"github.com/tedsuo/ifrit/sigmon"
 )
 
+var configFile = flag.String(
+	"config",
+	"",
+	"path to a YAML or JSON configuration file; its settings take precedence over flags and LDAP_* environment variables. On SIGHUP the log level, LDAP settings and id policy are reloaded from it",
+)
+
 var atAddress = flag.String(
 	"listenAddr",
 	"127.0.0.1:7589",
@@ -70,6 +84,96 @@ var mountDir = flag.String(
 	"Path to directory where NFS v3 volumes are created",
 )
 
+var unixSocketMode = flag.String(
+	"unixSocketMode",
+	"0660",
+	"octal permissions of the socket at listenAddr when transport is unix",
+)
+
+var unixSocketOwner = flag.String(
+	"unixSocketOwner",
+	"",
+	"user ('name' or uid) that owns the socket at listenAddr when transport is unix (default: the driver's user)",
+)
+
+var unixSocketGroup = flag.String(
+	"unixSocketGroup",
+	"",
+	"group ('name' or gid) that owns the socket at listenAddr when transport is unix (default: the driver's group)",
+)
+
+var unixSocketAllowedPeerUids = flag.String(
+	"unixSocketAllowedPeerUids",
+	"",
+	"comma separated uids and ranges of the processes allowed to connect to the socket at listenAddr, checked with SO_PEERCRED (default: any process that can open it)",
+)
+
+var adminRequireSSL = flag.Bool(
+	"adminRequireSSL",
+	false,
+	"whether the admin server should require mutual TLS, using the certFile, keyFile and caFile of the driver server",
+)
+
+var adminTokenFile = flag.String(
+	"adminTokenFile",
+	"",
+	"path to a file of bearer tokens, one per line, one of which admin requests must present (default: no token required)",
+)
+
+var adminTokenPollInterval = flag.Duration(
+	"adminTokenPollInterval",
+	30*time.Second,
+	"how often the adminTokenFile is checked for rotated tokens",
+)
+
+var adminHealthCheckTimeout = flag.Duration(
+	"adminHealthCheckTimeout",
+	driveradminlocal.DefaultHealthCheckTimeout,
+	"how long each check of the admin health endpoints may take before it is reported as unhealthy",
+)
+
+var evacuationTimeout = flag.Duration(
+	"evacuationTimeout",
+	driveradminlocal.DefaultEvacuationTimeout,
+	"how long an evacuation waits for volumes to unmount before giving up on them",
+)
+
+var evacuationParallelism = flag.Int(
+	"evacuationParallelism",
+	driveradminlocal.DefaultEvacuationParallelism,
+	"how many volumes an evacuation unmounts at once",
+)
+
+var adminSocket = flag.String(
+	"adminSocket",
+	"",
+	"path of a unix socket to serve process admin functions on instead of adminAddr",
+)
+
+var adminSocketMode = flag.String(
+	"adminSocketMode",
+	"0600",
+	"octal permissions of the adminSocket",
+)
+
+var adminSocketOwner = flag.String(
+	"adminSocketOwner",
+	"",
+	"user ('name' or uid) that owns the adminSocket (default: the driver's user)",
+)
+
+var adminSocketGroup = flag.String(
+	"adminSocketGroup",
+	"",
+	"group ('name' or gid) that owns the adminSocket (default: the driver's group)",
+)
+
+var adminSocketAllowedPeerUids = flag.String(
+	"adminSocketAllowedPeerUids",
+	"",
+	"comma separated uids and ranges of the processes allowed to connect to the adminSocket, checked with SO_PEERCRED (default: any process that can open it)",
+)
+
 var requireSSL = flag.Bool(
 	"requireSSL",
 	false,
@@ -111,51 +215,260 @@ var insecureSkipVerify = flag.Bool(
 	"whether SSL communication should skip verification of server IP addresses in the certificate",
 )
 
-const fsType = "nfs"
-const mountOptions = "rsize=1048576,wsize=1048576,hard,timeo=600,retrans=2,actimeo=0"
+var tlsPollInterval = flag.Duration(
+	"tlsPollInterval",
+	30*time.Second,
+	"how often certFile, keyFile and caFile are checked for rotated certificates",
+)
+
+var idResolvers = flag.String(
+	"idResolver",
+	"ldap",
+	"comma separated list of id resolvers consulted in order to map usernames to uid/gid: 'ldap' (enabled when LDAP_HOST is set), 'static' and 'ldap:<name>' for a directory in the ldap_sources of the configuration file",
+)
+
+var authoritativeIdResolvers = flag.String(
+	"authoritativeIdResolvers",
+	"",
+	"comma separated list of id resolvers that reject usernames they do not know instead of passing them to the next resolver",
+)
+
+var staticUsersFile = flag.String(
+	"staticUsersFile",
+	"",
+	"path to the users file for the static id resolver, one 'username:password-hash:uid:gid[:group,...]' entry per line",
+)
+
+var staticUsersPollInterval = flag.Duration(
+	"staticUsersPollInterval",
+	10*time.Second,
+	"how often the static users file is checked for changes",
+)
+
+var resolverHelperUser = flag.String(
+	"resolverHelperUser",
+	"",
+	"resolve usernames in a separate helper process running as this user ('name' or 'uid:gid'), so that directory responses are never parsed by the root process; the user needs read access to the configuration, users and credential files (default: resolve in-process)",
+)
+
+var resolverHelper = flag.Bool(
+	"resolverHelper",
+	false,
+	"run as the identity resolution helper; set by the driver when it starts the helper",
+)
+
+var ldapCredentialsPollInterval = flag.Duration(
+	"ldapCredentialsPollInterval",
+	30*time.Second,
+	"how often the files named by LDAP_SVC_USER_FILE, LDAP_SVC_PASS_FILE and LDAP_CA_CERT_FILE are checked for rotated credentials",
+)
+
+var staticUsernamePattern = flag.String(
+	"staticUsernamePattern",
+	"",
+	"regular expression restricting the usernames looked up in the static users file (default: all)",
+)
+
+var ldapUsernamePattern = flag.String(
+	"ldapUsernamePattern",
+	"",
+	"regular expression restricting the usernames looked up in LDAP (default: all)",
+)
+
+var maxUserLoginFailures = flag.Int(
+	"maxUserLoginFailures",
+	5,
+	"consecutive failed logins for a username before further attempts are delayed (0 to disable)",
+)
+
+var loginFailureBackoff = flag.Duration(
+	"loginFailureBackoff",
+	time.Second,
+	"initial delay imposed on a username after maxUserLoginFailures, doubled for every further failure",
+)
+
+var maxLoginFailureBackoff = flag.Duration(
+	"maxLoginFailureBackoff",
+	5*time.Minute,
+	"upper bound for the delay imposed on a username after repeated failed logins",
+)
+
+var maxGlobalLoginFailures = flag.Int(
+	"maxGlobalLoginFailures",
+	100,
+	"failed logins across all usernames within globalLoginFailureWindow before all logins are refused (0 to disable)",
+)
+
+var globalLoginFailureWindow = flag.Duration(
+	"globalLoginFailureWindow",
+	time.Minute,
+	"sliding window over which maxGlobalLoginFailures is counted",
+)
+
+var maxConcurrentLogins = flag.Int(
+	"maxConcurrentLogins",
+	0,
+	"logins checked against the id resolver at once; further logins wait for one to finish (0 for no limit)",
+)
+
+var loginFailureCacheTTL = flag.Duration(
+	"loginFailureCacheTTL",
+	30*time.Second,
+	"how long a failed username/password pair is rejected without consulting the id resolver (0 to disable)",
+)
+
+var tokenKeysFile = flag.String(
+	"tokenKeysFile",
+	"",
+	"path to a JSON Web Key Set whose keys sign identity tokens passed in the 'token' bind option (default: tokens disabled)",
+)
+
+var tokenKeysPollInterval = flag.Duration(
+	"tokenKeysPollInterval",
+	30*time.Second,
+	"how often the tokenKeysFile is checked for rotated keys",
+)
+
+var tokenAudience = flag.String(
+	"tokenAudience",
+	"nfsv3driver",
+	"audience that identity tokens must be issued for",
+)
+
+var tokenIssuer = flag.String(
+	"tokenIssuer",
+	"",
+	"issuer that identity tokens must come from (default: any)",
+)
 
-var (
-	ldapSvcUser  string
-	ldapSvcPass  string
-	ldapUserFqdn string
-	ldapHost     string
-	ldapPort     int
-	ldapCACert   string
-	ldapProto    string
-	ldapTimeout  int
+var tokenMaxAge = flag.Duration(
+	"tokenMaxAge",
+	5*time.Minute,
+	"identity tokens issued longer ago than this are rejected, limiting how long a leaked token can be replayed",
 )
 
+var tokenLeeway = flag.Duration(
+	"tokenLeeway",
+	30*time.Second,
+	"clock skew allowed when checking identity token timestamps",
+)
+
+var tokenReplayCacheSize = flag.Int(
+	"tokenReplayCacheSize",
+	10000,
+	"number of identity token ids (jti) remembered so that each token is accepted only once within tokenMaxAge; tokens without jti are rejected unless this is 0, which disables the check",
+)
+
+var passwordKeyFile = flag.String(
+	"passwordKeyFile",
+	"",
+	"path to a PEM encoded RSA private key for decrypting the 'encrypted_password' bind option; its public key is served on the admin API (default: encrypted passwords disabled)",
+)
+
+var passwordKeyPollInterval = flag.Duration(
+	"passwordKeyPollInterval",
+	30*time.Second,
+	"how often the passwordKeyFile is checked for a rotated key",
+)
+
+var allowedUids = flag.String(
+	"allowedUids",
+	"",
+	"comma separated uids and ranges (e.g. '1000-59999') that volumes may be mapped to (default: all)",
+)
+
+var deniedUids = flag.String(
+	"deniedUids",
+	"",
+	"comma separated uids and ranges that volumes may never be mapped to, even when allowed by allowedUids",
+)
+
+var allowedGids = flag.String(
+	"allowedGids",
+	"",
+	"comma separated gids and ranges that volumes may be mapped to (default: all)",
+)
+
+var deniedGids = flag.String(
+	"deniedGids",
+	"",
+	"comma separated gids and ranges that volumes may never be mapped to, even when allowed by allowedGids",
+)
+
+const fsType = "nfs"
+const mountOptions = "rsize=1048576,wsize=1048576,hard,timeo=600,retrans=2,actimeo=0"
+
 func main() {
 	parseCommandLine()
-	parseEnvironment()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		exitOnInvalidConfig(err)
+	}
 
 	var nfsDriverServer ifrit.Runner
 	var idResolver nfsv3driver.IdResolver
 	var mounter volumedriver.Mounter
 
-	logger, logSink := newLogger()
+	logger, logSink, logWriter := newLogger(cfg.LogLevel)
+	if *resolverHelper {
+		runResolverHelper(logger, logSink, cfg)
+		return
+	}
+
+	logController := nfsv3driver.NewLogController(logWriter, logSink, &timeshim.TimeShim{})
+	logger.RegisterSink(logController)
+
 	logger.Info("start")
 	defer logger.Info("end")
 
-	if ldapHost != "" {
-		idResolver = nfsv3driver.NewLdapIdResolver(
-			ldapSvcUser,
-			ldapSvcPass,
-			ldapHost,
-			ldapPort,
-			ldapProto,
-			ldapUserFqdn,
-			ldapCACert,
-			&ldapshim.LdapShim{},
-			time.Duration(ldapTimeout)*time.Second,
-		)
+	idPolicy, err := cfg.IdPolicy.policy()
+	exitOnFailure(logger, err)
+	live := &liveSettings{logSink: logSink, idPolicy: nfsv3driver.NewSwappableIdPolicy(idPolicy)}
+
+	var idResolverWatchers grouper.Members
+	if cfg.ResolverHelperUser != "" {
+		live.resolverHelper, idResolverWatchers = newResolverHelper(logger, cfg)
+		if live.resolverHelper != nil {
+			idResolver = live.resolverHelper
+		}
+	} else {
+		live.resolvers, err = newIdResolverFactory(logger, cfg)
+		exitOnFailure(logger, err)
+		resolver, err := live.resolvers.build(logger, cfg)
+		exitOnFailure(logger, err)
+		if resolver != nil {
+			live.idResolver = nfsv3driver.NewSwappableIdResolver(resolver)
+			idResolver = live.idResolver
+		}
+		idResolverWatchers = live.resolvers.watchers
+	}
+
+	var loginThrottle nfsv3driver.ThrottlingIdResolver
+	if idResolver != nil {
+		loginThrottle = nfsv3driver.NewThrottlingIdResolver(idResolver, &timeshim.TimeShim{}, nfsv3driver.ThrottleConfig{
+			MaxUserFailures:   cfg.LoginThrottle.MaxUserFailures,
+			BaseBackoff:       cfg.LoginThrottle.Backoff,
+			MaxBackoff:        cfg.LoginThrottle.MaxBackoff,
+			MaxGlobalFailures: cfg.LoginThrottle.MaxGlobalFailures,
+			GlobalWindow:      cfg.LoginThrottle.GlobalWindow,
+			MaxInFlight:       cfg.LoginThrottle.MaxConcurrent,
+			FailureCacheTTL:   cfg.LoginThrottle.FailureCacheTTL,
+		})
+		idResolver = loginThrottle
 	}
 
+	tokenResolver, tokenResolverWatchers := newTokenResolver(logger, cfg.Tokens)
+	passwordDecrypter, passwordDecrypterWatchers := newPasswordDecrypter(logger, cfg.PasswordKey)
+
 	mask, err := nfsv3driver.NewMapFsVolumeMountMask()
 	if err != nil {
 		exitOnFailure(logger, err)
 	}
 
+	// the volume driver keeps the options of its volumes, so their secrets are held apart from it
+	volumeSecrets := nfsv3driver.NewVolumeSecrets()
+
 	processGroupInvoker := invoker.NewProcessGroupInvoker()
 	mounter = nfsv3driver.NewMapfsMounter(
 		processGroupInvoker,
@@ -166,33 +479,52 @@ func main() {
 		fsType,
 		mountOptions,
 		idResolver,
+		tokenResolver,
+		volumeSecrets,
+		passwordDecrypter,
+		live.idPolicy,
 		mask,
-		*mapfsPath,
+		cfg.MapfsPath,
 	)
+	mountTracker := nfsv3driver.NewMountTracker(mounter, &timeshim.TimeShim{})
+	mounter = mountTracker
+	mounter = nfsv3driver.NewSecretInjectingMounter(mounter, volumeSecrets)
 
-	client := volumedriver.NewVolumeDriver(
+	volumeDriver := volumedriver.NewVolumeDriver(
 		logger,
 		&osshim.OsShim{},
 		&filepathshim.FilepathShim{},
 		&ioutilshim.IoutilShim{},
 		&timeshim.TimeShim{},
 		mountchecker.NewChecker(&bufioshim.BufioShim{}, &osshim.OsShim{}),
-		*mountDir,
+		cfg.MountDir,
 		mounter,
 		oshelper.NewOsHelper(),
 	)
+	secretKeepingDriver := nfsv3driver.NewSecretKeepingDriver(volumeDriver, volumeSecrets)
+	// the cordon check holds the volume's lock, so that a volume cannot be unmounted between being found mounted and
+	// being mounted again
+	cordoningDriver := nfsv3driver.NewCordoningDriver(logger, secretKeepingDriver, &ioutilshim.IoutilShim{}, filepath.Join(cfg.MountDir, "cordon-state.json"))
+	// the admin API remounts and force-unmounts volumes under the same locks as the rep's requests
+	volumeLocks := nfsv3driver.NewVolumeLocks()
+	client := nfsv3driver.NewVolumeLockingDriver(cordoningDriver, volumeLocks)
 
-	if *transport == "tcp" {
-		nfsDriverServer = createNfsDriverServer(logger, client, *atAddress, *driversPath, false)
-	} else if *transport == "tcp-json" {
-		nfsDriverServer = createNfsDriverServer(logger, client, *atAddress, *driversPath, true)
+	tlsIdentity, tlsIdentityWatchers := newTLSIdentity(logger, cfg.TLS)
+
+	if cfg.Transport == "tcp" {
+		nfsDriverServer = createNfsDriverServer(logger, client, cfg, tlsIdentity, false)
+	} else if cfg.Transport == "tcp-json" {
+		nfsDriverServer = createNfsDriverServer(logger, client, cfg, tlsIdentity, true)
 	} else {
-		nfsDriverServer = createNfsDriverUnixServer(logger, client, *atAddress)
+		nfsDriverServer = createNfsDriverUnixServer(logger, client, cfg)
 	}
 
-	servers := grouper.Members{
-		{Name: "nfsdriver-server", Runner: nfsDriverServer},
-	}
+	// the resolver helper has to be running before mounts arrive
+	servers := append(idResolverWatchers, grouper.Member{Name: "nfsdriver-server", Runner: nfsDriverServer})
+	servers = append(servers, tokenResolverWatchers...)
+	servers = append(servers, passwordDecrypterWatchers...)
+	servers = append(servers, tlsIdentityWatchers...)
+	servers = append(servers, grouper.Member{Name: "config-reloader", Runner: newConfigReloader(logger, cfg, live)})
 
 	if dbgAddr := cf_debug_server.DebugAddress(flag.CommandLine); dbgAddr != "" {
 		servers = append(grouper.Members{
@@ -201,8 +533,19 @@ func main() {
 	}
 
 	adminClient := driveradminlocal.NewDriverAdminLocal()
-	adminHandler, _ := driveradminhttp.NewHandler(logger, adminClient)
-	adminServer := http_server.New(*adminAddress, adminHandler)
+	adminClient.SetEvacuationTimeout(cfg.Admin.EvacuationTimeout)
+	adminClient.SetEvacuationParallelism(cfg.Admin.EvacuationParallelism)
+	adminClient.SetVolumeInspector(nfsv3driver.NewVolumeInspector(client, mountTracker, &ioutilshim.IoutilShim{}, &timeshim.TimeShim{}, "/proc"))
+	adminClient.SetVolumeOperator(nfsv3driver.NewVolumeOperator(secretKeepingDriver, volumeLocks, mounter, &ioutilshim.IoutilShim{}, &syscallshim.SyscallShim{}, "/proc"))
+	adminClient.SetCordoner(cordoningDriver)
+	logController.SetVolumeServers(cordoningDriver)
+	adminClient.SetLogController(logController)
+	if loginThrottle != nil {
+		adminClient.SetLoginThrottle(loginThrottle)
+	}
+	registerHealthChecks(logger, adminClient, cfg, client, mounter, live)
+	adminServer, adminTokenWatchers := createAdminServer(logger, adminClient, cfg, tlsIdentity)
+	servers = append(servers, adminTokenWatchers...)
 
 	servers = append(grouper.Members{
 		{Name: "driveradmin", Runner: adminServer},
@@ -213,6 +556,12 @@ func main() {
 
 	adminClient.SetServerProc(process)
 	adminClient.RegisterDrainable(client)
+	if passwordDecrypter != nil {
+		adminClient.SetPasswordKeySource(passwordDecrypter)
+	}
+	if tlsIdentity != nil {
+		adminClient.RegisterCertificateSource(tlsIdentity)
+	}
 
 	untilTerminated(logger, process)
 }
@@ -223,6 +572,15 @@ func exitOnFailure(logger lager.Logger, err error) {
 	}
 }
 
+// exitOnInvalidConfig reports every configuration problem, both in the log and on stderr, before the logger has
+// been configured from it
+func exitOnInvalidConfig(err error) {
+	logger, _, _ := newLogger(lagerflags.ConfigFromFlags().LogLevel)
+	logger.Error("invalid-configuration", err)
+	fmt.Fprintln(os.Stderr, err.Error())
+	os.Exit(1)
+}
+
 func untilTerminated(logger lager.Logger, process ifrit.Process) {
 	err := <-process.Wait()
 	exitOnFailure(logger, err)
@@ -232,30 +590,31 @@ func processRunnerFor(servers grouper.Members) ifrit.Runner {
 	return sigmon.New(grouper.NewOrdered(os.Interrupt, servers))
 }
 
-func createNfsDriverServer(logger lager.Logger, client dockerdriver.Driver, atAddress, driversPath string, jsonSpec bool) ifrit.Runner {
+func createNfsDriverServer(logger lager.Logger, client dockerdriver.Driver, cfg config, tlsIdentity nfsv3driver.ReloadableTLSIdentity, jsonSpec bool) ifrit.Runner {
+	atAddress := cfg.ListenAddr
 	advertisedUrl := "http://" + atAddress
-	logger.Info("writing-spec-file", lager.Data{"location": driversPath, "name": "nfsv3driver", "address": advertisedUrl})
+	logger.Info("writing-spec-file", lager.Data{"location": cfg.DriversPath, "name": "nfsv3driver", "address": advertisedUrl})
 	if jsonSpec {
 		driverJsonSpec := dockerdriver.DriverSpec{Name: "nfsv3driver", Address: advertisedUrl, UniqueVolumeIds: true}
 
-		if *requireSSL {
-			absCaFile, err := filepath.Abs(*caFile)
+		if cfg.TLS.RequireSSL {
+			absCaFile, err := filepath.Abs(cfg.TLS.CAFile)
 			exitOnFailure(logger, err)
-			absClientCertFile, err := filepath.Abs(*clientCertFile)
+			absClientCertFile, err := filepath.Abs(cfg.TLS.ClientCertFile)
 			exitOnFailure(logger, err)
-			absClientKeyFile, err := filepath.Abs(*clientKeyFile)
+			absClientKeyFile, err := filepath.Abs(cfg.TLS.ClientKeyFile)
 			exitOnFailure(logger, err)
-			driverJsonSpec.TLSConfig = &dockerdriver.TLSConfig{InsecureSkipVerify: *insecureSkipVerify, CAFile: absCaFile, CertFile: absClientCertFile, KeyFile: absClientKeyFile}
+			driverJsonSpec.TLSConfig = &dockerdriver.TLSConfig{InsecureSkipVerify: cfg.TLS.InsecureSkipVerify, CAFile: absCaFile, CertFile: absClientCertFile, KeyFile: absClientKeyFile}
 			driverJsonSpec.Address = "https://" + atAddress
 		}
 
 		jsonBytes, err := json.Marshal(driverJsonSpec)
 
 		exitOnFailure(logger, err)
-		err = dockerdriver.WriteDriverSpec(logger, driversPath, "nfsv3driver", "json", jsonBytes)
+		err = dockerdriver.WriteDriverSpec(logger, cfg.DriversPath, "nfsv3driver", "json", jsonBytes)
 		exitOnFailure(logger, err)
 	} else {
-		err := dockerdriver.WriteDriverSpec(logger, driversPath, "nfsv3driver", "spec", []byte(advertisedUrl))
+		err := dockerdriver.WriteDriverSpec(logger, cfg.DriversPath, "nfsv3driver", "spec", []byte(advertisedUrl))
 		exitOnFailure(logger, err)
 	}
 
@@ -264,17 +623,8 @@ func createNfsDriverServer(logger lager.Logger, client dockerdriver.Driver, atAd
 	exitOnFailure(logger, err)
 
 	var server ifrit.Runner
-	if *requireSSL {
-		tlsConfig, err := tlsconfig.
-			Build(
-				tlsconfig.WithIdentityFromFile(*certFile, *keyFile),
-				tlsconfig.WithInternalServiceDefaults(),
-			).
-			Server(tlsconfig.WithClientAuthenticationFromFile(*caFile))
-		if err != nil {
-			logger.Fatal("tls-configuration-failed", err)
-		}
-		server = http_server.NewTLSServer(atAddress, handler, tlsConfig)
+	if tlsIdentity != nil {
+		server = http_server.NewTLSServer(atAddress, handler, tlsIdentity.ServerConfig())
 	} else {
 		server = http_server.New(atAddress, handler)
 	}
@@ -282,51 +632,406 @@ func createNfsDriverServer(logger lager.Logger, client dockerdriver.Driver, atAd
 	return server
 }
 
-func createNfsDriverUnixServer(logger lager.Logger, client dockerdriver.Driver, atAddress string) ifrit.Runner {
+// createAdminServer serves the admin API on adminAddr, or on the admin socket when one is configured. Requests must
+// present a bearer token when a token file is configured.
+func createAdminServer(logger lager.Logger, client driveradmin.DriverAdmin, cfg config, tlsIdentity nfsv3driver.ReloadableTLSIdentity) (ifrit.Runner, grouper.Members) {
+	handler, err := driveradminhttp.NewHandler(logger, client)
+	exitOnFailure(logger, err)
+
+	var watchers grouper.Members
+	if cfg.Admin.TokenFile != "" {
+		token, err := nfsv3driver.NewFileAdminToken(logger, &ioutilshim.IoutilShim{}, cfg.Admin.TokenFile)
+		exitOnFailure(logger, err)
+		handler = driveradminhttp.NewBearerTokenHandler(logger, handler, token)
+		watchers = grouper.Members{{
+			Name:   "admin-token-poller",
+			Runner: nfsv3driver.NewFilePoller(logger, &osshim.OsShim{}, cfg.Admin.TokenPollInterval, []string{cfg.Admin.TokenFile}, token.Reload),
+		}}
+	}
+
+	if cfg.Admin.Socket != "" {
+		socketConfig, err := cfg.Admin.UnixSocket.socketConfig()
+		exitOnFailure(logger, err)
+		socketPath, err := filepath.Abs(cfg.Admin.Socket)
+		exitOnFailure(logger, err)
+		return nfsv3driver.NewUnixSocketServer(logger, socketPath, handler, socketConfig), watchers
+	}
+	if cfg.Admin.RequireSSL {
+		return http_server.NewTLSServer(cfg.AdminAddr, handler, tlsIdentity.ServerConfig()), watchers
+	}
+	return http_server.New(cfg.AdminAddr, handler), watchers
+}
+
+// registerHealthChecks reports the driver server as live, and whether what mounts depend on is in place as ready
+func registerHealthChecks(logger lager.Logger, adminClient *driveradminlocal.DriverAdminLocal, cfg config, client nfsv3driver.VolumeLister, mounter volumedriver.Mounter, live *liveSettings) {
+	adminClient.SetHealthCheckTimeout(cfg.Admin.HealthCheckTimeout)
+
+	if cfg.Transport == "unix" {
+		socketPath, err := filepath.Abs(cfg.ListenAddr)
+		exitOnFailure(logger, err)
+		adminClient.RegisterLivenessCheck("driver-server", nfsv3driver.NewListenerHealthCheck("unix", socketPath))
+	} else {
+		adminClient.RegisterLivenessCheck("driver-server", nfsv3driver.NewListenerHealthCheck("tcp", cfg.ListenAddr))
+	}
+
+	adminClient.RegisterReadinessCheck("mapfs", nfsv3driver.NewExecutableHealthCheck(&osshim.OsShim{}, cfg.MapfsPath))
+	adminClient.RegisterReadinessCheck("fuse", nfsv3driver.NewDeviceHealthCheck(&osshim.OsShim{}, "/dev/fuse"))
+	adminClient.RegisterReadinessCheck("mount-nfs", nfsv3driver.NewExecutableHealthCheck(&osshim.OsShim{}, "/sbin/mount.nfs", "/usr/sbin/mount.nfs"))
+	adminClient.RegisterReadinessCheck("mount-dir", nfsv3driver.NewWritableDirHealthCheck(&osshim.OsShim{}, cfg.MountDir))
+	adminClient.RegisterReadinessCheck("state-file", nfsv3driver.NewStateFileHealthCheck(&ioutilshim.IoutilShim{}, client, filepath.Join(cfg.MountDir, "driver-state.json")))
+	adminClient.RegisterReadinessCheck("volumes", nfsv3driver.NewVolumeHealthCheck(client, mounter))
+
+	if live.resolverHelper != nil {
+		adminClient.RegisterReadinessCheck("id-resolver", nfsv3driver.NewDirectoryHealthCheck(live.resolverHelper, &timeshim.TimeShim{}))
+	} else if live.idResolver != nil {
+		adminClient.RegisterReadinessCheck("id-resolver", nfsv3driver.NewDirectoryHealthCheck(live.idResolver, &timeshim.TimeShim{}))
+	}
+}
+
+func createNfsDriverUnixServer(logger lager.Logger, client dockerdriver.Driver, cfg config) ifrit.Runner {
+	socketConfig, err := cfg.UnixSocket.socketConfig()
+	exitOnFailure(logger, err)
+	socketPath, err := filepath.Abs(cfg.ListenAddr)
+	exitOnFailure(logger, err)
+
+	if cfg.DriversPath != "" {
+		err = writeUnixSocketSpec(logger, cfg.DriversPath, socketPath)
+		exitOnFailure(logger, err)
+	}
+
 	handler, err := driverhttp.NewHandler(logger, client)
 	exitOnFailure(logger, err)
-	return http_server.NewUnixServer(atAddress, handler)
+	return nfsv3driver.NewUnixSocketServer(logger, socketPath, handler, socketConfig)
 }
 
-func newLogger() (lager.Logger, *lager.ReconfigurableSink) {
-	lagerConfig := lagerflags.ConfigFromFlags()
-	lagerConfig.RedactSecrets = true
+// writeUnixSocketSpec registers the socket the way docker plugins are discovered: as nfsv3driver.sock in the
+// drivers path, which is a symlink unless the socket itself lives there
+func writeUnixSocketSpec(logger lager.Logger, driversPath string, socketPath string) error {
+	specPath := filepath.Join(driversPath, "nfsv3driver.sock")
+	logger.Info("writing-spec-file", lager.Data{"location": driversPath, "name": "nfsv3driver", "address": socketPath})
 
-	return lagerflags.NewFromConfig("nfs-driver-server", lagerConfig)
+	absSpecPath, err := filepath.Abs(specPath)
+	if err != nil {
+		return err
+	}
+	if absSpecPath == socketPath {
+		return nil
+	}
+
+	if err := os.MkdirAll(driversPath, 0755); err != nil {
+		return err
+	}
+	if info, err := os.Lstat(specPath); err == nil {
+		if info.Mode()&(os.ModeSymlink|os.ModeSocket) == 0 {
+			return fmt.Errorf("%s exists and is not a socket or symlink", specPath)
+		}
+		if err := os.Remove(specPath); err != nil {
+			return err
+		}
+	}
+	return os.Symlink(socketPath, specPath)
 }
 
-func parseCommandLine() {
-	lagerflags.AddFlags(flag.CommandLine)
-	cf_debug_server.AddFlags(flag.CommandLine)
-	flag.Parse()
+// idResolverFactory builds the id resolver chain. The static resolver and the LDAP credential sources watch files,
+// so they are created once and shared by the chains rebuilt when the configuration is reloaded.
+type idResolverFactory struct {
+	staticResolver nfsv3driver.ReloadableIdResolver
+	// ldapCredentials are keyed by id resolver name, "ldap" or "ldap:<name>"
+	ldapCredentials map[string]nfsv3driver.ReloadableLdapCredentialSource
+	watchers        grouper.Members
+}
+
+func newIdResolverFactory(logger lager.Logger, cfg config) (*idResolverFactory, error) {
+	f := &idResolverFactory{ldapCredentials: map[string]nfsv3driver.ReloadableLdapCredentialSource{}}
+
+	for _, name := range cfg.IdResolvers {
+		switch name {
+		case "static":
+			staticResolver, err := nfsv3driver.NewStaticIdResolver(logger, &ioutilshim.IoutilShim{}, cfg.StaticUsers.File)
+			if err != nil {
+				return nil, err
+			}
+
+			f.staticResolver = staticResolver
+			f.watchers = append(f.watchers, grouper.Member{
+				Name:   "static-users-poller",
+				Runner: nfsv3driver.NewFilePoller(logger, &osshim.OsShim{}, cfg.StaticUsers.PollInterval, []string{cfg.StaticUsers.File}, staticResolver.Reload),
+			})
+		default:
+			ldap, ok := cfg.ldapSource(name)
+			paths := ldap.credentialFiles().Paths()
+			if !ok || ldap.Host == "" || len(paths) == 0 {
+				continue
+			}
+
+			credentials, err := nfsv3driver.NewLdapCredentialFileSource(logger, &ioutilshim.IoutilShim{}, ldap.credentialFiles(), nfsv3driver.LdapCredentials{
+				SvcUser: ldap.SvcUser,
+				SvcPass: ldap.SvcPass,
+				CACert:  ldap.CACert,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			f.ldapCredentials[name] = credentials
+			f.watchers = append(f.watchers, grouper.Member{
+				Name:   strings.Replace(name, ":", "-", 1) + "-credentials-poller",
+				Runner: nfsv3driver.NewFilePoller(logger, &osshim.OsShim{}, ldap.CredentialFiles.PollInterval, paths, credentials.Reload),
+			})
+		}
+	}
+
+	return f, nil
+}
+
+// build returns nil when no id resolver is configured
+func (f *idResolverFactory) build(logger lager.Logger, cfg config) (nfsv3driver.IdResolver, error) {
+	var sources []nfsv3driver.IdResolverSource
+
+	for _, name := range cfg.IdResolvers {
+		source := nfsv3driver.IdResolverSource{Name: name, Authoritative: slices.Contains(cfg.AuthoritativeIdResolvers, name)}
+		var pattern string
+
+		switch source.Name {
+		case "static":
+			source.Resolver = f.staticResolver
+			pattern = cfg.StaticUsers.UsernamePattern
+		default:
+			ldap, ok := cfg.ldapSource(source.Name)
+			if !ok {
+				return nil, fmt.Errorf("unknown id resolver '%s'", source.Name)
+			}
+			if ldap.Host == "" {
+				logger.Info("ldap-not-configured", lager.Data{"source": source.Name})
+				continue
+			}
+
+			ldapOpts := []nfsv3driver.LdapIdResolverOption{
+				nfsv3driver.WithLdapDomains(ldap.Domains),
+				nfsv3driver.WithRequiredGroups(ldap.groupMembershipPolicy()),
+				nfsv3driver.WithSearchOptions(ldap.searchOptions()),
+			}
+			if idMapRange := ldap.idMapRange(); idMapRange != nil {
+				ldapOpts = append(ldapOpts, nfsv3driver.WithSidIdMapping(*idMapRange))
+			}
+			if len(ldap.credentialFiles().Paths()) > 0 {
+				credentials, ok := f.ldapCredentials[source.Name]
+				if !ok {
+					return nil, errors.New("LDAP credential files can only be configured with a restart")
+				}
+				ldapOpts = append(ldapOpts, nfsv3driver.WithCredentialSource(credentials))
+			}
+
+			source.Resolver = nfsv3driver.NewLdapIdResolver(
+				ldap.SvcUser,
+				ldap.SvcPass,
+				ldap.Host,
+				ldap.Port,
+				ldap.Proto,
+				ldap.UserFqdn,
+				ldap.CACert,
+				&ldapshim.LdapShim{},
+				ldap.Timeout,
+				ldapOpts...,
+			)
+			pattern = ldap.UsernamePattern
+		}
+
+		if pattern != "" {
+			var err error
+			if source.Pattern, err = regexp.Compile(pattern); err != nil {
+				return nil, err
+			}
+		}
+
+		sources = append(sources, source)
+	}
+
+	switch {
+	case len(sources) == 0:
+		return nil, nil
+	case len(sources) == 1 && sources[0].Pattern == nil:
+		return sources[0].Resolver, nil
+	default:
+		return nfsv3driver.NewChainedIdResolver(sources), nil
+	}
 }
 
-func parseEnvironment() {
-	ldapSvcUser, _ = os.LookupEnv("LDAP_SVC_USER")
-	ldapSvcPass, _ = os.LookupEnv("LDAP_SVC_PASS")
-	ldapUserFqdn, _ = os.LookupEnv("LDAP_USER_FQDN")
-	ldapHost, _ = os.LookupEnv("LDAP_HOST")
-	port, _ := os.LookupEnv("LDAP_PORT")
-	ldapPort, _ = strconv.Atoi(port)
-	ldapCACert, _ = os.LookupEnv("LDAP_CA_CERT")
-	ldapProto, _ = os.LookupEnv("LDAP_PROTO")
-	timeout, _ := os.LookupEnv("LDAP_TIMEOUT")
-	ldapTimeout, _ = strconv.Atoi(timeout)
+// newResolverHelper re-executes this binary with -resolverHelper as an unprivileged user. The helper loads the id
+// resolvers from the same flags, environment and configuration file.
+func newResolverHelper(logger lager.Logger, cfg config) (nfsv3driver.ResolverHelper, grouper.Members) {
+	if !cfg.idResolversConfigured() {
+		return nil, nil
+	}
+
+	credential, err := resolverHelperCredential(cfg.ResolverHelperUser)
+	exitOnFailure(logger, err)
+	executable, err := os.Executable()
+	exitOnFailure(logger, err)
+	args := append(append([]string{}, os.Args[1:]...), "-resolverHelper")
+
+	helper := nfsv3driver.NewResolverHelper(logger, func() *exec.Cmd {
+		cmd := exec.Command(executable, args...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		cmd.SysProcAttr = &syscall.SysProcAttr{Credential: credential, Pdeathsig: syscall.SIGKILL}
+		return cmd
+	}, time.Second)
+
+	return helper, grouper.Members{{Name: "resolver-helper", Runner: helper}}
+}
+
+func runResolverHelper(logger lager.Logger, logSink *lager.ReconfigurableSink, cfg config) {
+	logger = logger.Session("resolver-helper-process")
+	if os.Geteuid() == 0 {
+		logger.Info("running-as-root")
+	}
+
+	resolvers, err := newIdResolverFactory(logger, cfg)
+	exitOnFailure(logger, err)
+	idResolver, err := resolvers.build(logger, cfg)
+	exitOnFailure(logger, err)
+	if idResolver == nil {
+		exitOnFailure(logger, errors.New("no id resolver is configured"))
+	}
+
+	live := &liveSettings{logSink: logSink, idResolver: nfsv3driver.NewSwappableIdResolver(idResolver), resolvers: resolvers}
+	members := append(resolvers.watchers, grouper.Member{Name: "config-reloader", Runner: newConfigReloader(logger, cfg, live)})
 
-	if ldapProto == "" {
-		ldapProto = "tcp"
+	process := ifrit.Invoke(grouper.NewParallel(os.Interrupt, members))
+	err = nfsv3driver.ServeResolverHelper(logger, live.idResolver, os.NewFile(nfsv3driver.ResolverHelperFd, "resolver-helper"))
+	process.Signal(os.Interrupt)
+	<-process.Wait()
+	exitOnFailure(logger, err)
+}
+
+func (c config) idResolversConfigured() bool {
+	for _, name := range c.IdResolvers {
+		if name == "static" {
+			return true
+		}
+		if ldap, ok := c.ldapSource(name); ok && ldap.Host != "" {
+			return true
+		}
 	}
+	return false
+}
 
-	if ldapHost != "" && (ldapSvcUser == "" || ldapSvcPass == "" || ldapUserFqdn == "" || ldapPort == 0) {
-		panic("LDAP is enabled but required LDAP parameters are not set.")
+func resolverHelperCredential(spec string) (*syscall.Credential, error) {
+	uidString, gidString, found := strings.Cut(spec, ":")
+	if !found {
+		u, err := user.Lookup(spec)
+		if err != nil {
+			return nil, err
+		}
+		uidString, gidString = u.Uid, u.Gid
 	}
 
-	if ldapTimeout < 0 {
-		panic("LDAP_TIMEOUT is set to negtive value")
+	uid, err := strconv.ParseUint(uidString, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resolver helper uid '%s'", uidString)
 	}
+	gid, err := strconv.ParseUint(gidString, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resolver helper gid '%s'", gidString)
+	}
+	if uid == 0 || gid == 0 {
+		return nil, errors.New("the resolver helper must not run as root")
+	}
+
+	return &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}, nil
+}
 
-	// if ldapTimeout is not set, use default value
-	if ldapTimeout == 0 {
-		ldapTimeout = 120
+func newTokenResolver(logger lager.Logger, settings tokenSettings) (nfsv3driver.TokenResolver, grouper.Members) {
+	if settings.KeysFile == "" {
+		return nil, nil
 	}
+
+	tokenResolver, err := nfsv3driver.NewJwsTokenResolver(logger, &ioutilshim.IoutilShim{}, settings.KeysFile, &timeshim.TimeShim{}, nfsv3driver.TokenConfig{
+		Audience:        settings.Audience,
+		Issuer:          settings.Issuer,
+		MaxAge:          settings.MaxAge,
+		Leeway:          settings.Leeway,
+		ReplayCacheSize: settings.ReplayCacheSize,
+	})
+	exitOnFailure(logger, err)
+
+	return tokenResolver, grouper.Members{{
+		Name:   "token-keys-poller",
+		Runner: nfsv3driver.NewFilePoller(logger, &osshim.OsShim{}, settings.PollInterval, []string{settings.KeysFile}, tokenResolver.Reload),
+	}}
+}
+
+func newPasswordDecrypter(logger lager.Logger, settings passwordKeySettings) (nfsv3driver.ReloadablePasswordDecrypter, grouper.Members) {
+	if settings.File == "" {
+		return nil, nil
+	}
+
+	decrypter, err := nfsv3driver.NewRsaPasswordDecrypter(logger, &ioutilshim.IoutilShim{}, settings.File)
+	exitOnFailure(logger, err)
+
+	return decrypter, grouper.Members{{
+		Name:   "password-key-poller",
+		Runner: nfsv3driver.NewFilePoller(logger, &osshim.OsShim{}, settings.PollInterval, []string{settings.File}, decrypter.Reload),
+	}}
+}
+
+// newTLSIdentity loads the server certificate and client CA once at startup and then again whenever one of the
+// files changes
+func newTLSIdentity(logger lager.Logger, settings tlsSettings) (nfsv3driver.ReloadableTLSIdentity, grouper.Members) {
+	if !settings.RequireSSL {
+		return nil, nil
+	}
+
+	files := nfsv3driver.TLSFiles{CertFile: settings.CertFile, KeyFile: settings.KeyFile, CAFile: settings.CAFile}
+	identity, err := nfsv3driver.NewTLSIdentity(logger, &ioutilshim.IoutilShim{}, &timeshim.TimeShim{}, "driver", files)
+	if err != nil {
+		logger.Fatal("tls-configuration-failed", err)
+	}
+
+	return identity, grouper.Members{{
+		Name:   "tls-poller",
+		Runner: nfsv3driver.NewFilePoller(logger, &osshim.OsShim{}, settings.PollInterval, files.Paths(), identity.Reload),
+	}}
+}
+
+// redactedLogKeys are lager's default key patterns, which cover the password and encrypted_password bind options,
+// plus the token option and keytabs, in case a secret reaches a log other than through the volume driver, which
+// never sees them.
+var redactedLogKeys = []string{"[Pp]wd", "[Pp]ass", "[Tt]oken", "[Kk]eytab"}
+
+// newLogger builds the sinks the way lagerflags.NewFromConfig does, but also returns the sink that writes the logs,
+// through which debug logs of single volumes and NFS servers can bypass the log level
+func newLogger(logLevel string) (lager.Logger, *lager.ReconfigurableSink, lager.Sink) {
+	lagerConfig := lagerflags.ConfigFromFlags()
+
+	var sink lager.Sink
+	if lagerConfig.TimeFormat == lagerflags.FormatRFC3339 {
+		sink = lager.NewPrettySink(os.Stdout, lager.DEBUG)
+	} else {
+		sink = lager.NewWriterSink(os.Stdout, lager.DEBUG)
+	}
+	sink, err := lager.NewRedactingSink(sink, redactedLogKeys, lagerConfig.RedactPatterns)
+	if err != nil {
+		panic(err)
+	}
+	if lagerConfig.MaxDataStringLength > 0 {
+		sink = lager.NewTruncatingSink(sink, lagerConfig.MaxDataStringLength)
+	}
+
+	minLevel, err := lager.LogLevelFromString(logLevel)
+	if err != nil {
+		minLevel = lager.INFO
+	}
+	logSink := lager.NewReconfigurableSink(sink, minLevel)
+
+	logger := lager.NewLogger("nfs-driver-server")
+	logger.RegisterSink(logSink)
+	return logger, logSink, sink
+}
+
+func parseCommandLine() {
+	lagerflags.AddFlags(flag.CommandLine)
+	cf_debug_server.AddFlags(flag.CommandLine)
+	flag.Parse()
 }
@@ -1,12 +1,25 @@
 package main_test
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
 	"io/ioutil"
+	"math/big"
 	"net"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
@@ -167,6 +180,878 @@ var _ = Describe("Main", func() {
 			})
 		})
 
+		Context("given LDAP service credentials in files", func() {
+			var passFile string
+
+			BeforeEach(func() {
+				passFile = filepath.Join(dir, "svc-pass")
+				Expect(ioutil.WriteFile(passFile, []byte("password\n"), 0600)).To(Succeed())
+
+				Expect(os.Setenv("LDAP_SVC_USER", "user")).To(Succeed())
+				Expect(os.Setenv("LDAP_SVC_PASS_FILE", passFile)).To(Succeed())
+				Expect(os.Setenv("LDAP_USER_FQDN", "cn=Users,dc=corp,dc=testdomain,dc=com")).To(Succeed())
+				Expect(os.Setenv("LDAP_HOST", "ldap.testdomain.com")).To(Succeed())
+				Expect(os.Setenv("LDAP_PORT", "389")).To(Succeed())
+				command.Args = append(command.Args, "-listenAddr=0.0.0.0:7597")
+				command.Args = append(command.Args, "-adminAddr=0.0.0.0:7598")
+			})
+
+			AfterEach(func() {
+				Expect(os.Unsetenv("LDAP_SVC_USER")).To(Succeed())
+				Expect(os.Unsetenv("LDAP_SVC_PASS_FILE")).To(Succeed())
+				Expect(os.Unsetenv("LDAP_USER_FQDN")).To(Succeed())
+				Expect(os.Unsetenv("LDAP_HOST")).To(Succeed())
+				Expect(os.Unsetenv("LDAP_PORT")).To(Succeed())
+			})
+
+			It("loads them and starts without LDAP_SVC_PASS", func() {
+				Expect(string(session.Out.Contents())).To(ContainSubstring("ldap-credentials-reload.loaded"))
+				EventuallyWithOffset(1, func() error {
+					_, err := net.Dial("tcp", "0.0.0.0:7597")
+					return err
+				}, 5).ShouldNot(HaveOccurred())
+			})
+		})
+
+		Context("given the static id resolver", func() {
+			var usersFile string
+
+			BeforeEach(func() {
+				usersFile = filepath.Join(dir, "users")
+				Expect(ioutil.WriteFile(usersFile, []byte("alice:$pbkdf2-sha256$1000$c2FsdA$dClvKSmj66n6MdMWNv3Go4mvH1Ym2WIGiJvquqa.mfE:1001:1001\n"), 0600)).To(Succeed())
+
+				command.Args = append(command.Args, "-idResolver=static")
+				command.Args = append(command.Args, "-listenAddr=0.0.0.0:7597")
+				command.Args = append(command.Args, "-adminAddr=0.0.0.0:7598")
+			})
+
+			Context("with a valid users file", func() {
+				BeforeEach(func() {
+					command.Args = append(command.Args, "-staticUsersFile="+usersFile)
+				})
+
+				It("loads the users and starts", func() {
+					Expect(string(session.Out.Contents())).To(ContainSubstring("static-users-reload.loaded"))
+					EventuallyWithOffset(1, func() error {
+						_, err := net.Dial("tcp", "0.0.0.0:7597")
+						return err
+					}, 5).ShouldNot(HaveOccurred())
+				})
+			})
+
+			Context("chained with ldap and restricted to a username pattern", func() {
+				BeforeEach(func() {
+					command.Args = append(command.Args, "-staticUsersFile="+usersFile)
+					command.Args = append(command.Args, "-idResolver=static,ldap")
+					command.Args = append(command.Args, "-staticUsernamePattern=^svc-")
+				})
+
+				It("starts", func() {
+					EventuallyWithOffset(1, func() error {
+						_, err := net.Dial("tcp", "0.0.0.0:7597")
+						return err
+					}, 5).ShouldNot(HaveOccurred())
+				})
+			})
+
+			Context("with an invalid username pattern", func() {
+				BeforeEach(func() {
+					command.Args = append(command.Args, "-staticUsersFile="+usersFile)
+					command.Args = append(command.Args, "-staticUsernamePattern=[")
+					expectedStartOutput = "invalid-configuration"
+					expectedStartErrOutput = "static_users.username_pattern: error parsing regexp"
+				})
+
+				It("fails to start", func() {
+					Eventually(session).Should(gexec.Exit())
+					Expect(session.ExitCode()).NotTo(BeZero())
+				})
+			})
+
+			Context("without a users file", func() {
+				BeforeEach(func() {
+					expectedStartOutput = "invalid-configuration"
+					expectedStartErrOutput = "static_users.file: is required by the static id resolver"
+				})
+
+				It("fails to start", func() {
+					Eventually(session).Should(gexec.Exit())
+					Expect(session.ExitCode()).NotTo(BeZero())
+				})
+			})
+		})
+
+		Context("given an unknown id resolver", func() {
+			BeforeEach(func() {
+				command.Args = append(command.Args, "-idResolver=kerberos")
+				expectedStartOutput = "unknown id resolver 'kerberos'"
+			})
+
+			It("fails to start", func() {
+				Eventually(session).Should(gexec.Exit())
+				Expect(session.ExitCode()).NotTo(BeZero())
+			})
+		})
+
+		Context("given a resolver helper running as root", func() {
+			BeforeEach(func() {
+				command.Args = append(command.Args, "-idResolver=static", "-resolverHelperUser=0:0")
+				expectedStartOutput = "the resolver helper must not run as root"
+			})
+
+			It("fails to start", func() {
+				Eventually(session).Should(gexec.Exit())
+				Expect(session.ExitCode()).NotTo(BeZero())
+			})
+		})
+
+		Context("given a configuration file", func() {
+			var configFile string
+
+			writeConfig := func(contents string) {
+				Expect(ioutil.WriteFile(configFile, []byte(contents), 0600)).To(Succeed())
+			}
+
+			BeforeEach(func() {
+				configFile = filepath.Join(dir, "config.yml")
+				writeConfig(`
+listen_addr: 0.0.0.0:7599
+admin_addr: 0.0.0.0:7600
+id_policy:
+  denied_uids: "0"
+`)
+				command.Args = append(command.Args, "-config="+configFile)
+			})
+
+			It("takes precedence over the flags", func() {
+				EventuallyWithOffset(1, func() error {
+					_, err := net.Dial("tcp", "0.0.0.0:7599")
+					return err
+				}, 5).ShouldNot(HaveOccurred())
+			})
+
+			Context("when it is reloaded", func() {
+				JustBeforeEach(func() {
+					Eventually(func() error {
+						_, err := net.Dial("tcp", "0.0.0.0:7599")
+						return err
+					}, 5).ShouldNot(HaveOccurred())
+				})
+
+				It("applies the settings that can change while running", func() {
+					writeConfig(`
+listen_addr: 0.0.0.0:7599
+admin_addr: 0.0.0.0:7600
+log_level: debug
+id_policy:
+  denied_uids: "0-999"
+`)
+					session.Signal(syscall.SIGHUP)
+					Eventually(session.Out).Should(gbytes.Say(`config-reload.applied.*"settings":\["log_level","id_policy.denied_uids"\]`))
+					Consistently(session).ShouldNot(gexec.Exit())
+				})
+
+				It("reports settings that require a restart", func() {
+					writeConfig(`
+listen_addr: 0.0.0.0:7601
+admin_addr: 0.0.0.0:7600
+`)
+					session.Signal(syscall.SIGHUP)
+					Eventually(session.Out).Should(gbytes.Say(`config-reload.restart-required.*"settings":\["listen_addr"\]`))
+				})
+
+				It("keeps running on the previous configuration when the new one is invalid", func() {
+					writeConfig(`log_level: verbose`)
+					session.Signal(syscall.SIGHUP)
+					Eventually(session.Out).Should(gbytes.Say(`config-reload.invalid-configuration.*unknown log level 'verbose'`))
+
+					Consistently(session).ShouldNot(gexec.Exit())
+					_, err := net.Dial("tcp", "0.0.0.0:7599")
+					Expect(err).NotTo(HaveOccurred())
+				})
+			})
+
+			Context("when it contains unknown settings", func() {
+				BeforeEach(func() {
+					writeConfig(`listen_address: 0.0.0.0:7599`)
+					expectedStartOutput = "invalid-configuration"
+					expectedStartErrOutput = "field listen_address not found"
+				})
+
+				It("fails to start", func() {
+					Eventually(session).Should(gexec.Exit(1))
+				})
+			})
+
+			Context("when it contains unknown settings and the environment is invalid too", func() {
+				BeforeEach(func() {
+					Expect(os.Setenv("LDAP_PORT", "ldap")).To(Succeed())
+					writeConfig(`listen_address: 0.0.0.0:7599`)
+					expectedStartOutput = "invalid-configuration"
+					expectedStartErrOutput = "invalid configuration:"
+				})
+
+				AfterEach(func() {
+					Expect(os.Unsetenv("LDAP_PORT")).To(Succeed())
+				})
+
+				It("reports the problems of both", func() {
+					Eventually(session).Should(gexec.Exit(1))
+					Expect(session.Err).To(gbytes.Say("LDAP_PORT: 'ldap' is not a number"))
+					Expect(session.Err).To(gbytes.Say("field listen_address not found"))
+				})
+			})
+
+			Context("when it is JSON", func() {
+				BeforeEach(func() {
+					writeConfig(`{"listen_addr": "0.0.0.0:7599", "admin_addr": "0.0.0.0:7600", "static_users": {"poll_interval": "1m"}}`)
+				})
+
+				It("starts", func() {
+					EventuallyWithOffset(1, func() error {
+						_, err := net.Dial("tcp", "0.0.0.0:7599")
+						return err
+					}, 5).ShouldNot(HaveOccurred())
+				})
+			})
+
+			Context("when it chains named LDAP sources", func() {
+				BeforeEach(func() {
+					usersFile := filepath.Join(dir, "users")
+					Expect(ioutil.WriteFile(usersFile, []byte("alice:$pbkdf2-sha256$1000$c2FsdA$dClvKSmj66n6MdMWNv3Go4mvH1Ym2WIGiJvquqa.mfE:1001:1001\n"), 0600)).To(Succeed())
+					passFile := filepath.Join(dir, "corp-svc-pass")
+					Expect(ioutil.WriteFile(passFile, []byte("password\n"), 0600)).To(Succeed())
+
+					writeConfig(`
+listen_addr: 0.0.0.0:7599
+admin_addr: 0.0.0.0:7600
+id_resolvers: [static, ldap:corp, ldap:partner]
+authoritative_id_resolvers: [static]
+static_users:
+  file: ` + usersFile + `
+  username_pattern: ^svc-
+ldap_sources:
+  corp:
+    host: ldap.corp.testdomain.com
+    port: 389
+    svc_user: user
+    user_fqdn: cn=Users,dc=corp,dc=testdomain,dc=com
+    credential_files:
+      svc_pass: ` + passFile + `
+  partner:
+    host: ldap.partner.testdomain.com
+    port: 636
+    proto: tcp
+    svc_user: user
+    svc_pass: password
+    user_fqdn: cn=Users,dc=partner,dc=testdomain,dc=com
+`)
+				})
+
+				It("loads the credential files of each source and starts", func() {
+					Expect(string(session.Out.Contents())).To(ContainSubstring("ldap-credentials-reload.loaded"))
+					EventuallyWithOffset(1, func() error {
+						_, err := net.Dial("tcp", "0.0.0.0:7599")
+						return err
+					}, 5).ShouldNot(HaveOccurred())
+				})
+			})
+
+			Context("when the named LDAP sources are invalid", func() {
+				BeforeEach(func() {
+					writeConfig(`
+id_resolvers: [ldap:corp, ldap:partner]
+authoritative_id_resolvers: [static]
+ldap_sources:
+  corp:
+    port: 70000
+`)
+					expectedStartOutput = "invalid-configuration"
+					expectedStartErrOutput = "invalid configuration:"
+				})
+
+				It("reports all of them", func() {
+					Eventually(session).Should(gexec.Exit(1))
+					Expect(session.Err).To(gbytes.Say("authoritative_id_resolvers: 'static' is not one of the id_resolvers"))
+					Expect(session.Err).To(gbytes.Say("id_resolvers: unknown ldap source 'partner', it must be defined in ldap_sources"))
+					Expect(session.Err).To(gbytes.Say("ldap_sources.corp.host: is required"))
+					Expect(session.Err).To(gbytes.Say("ldap_sources.corp.port: must be between 1 and 65535"))
+				})
+			})
+
+			Context("when a named LDAP source contains unknown settings", func() {
+				BeforeEach(func() {
+					writeConfig(`
+ldap_sources:
+  corp:
+    hots: ldap.corp.testdomain.com
+`)
+					expectedStartOutput = "invalid-configuration"
+					expectedStartErrOutput = "ldap_sources.corp: yaml: unmarshal errors:"
+				})
+
+				It("fails to start", func() {
+					Eventually(session).Should(gexec.Exit(1))
+					Expect(session.Err).To(gbytes.Say("field hots not found"))
+				})
+			})
+
+			Context("when several settings are invalid", func() {
+				BeforeEach(func() {
+					writeConfig(`
+transport: udp
+ldap:
+  host: ldap.testdomain.com
+  port: 70000
+login_throttle:
+  max_user_failures: -1
+`)
+					expectedStartOutput = "invalid-configuration"
+					expectedStartErrOutput = "invalid configuration:"
+				})
+
+				It("reports all of them", func() {
+					Eventually(session).Should(gexec.Exit(1))
+					Expect(session.Err).To(gbytes.Say("ldap.port: must be between 1 and 65535"))
+					Expect(session.Err).To(gbytes.Say("ldap: LDAP is enabled but required LDAP parameters are not set: svc_user, svc_pass, user_fqdn"))
+					Expect(session.Err).To(gbytes.Say("login_throttle.max_user_failures: must not be negative"))
+					Expect(session.Err).To(gbytes.Say("transport: unknown transport 'udp'"))
+				})
+			})
+		})
+
+		Context("given the unix transport", func() {
+			var socketPath string
+
+			BeforeEach(func() {
+				socketPath = filepath.Join(dir, "sockets", "nfsv3driver.sock")
+				Expect(os.MkdirAll(filepath.Dir(socketPath), 0755)).To(Succeed())
+
+				command.Args = append(command.Args, "-transport=unix", "-listenAddr="+socketPath, "-adminAddr=0.0.0.0:7600")
+			})
+
+			It("registers the socket in the drivers path", func() {
+				Eventually(func() error {
+					_, err := net.Dial("unix", filepath.Join(dir, "nfsv3driver.sock"))
+					return err
+				}, 5).ShouldNot(HaveOccurred())
+
+				target, err := os.Readlink(filepath.Join(dir, "nfsv3driver.sock"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(target).To(Equal(socketPath))
+			})
+
+			It("restricts the socket's permissions", func() {
+				Eventually(func() error {
+					_, err := net.Dial("unix", socketPath)
+					return err
+				}, 5).ShouldNot(HaveOccurred())
+
+				info, err := os.Stat(socketPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(info.Mode().Perm()).To(Equal(os.FileMode(0660)))
+			})
+
+			Context("when a stale socket is left behind", func() {
+				BeforeEach(func() {
+					listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: socketPath, Net: "unix"})
+					Expect(err).NotTo(HaveOccurred())
+					listener.SetUnlinkOnClose(false)
+					Expect(listener.Close()).To(Succeed())
+				})
+
+				It("replaces it", func() {
+					Eventually(func() error {
+						_, err := net.Dial("unix", socketPath)
+						return err
+					}, 5).ShouldNot(HaveOccurred())
+				})
+			})
+
+			Context("with an invalid socket mode", func() {
+				BeforeEach(func() {
+					command.Args = append(command.Args, "-unixSocketMode=rw-rw----")
+					expectedStartOutput = "invalid-configuration"
+					expectedStartErrOutput = "unix_socket.mode: invalid mode 'rw-rw----'"
+				})
+
+				It("fails to start", func() {
+					Eventually(session).Should(gexec.Exit(1))
+				})
+			})
+		})
+
+		Context("given TLS certificates", func() {
+			var (
+				ca         *x509.Certificate
+				caKey      *rsa.PrivateKey
+				certsDir   string
+				adminCerts = func() string {
+					resp, err := http.Get("http://0.0.0.0:7604/certificates")
+					if err != nil {
+						return err.Error()
+					}
+					defer resp.Body.Close()
+					body, err := ioutil.ReadAll(resp.Body)
+					Expect(err).NotTo(HaveOccurred())
+					return string(body)
+				}
+			)
+
+			BeforeEach(func() {
+				certsDir = filepath.Join(dir, "certs")
+				Expect(os.MkdirAll(certsDir, 0755)).To(Succeed())
+				ca, caKey = writeCertificate(filepath.Join(certsDir, "ca"), "ca", 365*24*time.Hour, nil, nil)
+				writeCertificate(filepath.Join(certsDir, "server"), "nfsv3driver", 30*24*time.Hour+time.Hour, ca, caKey)
+
+				command.Args = append(command.Args,
+					"-listenAddr=0.0.0.0:7603",
+					"-adminAddr=0.0.0.0:7604",
+					"-requireSSL",
+					"-caFile="+filepath.Join(certsDir, "ca.pem"),
+					"-certFile="+filepath.Join(certsDir, "server.pem"),
+					"-keyFile="+filepath.Join(certsDir, "server-key.pem"),
+					"-tlsPollInterval=50ms",
+				)
+			})
+
+			It("reports the certificate's expiry on the admin API", func() {
+				Eventually(adminCerts, 5).Should(ContainSubstring(`"Subject":"CN=nfsv3driver"`))
+				Expect(adminCerts()).To(ContainSubstring(`"DaysUntilExpiry":30`))
+			})
+
+			It("picks up rotated certificates without a restart", func() {
+				Eventually(adminCerts, 5).Should(ContainSubstring(`"DaysUntilExpiry":30`))
+
+				writeCertificate(filepath.Join(certsDir, "server"), "nfsv3driver", 90*24*time.Hour+time.Hour, ca, caKey)
+				Eventually(session.Out, 5).Should(gbytes.Say(`tls-reload.loaded.*"days-until-expiry":90`))
+				Expect(adminCerts()).To(ContainSubstring(`"DaysUntilExpiry":90`))
+			})
+
+			Context("when the admin server requires TLS", func() {
+				var client *http.Client
+
+				BeforeEach(func() {
+					command.Args = append(command.Args, "-adminRequireSSL")
+
+					clientCert, clientKey := writeCertificate(filepath.Join(certsDir, "client"), "rep", time.Hour, ca, caKey)
+					roots := x509.NewCertPool()
+					roots.AddCert(ca)
+					client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+						RootCAs:      roots,
+						ServerName:   "nfsv3driver",
+						Certificates: []tls.Certificate{{Certificate: [][]byte{clientCert.Raw}, PrivateKey: clientKey}},
+					}}}
+				})
+
+				It("serves clients with a trusted certificate", func() {
+					Eventually(func() (int, error) {
+						resp, err := client.Get("https://0.0.0.0:7604/ping")
+						if err != nil {
+							return 0, err
+						}
+						resp.Body.Close()
+						return resp.StatusCode, nil
+					}, 5).Should(Equal(http.StatusOK))
+				})
+
+				It("does not serve plain http", func() {
+					Eventually(func() error {
+						_, err := net.Dial("tcp", "0.0.0.0:7604")
+						return err
+					}, 5).ShouldNot(HaveOccurred())
+
+					resp, err := http.Get("http://0.0.0.0:7604/ping")
+					if err == nil {
+						defer resp.Body.Close()
+						Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+					}
+				})
+			})
+
+			Context("with an invalid poll interval", func() {
+				BeforeEach(func() {
+					command.Args = append(command.Args, "-tlsPollInterval=0s")
+					expectedStartOutput = "invalid-configuration"
+					expectedStartErrOutput = "tls.poll_interval: must be positive"
+				})
+
+				It("fails to start", func() {
+					Eventually(session).Should(gexec.Exit(1))
+				})
+			})
+		})
+
+		Context("given an admin token file", func() {
+			var token string
+
+			adminRequest := func(method string, path string, token string) (int, error) {
+				req, err := http.NewRequest(method, "http://0.0.0.0:7606"+path, nil)
+				Expect(err).NotTo(HaveOccurred())
+				if token != "" {
+					req.Header.Set("Authorization", "Bearer "+token)
+				}
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					return 0, err
+				}
+				resp.Body.Close()
+				return resp.StatusCode, nil
+			}
+
+			BeforeEach(func() {
+				token = strings.Repeat("0123456789abcdef", 2)
+				tokenFile := filepath.Join(dir, "admin_token")
+				Expect(ioutil.WriteFile(tokenFile, []byte(token+"\n"), 0600)).To(Succeed())
+
+				command.Args = append(command.Args, "-listenAddr=0.0.0.0:7605", "-adminAddr=0.0.0.0:7606", "-adminTokenFile="+tokenFile)
+			})
+
+			It("serves requests that present the token", func() {
+				Eventually(func() (int, error) {
+					return adminRequest("GET", "/ping", token)
+				}, 5).Should(Equal(http.StatusOK))
+			})
+
+			It("rejects requests without the token", func() {
+				Eventually(func() (int, error) {
+					return adminRequest("GET", "/ping", "")
+				}, 5).Should(Equal(http.StatusUnauthorized))
+				Expect(adminRequest("GET", "/ping", strings.Repeat("x", 32))).To(Equal(http.StatusUnauthorized))
+			})
+
+			It("only evacuates on POST", func() {
+				Eventually(func() (int, error) {
+					return adminRequest("GET", "/evacuate", token)
+				}, 5).Should(Equal(http.StatusMethodNotAllowed))
+				Consistently(session, "200ms").ShouldNot(gexec.Exit())
+
+				Expect(adminRequest("POST", "/evacuate", token)).To(Equal(http.StatusAccepted))
+				Eventually(session, 5).Should(gexec.Exit())
+			})
+
+			Context("when the token is too short", func() {
+				BeforeEach(func() {
+					Expect(ioutil.WriteFile(filepath.Join(dir, "admin_token"), []byte("short"), 0600)).To(Succeed())
+					expectedStartOutput = "admin tokens must be at least 32 characters long"
+				})
+
+				It("fails to start", func() {
+					Eventually(session).Should(gexec.Exit(2))
+				})
+			})
+		})
+
+		Context("given an admin socket", func() {
+			var socketPath string
+
+			BeforeEach(func() {
+				socketPath = filepath.Join(dir, "admin.sock")
+				command.Args = append(command.Args, "-listenAddr=0.0.0.0:7605", "-adminSocket="+socketPath)
+			})
+
+			It("serves the admin API on it instead of adminAddr", func() {
+				client := &http.Client{Transport: &http.Transport{
+					Dial: func(_, _ string) (net.Conn, error) {
+						return net.Dial("unix", socketPath)
+					},
+				}}
+				Eventually(func() (int, error) {
+					resp, err := client.Get("http://nfsv3driver/ping")
+					if err != nil {
+						return 0, err
+					}
+					resp.Body.Close()
+					return resp.StatusCode, nil
+				}, 5).Should(Equal(http.StatusOK))
+
+				_, err := net.Dial("tcp", "0.0.0.0:7590")
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("restricts the socket to the driver's user", func() {
+				Eventually(func() error {
+					_, err := net.Dial("unix", socketPath)
+					return err
+				}, 5).ShouldNot(HaveOccurred())
+
+				info, err := os.Stat(socketPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+			})
+
+			Context("when the admin server also requires TLS", func() {
+				BeforeEach(func() {
+					command.Args = append(command.Args, "-adminRequireSSL")
+					expectedStartOutput = "invalid-configuration"
+					expectedStartErrOutput = "admin.require_ssl: is not supported with admin.socket"
+				})
+
+				It("fails to start", func() {
+					Eventually(session).Should(gexec.Exit(1))
+				})
+			})
+		})
+
+		Context("given the health endpoints", func() {
+			get := func(path string) (int, driveradmin.HealthResponse, error) {
+				var health driveradmin.HealthResponse
+				resp, err := http.Get("http://0.0.0.0:7608" + path)
+				if err != nil {
+					return 0, health, err
+				}
+				defer resp.Body.Close()
+				err = json.NewDecoder(resp.Body).Decode(&health)
+				return resp.StatusCode, health, err
+			}
+
+			componentNames := func(health driveradmin.HealthResponse) []string {
+				var names []string
+				for _, component := range health.Components {
+					names = append(names, component.Name)
+				}
+				return names
+			}
+
+			BeforeEach(func() {
+				command.Args = append(command.Args, "-listenAddr=0.0.0.0:7607", "-adminAddr=0.0.0.0:7608", "-mountDir="+dir)
+			})
+
+			It("reports the driver server as live", func() {
+				Eventually(func() (int, error) {
+					status, _, err := get("/health/live")
+					return status, err
+				}, 5).Should(Equal(http.StatusOK))
+
+				_, health, err := get("/health/live")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(health.Healthy).To(BeTrue())
+				Expect(componentNames(health)).To(Equal([]string{"driver-server"}))
+			})
+
+			It("reports whether the driver can mount volumes", func() {
+				var (
+					status int
+					health driveradmin.HealthResponse
+				)
+				Eventually(func() error {
+					var err error
+					status, health, err = get("/health/ready")
+					return err
+				}, 5).Should(Succeed())
+
+				Expect(componentNames(health)).To(Equal([]string{"driver-server", "mapfs", "fuse", "mount-nfs", "mount-dir", "state-file", "volumes"}))
+				if health.Healthy {
+					Expect(status).To(Equal(http.StatusOK))
+				} else {
+					Expect(status).To(Equal(http.StatusServiceUnavailable))
+				}
+				for _, component := range health.Components {
+					if component.Name == "mount-dir" || component.Name == "state-file" || component.Name == "volumes" {
+						Expect(component.Healthy).To(BeTrue(), component.Name)
+					}
+				}
+			})
+
+			Context("when the health check timeout is not positive", func() {
+				BeforeEach(func() {
+					command.Args = append(command.Args, "-adminHealthCheckTimeout=0s")
+					expectedStartOutput = "invalid-configuration"
+					expectedStartErrOutput = "admin.health_check_timeout: must be positive"
+				})
+
+				It("fails to start", func() {
+					Eventually(session).Should(gexec.Exit(1))
+				})
+			})
+		})
+
+		Context("given the volumes endpoint", func() {
+			BeforeEach(func() {
+				command.Args = append(command.Args, "-listenAddr=0.0.0.0:7607", "-adminAddr=0.0.0.0:7608", "-mountDir="+dir)
+			})
+
+			It("lists the volumes as JSON", func() {
+				var volumes driveradmin.VolumesResponse
+				Eventually(func() error {
+					resp, err := http.Get("http://0.0.0.0:7608/volumes?server=nfs.example.com")
+					if err != nil {
+						return err
+					}
+					defer resp.Body.Close()
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+					return json.NewDecoder(resp.Body).Decode(&volumes)
+				}, 5).Should(Succeed())
+
+				Expect(volumes.Err).To(BeEmpty())
+				Expect(volumes.Volumes).To(BeEmpty())
+			})
+		})
+
+		Context("given a volume created with secret options", func() {
+			BeforeEach(func() {
+				command.Args = append(command.Args, "-listenAddr=0.0.0.0:7607", "-adminAddr=0.0.0.0:7608", "-mountDir="+dir)
+			})
+
+			It("does not log the secrets", func() {
+				request := `{"Name": "vol1", "Opts": {"source": "nfs://nfs.example.com/export", "token": "secret-identity-token", "password": "secret-password"}}`
+				Eventually(func() error {
+					resp, err := http.Post("http://0.0.0.0:7607/VolumeDriver.Create", "application/json", strings.NewReader(request))
+					if err != nil {
+						return err
+					}
+					defer resp.Body.Close()
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+					return nil
+				}, 5).Should(Succeed())
+
+				Eventually(session.Out).Should(gbytes.Say(`with-opts`))
+				Expect(string(session.Out.Contents())).NotTo(ContainSubstring("secret-identity-token"))
+				Expect(string(session.Out.Contents())).NotTo(ContainSubstring("secret-password"))
+			})
+		})
+
+		Context("given a remount of a volume the driver does not know", func() {
+			BeforeEach(func() {
+				command.Args = append(command.Args, "-listenAddr=0.0.0.0:7607", "-adminAddr=0.0.0.0:7608", "-mountDir="+dir)
+			})
+
+			It("fails and audits the request", func() {
+				var response driveradmin.ErrorResponse
+				Eventually(func() error {
+					resp, err := http.Post("http://0.0.0.0:7608/volumes/missing/remount", "application/json", nil)
+					if err != nil {
+						return err
+					}
+					defer resp.Body.Close()
+					Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+					return json.NewDecoder(resp.Body).Decode(&response)
+				}, 5).Should(Succeed())
+
+				Expect(response.Err).To(Equal("volume missing not found"))
+				Eventually(session.Out).Should(gbytes.Say(`handle-remount.audit.failed`))
+			})
+		})
+
+		Context("given a cordoned NFS server", func() {
+			BeforeEach(func() {
+				command.Args = append(command.Args, "-listenAddr=0.0.0.0:7607", "-adminAddr=0.0.0.0:7608", "-mountDir="+dir)
+			})
+
+			It("keeps the cordon in the mount directory", func() {
+				var response driveradmin.CordonResponse
+				Eventually(func() error {
+					resp, err := http.Post("http://0.0.0.0:7608/cordon?server=nfs.example.com", "application/json", nil)
+					if err != nil {
+						return err
+					}
+					defer resp.Body.Close()
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+					return json.NewDecoder(resp.Body).Decode(&response)
+				}, 5).Should(Succeed())
+
+				Expect(response.Servers).To(Equal([]string{"nfs.example.com"}))
+				Expect(filepath.Join(dir, "cordon-state.json")).To(BeAnExistingFile())
+			})
+		})
+
+		Context("given the log level endpoints", func() {
+			BeforeEach(func() {
+				command.Args = append(command.Args, "-listenAddr=0.0.0.0:7607", "-adminAddr=0.0.0.0:7608", "-mountDir="+dir)
+			})
+
+			It("changes the log level at runtime", func() {
+				var response driveradmin.LogLevelResponse
+				Eventually(func() error {
+					resp, err := http.Post("http://0.0.0.0:7608/log-level?level=error", "application/json", nil)
+					if err != nil {
+						return err
+					}
+					defer resp.Body.Close()
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+					return json.NewDecoder(resp.Body).Decode(&response)
+				}, 5).Should(Succeed())
+				Expect(response.Level).To(Equal("error"))
+
+				resp, err := http.Post("http://0.0.0.0:7608/log-level/debug?volume=vol1&duration=1m", "application/json", nil)
+				Expect(err).NotTo(HaveOccurred())
+				defer resp.Body.Close()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(json.NewDecoder(resp.Body).Decode(&response)).To(Succeed())
+				Expect(response.DebugScopes).To(HaveLen(1))
+				Expect(response.DebugScopes[0].Volume).To(Equal("vol1"))
+			})
+		})
+
+		Context("given an evacuation that only drains", func() {
+			BeforeEach(func() {
+				command.Args = append(command.Args, "-listenAddr=0.0.0.0:7607", "-adminAddr=0.0.0.0:7608", "-mountDir="+dir, "-evacuationTimeout=10s")
+			})
+
+			It("reports its progress and keeps the driver running", func() {
+				Eventually(func() (int, error) {
+					resp, err := http.Post("http://0.0.0.0:7608/evacuate?exit=false", "application/json", nil)
+					if err != nil {
+						return 0, err
+					}
+					resp.Body.Close()
+					return resp.StatusCode, nil
+				}, 5).Should(Equal(http.StatusAccepted))
+
+				Eventually(func() (string, error) {
+					var evacuation driveradmin.EvacuationResponse
+					resp, err := http.Get("http://0.0.0.0:7608/evacuation")
+					if err != nil {
+						return "", err
+					}
+					defer resp.Body.Close()
+					err = json.NewDecoder(resp.Body).Decode(&evacuation)
+					return evacuation.State, err
+				}, 10).Should(Equal(driveradmin.EvacuationSucceeded))
+				Consistently(session, "200ms").ShouldNot(gexec.Exit())
+			})
+
+			Context("when the evacuation parallelism is not positive", func() {
+				BeforeEach(func() {
+					command.Args = append(command.Args, "-evacuationParallelism=0")
+					expectedStartOutput = "invalid-configuration"
+					expectedStartErrOutput = "admin.evacuation_parallelism: must be at least 1"
+				})
+
+				It("fails to start", func() {
+					Eventually(session).Should(gexec.Exit(1))
+				})
+			})
+		})
+
+		Context("given an admin server requiring TLS without driver certificates", func() {
+			BeforeEach(func() {
+				command.Args = append(command.Args, "-adminRequireSSL")
+				expectedStartOutput = "invalid-configuration"
+				expectedStartErrOutput = "admin.require_ssl: requires tls.require_ssl"
+			})
+
+			It("fails to start", func() {
+				Eventually(session).Should(gexec.Exit(1))
+			})
+		})
+
+		Context("given an invalid LDAP_PORT in the environment", func() {
+			BeforeEach(func() {
+				Expect(os.Setenv("LDAP_PORT", "ldap")).To(Succeed())
+				expectedStartOutput = "invalid-configuration"
+				expectedStartErrOutput = "LDAP_PORT: 'ldap' is not a number"
+			})
+
+			AfterEach(func() {
+				Expect(os.Unsetenv("LDAP_PORT")).To(Succeed())
+			})
+
+			It("fails to start", func() {
+				Eventually(session).Should(gexec.Exit(1))
+			})
+		})
+
 		Context("given LDAP_TIMEOUT are set in the the environment", func() {
 			BeforeEach(func() {
 				Expect(os.Setenv("LDAP_SVC_USER", "user")).To(Succeed())
@@ -199,3 +1084,33 @@ var _ = Describe("Main", func() {
 		})
 	})
 })
+
+// writeCertificate writes prefix.pem and prefix-key.pem, signed by issuer or self-signed when issuer is nil
+func writeCertificate(prefix string, commonName string, validFor time.Duration, issuer *x509.Certificate, issuerKey *rsa.PrivateKey) (*x509.Certificate, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Expect(err).NotTo(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(validFor),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{commonName},
+	}
+	if issuer == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		issuer, issuerKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, issuerKey)
+	Expect(err).NotTo(HaveOccurred())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+
+	Expect(ioutil.WriteFile(prefix+"-key.pem", pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600)).To(Succeed())
+	Expect(ioutil.WriteFile(prefix+".pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)).To(Succeed())
+	return cert, key
+}
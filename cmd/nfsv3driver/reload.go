@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/nfsv3driver"
+)
+
+// configReloader reloads the configuration on SIGHUP and applies the settings that are safe to change while
+// running. Changes to any other setting are logged and take effect on the next restart.
+type configReloader struct {
+	logger  lager.Logger
+	current config
+	live    *liveSettings
+}
+
+func newConfigReloader(logger lager.Logger, current config, live *liveSettings) *configReloader {
+	return &configReloader{
+		logger:  logger.Session("config-reload"),
+		current: current,
+		live:    live,
+	}
+}
+
+func (r *configReloader) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	close(ready)
+	for {
+		select {
+		case <-signals:
+			return nil
+		case <-hup:
+			r.reload()
+		}
+	}
+}
+
+func (r *configReloader) reload() {
+	logger := r.logger
+	logger.Info("start")
+	defer logger.Info("end")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		logger.Error("invalid-configuration", err)
+		return
+	}
+
+	next := r.current.withLiveSettings(cfg)
+	if restart := changedSettings(next, cfg); len(restart) > 0 {
+		logger.Info("restart-required", lager.Data{"settings": restart})
+	}
+
+	changed := changedSettings(r.current, next)
+	if err := r.live.apply(logger, next, changed); err != nil {
+		logger.Error("failed-to-apply", err)
+		return
+	}
+	r.current = next
+	logger.Info("applied", lager.Data{"settings": changed})
+}
+
+// liveSettings are the components that pick up configuration changes without a restart
+type liveSettings struct {
+	logSink  *lager.ReconfigurableSink
+	idPolicy *nfsv3driver.SwappableIdPolicy
+
+	// idResolver is rebuilt by resolvers when the LDAP settings or sources change, unless resolution happens in
+	// resolverHelper, which reloads its own configuration
+	idResolver     *nfsv3driver.SwappableIdResolver
+	resolvers      *idResolverFactory
+	resolverHelper nfsv3driver.ResolverHelper
+}
+
+func (l *liveSettings) apply(logger lager.Logger, cfg config, changed []string) error {
+	if l.resolverHelper != nil {
+		if err := l.resolverHelper.Reload(); err != nil {
+			return err
+		}
+	} else if settingsChanged(changed, "ldap") || slices.Contains(changed, "ldap_sources") {
+		if l.idResolver == nil {
+			return errors.New("id resolution was not configured at startup, enabling it requires a restart")
+		}
+		resolver, err := l.resolvers.build(logger, cfg)
+		if err != nil {
+			return err
+		}
+		if resolver == nil {
+			return errors.New("disabling id resolution requires a restart")
+		}
+		l.idResolver.Swap(resolver)
+	}
+
+	if l.idPolicy != nil {
+		policy, err := cfg.IdPolicy.policy()
+		if err != nil {
+			return err
+		}
+		l.idPolicy.Swap(policy)
+	}
+
+	// a level set through the admin API lasts until log_level itself changes
+	if slices.Contains(changed, "log_level") {
+		l.logSink.SetMinLevel(logLevels[cfg.LogLevel])
+	}
+	return nil
+}
+
+func settingsChanged(changed []string, section string) bool {
+	for _, path := range changed {
+		if strings.HasPrefix(path, section+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,214 @@
+package nfsv3driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/goshims/ioutilshim"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+// CordoningDriver is a driver whose new mounts can be stopped, e.g. while an NFS server is being maintained
+type CordoningDriver interface {
+	DrainableDriver
+	driveradmin.Cordoner
+	VolumeServers
+}
+
+type cordonState struct {
+	All     bool     `json:"all"`
+	Servers []string `json:"servers"`
+}
+
+type cordoningDriver struct {
+	DrainableDriver
+	ioutil    ioutilshim.Ioutil
+	statePath string
+
+	lock    sync.RWMutex
+	all     bool
+	servers map[string]bool
+
+	// sources are the sources the volumes were created with, which the driver does not report. They have their own
+	// lock because the log controller looks them up while logging.
+	sourcesLock sync.RWMutex
+	sources     map[string]string
+}
+
+// NewCordoningDriver refuses to mount volumes that are not mounted yet while the driver or their NFS server is
+// cordoned. Volumes that are already mounted can be mounted again and unmounted. The cordon state is kept in
+// statePath and restored from it.
+func NewCordoningDriver(logger lager.Logger, driver DrainableDriver, ioutil ioutilshim.Ioutil, statePath string) CordoningDriver {
+	d := &cordoningDriver{
+		DrainableDriver: driver,
+		ioutil:          ioutil,
+		statePath:       statePath,
+		servers:         map[string]bool{},
+		sources:         map[string]string{},
+	}
+	d.restoreState(logger)
+	return d
+}
+
+func (d *cordoningDriver) Create(env dockerdriver.Env, createRequest dockerdriver.CreateRequest) dockerdriver.ErrorResponse {
+	response := d.DrainableDriver.Create(env, createRequest)
+	if response.Err == "" {
+		source, _ := createRequest.Opts["source"].(string)
+
+		d.sourcesLock.Lock()
+		d.sources[createRequest.Name] = source
+		d.sourcesLock.Unlock()
+	}
+	return response
+}
+
+func (d *cordoningDriver) Mount(env dockerdriver.Env, mountRequest dockerdriver.MountRequest) dockerdriver.MountResponse {
+	if reason := d.cordoned(env, mountRequest.Name); reason != "" {
+		logger := env.Logger().Session("mount", lager.Data{"volume": mountRequest.Name})
+		logger.Info("cordoned", lager.Data{"reason": reason})
+
+		errBytes, err := json.Marshal(dockerdriver.SafeError{SafeDescription: reason})
+		if err != nil {
+			return dockerdriver.MountResponse{Err: reason}
+		}
+		return dockerdriver.MountResponse{Err: string(errBytes)}
+	}
+	return d.DrainableDriver.Mount(env, mountRequest)
+}
+
+func (d *cordoningDriver) Remove(env dockerdriver.Env, removeRequest dockerdriver.RemoveRequest) dockerdriver.ErrorResponse {
+	response := d.DrainableDriver.Remove(env, removeRequest)
+	if response.Err == "" {
+		d.sourcesLock.Lock()
+		delete(d.sources, removeRequest.Name)
+		d.sourcesLock.Unlock()
+	}
+	return response
+}
+
+// cordoned explains why name may not be mounted, or returns empty if it may
+func (d *cordoningDriver) cordoned(env dockerdriver.Env, name string) string {
+	server := d.VolumeServer(name)
+
+	d.lock.RLock()
+	all := d.all
+	serverCordoned := server != "" && d.servers[server]
+	d.lock.RUnlock()
+
+	if !all && !serverCordoned {
+		return ""
+	}
+	for _, volume := range d.DrainableDriver.List(env).Volumes {
+		if volume.Name == name && volume.MountCount > 0 {
+			return ""
+		}
+	}
+
+	if serverCordoned {
+		return fmt.Sprintf("new mounts from nfs server %s are suspended for maintenance", server)
+	}
+	return "new mounts are suspended for maintenance"
+}
+
+func (d *cordoningDriver) VolumeServer(volume string) string {
+	d.sourcesLock.RLock()
+	defer d.sourcesLock.RUnlock()
+
+	return sourceServer(d.sources[volume])
+}
+
+func (d *cordoningDriver) Cordon(env dockerdriver.Env, server string) (driveradmin.CordonStatus, error) {
+	return d.update(env, server, true)
+}
+
+func (d *cordoningDriver) Uncordon(env dockerdriver.Env, server string) (driveradmin.CordonStatus, error) {
+	return d.update(env, server, false)
+}
+
+// update cordons or uncordons server, or the whole driver when server is empty, once the new state is persisted
+func (d *cordoningDriver) update(env dockerdriver.Env, server string, cordoned bool) (driveradmin.CordonStatus, error) {
+	logger := env.Logger().Session("update-cordon", lager.Data{"server": server, "cordoned": cordoned})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	all := d.all
+	servers := map[string]bool{}
+	for s := range d.servers {
+		servers[s] = true
+	}
+	if server == "" {
+		all = cordoned
+	} else if cordoned {
+		servers[server] = true
+	} else {
+		delete(servers, server)
+	}
+
+	state := cordonState{All: all, Servers: sortedServers(servers)}
+	stateData, err := json.Marshal(state)
+	if err != nil {
+		logger.Error("failed-to-marshall-state", err)
+		return driveradmin.CordonStatus{}, err
+	}
+	if err := d.ioutil.WriteFile(d.statePath, stateData, 0644); err != nil {
+		logger.Error("failed-to-write-state-file", err, lager.Data{"state-file": d.statePath})
+		return driveradmin.CordonStatus{}, err
+	}
+
+	d.all = all
+	d.servers = servers
+	return driveradmin.CordonStatus{All: state.All, Servers: state.Servers}, nil
+}
+
+func (d *cordoningDriver) CordonStatus() driveradmin.CordonStatus {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	return driveradmin.CordonStatus{All: d.all, Servers: sortedServers(d.servers)}
+}
+
+func (d *cordoningDriver) restoreState(logger lager.Logger) {
+	logger = logger.Session("restore-cordon-state")
+
+	stateData, err := d.ioutil.ReadFile(d.statePath)
+	if err != nil {
+		logger.Info("failed-to-read-state-file", lager.Data{"err": err.Error(), "state-file": d.statePath})
+		return
+	}
+
+	var state cordonState
+	if err := json.Unmarshal(stateData, &state); err != nil {
+		logger.Error("failed-to-unmarshall-state", err, lager.Data{"state-file": d.statePath})
+		return
+	}
+
+	d.all = state.All
+	for _, server := range state.Servers {
+		d.servers[server] = true
+	}
+	logger.Info("state-restored", lager.Data{"all": state.All, "servers": state.Servers})
+}
+
+func sortedServers(servers map[string]bool) []string {
+	sorted := []string{}
+	for server := range servers {
+		sorted = append(sorted, server)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// sourceServer is the NFS server of a volume's source, which may also be given as nfs://server/path
+func sourceServer(source string) string {
+	if match := legacyNfsSharePattern.FindStringSubmatch(source); len(match) > 1 {
+		return match[1]
+	}
+	return nfsServer(source)
+}
@@ -0,0 +1,167 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/ioutilshim"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CordoningDriver", func() {
+	var (
+		logger     *lagertest.TestLogger
+		env        dockerdriver.Env
+		statePath  string
+		fakeDriver *nfsdriverfakes.FakeDrainableDriver
+		driver     nfsv3driver.CordoningDriver
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("cordoning-driver")
+		env = driverhttp.NewHttpDriverEnv(logger, context.TODO())
+		statePath = filepath.Join(GinkgoT().TempDir(), "cordon-state.json")
+
+		fakeDriver = &nfsdriverfakes.FakeDrainableDriver{}
+		fakeDriver.MountReturns(dockerdriver.MountResponse{Mountpoint: "/var/vcap/data/volumes/nfs/vol1"})
+		fakeDriver.ListReturns(dockerdriver.ListResponse{Volumes: []dockerdriver.VolumeInfo{
+			{Name: "vol1"},
+			{Name: "vol2", Mountpoint: "/var/vcap/data/volumes/nfs/vol2", MountCount: 1},
+			{Name: "vol3"},
+		}})
+	})
+
+	JustBeforeEach(func() {
+		driver = nfsv3driver.NewCordoningDriver(logger, fakeDriver, &ioutilshim.IoutilShim{}, statePath)
+
+		for name, source := range map[string]string{
+			"vol1": "nfs1.example.com:/export/a",
+			"vol2": "nfs1.example.com:/export/b",
+			"vol3": "nfs://nfs2.example.com/export/c",
+		} {
+			Expect(driver.Create(env, dockerdriver.CreateRequest{Name: name, Opts: map[string]interface{}{"source": source}}).Err).To(BeEmpty())
+		}
+	})
+
+	safeError := func(response dockerdriver.MountResponse) dockerdriver.SafeError {
+		var safeError dockerdriver.SafeError
+		Expect(json.Unmarshal([]byte(response.Err), &safeError)).To(Succeed())
+		return safeError
+	}
+
+	It("mounts while nothing is cordoned", func() {
+		Expect(driver.Mount(env, dockerdriver.MountRequest{Name: "vol1"}).Err).To(BeEmpty())
+		Expect(fakeDriver.MountCallCount()).To(Equal(1))
+		Expect(driver.CordonStatus()).To(Equal(driveradmin.CordonStatus{Servers: []string{}}))
+	})
+
+	It("names the NFS server of each volume it created", func() {
+		Expect(driver.VolumeServer("vol1")).To(Equal("nfs1.example.com"))
+		Expect(driver.VolumeServer("vol3")).To(Equal("nfs2.example.com"))
+		Expect(driver.VolumeServer("unknown")).To(BeEmpty())
+
+		Expect(driver.Remove(env, dockerdriver.RemoveRequest{Name: "vol1"}).Err).To(BeEmpty())
+		Expect(driver.VolumeServer("vol1")).To(BeEmpty())
+	})
+
+	Context("when an NFS server is cordoned", func() {
+		var status driveradmin.CordonStatus
+
+		JustBeforeEach(func() {
+			var err error
+			status, err = driver.Cordon(env, "nfs1.example.com")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("reports the server", func() {
+			Expect(status).To(Equal(driveradmin.CordonStatus{Servers: []string{"nfs1.example.com"}}))
+			Expect(driver.CordonStatus()).To(Equal(status))
+		})
+
+		It("refuses new mounts from it", func() {
+			response := driver.Mount(env, dockerdriver.MountRequest{Name: "vol1"})
+			Expect(safeError(response).SafeDescription).To(Equal("new mounts from nfs server nfs1.example.com are suspended for maintenance"))
+			Expect(fakeDriver.MountCallCount()).To(Equal(0))
+		})
+
+		It("still mounts volumes that are already mounted from it", func() {
+			Expect(driver.Mount(env, dockerdriver.MountRequest{Name: "vol2"}).Err).To(BeEmpty())
+		})
+
+		It("still mounts volumes from other servers", func() {
+			Expect(driver.Mount(env, dockerdriver.MountRequest{Name: "vol3"}).Err).To(BeEmpty())
+		})
+
+		It("still unmounts and finds volumes", func() {
+			Expect(driver.Unmount(env, dockerdriver.UnmountRequest{Name: "vol2"}).Err).To(BeEmpty())
+			driver.Path(env, dockerdriver.PathRequest{Name: "vol2"})
+			Expect(fakeDriver.UnmountCallCount()).To(Equal(1))
+			Expect(fakeDriver.PathCallCount()).To(Equal(1))
+		})
+
+		It("keeps the cordon when the driver restarts", func() {
+			restarted := nfsv3driver.NewCordoningDriver(logger, fakeDriver, &ioutilshim.IoutilShim{}, statePath)
+			Expect(restarted.CordonStatus()).To(Equal(driveradmin.CordonStatus{Servers: []string{"nfs1.example.com"}}))
+		})
+
+		Context("and then uncordoned", func() {
+			JustBeforeEach(func() {
+				var err error
+				status, err = driver.Uncordon(env, "nfs1.example.com")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("mounts from it again", func() {
+				Expect(status.Servers).To(BeEmpty())
+				Expect(driver.Mount(env, dockerdriver.MountRequest{Name: "vol1"}).Err).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("when the whole driver is cordoned", func() {
+		JustBeforeEach(func() {
+			_, err := driver.Cordon(env, "")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("refuses every new mount", func() {
+			Expect(safeError(driver.Mount(env, dockerdriver.MountRequest{Name: "vol3"})).SafeDescription).To(Equal("new mounts are suspended for maintenance"))
+			Expect(driver.Mount(env, dockerdriver.MountRequest{Name: "vol2"}).Err).To(BeEmpty())
+		})
+
+		It("refuses volumes it does not know the source of", func() {
+			Expect(driver.Mount(env, dockerdriver.MountRequest{Name: "unknown"}).Err).NotTo(BeEmpty())
+		})
+	})
+
+	Context("when the cordon state cannot be written", func() {
+		BeforeEach(func() {
+			statePath = filepath.Join(GinkgoT().TempDir(), "missing", "cordon-state.json")
+		})
+
+		It("fails and stays uncordoned", func() {
+			_, err := driver.Cordon(env, "nfs1.example.com")
+			Expect(err).To(HaveOccurred())
+			Expect(driver.CordonStatus().Servers).To(BeEmpty())
+		})
+	})
+
+	Context("when the cordon state file is corrupt", func() {
+		BeforeEach(func() {
+			Expect(os.WriteFile(statePath, []byte("{"), 0644)).To(Succeed())
+		})
+
+		It("starts uncordoned", func() {
+			Expect(driver.CordonStatus()).To(Equal(driveradmin.CordonStatus{Servers: []string{}}))
+		})
+	})
+})
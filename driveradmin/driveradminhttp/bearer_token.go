@@ -0,0 +1,27 @@
+package driveradminhttp
+
+import (
+	"net/http"
+	"strings"
+
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+// NewBearerTokenHandler passes on the requests whose Authorization header carries a bearer token that token
+// matches, and answers every other request with 401
+func NewBearerTokenHandler(logger lager.Logger, handler http.Handler, token driveradmin.AdminToken) http.Handler {
+	logger = logger.Session("bearer-token")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		presented, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if !ok || !token.Matches(strings.TrimSpace(presented)) {
+			logger.Info("unauthorized", lager.Data{"method": req.Method, "path": req.URL.Path, "remote-addr": req.RemoteAddr})
+			w.Header().Set("WWW-Authenticate", `Bearer realm="nfsv3driver"`)
+			writeJSONResponse(w, http.StatusUnauthorized, driveradmin.ErrorResponse{Err: "missing or invalid bearer token"})
+			return
+		}
+
+		handler.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,89 @@
+package driveradminhttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin/driveradminhttp"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("BearerTokenHandler", func() {
+	var (
+		testLogger           *lagertest.TestLogger
+		fakeAdminToken       *nfsdriverfakes.FakeAdminToken
+		authorization        string
+		httpResponseRecorder *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		testLogger = lagertest.NewTestLogger("BearerTokenTest")
+		fakeAdminToken = &nfsdriverfakes.FakeAdminToken{}
+		fakeAdminToken.MatchesStub = func(token string) bool {
+			return token == "s3cr3t"
+		}
+		authorization = "Bearer s3cr3t"
+	})
+
+	JustBeforeEach(func() {
+		handler := driveradminhttp.NewBearerTokenHandler(testLogger, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, _ = w.Write([]byte("passed"))
+		}), fakeAdminToken)
+
+		httpRequest, err := http.NewRequest("POST", "http://0.0.0.0/evacuate", nil)
+		Expect(err).NotTo(HaveOccurred())
+		if authorization != "" {
+			httpRequest.Header.Set("Authorization", authorization)
+		}
+
+		httpResponseRecorder = httptest.NewRecorder()
+		handler.ServeHTTP(httpResponseRecorder, httpRequest)
+	})
+
+	It("should pass on requests with a matching token", func() {
+		Expect(httpResponseRecorder.Code).To(Equal(200))
+		Expect(httpResponseRecorder.Body.String()).To(Equal("passed"))
+	})
+
+	Context("when the token does not match", func() {
+		BeforeEach(func() {
+			authorization = "Bearer guess"
+		})
+
+		It("should return an http 401 response", func() {
+			Expect(httpResponseRecorder.Code).To(Equal(401))
+			Expect(httpResponseRecorder.Header().Get("WWW-Authenticate")).To(Equal(`Bearer realm="nfsv3driver"`))
+			Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Err":"missing or invalid bearer token"}`))
+			Expect(testLogger).To(gbytes.Say(`bearer-token.unauthorized.*"path":"/evacuate"`))
+		})
+
+		It("should not log the token", func() {
+			Expect(testLogger.Buffer().Contents()).NotTo(ContainSubstring("guess"))
+		})
+	})
+
+	Context("when no token is presented", func() {
+		BeforeEach(func() {
+			authorization = ""
+		})
+
+		It("should return an http 401 response", func() {
+			Expect(httpResponseRecorder.Code).To(Equal(401))
+			Expect(fakeAdminToken.MatchesCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when another authorization scheme is used", func() {
+		BeforeEach(func() {
+			authorization = "Basic czNjcjN0"
+		})
+
+		It("should return an http 401 response", func() {
+			Expect(httpResponseRecorder.Code).To(Equal(401))
+		})
+	})
+})
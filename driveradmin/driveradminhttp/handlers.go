@@ -3,9 +3,13 @@ package driveradminhttp
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"code.cloudfoundry.org/dockerdriver"
 	"code.cloudfoundry.org/dockerdriver/driverhttp"
 	"code.cloudfoundry.org/lager/v3"
 	"code.cloudfoundry.org/nfsv3driver/driveradmin"
@@ -18,28 +22,121 @@ func NewHandler(logger lager.Logger, client driveradmin.DriverAdmin) (http.Handl
 	defer logger.Info("end")
 
 	var handlers = rata.Handlers{
-		driveradmin.EvacuateRoute: newEvacuateHandler(logger, client),
-		driveradmin.PingRoute:     newPingHandler(logger, client),
+		driveradmin.EvacuateRoute:         newEvacuateHandler(logger, client),
+		driveradmin.EvacuationStatusRoute: newEvacuationStatusHandler(logger, client),
+		driveradmin.PingRoute:             newPingHandler(logger, client),
+		driveradmin.PasswordKeyRoute:      newPasswordKeyHandler(logger, client),
+		driveradmin.CertificatesRoute:     newCertificatesHandler(logger, client),
+		driveradmin.LivenessRoute:         newHealthHandler(logger, "handle-liveness", client.Liveness),
+		driveradmin.ReadinessRoute:        newHealthHandler(logger, "handle-readiness", client.Readiness),
+		driveradmin.VolumesRoute:          newVolumesHandler(logger, client),
+		driveradmin.ForceUnmountRoute:     newVolumeOperationHandler(logger, "force-unmount", client.ForceUnmount),
+		driveradmin.RemountRoute:          newVolumeOperationHandler(logger, "remount", client.Remount),
+		driveradmin.CordonStatusRoute:     newCordonStatusHandler(logger, client),
+		driveradmin.CordonRoute:           newCordonHandler(logger, "cordon", client.Cordon),
+		driveradmin.UncordonRoute:         newCordonHandler(logger, "uncordon", client.Uncordon),
+		driveradmin.LogLevelRoute:         newLogLevelHandler(logger, client),
+		driveradmin.SetLogLevelRoute:      newSetLogLevelHandler(logger, client),
+		driveradmin.DebugLoggingRoute:     newDebugLoggingHandler(logger, client),
+		driveradmin.LoginThrottleRoute:    newLoginThrottleHandler(logger, client),
 	}
 
-	return rata.NewRouter(driveradmin.Routes, handlers)
+	router, err := rata.NewRouter(driveradmin.Routes, handlers)
+	if err != nil {
+		return nil, err
+	}
+	return newMethodCheckingHandler(router), nil
 }
 
+// newMethodCheckingHandler answers requests for a known route with the wrong method with 405 rather than 404, so
+// that callers still using GET for state-changing routes learn to switch to POST
+func newMethodCheckingHandler(router http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var path string
+		var methods []string
+		for _, route := range driveradmin.Routes {
+			if !pathMatches(route.Path, req.URL.Path) {
+				continue
+			}
+			if route.Method == req.Method {
+				router.ServeHTTP(w, req)
+				return
+			}
+			path = route.Path
+			methods = append(methods, route.Method)
+		}
+
+		if len(methods) > 0 {
+			w.Header().Set("Allow", strings.Join(methods, ", "))
+			writeJSONResponse(w, http.StatusMethodNotAllowed, driveradmin.ErrorResponse{
+				Err: fmt.Sprintf("%s requires %s", path, strings.Join(methods, " or ")),
+			})
+			return
+		}
+		router.ServeHTTP(w, req)
+	}
+}
+
+// pathMatches compares a route's path with a request's, where a route segment such as ':name' matches any segment
+func pathMatches(routePath string, path string) bool {
+	routeSegments := strings.Split(routePath, "/")
+	segments := strings.Split(path, "/")
+	if len(routeSegments) != len(segments) {
+		return false
+	}
+	for i, segment := range routeSegments {
+		if !strings.HasPrefix(segment, ":") && segment != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// newEvacuateHandler accepts an evacuation that goes on in the background; exit=false drains the volumes without
+// terminating the driver
 func newEvacuateHandler(logger lager.Logger, client driveradmin.DriverAdmin) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		logger := logger.Session("handle-evacuate")
 		logger.Info("start")
 		defer logger.Info("end")
 
+		evacuateRequest := driveradmin.EvacuateRequest{Exit: true}
+		if exit := req.URL.Query().Get("exit"); exit != "" {
+			var err error
+			if evacuateRequest.Exit, err = strconv.ParseBool(exit); err != nil {
+				logger.Error("failed-parsing-exit", err)
+				writeJSONResponse(w, http.StatusBadRequest, driveradmin.ErrorResponse{Err: fmt.Sprintf("invalid exit '%s'", exit)})
+				return
+			}
+		}
+
 		env := driverhttp.EnvWithMonitor(logger, req.Context(), w)
 
-		response := client.Evacuate(env)
+		response := client.Evacuate(env, evacuateRequest)
 		if response.Err != "" {
 			logger.Error("failed-evacuating", errors.New(response.Err))
 			writeJSONResponse(w, http.StatusInternalServerError, response)
 			return
 		}
 
+		writeJSONResponse(w, http.StatusAccepted, response)
+	}
+}
+
+func newEvacuationStatusHandler(logger lager.Logger, client driveradmin.DriverAdmin) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		logger := logger.Session("handle-evacuation-status")
+		logger.Info("start")
+		defer logger.Info("end")
+
+		env := driverhttp.EnvWithMonitor(logger, req.Context(), w)
+
+		response := client.EvacuationStatus(env)
+		if response.Err != "" {
+			writeJSONResponse(w, http.StatusNotFound, response)
+			return
+		}
+
 		writeJSONResponse(w, http.StatusOK, response)
 	}
 }
@@ -63,6 +160,257 @@ func newPingHandler(logger lager.Logger, client driveradmin.DriverAdmin) http.Ha
 	}
 }
 
+func newPasswordKeyHandler(logger lager.Logger, client driveradmin.DriverAdmin) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		logger := logger.Session("handle-password-key")
+		logger.Info("start")
+		defer logger.Info("end")
+
+		env := driverhttp.EnvWithMonitor(logger, req.Context(), w)
+
+		response := client.PasswordKey(env)
+		if response.Err != "" {
+			logger.Error("failed-getting-password-key", errors.New(response.Err))
+			writeJSONResponse(w, http.StatusNotFound, response)
+			return
+		}
+
+		writeJSONResponse(w, http.StatusOK, response)
+	}
+}
+
+func newCertificatesHandler(logger lager.Logger, client driveradmin.DriverAdmin) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		logger := logger.Session("handle-certificates")
+		logger.Info("start")
+		defer logger.Info("end")
+
+		env := driverhttp.EnvWithMonitor(logger, req.Context(), w)
+
+		response := client.Certificates(env)
+		if response.Err != "" {
+			logger.Error("failed-getting-certificates", errors.New(response.Err))
+			writeJSONResponse(w, http.StatusNotFound, response)
+			return
+		}
+
+		writeJSONResponse(w, http.StatusOK, response)
+	}
+}
+
+// newVolumesHandler lists the driver's volumes, only those mounted from one NFS server when the server parameter is set
+func newVolumesHandler(logger lager.Logger, client driveradmin.DriverAdmin) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		logger := logger.Session("handle-volumes")
+		logger.Info("start")
+		defer logger.Info("end")
+
+		env := driverhttp.EnvWithMonitor(logger, req.Context(), w)
+
+		response := client.Volumes(env, driveradmin.VolumesRequest{Server: req.URL.Query().Get("server")})
+		if response.Err != "" {
+			logger.Error("failed-listing-volumes", errors.New(response.Err))
+			writeJSONResponse(w, http.StatusInternalServerError, response)
+			return
+		}
+
+		writeJSONResponse(w, http.StatusOK, response)
+	}
+}
+
+// newVolumeOperationHandler runs an operation on the volume named in the path. These operations disturb the apps
+// using the volume, so who asked for each and how it went is logged to the audit session.
+func newVolumeOperationHandler(logger lager.Logger, operation string, operate func(dockerdriver.Env, driveradmin.VolumeRequest) driveradmin.ErrorResponse) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		logger := logger.Session("handle-" + operation)
+		logger.Info("start")
+		defer logger.Info("end")
+
+		volumeRequest := driveradmin.VolumeRequest{Name: rata.Param(req, "name")}
+		audit := logger.Session("audit", lager.Data{"operation": operation, "volume": volumeRequest.Name, "remote-addr": req.RemoteAddr})
+		audit.Info("requested")
+
+		env := driverhttp.EnvWithMonitor(logger, req.Context(), w)
+
+		response := operate(env, volumeRequest)
+		if response.Err != "" {
+			audit.Info("failed", lager.Data{"error": response.Err})
+			writeJSONResponse(w, http.StatusInternalServerError, response)
+			return
+		}
+
+		audit.Info("succeeded")
+		writeJSONResponse(w, http.StatusOK, response)
+	}
+}
+
+// newCordonHandler cordons or uncordons the NFS server given as ?server=, or the whole driver without it
+func newCordonHandler(logger lager.Logger, operation string, update func(dockerdriver.Env, driveradmin.CordonRequest) driveradmin.CordonResponse) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		logger := logger.Session("handle-" + operation)
+		logger.Info("start")
+		defer logger.Info("end")
+
+		cordonRequest := driveradmin.CordonRequest{Server: req.URL.Query().Get("server")}
+		audit := logger.Session("audit", lager.Data{"operation": operation, "server": cordonRequest.Server, "remote-addr": req.RemoteAddr})
+		audit.Info("requested")
+
+		env := driverhttp.EnvWithMonitor(logger, req.Context(), w)
+
+		response := update(env, cordonRequest)
+		if response.Err != "" {
+			audit.Info("failed", lager.Data{"error": response.Err})
+			writeJSONResponse(w, http.StatusInternalServerError, response)
+			return
+		}
+
+		audit.Info("succeeded")
+		writeJSONResponse(w, http.StatusOK, response)
+	}
+}
+
+func newCordonStatusHandler(logger lager.Logger, client driveradmin.DriverAdmin) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		logger := logger.Session("handle-cordon-status")
+		logger.Info("start")
+		defer logger.Info("end")
+
+		env := driverhttp.EnvWithMonitor(logger, req.Context(), w)
+
+		response := client.CordonStatus(env)
+		if response.Err != "" {
+			writeJSONResponse(w, http.StatusNotFound, response)
+			return
+		}
+
+		writeJSONResponse(w, http.StatusOK, response)
+	}
+}
+
+func newLogLevelHandler(logger lager.Logger, client driveradmin.DriverAdmin) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		logger := logger.Session("handle-log-level")
+		logger.Info("start")
+		defer logger.Info("end")
+
+		env := driverhttp.EnvWithMonitor(logger, req.Context(), w)
+
+		response := client.LogLevel(env)
+		if response.Err != "" {
+			writeJSONResponse(w, http.StatusNotFound, response)
+			return
+		}
+
+		writeJSONResponse(w, http.StatusOK, response)
+	}
+}
+
+// newSetLogLevelHandler sets the level given as ?level= until the driver restarts or its configuration is reloaded
+// with a different log_level
+func newSetLogLevelHandler(logger lager.Logger, client driveradmin.DriverAdmin) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		logger := logger.Session("handle-set-log-level")
+		logger.Info("start")
+		defer logger.Info("end")
+
+		level := req.URL.Query().Get("level")
+		if _, err := lager.LogLevelFromString(level); err != nil {
+			writeJSONResponse(w, http.StatusBadRequest, driveradmin.ErrorResponse{Err: fmt.Sprintf("invalid level '%s'", level)})
+			return
+		}
+
+		env := driverhttp.EnvWithMonitor(logger, req.Context(), w)
+
+		response := client.SetLogLevel(env, driveradmin.SetLogLevelRequest{Level: level})
+		if response.Err != "" {
+			logger.Error("failed-setting-log-level", errors.New(response.Err))
+			writeJSONResponse(w, http.StatusInternalServerError, response)
+			return
+		}
+
+		writeJSONResponse(w, http.StatusOK, response)
+	}
+}
+
+// newDebugLoggingHandler logs at debug level for the ?volume= or ?server= given, for ?duration= or
+// driveradmin.DefaultDebugLoggingDuration
+func newDebugLoggingHandler(logger lager.Logger, client driveradmin.DriverAdmin) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		logger := logger.Session("handle-debug-logging")
+		logger.Info("start")
+		defer logger.Info("end")
+
+		query := req.URL.Query()
+		debugLoggingRequest := driveradmin.DebugLoggingRequest{
+			Volume:   query.Get("volume"),
+			Server:   query.Get("server"),
+			Duration: driveradmin.DefaultDebugLoggingDuration,
+		}
+		if (debugLoggingRequest.Volume == "") == (debugLoggingRequest.Server == "") {
+			writeJSONResponse(w, http.StatusBadRequest, driveradmin.ErrorResponse{Err: "exactly one of volume or server is required"})
+			return
+		}
+		if duration := query.Get("duration"); duration != "" {
+			parsed, err := time.ParseDuration(duration)
+			if err != nil || parsed <= 0 || parsed > driveradmin.MaxDebugLoggingDuration {
+				writeJSONResponse(w, http.StatusBadRequest, driveradmin.ErrorResponse{
+					Err: fmt.Sprintf("invalid duration '%s', must be positive and at most %s", duration, driveradmin.MaxDebugLoggingDuration),
+				})
+				return
+			}
+			debugLoggingRequest.Duration = parsed
+		}
+
+		env := driverhttp.EnvWithMonitor(logger, req.Context(), w)
+
+		response := client.EnableDebugLogging(env, debugLoggingRequest)
+		if response.Err != "" {
+			logger.Error("failed-enabling-debug-logging", errors.New(response.Err))
+			writeJSONResponse(w, http.StatusInternalServerError, response)
+			return
+		}
+
+		writeJSONResponse(w, http.StatusOK, response)
+	}
+}
+
+func newLoginThrottleHandler(logger lager.Logger, client driveradmin.DriverAdmin) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		logger := logger.Session("handle-login-throttle")
+		logger.Info("start")
+		defer logger.Info("end")
+
+		env := driverhttp.EnvWithMonitor(logger, req.Context(), w)
+
+		response := client.LoginThrottle(env)
+		if response.Err != "" {
+			writeJSONResponse(w, http.StatusNotFound, response)
+			return
+		}
+
+		writeJSONResponse(w, http.StatusOK, response)
+	}
+}
+
+// newHealthHandler answers 503 when any component is unhealthy, so that process monitors only need the status code
+func newHealthHandler(logger lager.Logger, session string, check func(dockerdriver.Env) driveradmin.HealthResponse) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		logger := logger.Session(session)
+		logger.Info("start")
+		defer logger.Info("end")
+
+		env := driverhttp.EnvWithMonitor(logger, req.Context(), w)
+
+		response := check(env)
+		if !response.Healthy {
+			writeJSONResponse(w, http.StatusServiceUnavailable, response)
+			return
+		}
+
+		writeJSONResponse(w, http.StatusOK, response)
+	}
+}
+
 func writeJSONResponse(w http.ResponseWriter, statusCode int, jsonObj interface{}) {
 	jsonBytes, err := json.Marshal(jsonObj)
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"time"
 
 	"code.cloudfoundry.org/lager/v3/lagertest"
 	"code.cloudfoundry.org/nfsv3driver/driveradmin"
@@ -11,6 +12,7 @@ import (
 	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 	"github.com/tedsuo/rata"
 )
 
@@ -24,18 +26,25 @@ var _ = Describe("Volman Driver Handlers", func() {
 			httpRequest          *http.Request
 			httpResponseRecorder *httptest.ResponseRecorder
 			route                rata.Route
+			method               string
+			query                string
 		)
 
 		BeforeEach(func() {
 			var err error
 			handler, err = driveradminhttp.NewHandler(testLogger, fakeDriverAdmin)
 			Expect(err).NotTo(HaveOccurred())
+			method = ""
+			query = ""
 		})
 
 		JustBeforeEach(func() {
 			var err error
-			path := fmt.Sprintf("http://0.0.0.0%s", route.Path)
-			httpRequest, err = http.NewRequest("GET", path, nil)
+			path := fmt.Sprintf("http://0.0.0.0%s%s", route.Path, query)
+			if method == "" {
+				method = route.Method
+			}
+			httpRequest, err = http.NewRequest(method, path, nil)
 			Expect(err).NotTo(HaveOccurred())
 
 			httpResponseRecorder = httptest.NewRecorder()
@@ -44,36 +53,127 @@ var _ = Describe("Volman Driver Handlers", func() {
 
 		Context("Evacuate", func() {
 			BeforeEach(func() {
-				fakeDriverAdmin.EvacuateReturns(driveradmin.ErrorResponse{})
+				fakeDriverAdmin.EvacuateReturns(driveradmin.EvacuationResponse{EvacuationStatus: driveradmin.EvacuationStatus{
+					State:     driveradmin.EvacuationRunning,
+					Exit:      true,
+					StartedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+					Deadline:  time.Date(2026, 1, 2, 3, 9, 5, 0, time.UTC),
+					Volumes:   []driveradmin.VolumeEvacuation{},
+				}})
 
 				var found bool
 				route, found = driveradmin.Routes.FindRouteByName(driveradmin.EvacuateRoute)
 				Expect(found).To(BeTrue())
 			})
 
-			It("should produce a handler with an evacuate route", func() {
-				Expect(httpResponseRecorder.Code).To(Equal(200))
-				Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Err":""}`))
+			It("should accept the evacuation and terminate the driver once it finishes", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(202))
+				Expect(httpResponseRecorder.Body).Should(MatchJSON(`{
+					"State": "running",
+					"Exit": true,
+					"StartedAt": "2026-01-02T03:04:05Z",
+					"Deadline": "2026-01-02T03:09:05Z",
+					"FinishedAt": "0001-01-01T00:00:00Z",
+					"Volumes": [],
+					"Err": ""
+				}`))
+
+				_, evacuateRequest := fakeDriverAdmin.EvacuateArgsForCall(fakeDriverAdmin.EvacuateCallCount() - 1)
+				Expect(evacuateRequest.Exit).To(BeTrue())
+			})
+
+			Context("when asked not to exit", func() {
+				BeforeEach(func() {
+					query = "?exit=false"
+				})
+
+				It("should only drain", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(202))
+					_, evacuateRequest := fakeDriverAdmin.EvacuateArgsForCall(fakeDriverAdmin.EvacuateCallCount() - 1)
+					Expect(evacuateRequest.Exit).To(BeFalse())
+				})
+			})
+
+			Context("when exit is not a boolean", func() {
+				var evacuateCalls int
+
+				BeforeEach(func() {
+					query = "?exit=maybe"
+					evacuateCalls = fakeDriverAdmin.EvacuateCallCount()
+				})
+
+				It("should return an http 400 response without evacuating", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(400))
+					Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Err":"invalid exit 'maybe'"}`))
+					Expect(fakeDriverAdmin.EvacuateCallCount()).To(Equal(evacuateCalls))
+				})
 			})
 
 			Context("when invoking evacuate returns an error", func() {
 				BeforeEach(func() {
-					fakeDriverAdmin.EvacuateReturns(driveradmin.ErrorResponse{
+					fakeDriverAdmin.EvacuateReturns(driveradmin.EvacuationResponse{
 						Err: "unable to evacuate",
 					})
 				})
 
 				It("should return an http 500 response and an error string", func() {
 					Expect(httpResponseRecorder.Code).To(Equal(500))
-					Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Err":"unable to evacuate"}`))
+					Expect(httpResponseRecorder.Body).Should(ContainSubstring(`"Err":"unable to evacuate"`))
+				})
+			})
+
+			Context("when requested with GET", func() {
+				var evacuateCalls int
+
+				BeforeEach(func() {
+					method = "GET"
+					evacuateCalls = fakeDriverAdmin.EvacuateCallCount()
+				})
+
+				It("should return an http 405 response without evacuating", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(405))
+					Expect(httpResponseRecorder.Header().Get("Allow")).To(Equal("POST"))
+					Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Err":"/evacuate requires POST"}`))
+					Expect(fakeDriverAdmin.EvacuateCallCount()).To(Equal(evacuateCalls))
 				})
 			})
 		})
 
-		Context("Ping", func() {
+		Context("EvacuationStatus", func() {
 			BeforeEach(func() {
-				fakeDriverAdmin.EvacuateReturns(driveradmin.ErrorResponse{})
+				fakeDriverAdmin.EvacuationStatusReturns(driveradmin.EvacuationResponse{EvacuationStatus: driveradmin.EvacuationStatus{
+					State: driveradmin.EvacuationFailed,
+					Volumes: []driveradmin.VolumeEvacuation{
+						{Name: "vol1", Mountpoint: "/var/vcap/data/volumes/nfs/vol1", State: driveradmin.VolumeUnmounted},
+						{Name: "vol2", Mountpoint: "/var/vcap/data/volumes/nfs/vol2", State: driveradmin.VolumeFailed, Err: "device busy"},
+					},
+				}})
 
+				var found bool
+				route, found = driveradmin.Routes.FindRouteByName(driveradmin.EvacuationStatusRoute)
+				Expect(found).To(BeTrue())
+			})
+
+			It("should report the result for each volume", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(200))
+				Expect(httpResponseRecorder.Body).Should(ContainSubstring(`"State":"failed"`))
+				Expect(httpResponseRecorder.Body).Should(ContainSubstring(`{"Name":"vol2","Mountpoint":"/var/vcap/data/volumes/nfs/vol2","State":"failed","Err":"device busy"}`))
+			})
+
+			Context("when no evacuation has been started", func() {
+				BeforeEach(func() {
+					fakeDriverAdmin.EvacuationStatusReturns(driveradmin.EvacuationResponse{Err: "no evacuation has been started"})
+				})
+
+				It("should return an http 404 response", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(404))
+					Expect(httpResponseRecorder.Body).Should(ContainSubstring(`"Err":"no evacuation has been started"`))
+				})
+			})
+		})
+
+		Context("Ping", func() {
+			BeforeEach(func() {
 				var found bool
 				route, found = driveradmin.Routes.FindRouteByName(driveradmin.PingRoute)
 				Expect(found).To(BeTrue())
@@ -98,5 +198,478 @@ var _ = Describe("Volman Driver Handlers", func() {
 			})
 		})
 
+		Context("Volumes", func() {
+			BeforeEach(func() {
+				fakeDriverAdmin.VolumesReturns(driveradmin.VolumesResponse{Volumes: []driveradmin.VolumeStatus{{
+					Name:         "vol1",
+					Source:       "nfs1.example.com:/export/a",
+					Server:       "nfs1.example.com",
+					Mountpoint:   "/var/vcap/data/volumes/nfs/vol1",
+					MountCount:   2,
+					UidMapping:   true,
+					Uid:          "1001",
+					Gid:          "2001",
+					MapfsPid:     4242,
+					MountedAt:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+					AgeSeconds:   60,
+					LastCheck:    &driveradmin.VolumeCheck{At: time.Date(2026, 1, 2, 3, 5, 0, 0, time.UTC), Healthy: true},
+					MountOptions: "rw,vers=3",
+				}}})
+
+				var found bool
+				route, found = driveradmin.Routes.FindRouteByName(driveradmin.VolumesRoute)
+				Expect(found).To(BeTrue())
+			})
+
+			It("should list the volumes as JSON", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(200))
+				Expect(httpResponseRecorder.Body).Should(MatchJSON(`{
+					"Volumes": [{
+						"Name": "vol1",
+						"Source": "nfs1.example.com:/export/a",
+						"Server": "nfs1.example.com",
+						"Mountpoint": "/var/vcap/data/volumes/nfs/vol1",
+						"MountCount": 2,
+						"UidMapping": true,
+						"Uid": "1001",
+						"Gid": "2001",
+						"MapfsPid": 4242,
+						"MountedAt": "2026-01-02T03:04:05Z",
+						"AgeSeconds": 60,
+						"LastCheck": {"At": "2026-01-02T03:05:00Z", "Healthy": true},
+						"MountOptions": "rw,vers=3"
+					}],
+					"Err": ""
+				}`))
+			})
+
+			Context("when filtering by server", func() {
+				BeforeEach(func() {
+					query = "?server=nfs1.example.com"
+				})
+
+				It("should pass the server on", func() {
+					_, volumesRequest := fakeDriverAdmin.VolumesArgsForCall(fakeDriverAdmin.VolumesCallCount() - 1)
+					Expect(volumesRequest.Server).To(Equal("nfs1.example.com"))
+				})
+			})
+
+			Context("when listing the volumes fails", func() {
+				BeforeEach(func() {
+					fakeDriverAdmin.VolumesReturns(driveradmin.VolumesResponse{Err: "open /proc/mounts: permission denied"})
+				})
+
+				It("should return an http 500 response and an error string", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(500))
+					Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Volumes":null,"Err":"open /proc/mounts: permission denied"}`))
+				})
+			})
+		})
+
+		Context("Remount", func() {
+			BeforeEach(func() {
+				fakeDriverAdmin.RemountReturns(driveradmin.ErrorResponse{})
+
+				remountRoute, found := driveradmin.Routes.FindRouteByName(driveradmin.RemountRoute)
+				Expect(found).To(BeTrue())
+				route = remountRoute
+				route.Path, _ = remountRoute.CreatePath(rata.Params{"name": "vol1"})
+			})
+
+			It("should remount the named volume", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(200))
+				Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Err":""}`))
+
+				_, volumeRequest := fakeDriverAdmin.RemountArgsForCall(fakeDriverAdmin.RemountCallCount() - 1)
+				Expect(volumeRequest.Name).To(Equal("vol1"))
+			})
+
+			It("should audit the request", func() {
+				Expect(testLogger).To(gbytes.Say(`handle-remount.audit.requested.*"operation":"remount".*"volume":"vol1"`))
+				Expect(testLogger).To(gbytes.Say(`handle-remount.audit.succeeded`))
+			})
+
+			Context("when remounting fails", func() {
+				BeforeEach(func() {
+					fakeDriverAdmin.RemountReturns(driveradmin.ErrorResponse{Err: "volume vol1 is not mounted"})
+				})
+
+				It("should return an http 500 response and an error string", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(500))
+					Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Err":"volume vol1 is not mounted"}`))
+					Expect(testLogger).To(gbytes.Say(`handle-remount.audit.failed.*volume vol1 is not mounted`))
+				})
+			})
+
+			Context("when requested with GET", func() {
+				var remountCalls int
+
+				BeforeEach(func() {
+					method = "GET"
+					remountCalls = fakeDriverAdmin.RemountCallCount()
+				})
+
+				It("should return an http 405 response without remounting", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(405))
+					Expect(httpResponseRecorder.Header().Get("Allow")).To(Equal("POST"))
+					Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Err":"/volumes/:name/remount requires POST"}`))
+					Expect(fakeDriverAdmin.RemountCallCount()).To(Equal(remountCalls))
+				})
+			})
+		})
+
+		Context("ForceUnmount", func() {
+			BeforeEach(func() {
+				fakeDriverAdmin.ForceUnmountReturns(driveradmin.ErrorResponse{})
+
+				forceUnmountRoute, found := driveradmin.Routes.FindRouteByName(driveradmin.ForceUnmountRoute)
+				Expect(found).To(BeTrue())
+				route = forceUnmountRoute
+				route.Path, _ = forceUnmountRoute.CreatePath(rata.Params{"name": "vol2"})
+			})
+
+			It("should force the named volume off", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(200))
+
+				_, volumeRequest := fakeDriverAdmin.ForceUnmountArgsForCall(fakeDriverAdmin.ForceUnmountCallCount() - 1)
+				Expect(volumeRequest.Name).To(Equal("vol2"))
+				Expect(testLogger).To(gbytes.Say(`handle-force-unmount.audit.requested.*"operation":"force-unmount".*"volume":"vol2"`))
+			})
+
+			Context("when forcing the volume off fails", func() {
+				BeforeEach(func() {
+					fakeDriverAdmin.ForceUnmountReturns(driveradmin.ErrorResponse{Err: "volume vol2 not found"})
+				})
+
+				It("should return an http 500 response and an error string", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(500))
+					Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Err":"volume vol2 not found"}`))
+				})
+			})
+		})
+
+		Context("Cordon", func() {
+			BeforeEach(func() {
+				fakeDriverAdmin.CordonReturns(driveradmin.CordonResponse{CordonStatus: driveradmin.CordonStatus{Servers: []string{"nfs1.example.com"}}})
+				query = "?server=nfs1.example.com"
+
+				var found bool
+				route, found = driveradmin.Routes.FindRouteByName(driveradmin.CordonRoute)
+				Expect(found).To(BeTrue())
+			})
+
+			It("should cordon the server and audit the request", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(200))
+				Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"All":false,"Servers":["nfs1.example.com"],"Err":""}`))
+
+				_, cordonRequest := fakeDriverAdmin.CordonArgsForCall(fakeDriverAdmin.CordonCallCount() - 1)
+				Expect(cordonRequest.Server).To(Equal("nfs1.example.com"))
+				Expect(testLogger).To(gbytes.Say(`handle-cordon.audit.succeeded.*"server":"nfs1.example.com"`))
+			})
+
+			Context("when cordoning fails", func() {
+				BeforeEach(func() {
+					fakeDriverAdmin.CordonReturns(driveradmin.CordonResponse{Err: "read-only file system"})
+				})
+
+				It("should return an http 500 response and an error string", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(500))
+					Expect(httpResponseRecorder.Body).Should(ContainSubstring(`"Err":"read-only file system"`))
+				})
+			})
+
+			Context("when requested with PUT", func() {
+				BeforeEach(func() {
+					method = "PUT"
+				})
+
+				It("should return an http 405 response listing the allowed methods", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(405))
+					Expect(httpResponseRecorder.Header().Get("Allow")).To(Equal("GET, POST"))
+					Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Err":"/cordon requires GET or POST"}`))
+				})
+			})
+		})
+
+		Context("Uncordon", func() {
+			BeforeEach(func() {
+				fakeDriverAdmin.UncordonReturns(driveradmin.CordonResponse{CordonStatus: driveradmin.CordonStatus{Servers: []string{}}})
+
+				var found bool
+				route, found = driveradmin.Routes.FindRouteByName(driveradmin.UncordonRoute)
+				Expect(found).To(BeTrue())
+			})
+
+			It("should uncordon the whole driver when no server is given", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(200))
+				_, cordonRequest := fakeDriverAdmin.UncordonArgsForCall(fakeDriverAdmin.UncordonCallCount() - 1)
+				Expect(cordonRequest.Server).To(BeEmpty())
+			})
+		})
+
+		Context("CordonStatus", func() {
+			BeforeEach(func() {
+				fakeDriverAdmin.CordonStatusReturns(driveradmin.CordonResponse{CordonStatus: driveradmin.CordonStatus{All: true, Servers: []string{}}})
+
+				var found bool
+				route, found = driveradmin.Routes.FindRouteByName(driveradmin.CordonStatusRoute)
+				Expect(found).To(BeTrue())
+			})
+
+			It("should report the cordon state", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(200))
+				Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"All":true,"Servers":[],"Err":""}`))
+			})
+
+			Context("when cordoning is not configured", func() {
+				BeforeEach(func() {
+					fakeDriverAdmin.CordonStatusReturns(driveradmin.CordonResponse{Err: "cordoning is not configured"})
+				})
+
+				It("should return an http 404 response", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(404))
+				})
+			})
+		})
+
+		Context("LoginThrottle", func() {
+			BeforeEach(func() {
+				fakeDriverAdmin.LoginThrottleReturns(driveradmin.LoginThrottleResponse{LoginThrottleStatus: driveradmin.LoginThrottleStatus{
+					UserThrottled:   2,
+					GlobalThrottled: 1,
+					RecentFailures:  5,
+				}})
+
+				var found bool
+				route, found = driveradmin.Routes.FindRouteByName(driveradmin.LoginThrottleRoute)
+				Expect(found).To(BeTrue())
+			})
+
+			It("should report the throttle counters", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(200))
+				Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"UserThrottled":2,"GlobalThrottled":1,"RecentFailures":5,"InFlight":0,"Err":""}`))
+			})
+
+			Context("when login throttling is not configured", func() {
+				BeforeEach(func() {
+					fakeDriverAdmin.LoginThrottleReturns(driveradmin.LoginThrottleResponse{Err: "login throttling is not configured"})
+				})
+
+				It("should return an http 404 response", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(404))
+				})
+			})
+		})
+
+		Context("LogLevel", func() {
+			BeforeEach(func() {
+				fakeDriverAdmin.LogLevelReturns(driveradmin.LogLevelResponse{LogLevelStatus: driveradmin.LogLevelStatus{
+					Level:       "info",
+					DebugScopes: []driveradmin.DebugScope{{Volume: "vol1", Until: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}},
+				}})
+
+				var found bool
+				route, found = driveradmin.Routes.FindRouteByName(driveradmin.LogLevelRoute)
+				Expect(found).To(BeTrue())
+			})
+
+			It("should report the log level and what is being debugged", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(200))
+				Expect(httpResponseRecorder.Body).Should(MatchJSON(`{
+					"Level": "info",
+					"DebugScopes": [{"Volume": "vol1", "Until": "2026-01-02T03:04:05Z"}],
+					"Err": ""
+				}`))
+			})
+		})
+
+		Context("SetLogLevel", func() {
+			BeforeEach(func() {
+				fakeDriverAdmin.SetLogLevelReturns(driveradmin.LogLevelResponse{LogLevelStatus: driveradmin.LogLevelStatus{Level: "debug"}})
+				query = "?level=debug"
+
+				var found bool
+				route, found = driveradmin.Routes.FindRouteByName(driveradmin.SetLogLevelRoute)
+				Expect(found).To(BeTrue())
+			})
+
+			It("should set the log level", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(200))
+				_, setLogLevelRequest := fakeDriverAdmin.SetLogLevelArgsForCall(fakeDriverAdmin.SetLogLevelCallCount() - 1)
+				Expect(setLogLevelRequest.Level).To(Equal("debug"))
+			})
+
+			Context("when the level is unknown", func() {
+				var setLogLevelCalls int
+
+				BeforeEach(func() {
+					query = "?level=verbose"
+					setLogLevelCalls = fakeDriverAdmin.SetLogLevelCallCount()
+				})
+
+				It("should return an http 400 response without changing the level", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(400))
+					Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Err":"invalid level 'verbose'"}`))
+					Expect(fakeDriverAdmin.SetLogLevelCallCount()).To(Equal(setLogLevelCalls))
+				})
+			})
+		})
+
+		Context("DebugLogging", func() {
+			BeforeEach(func() {
+				fakeDriverAdmin.EnableDebugLoggingReturns(driveradmin.LogLevelResponse{LogLevelStatus: driveradmin.LogLevelStatus{Level: "info"}})
+				query = "?server=nfs1.example.com&duration=30m"
+
+				var found bool
+				route, found = driveradmin.Routes.FindRouteByName(driveradmin.DebugLoggingRoute)
+				Expect(found).To(BeTrue())
+			})
+
+			It("should debug the server for the duration", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(200))
+				_, debugLoggingRequest := fakeDriverAdmin.EnableDebugLoggingArgsForCall(fakeDriverAdmin.EnableDebugLoggingCallCount() - 1)
+				Expect(debugLoggingRequest).To(Equal(driveradmin.DebugLoggingRequest{Server: "nfs1.example.com", Duration: 30 * time.Minute}))
+			})
+
+			Context("when no duration is given", func() {
+				BeforeEach(func() {
+					query = "?volume=vol1"
+				})
+
+				It("should debug the volume for the default duration", func() {
+					_, debugLoggingRequest := fakeDriverAdmin.EnableDebugLoggingArgsForCall(fakeDriverAdmin.EnableDebugLoggingCallCount() - 1)
+					Expect(debugLoggingRequest).To(Equal(driveradmin.DebugLoggingRequest{Volume: "vol1", Duration: driveradmin.DefaultDebugLoggingDuration}))
+				})
+			})
+
+			Context("when neither a volume nor a server is given", func() {
+				BeforeEach(func() {
+					query = "?duration=30m"
+				})
+
+				It("should return an http 400 response", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(400))
+					Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Err":"exactly one of volume or server is required"}`))
+				})
+			})
+
+			Context("when the duration is too long", func() {
+				BeforeEach(func() {
+					query = "?volume=vol1&duration=48h"
+				})
+
+				It("should return an http 400 response", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(400))
+					Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Err":"invalid duration '48h', must be positive and at most 24h0m0s"}`))
+				})
+			})
+		})
+
+		Context("PasswordKey", func() {
+			BeforeEach(func() {
+				fakeDriverAdmin.PasswordKeyReturns(driveradmin.PasswordKeyResponse{PasswordKey: driveradmin.PasswordKey{
+					KeyId:     "0123456789abcdef",
+					Algorithm: "RSA-OAEP-256",
+					PublicKey: "-----BEGIN PUBLIC KEY-----",
+				}})
+
+				var found bool
+				route, found = driveradmin.Routes.FindRouteByName(driveradmin.PasswordKeyRoute)
+				Expect(found).To(BeTrue())
+			})
+
+			It("should produce a handler with a password key route", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(200))
+				Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"KeyId":"0123456789abcdef","Algorithm":"RSA-OAEP-256","PublicKey":"-----BEGIN PUBLIC KEY-----","Err":""}`))
+			})
+
+			Context("when password encryption is not configured", func() {
+				BeforeEach(func() {
+					fakeDriverAdmin.PasswordKeyReturns(driveradmin.PasswordKeyResponse{Err: "password encryption is not configured"})
+				})
+
+				It("should return an http 404 response and an error string", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(404))
+					Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"KeyId":"","Algorithm":"","PublicKey":"","Err":"password encryption is not configured"}`))
+				})
+			})
+		})
+
+		Context("Readiness", func() {
+			BeforeEach(func() {
+				fakeDriverAdmin.ReadinessReturns(driveradmin.HealthResponse{Healthy: true, Components: []driveradmin.ComponentHealth{{
+					Name:    "ldap",
+					Healthy: true,
+					Details: map[string]interface{}{"bind-latency-ms": 3},
+				}}})
+
+				var found bool
+				route, found = driveradmin.Routes.FindRouteByName(driveradmin.ReadinessRoute)
+				Expect(found).To(BeTrue())
+			})
+
+			It("should produce a handler with a readiness route", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(200))
+				Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Healthy":true,"Components":[{"Name":"ldap","Healthy":true,"Message":"","Details":{"bind-latency-ms":3}}],"Err":""}`))
+			})
+
+			Context("when a component is unhealthy", func() {
+				BeforeEach(func() {
+					fakeDriverAdmin.ReadinessReturns(driveradmin.HealthResponse{Components: []driveradmin.ComponentHealth{{Name: "fuse", Message: "permission denied"}}})
+				})
+
+				It("should return an http 503 response", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(503))
+					Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Healthy":false,"Components":[{"Name":"fuse","Healthy":false,"Message":"permission denied","Details":null}],"Err":""}`))
+				})
+			})
+		})
+
+		Context("Liveness", func() {
+			BeforeEach(func() {
+				fakeDriverAdmin.LivenessReturns(driveradmin.HealthResponse{Healthy: true})
+
+				var found bool
+				route, found = driveradmin.Routes.FindRouteByName(driveradmin.LivenessRoute)
+				Expect(found).To(BeTrue())
+			})
+
+			It("should produce a handler with a liveness route", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(200))
+				Expect(fakeDriverAdmin.LivenessCallCount()).NotTo(BeZero())
+			})
+		})
+
+		Context("Certificates", func() {
+			BeforeEach(func() {
+				fakeDriverAdmin.CertificatesReturns(driveradmin.CertificatesResponse{Certificates: []driveradmin.CertificateStatus{{
+					Name:            "driver",
+					Subject:         "CN=nfsv3driver",
+					NotAfter:        time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+					DaysUntilExpiry: 30,
+				}}})
+
+				var found bool
+				route, found = driveradmin.Routes.FindRouteByName(driveradmin.CertificatesRoute)
+				Expect(found).To(BeTrue())
+			})
+
+			It("should produce a handler with a certificates route", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(200))
+				Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Certificates":[{"Name":"driver","Subject":"CN=nfsv3driver","NotAfter":"2030-01-02T03:04:05Z","DaysUntilExpiry":30}],"Err":""}`))
+			})
+
+			Context("when TLS is not configured", func() {
+				BeforeEach(func() {
+					fakeDriverAdmin.CertificatesReturns(driveradmin.CertificatesResponse{Err: "TLS is not configured"})
+				})
+
+				It("should return an http 404 response and an error string", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(404))
+					Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Certificates":null,"Err":"TLS is not configured"}`))
+				})
+			})
+		})
+
 	})
 })
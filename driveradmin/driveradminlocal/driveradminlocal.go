@@ -1,20 +1,52 @@
 package driveradminlocal
 
 import (
-	"os"
+	"context"
+	"fmt"
+	"sync"
+	"time"
 
 	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/lager/v3"
 	"code.cloudfoundry.org/nfsv3driver/driveradmin"
 	"github.com/tedsuo/ifrit"
 )
 
+const DefaultHealthCheckTimeout = 10 * time.Second
+
+type namedHealthCheck struct {
+	name  string
+	check driveradmin.HealthCheck
+}
+
 type DriverAdminLocal struct {
 	serverProcess ifrit.Process
 	drainables    []driveradmin.Drainable
+	passwordKey   driveradmin.PasswordKeySource
+	certificates  []driveradmin.CertificateSource
+	volumes       driveradmin.VolumeInspector
+	operator      driveradmin.VolumeOperator
+	cordoner      driveradmin.Cordoner
+	logs          driveradmin.LogController
+	loginThrottle driveradmin.LoginThrottle
+
+	livenessChecks     []namedHealthCheck
+	readinessChecks    []namedHealthCheck
+	healthCheckTimeout time.Duration
+
+	evacuationTimeout     time.Duration
+	evacuationParallelism int
+	evacuationLock        sync.Mutex
+	evacuation            *evacuation
 }
 
 func NewDriverAdminLocal() *DriverAdminLocal {
-	d := &DriverAdminLocal{}
+	d := &DriverAdminLocal{
+		healthCheckTimeout:    DefaultHealthCheckTimeout,
+		evacuationTimeout:     DefaultEvacuationTimeout,
+		evacuationParallelism: DefaultEvacuationParallelism,
+	}
 
 	return d
 }
@@ -27,30 +59,271 @@ func (d *DriverAdminLocal) RegisterDrainable(rhs driveradmin.Drainable) {
 	d.drainables = append(d.drainables, rhs)
 }
 
-func (d *DriverAdminLocal) Evacuate(env dockerdriver.Env) driveradmin.ErrorResponse {
-	logger := env.Logger().Session("evacuate")
+func (d *DriverAdminLocal) SetPasswordKeySource(source driveradmin.PasswordKeySource) {
+	d.passwordKey = source
+}
+
+func (d *DriverAdminLocal) RegisterCertificateSource(source driveradmin.CertificateSource) {
+	d.certificates = append(d.certificates, source)
+}
+
+func (d *DriverAdminLocal) SetVolumeInspector(inspector driveradmin.VolumeInspector) {
+	d.volumes = inspector
+}
+
+func (d *DriverAdminLocal) SetVolumeOperator(operator driveradmin.VolumeOperator) {
+	d.operator = operator
+}
+
+func (d *DriverAdminLocal) SetCordoner(cordoner driveradmin.Cordoner) {
+	d.cordoner = cordoner
+}
+
+func (d *DriverAdminLocal) SetLogController(logs driveradmin.LogController) {
+	d.logs = logs
+}
+
+func (d *DriverAdminLocal) SetLoginThrottle(loginThrottle driveradmin.LoginThrottle) {
+	d.loginThrottle = loginThrottle
+}
+
+// RegisterLivenessCheck adds a check to both liveness and readiness
+func (d *DriverAdminLocal) RegisterLivenessCheck(name string, check driveradmin.HealthCheck) {
+	d.livenessChecks = append(d.livenessChecks, namedHealthCheck{name: name, check: check})
+}
+
+func (d *DriverAdminLocal) RegisterReadinessCheck(name string, check driveradmin.HealthCheck) {
+	d.readinessChecks = append(d.readinessChecks, namedHealthCheck{name: name, check: check})
+}
+
+// SetHealthCheckTimeout bounds each health check; a check that takes longer is reported as unhealthy
+func (d *DriverAdminLocal) SetHealthCheckTimeout(timeout time.Duration) {
+	d.healthCheckTimeout = timeout
+}
+
+func (d *DriverAdminLocal) Ping(env dockerdriver.Env) driveradmin.ErrorResponse {
+	logger := env.Logger().Session("ping")
 	logger.Info("start")
 	defer logger.Info("end")
 
-	if d.serverProcess == nil {
-		return driveradmin.ErrorResponse{Err: "unexpected error: server process not found"}
+	return driveradmin.ErrorResponse{}
+}
+
+func (d *DriverAdminLocal) PasswordKey(env dockerdriver.Env) driveradmin.PasswordKeyResponse {
+	logger := env.Logger().Session("password-key")
+	logger.Info("start")
+	defer logger.Info("end")
+
+	if d.passwordKey == nil {
+		return driveradmin.PasswordKeyResponse{Err: "password encryption is not configured"}
 	}
 
-	for _, svr := range d.drainables {
-		if err := svr.Drain(env); err != nil {
-			logger.Error("failed-draining", err)
+	return driveradmin.PasswordKeyResponse{PasswordKey: d.passwordKey.PasswordKey()}
+}
+
+func (d *DriverAdminLocal) Certificates(env dockerdriver.Env) driveradmin.CertificatesResponse {
+	logger := env.Logger().Session("certificates")
+	logger.Info("start")
+	defer logger.Info("end")
+
+	if len(d.certificates) == 0 {
+		return driveradmin.CertificatesResponse{Err: "TLS is not configured"}
+	}
+
+	var response driveradmin.CertificatesResponse
+	for _, source := range d.certificates {
+		response.Certificates = append(response.Certificates, source.CertificateStatus())
+	}
+	return response
+}
+
+func (d *DriverAdminLocal) Volumes(env dockerdriver.Env, volumesRequest driveradmin.VolumesRequest) driveradmin.VolumesResponse {
+	logger := env.Logger().Session("volumes", lager.Data{"server": volumesRequest.Server})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	if d.volumes == nil {
+		return driveradmin.VolumesResponse{Err: "volume inspection is not configured"}
+	}
+
+	volumes, err := d.volumes.InspectVolumes(driverhttp.EnvWithLogger(logger, env))
+	if err != nil {
+		return driveradmin.VolumesResponse{Err: err.Error()}
+	}
+
+	response := driveradmin.VolumesResponse{Volumes: []driveradmin.VolumeStatus{}}
+	for _, volume := range volumes {
+		if volumesRequest.Server == "" || volume.Server == volumesRequest.Server {
+			response.Volumes = append(response.Volumes, volume)
 		}
 	}
+	return response
+}
 
-	d.serverProcess.Signal(os.Interrupt)
+func (d *DriverAdminLocal) ForceUnmount(env dockerdriver.Env, volumeRequest driveradmin.VolumeRequest) driveradmin.ErrorResponse {
+	logger := env.Logger().Session("force-unmount", lager.Data{"volume": volumeRequest.Name})
+	logger.Info("start")
+	defer logger.Info("end")
 
+	if d.operator == nil {
+		return driveradmin.ErrorResponse{Err: "volume operations are not configured"}
+	}
+	if err := d.operator.ForceUnmount(driverhttp.EnvWithLogger(logger, env), volumeRequest.Name); err != nil {
+		return driveradmin.ErrorResponse{Err: err.Error()}
+	}
 	return driveradmin.ErrorResponse{}
 }
 
-func (d *DriverAdminLocal) Ping(env dockerdriver.Env) driveradmin.ErrorResponse {
-	logger := env.Logger().Session("ping")
+func (d *DriverAdminLocal) Remount(env dockerdriver.Env, volumeRequest driveradmin.VolumeRequest) driveradmin.ErrorResponse {
+	logger := env.Logger().Session("remount", lager.Data{"volume": volumeRequest.Name})
 	logger.Info("start")
 	defer logger.Info("end")
 
+	if d.operator == nil {
+		return driveradmin.ErrorResponse{Err: "volume operations are not configured"}
+	}
+	if err := d.operator.Remount(driverhttp.EnvWithLogger(logger, env), volumeRequest.Name); err != nil {
+		return driveradmin.ErrorResponse{Err: err.Error()}
+	}
 	return driveradmin.ErrorResponse{}
 }
+
+func (d *DriverAdminLocal) Cordon(env dockerdriver.Env, cordonRequest driveradmin.CordonRequest) driveradmin.CordonResponse {
+	logger := env.Logger().Session("cordon", lager.Data{"server": cordonRequest.Server})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	if d.cordoner == nil {
+		return driveradmin.CordonResponse{Err: "cordoning is not configured"}
+	}
+	status, err := d.cordoner.Cordon(driverhttp.EnvWithLogger(logger, env), cordonRequest.Server)
+	if err != nil {
+		return driveradmin.CordonResponse{Err: err.Error()}
+	}
+	return driveradmin.CordonResponse{CordonStatus: status}
+}
+
+func (d *DriverAdminLocal) Uncordon(env dockerdriver.Env, cordonRequest driveradmin.CordonRequest) driveradmin.CordonResponse {
+	logger := env.Logger().Session("uncordon", lager.Data{"server": cordonRequest.Server})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	if d.cordoner == nil {
+		return driveradmin.CordonResponse{Err: "cordoning is not configured"}
+	}
+	status, err := d.cordoner.Uncordon(driverhttp.EnvWithLogger(logger, env), cordonRequest.Server)
+	if err != nil {
+		return driveradmin.CordonResponse{Err: err.Error()}
+	}
+	return driveradmin.CordonResponse{CordonStatus: status}
+}
+
+func (d *DriverAdminLocal) CordonStatus(env dockerdriver.Env) driveradmin.CordonResponse {
+	if d.cordoner == nil {
+		return driveradmin.CordonResponse{Err: "cordoning is not configured"}
+	}
+	return driveradmin.CordonResponse{CordonStatus: d.cordoner.CordonStatus()}
+}
+
+func (d *DriverAdminLocal) LogLevel(env dockerdriver.Env) driveradmin.LogLevelResponse {
+	if d.logs == nil {
+		return driveradmin.LogLevelResponse{Err: "log level control is not configured"}
+	}
+	return driveradmin.LogLevelResponse{LogLevelStatus: d.logs.LogLevel()}
+}
+
+func (d *DriverAdminLocal) SetLogLevel(env dockerdriver.Env, setLogLevelRequest driveradmin.SetLogLevelRequest) driveradmin.LogLevelResponse {
+	logger := env.Logger().Session("set-log-level", lager.Data{"level": setLogLevelRequest.Level})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	if d.logs == nil {
+		return driveradmin.LogLevelResponse{Err: "log level control is not configured"}
+	}
+	status, err := d.logs.SetLogLevel(setLogLevelRequest.Level)
+	if err != nil {
+		return driveradmin.LogLevelResponse{Err: err.Error()}
+	}
+	return driveradmin.LogLevelResponse{LogLevelStatus: status}
+}
+
+func (d *DriverAdminLocal) EnableDebugLogging(env dockerdriver.Env, debugLoggingRequest driveradmin.DebugLoggingRequest) driveradmin.LogLevelResponse {
+	logger := env.Logger().Session("enable-debug-logging", lager.Data{
+		"volume":   debugLoggingRequest.Volume,
+		"server":   debugLoggingRequest.Server,
+		"duration": debugLoggingRequest.Duration.String(),
+	})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	if d.logs == nil {
+		return driveradmin.LogLevelResponse{Err: "log level control is not configured"}
+	}
+	return driveradmin.LogLevelResponse{
+		LogLevelStatus: d.logs.EnableDebugLogging(debugLoggingRequest.Volume, debugLoggingRequest.Server, debugLoggingRequest.Duration),
+	}
+}
+
+func (d *DriverAdminLocal) LoginThrottle(env dockerdriver.Env) driveradmin.LoginThrottleResponse {
+	if d.loginThrottle == nil {
+		return driveradmin.LoginThrottleResponse{Err: "login throttling is not configured"}
+	}
+	return driveradmin.LoginThrottleResponse{LoginThrottleStatus: d.loginThrottle.LoginThrottleStatus()}
+}
+
+func (d *DriverAdminLocal) Liveness(env dockerdriver.Env) driveradmin.HealthResponse {
+	logger := env.Logger().Session("liveness")
+	logger.Info("start")
+	defer logger.Info("end")
+
+	return d.checkHealth(driverhttp.EnvWithLogger(logger, env), d.livenessChecks)
+}
+
+func (d *DriverAdminLocal) Readiness(env dockerdriver.Env) driveradmin.HealthResponse {
+	logger := env.Logger().Session("readiness")
+	logger.Info("start")
+	defer logger.Info("end")
+
+	checks := append(append([]namedHealthCheck{}, d.livenessChecks...), d.readinessChecks...)
+	response := d.checkHealth(driverhttp.EnvWithLogger(logger, env), checks)
+	if d.evacuating() {
+		response.Healthy = false
+		response.Components = append([]driveradmin.ComponentHealth{{Name: "driver", Message: "evacuating"}}, response.Components...)
+	}
+	return response
+}
+
+// checkHealth runs the checks concurrently and reports them in the order they were registered
+func (d *DriverAdminLocal) checkHealth(env dockerdriver.Env, checks []namedHealthCheck) driveradmin.HealthResponse {
+	logger := env.Logger()
+
+	ctx, cancel := context.WithTimeout(env.Context(), d.healthCheckTimeout)
+	defer cancel()
+	checkEnv := driverhttp.EnvWithContext(ctx, env)
+
+	results := make([]chan driveradmin.ComponentHealth, len(checks))
+	for i, check := range checks {
+		results[i] = make(chan driveradmin.ComponentHealth, 1)
+		go func(check driveradmin.HealthCheck, result chan<- driveradmin.ComponentHealth) {
+			result <- check.CheckHealth(checkEnv)
+		}(check.check, results[i])
+	}
+
+	response := driveradmin.HealthResponse{Healthy: true, Components: []driveradmin.ComponentHealth{}}
+	for i, check := range checks {
+		var health driveradmin.ComponentHealth
+		select {
+		case health = <-results[i]:
+		case <-ctx.Done():
+			health = driveradmin.ComponentHealth{Message: fmt.Sprintf("timed out after %s", d.healthCheckTimeout)}
+		}
+		health.Name = check.name
+
+		if !health.Healthy {
+			logger.Info("unhealthy", lager.Data{"component": health.Name, "message": health.Message})
+			response.Healthy = false
+		}
+		response.Components = append(response.Components, health)
+	}
+	return response
+}
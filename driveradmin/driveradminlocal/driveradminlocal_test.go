@@ -2,6 +2,8 @@ package driveradminlocal_test
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"code.cloudfoundry.org/dockerdriver"
 	"code.cloudfoundry.org/dockerdriver/driverhttp"
@@ -12,6 +14,7 @@ import (
 	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 )
 
 var _ = Describe("Driver Admin Local", func() {
@@ -32,49 +35,434 @@ var _ = Describe("Driver Admin Local", func() {
 			driverAdminLocal = driveradminlocal.NewDriverAdminLocal()
 		})
 
-		Describe("Evacuate", func() {
+		Describe("Ping", func() {
+			Context("when the driver pings", func() {
+				BeforeEach(func() {
+					err = driverAdminLocal.Ping(env)
+				})
+
+				It("should not fail", func() {
+					Expect(err.Err).To(Equal(""))
+				})
+			})
+		})
+
+		Describe("PasswordKey", func() {
+			var response driveradmin.PasswordKeyResponse
+
 			JustBeforeEach(func() {
-				err = driverAdminLocal.Evacuate(env)
+				response = driverAdminLocal.PasswordKey(env)
 			})
-			Context("when the driver evacuates with no process set", func() {
+
+			Context("when password encryption is not configured", func() {
 				It("should fail", func() {
-					Expect(err.Err).To(ContainSubstring("server process not found"))
+					Expect(response.Err).To(Equal("password encryption is not configured"))
+				})
+			})
+
+			Context("when a password key source is set", func() {
+				BeforeEach(func() {
+					fakeSource := &nfsdriverfakes.FakePasswordKeySource{}
+					fakeSource.PasswordKeyReturns(driveradmin.PasswordKey{KeyId: "0123456789abcdef", Algorithm: "RSA-OAEP-256"})
+					driverAdminLocal.SetPasswordKeySource(fakeSource)
+				})
+
+				It("should return its key", func() {
+					Expect(response.Err).To(BeEmpty())
+					Expect(response.KeyId).To(Equal("0123456789abcdef"))
 				})
 			})
-			Context("when the driver evacuates with a process set", func() {
-				var fakeProcess *nfsdriverfakes.FakeProcess
+		})
+
+		Describe("Health", func() {
+			var (
+				liveCheck  *nfsdriverfakes.FakeHealthCheck
+				readyCheck *nfsdriverfakes.FakeHealthCheck
+			)
+
+			BeforeEach(func() {
+				liveCheck = &nfsdriverfakes.FakeHealthCheck{}
+				liveCheck.CheckHealthReturns(driveradmin.ComponentHealth{Healthy: true})
+				readyCheck = &nfsdriverfakes.FakeHealthCheck{}
+				readyCheck.CheckHealthReturns(driveradmin.ComponentHealth{Healthy: true, Details: map[string]interface{}{"latency-ms": 3}})
+
+				driverAdminLocal.RegisterLivenessCheck("driver-server", liveCheck)
+				driverAdminLocal.RegisterReadinessCheck("ldap", readyCheck)
+			})
+
+			It("should only run liveness checks for liveness", func() {
+				response := driverAdminLocal.Liveness(env)
+				Expect(response.Healthy).To(BeTrue())
+				Expect(response.Components).To(Equal([]driveradmin.ComponentHealth{{Name: "driver-server", Healthy: true}}))
+				Expect(readyCheck.CheckHealthCallCount()).To(Equal(0))
+			})
+
+			It("should run every check for readiness, in the order they were registered", func() {
+				response := driverAdminLocal.Readiness(env)
+				Expect(response.Healthy).To(BeTrue())
+				Expect(response.Components).To(HaveLen(2))
+				Expect(response.Components[0].Name).To(Equal("driver-server"))
+				Expect(response.Components[1].Name).To(Equal("ldap"))
+				Expect(response.Components[1].Details).To(HaveKeyWithValue("latency-ms", 3))
+			})
+
+			Context("when a component is unhealthy", func() {
+				BeforeEach(func() {
+					readyCheck.CheckHealthReturns(driveradmin.ComponentHealth{Message: "LDAP server could not be reached"})
+				})
+
+				It("should not be ready", func() {
+					response := driverAdminLocal.Readiness(env)
+					Expect(response.Healthy).To(BeFalse())
+					Expect(response.Components[1].Message).To(Equal("LDAP server could not be reached"))
+					Expect(logger).To(gbytes.Say(`readiness.unhealthy.*"component":"ldap"`))
+				})
+
+				It("should still be live", func() {
+					Expect(driverAdminLocal.Liveness(env).Healthy).To(BeTrue())
+				})
+			})
+
+			Context("when a check does not return in time", func() {
+				var release chan struct{}
+
+				BeforeEach(func() {
+					release = make(chan struct{})
+					readyCheck.CheckHealthStub = func(dockerdriver.Env) driveradmin.ComponentHealth {
+						<-release
+						return driveradmin.ComponentHealth{Healthy: true}
+					}
+					driverAdminLocal.SetHealthCheckTimeout(50 * time.Millisecond)
+				})
+
+				AfterEach(func() {
+					close(release)
+				})
+
+				It("should report it as unhealthy", func() {
+					response := driverAdminLocal.Readiness(env)
+					Expect(response.Healthy).To(BeFalse())
+					Expect(response.Components[0].Healthy).To(BeTrue())
+					Expect(response.Components[1]).To(Equal(driveradmin.ComponentHealth{Name: "ldap", Message: "timed out after 50ms"}))
+				})
+			})
+
+			Context("when the driver is evacuating", func() {
+				var drained chan struct{}
 
 				BeforeEach(func() {
-					fakeProcess = &nfsdriverfakes.FakeProcess{}
-					driverAdminLocal.SetServerProc(fakeProcess)
+					drained = make(chan struct{})
+					fakeDrainable := &nfsdriverfakes.FakeDrainable{}
+					fakeDrainable.DrainStub = func(dockerdriver.Env) error {
+						<-drained
+						return nil
+					}
+					driverAdminLocal.RegisterDrainable(fakeDrainable)
+					Expect(driverAdminLocal.Evacuate(env, driveradmin.EvacuateRequest{}).Err).To(BeEmpty())
 				})
 
-				It("should signal the process to terminate", func() {
-					Expect(err.Err).To(BeEmpty())
-					Expect(fakeProcess.SignalCallCount()).NotTo(Equal(0))
+				AfterEach(func() {
+					close(drained)
 				})
-				Context("when there is a drainable server registered", func() {
-					var fakeDrainable *nfsdriverfakes.FakeDrainable
+
+				It("should not be ready", func() {
+					response := driverAdminLocal.Readiness(env)
+					Expect(response.Healthy).To(BeFalse())
+					Expect(response.Components[0]).To(Equal(driveradmin.ComponentHealth{Name: "driver", Message: "evacuating"}))
+				})
+			})
+		})
+
+		Describe("Volumes", func() {
+			var (
+				volumesRequest driveradmin.VolumesRequest
+				response       driveradmin.VolumesResponse
+			)
+
+			BeforeEach(func() {
+				volumesRequest = driveradmin.VolumesRequest{}
+			})
+
+			JustBeforeEach(func() {
+				response = driverAdminLocal.Volumes(env, volumesRequest)
+			})
+
+			Context("when volume inspection is not configured", func() {
+				It("should fail", func() {
+					Expect(response.Err).To(Equal("volume inspection is not configured"))
+				})
+			})
+
+			Context("when a volume inspector is set", func() {
+				var fakeInspector *nfsdriverfakes.FakeVolumeInspector
+
+				BeforeEach(func() {
+					fakeInspector = &nfsdriverfakes.FakeVolumeInspector{}
+					fakeInspector.InspectVolumesReturns([]driveradmin.VolumeStatus{
+						{Name: "vol1", Server: "nfs1.example.com"},
+						{Name: "vol2", Server: "nfs2.example.com"},
+						{Name: "vol3", Server: "nfs1.example.com"},
+					}, nil)
+					driverAdminLocal.SetVolumeInspector(fakeInspector)
+				})
+
+				It("should return every volume", func() {
+					Expect(response.Err).To(BeEmpty())
+					Expect(response.Volumes).To(HaveLen(3))
+				})
+
+				Context("when filtering by server", func() {
 					BeforeEach(func() {
-						fakeDrainable = &nfsdriverfakes.FakeDrainable{}
-						driverAdminLocal.RegisterDrainable(fakeDrainable)
+						volumesRequest.Server = "nfs1.example.com"
 					})
-					It("should drain", func() {
-						Expect(fakeDrainable.DrainCallCount()).NotTo(Equal(0))
+
+					It("should return the volumes mounted from it", func() {
+						Expect(response.Volumes).To(Equal([]driveradmin.VolumeStatus{
+							{Name: "vol1", Server: "nfs1.example.com"},
+							{Name: "vol3", Server: "nfs1.example.com"},
+						}))
 					})
 				})
 
+				Context("when inspecting fails", func() {
+					BeforeEach(func() {
+						fakeInspector.InspectVolumesReturns(nil, errors.New("open /proc/mounts: permission denied"))
+					})
+
+					It("should fail", func() {
+						Expect(response.Err).To(Equal("open /proc/mounts: permission denied"))
+					})
+				})
 			})
 		})
 
-		Describe("Ping", func() {
-			Context("when the driver pings", func() {
+		Describe("Remount", func() {
+			var response driveradmin.ErrorResponse
+
+			JustBeforeEach(func() {
+				response = driverAdminLocal.Remount(env, driveradmin.VolumeRequest{Name: "vol1"})
+			})
+
+			Context("when volume operations are not configured", func() {
+				It("should fail", func() {
+					Expect(response.Err).To(Equal("volume operations are not configured"))
+				})
+			})
+
+			Context("when a volume operator is set", func() {
+				var fakeOperator *nfsdriverfakes.FakeVolumeOperator
+
 				BeforeEach(func() {
-					err = driverAdminLocal.Ping(env)
+					fakeOperator = &nfsdriverfakes.FakeVolumeOperator{}
+					driverAdminLocal.SetVolumeOperator(fakeOperator)
 				})
 
-				It("should not fail", func() {
-					Expect(err.Err).To(Equal(""))
+				It("should remount the volume", func() {
+					Expect(response.Err).To(BeEmpty())
+					Expect(fakeOperator.RemountCallCount()).To(Equal(1))
+					_, name := fakeOperator.RemountArgsForCall(0)
+					Expect(name).To(Equal("vol1"))
+				})
+
+				Context("when remounting fails", func() {
+					BeforeEach(func() {
+						fakeOperator.RemountReturns(errors.New("volume vol1 is not mounted"))
+					})
+
+					It("should fail", func() {
+						Expect(response.Err).To(Equal("volume vol1 is not mounted"))
+					})
+				})
+			})
+		})
+
+		Describe("ForceUnmount", func() {
+			var response driveradmin.ErrorResponse
+
+			JustBeforeEach(func() {
+				response = driverAdminLocal.ForceUnmount(env, driveradmin.VolumeRequest{Name: "vol1"})
+			})
+
+			Context("when volume operations are not configured", func() {
+				It("should fail", func() {
+					Expect(response.Err).To(Equal("volume operations are not configured"))
+				})
+			})
+
+			Context("when a volume operator is set", func() {
+				var fakeOperator *nfsdriverfakes.FakeVolumeOperator
+
+				BeforeEach(func() {
+					fakeOperator = &nfsdriverfakes.FakeVolumeOperator{}
+					driverAdminLocal.SetVolumeOperator(fakeOperator)
+				})
+
+				It("should force the volume off", func() {
+					Expect(response.Err).To(BeEmpty())
+					_, name := fakeOperator.ForceUnmountArgsForCall(0)
+					Expect(name).To(Equal("vol1"))
+				})
+
+				Context("when forcing the volume off fails", func() {
+					BeforeEach(func() {
+						fakeOperator.ForceUnmountReturns(errors.New("volume vol1 not found"))
+					})
+
+					It("should fail", func() {
+						Expect(response.Err).To(Equal("volume vol1 not found"))
+					})
+				})
+			})
+		})
+
+		Describe("Cordon", func() {
+			var response driveradmin.CordonResponse
+
+			JustBeforeEach(func() {
+				response = driverAdminLocal.Cordon(env, driveradmin.CordonRequest{Server: "nfs1.example.com"})
+			})
+
+			Context("when cordoning is not configured", func() {
+				It("should fail", func() {
+					Expect(response.Err).To(Equal("cordoning is not configured"))
+					Expect(driverAdminLocal.CordonStatus(env).Err).To(Equal("cordoning is not configured"))
+				})
+			})
+
+			Context("when a cordoner is set", func() {
+				var fakeCordoner *nfsdriverfakes.FakeCordoner
+
+				BeforeEach(func() {
+					fakeCordoner = &nfsdriverfakes.FakeCordoner{}
+					fakeCordoner.CordonReturns(driveradmin.CordonStatus{Servers: []string{"nfs1.example.com"}}, nil)
+					driverAdminLocal.SetCordoner(fakeCordoner)
+				})
+
+				It("should cordon the server", func() {
+					Expect(response.Err).To(BeEmpty())
+					Expect(response.Servers).To(Equal([]string{"nfs1.example.com"}))
+					_, server := fakeCordoner.CordonArgsForCall(0)
+					Expect(server).To(Equal("nfs1.example.com"))
+				})
+
+				It("should uncordon the server", func() {
+					fakeCordoner.UncordonReturns(driveradmin.CordonStatus{Servers: []string{}}, nil)
+					uncordonResponse := driverAdminLocal.Uncordon(env, driveradmin.CordonRequest{Server: "nfs1.example.com"})
+					Expect(uncordonResponse.Err).To(BeEmpty())
+					Expect(uncordonResponse.Servers).To(BeEmpty())
+				})
+
+				It("should report the cordon state", func() {
+					fakeCordoner.CordonStatusReturns(driveradmin.CordonStatus{All: true})
+					Expect(driverAdminLocal.CordonStatus(env)).To(Equal(driveradmin.CordonResponse{CordonStatus: driveradmin.CordonStatus{All: true}}))
+				})
+
+				Context("when the state cannot be persisted", func() {
+					BeforeEach(func() {
+						fakeCordoner.CordonReturns(driveradmin.CordonStatus{}, errors.New("read-only file system"))
+					})
+
+					It("should fail", func() {
+						Expect(response.Err).To(Equal("read-only file system"))
+					})
+				})
+			})
+		})
+
+		Describe("LoginThrottle", func() {
+			Context("when login throttling is not configured", func() {
+				It("should fail", func() {
+					Expect(driverAdminLocal.LoginThrottle(env).Err).To(Equal("login throttling is not configured"))
+				})
+			})
+
+			Context("when a login throttle is set", func() {
+				BeforeEach(func() {
+					fakeLoginThrottle := &nfsdriverfakes.FakeLoginThrottle{}
+					fakeLoginThrottle.LoginThrottleStatusReturns(driveradmin.LoginThrottleStatus{UserThrottled: 3, RecentFailures: 7})
+					driverAdminLocal.SetLoginThrottle(fakeLoginThrottle)
+				})
+
+				It("should report its counters", func() {
+					Expect(driverAdminLocal.LoginThrottle(env)).To(Equal(driveradmin.LoginThrottleResponse{
+						LoginThrottleStatus: driveradmin.LoginThrottleStatus{UserThrottled: 3, RecentFailures: 7},
+					}))
+				})
+			})
+		})
+
+		Describe("LogLevel", func() {
+			Context("when log level control is not configured", func() {
+				It("should fail", func() {
+					Expect(driverAdminLocal.LogLevel(env).Err).To(Equal("log level control is not configured"))
+					Expect(driverAdminLocal.SetLogLevel(env, driveradmin.SetLogLevelRequest{Level: "debug"}).Err).To(Equal("log level control is not configured"))
+					Expect(driverAdminLocal.EnableDebugLogging(env, driveradmin.DebugLoggingRequest{Volume: "vol1"}).Err).To(Equal("log level control is not configured"))
+				})
+			})
+
+			Context("when a log controller is set", func() {
+				var fakeLogs *nfsdriverfakes.FakeLogController
+
+				BeforeEach(func() {
+					fakeLogs = &nfsdriverfakes.FakeLogController{}
+					fakeLogs.LogLevelReturns(driveradmin.LogLevelStatus{Level: "info"})
+					driverAdminLocal.SetLogController(fakeLogs)
+				})
+
+				It("should report the log level", func() {
+					Expect(driverAdminLocal.LogLevel(env)).To(Equal(driveradmin.LogLevelResponse{LogLevelStatus: driveradmin.LogLevelStatus{Level: "info"}}))
+				})
+
+				It("should set the log level", func() {
+					fakeLogs.SetLogLevelReturns(driveradmin.LogLevelStatus{Level: "debug"}, nil)
+					response := driverAdminLocal.SetLogLevel(env, driveradmin.SetLogLevelRequest{Level: "debug"})
+					Expect(response.Level).To(Equal("debug"))
+					Expect(fakeLogs.SetLogLevelArgsForCall(0)).To(Equal("debug"))
+				})
+
+				It("should fail to set an unknown log level", func() {
+					fakeLogs.SetLogLevelReturns(driveradmin.LogLevelStatus{}, errors.New("invalid log level: verbose"))
+					Expect(driverAdminLocal.SetLogLevel(env, driveradmin.SetLogLevelRequest{Level: "verbose"}).Err).To(Equal("invalid log level: verbose"))
+				})
+
+				It("should debug a volume for a while", func() {
+					driverAdminLocal.EnableDebugLogging(env, driveradmin.DebugLoggingRequest{Volume: "vol1", Duration: time.Minute})
+					volume, server, duration := fakeLogs.EnableDebugLoggingArgsForCall(0)
+					Expect(volume).To(Equal("vol1"))
+					Expect(server).To(BeEmpty())
+					Expect(duration).To(Equal(time.Minute))
+				})
+			})
+		})
+
+		Describe("Certificates", func() {
+			var response driveradmin.CertificatesResponse
+
+			JustBeforeEach(func() {
+				response = driverAdminLocal.Certificates(env)
+			})
+
+			Context("when TLS is not configured", func() {
+				It("should fail", func() {
+					Expect(response.Err).To(Equal("TLS is not configured"))
+				})
+			})
+
+			Context("when certificate sources are registered", func() {
+				BeforeEach(func() {
+					for _, name := range []string{"driver", "admin"} {
+						fakeSource := &nfsdriverfakes.FakeCertificateSource{}
+						fakeSource.CertificateStatusReturns(driveradmin.CertificateStatus{Name: name, DaysUntilExpiry: 30})
+						driverAdminLocal.RegisterCertificateSource(fakeSource)
+					}
+				})
+
+				It("should return the status of each", func() {
+					Expect(response.Err).To(BeEmpty())
+					Expect(response.Certificates).To(HaveLen(2))
+					Expect(response.Certificates[0].Name).To(Equal("driver"))
+					Expect(response.Certificates[1].Name).To(Equal("admin"))
+					Expect(response.Certificates[1].DaysUntilExpiry).To(Equal(30))
 				})
 			})
 		})
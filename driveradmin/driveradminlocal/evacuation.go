@@ -0,0 +1,264 @@
+package driveradminlocal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+const (
+	DefaultEvacuationTimeout     = 5 * time.Minute
+	DefaultEvacuationParallelism = 4
+)
+
+// evacuation tracks a drain running in the background. Its volumes are only updated while it holds lock.
+type evacuation struct {
+	lock   sync.Mutex
+	status driveradmin.EvacuationStatus
+}
+
+func (e *evacuation) snapshot() driveradmin.EvacuationStatus {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	status := e.status
+	status.Volumes = append([]driveradmin.VolumeEvacuation{}, e.status.Volumes...)
+	return status
+}
+
+func (e *evacuation) running() bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	return e.status.State == driveradmin.EvacuationRunning
+}
+
+// join reports whether the job is still running, and if so makes it terminate the server when exit is requested
+func (e *evacuation) join(exit bool) bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.status.State != driveradmin.EvacuationRunning {
+		return false
+	}
+	e.status.Exit = e.status.Exit || exit
+	return true
+}
+
+func (e *evacuation) addVolume(volume dockerdriver.VolumeInfo) int {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.status.Volumes = append(e.status.Volumes, driveradmin.VolumeEvacuation{
+		Name:       volume.Name,
+		Mountpoint: volume.Mountpoint,
+		State:      driveradmin.VolumePending,
+	})
+	return len(e.status.Volumes) - 1
+}
+
+// setVolumeState moves a volume on from one of the from states, so that an unmount finishing after the deadline
+// does not overwrite its timed-out state
+func (e *evacuation) setVolumeState(i int, state string, err string, from ...string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	for _, current := range from {
+		if e.status.Volumes[i].State == current {
+			e.status.Volumes[i].State = state
+			e.status.Volumes[i].Err = err
+			return
+		}
+	}
+}
+
+// finish returns whether the server should terminate, which requests may have asked for while the job was running
+func (e *evacuation) finish(state string) bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.status.State = state
+	e.status.FinishedAt = time.Now()
+	return e.status.Exit
+}
+
+func (e *evacuation) failed() bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	for _, volume := range e.status.Volumes {
+		if volume.State != driveradmin.VolumeUnmounted {
+			return true
+		}
+	}
+	return false
+}
+
+// SetEvacuationTimeout bounds how long an evacuation waits for volumes to unmount before giving up on them
+func (d *DriverAdminLocal) SetEvacuationTimeout(timeout time.Duration) {
+	d.evacuationTimeout = timeout
+}
+
+// SetEvacuationParallelism bounds how many volumes an evacuation unmounts at once
+func (d *DriverAdminLocal) SetEvacuationParallelism(parallelism int) {
+	d.evacuationParallelism = parallelism
+}
+
+func (d *DriverAdminLocal) Evacuate(env dockerdriver.Env, evacuateRequest driveradmin.EvacuateRequest) driveradmin.EvacuationResponse {
+	logger := env.Logger().Session("evacuate", lager.Data{"exit": evacuateRequest.Exit})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	if evacuateRequest.Exit && d.serverProcess == nil {
+		return driveradmin.EvacuationResponse{Err: "unexpected error: server process not found"}
+	}
+
+	d.evacuationLock.Lock()
+	defer d.evacuationLock.Unlock()
+
+	if d.evacuation != nil && d.evacuation.join(evacuateRequest.Exit) {
+		logger.Info("already-running")
+		return driveradmin.EvacuationResponse{EvacuationStatus: d.evacuation.snapshot()}
+	}
+
+	now := time.Now()
+	job := &evacuation{status: driveradmin.EvacuationStatus{
+		State:     driveradmin.EvacuationRunning,
+		Exit:      evacuateRequest.Exit,
+		StartedAt: now,
+		Deadline:  now.Add(d.evacuationTimeout),
+		Volumes:   []driveradmin.VolumeEvacuation{},
+	}}
+	d.evacuation = job
+
+	// the job outlives the request that started it
+	go d.evacuate(env.Logger().Session("evacuation"), job)
+
+	return driveradmin.EvacuationResponse{EvacuationStatus: job.snapshot()}
+}
+
+func (d *DriverAdminLocal) EvacuationStatus(env dockerdriver.Env) driveradmin.EvacuationResponse {
+	logger := env.Logger().Session("evacuation-status")
+	logger.Info("start")
+	defer logger.Info("end")
+
+	d.evacuationLock.Lock()
+	job := d.evacuation
+	d.evacuationLock.Unlock()
+
+	if job == nil {
+		return driveradmin.EvacuationResponse{Err: "no evacuation has been started"}
+	}
+	return driveradmin.EvacuationResponse{EvacuationStatus: job.snapshot()}
+}
+
+func (d *DriverAdminLocal) evacuating() bool {
+	d.evacuationLock.Lock()
+	defer d.evacuationLock.Unlock()
+
+	return d.evacuation != nil && d.evacuation.running()
+}
+
+func (d *DriverAdminLocal) evacuate(logger lager.Logger, job *evacuation) {
+	logger.Info("start")
+	defer logger.Info("end")
+
+	status := job.snapshot()
+	ctx, cancel := context.WithDeadline(context.Background(), status.Deadline)
+	defer cancel()
+	env := driverhttp.NewHttpDriverEnv(logger, ctx)
+
+	for _, drainable := range d.drainables {
+		if volumes, ok := drainable.(driveradmin.VolumeDrainable); ok {
+			d.drainVolumes(env, job, volumes)
+		}
+	}
+
+	state := driveradmin.EvacuationSucceeded
+	if ctx.Err() != nil {
+		logger.Info("deadline-exceeded", lager.Data{"deadline": status.Deadline})
+		state = driveradmin.EvacuationTimedOut
+	} else {
+		for _, drainable := range d.drainables {
+			if err := drainable.Drain(env); err != nil {
+				logger.Error("failed-draining", err)
+				state = driveradmin.EvacuationFailed
+			}
+		}
+		if job.failed() {
+			state = driveradmin.EvacuationFailed
+		}
+	}
+	exit := job.finish(state)
+	logger.Info("finished", lager.Data{"state": state, "exit": exit})
+
+	if exit {
+		d.serverProcess.Signal(os.Interrupt)
+	}
+}
+
+// drainVolumes removes the mounted volumes of drainable, at most evacuationParallelism at a time, and stops waiting
+// for them at the job's deadline
+func (d *DriverAdminLocal) drainVolumes(env dockerdriver.Env, job *evacuation, drainable driveradmin.VolumeDrainable) {
+	logger := env.Logger().Session("drain-volumes")
+	ctx := env.Context()
+
+	var (
+		wg      sync.WaitGroup
+		mounted []dockerdriver.VolumeInfo
+		indexes []int
+		slots   = make(chan struct{}, d.evacuationParallelism)
+	)
+	for _, volume := range drainable.List(env).Volumes {
+		if volume.Mountpoint != "" && volume.MountCount > 0 {
+			mounted = append(mounted, volume)
+			indexes = append(indexes, job.addVolume(volume))
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for n, i := range indexes {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			job.setVolumeState(i, driveradmin.VolumeUnmounting, "", driveradmin.VolumePending)
+
+			wg.Add(1)
+			go func(i int, name string) {
+				defer func() { <-slots; wg.Done() }()
+
+				response := drainable.Remove(env, dockerdriver.RemoveRequest{Name: name})
+				if response.Err != "" {
+					logger.Error("failed-unmounting", fmt.Errorf("%s", response.Err), lager.Data{"volume": name})
+					job.setVolumeState(i, driveradmin.VolumeFailed, response.Err, driveradmin.VolumeUnmounting)
+					return
+				}
+				logger.Info("unmounted", lager.Data{"volume": name})
+				job.setVolumeState(i, driveradmin.VolumeUnmounted, "", driveradmin.VolumeUnmounting)
+			}(i, mounted[n].Name)
+		}
+		wg.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	if ctx.Err() != nil {
+		for _, i := range indexes {
+			job.setVolumeState(i, driveradmin.VolumeTimedOut, "evacuation deadline exceeded", driveradmin.VolumePending, driveradmin.VolumeUnmounting)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package driveradminlocal_test
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin/driveradminlocal"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Evacuation", func() {
+	var (
+		env              dockerdriver.Env
+		driverAdminLocal *driveradminlocal.DriverAdminLocal
+		fakeProcess      *nfsdriverfakes.FakeProcess
+		evacuateRequest  driveradmin.EvacuateRequest
+		response         driveradmin.EvacuationResponse
+	)
+
+	status := func() driveradmin.EvacuationStatus {
+		return driverAdminLocal.EvacuationStatus(env).EvacuationStatus
+	}
+
+	finished := func() string {
+		return status().State
+	}
+
+	BeforeEach(func() {
+		env = driverhttp.NewHttpDriverEnv(lagertest.NewTestLogger("evacuation"), context.TODO())
+		driverAdminLocal = driveradminlocal.NewDriverAdminLocal()
+		fakeProcess = &nfsdriverfakes.FakeProcess{}
+		evacuateRequest = driveradmin.EvacuateRequest{Exit: true}
+	})
+
+	JustBeforeEach(func() {
+		response = driverAdminLocal.Evacuate(env, evacuateRequest)
+	})
+
+	Context("when no server process is set", func() {
+		It("should fail", func() {
+			Expect(response.Err).To(ContainSubstring("server process not found"))
+		})
+
+		Context("and the driver is only drained", func() {
+			BeforeEach(func() {
+				evacuateRequest.Exit = false
+			})
+
+			It("should not need one", func() {
+				Expect(response.Err).To(BeEmpty())
+				Eventually(finished).Should(Equal(driveradmin.EvacuationSucceeded))
+			})
+		})
+	})
+
+	Context("when a server process is set", func() {
+		var fakeDrainable *nfsdriverfakes.FakeDrainable
+
+		BeforeEach(func() {
+			driverAdminLocal.SetServerProc(fakeProcess)
+			fakeDrainable = &nfsdriverfakes.FakeDrainable{}
+			driverAdminLocal.RegisterDrainable(fakeDrainable)
+		})
+
+		It("should drain in the background and then signal the process to terminate", func() {
+			Expect(response.Err).To(BeEmpty())
+			Expect(response.State).To(Equal(driveradmin.EvacuationRunning))
+			Expect(response.Deadline).To(BeTemporally("~", response.StartedAt.Add(driveradminlocal.DefaultEvacuationTimeout)))
+
+			Eventually(finished).Should(Equal(driveradmin.EvacuationSucceeded))
+			Expect(fakeDrainable.DrainCallCount()).To(Equal(1))
+			Eventually(fakeProcess.SignalCallCount).Should(Equal(1))
+			Expect(fakeProcess.SignalArgsForCall(0)).To(Equal(os.Interrupt))
+			Expect(status().FinishedAt).NotTo(BeZero())
+		})
+
+		Context("when asked not to exit", func() {
+			BeforeEach(func() {
+				evacuateRequest.Exit = false
+			})
+
+			It("should drain without terminating the process", func() {
+				Eventually(finished).Should(Equal(driveradmin.EvacuationSucceeded))
+				Expect(fakeDrainable.DrainCallCount()).To(Equal(1))
+				Consistently(fakeProcess.SignalCallCount).Should(Equal(0))
+			})
+
+			It("can be started again once finished", func() {
+				Eventually(finished).Should(Equal(driveradmin.EvacuationSucceeded))
+				Expect(driverAdminLocal.Evacuate(env, evacuateRequest).State).To(Equal(driveradmin.EvacuationRunning))
+				Eventually(fakeDrainable.DrainCallCount).Should(Equal(2))
+			})
+		})
+
+		Context("when draining fails", func() {
+			BeforeEach(func() {
+				fakeDrainable.DrainReturns(&os.PathError{Op: "unlinkat", Path: "/var/vcap/data/volumes/nfs", Err: os.ErrPermission})
+			})
+
+			It("should report the evacuation as failed and still terminate", func() {
+				Eventually(finished).Should(Equal(driveradmin.EvacuationFailed))
+				Eventually(fakeProcess.SignalCallCount).Should(Equal(1))
+			})
+		})
+
+		Context("while an evacuation is running", func() {
+			var drained chan struct{}
+
+			BeforeEach(func() {
+				drained = make(chan struct{})
+				fakeDrainable.DrainStub = func(dockerdriver.Env) error {
+					<-drained
+					return nil
+				}
+			})
+
+			It("should report it rather than start another", func() {
+				Eventually(fakeDrainable.DrainCallCount).Should(Equal(1))
+
+				again := driverAdminLocal.Evacuate(env, driveradmin.EvacuateRequest{Exit: false})
+				Expect(again.Err).To(BeEmpty())
+				Expect(again.StartedAt).To(Equal(response.StartedAt))
+				Expect(again.Exit).To(BeTrue())
+
+				close(drained)
+				Eventually(finished).Should(Equal(driveradmin.EvacuationSucceeded))
+				Expect(fakeDrainable.DrainCallCount()).To(Equal(1))
+			})
+
+			Context("without exiting", func() {
+				BeforeEach(func() {
+					evacuateRequest.Exit = false
+				})
+
+				It("should terminate the process once finished when a later request asks to exit", func() {
+					Eventually(fakeDrainable.DrainCallCount).Should(Equal(1))
+
+					again := driverAdminLocal.Evacuate(env, driveradmin.EvacuateRequest{Exit: true})
+					Expect(again.Err).To(BeEmpty())
+					Expect(again.StartedAt).To(Equal(response.StartedAt))
+					Expect(again.Exit).To(BeTrue())
+
+					close(drained)
+					Eventually(finished).Should(Equal(driveradmin.EvacuationSucceeded))
+					Eventually(fakeProcess.SignalCallCount).Should(Equal(1))
+					Expect(fakeProcess.SignalArgsForCall(0)).To(Equal(os.Interrupt))
+					Expect(fakeDrainable.DrainCallCount()).To(Equal(1))
+				})
+			})
+		})
+	})
+
+	Context("when a volume drainable is registered", func() {
+		var (
+			fakeVolumes *nfsdriverfakes.FakeVolumeDrainable
+			unmounting  atomic.Int32
+			maxParallel atomic.Int32
+			release     chan struct{}
+		)
+
+		BeforeEach(func() {
+			driverAdminLocal.SetServerProc(fakeProcess)
+			driverAdminLocal.SetEvacuationParallelism(2)
+
+			unmounting.Store(0)
+			maxParallel.Store(0)
+			release = make(chan struct{})
+
+			fakeVolumes = &nfsdriverfakes.FakeVolumeDrainable{}
+			fakeVolumes.ListReturns(dockerdriver.ListResponse{Volumes: []dockerdriver.VolumeInfo{
+				{Name: "vol1", Mountpoint: "/var/vcap/data/volumes/nfs/vol1", MountCount: 1},
+				{Name: "vol2", Mountpoint: "/var/vcap/data/volumes/nfs/vol2", MountCount: 2},
+				{Name: "created"},
+				{Name: "vol3", Mountpoint: "/var/vcap/data/volumes/nfs/vol3", MountCount: 1},
+			}})
+			fakeVolumes.RemoveStub = func(env dockerdriver.Env, removeRequest dockerdriver.RemoveRequest) dockerdriver.ErrorResponse {
+				current := unmounting.Add(1)
+				defer unmounting.Add(-1)
+				for {
+					highest := maxParallel.Load()
+					if current <= highest || maxParallel.CompareAndSwap(highest, current) {
+						break
+					}
+				}
+
+				<-release
+				if removeRequest.Name == "vol2" {
+					return dockerdriver.ErrorResponse{Err: "Error unmounting volume: device busy"}
+				}
+				return dockerdriver.ErrorResponse{}
+			}
+			driverAdminLocal.RegisterDrainable(fakeVolumes)
+		})
+
+		It("should unmount each mounted volume in parallel, at most parallelism at a time", func() {
+			Eventually(func() []string {
+				var states []string
+				for _, volume := range status().Volumes {
+					states = append(states, volume.State)
+				}
+				return states
+			}).Should(Equal([]string{driveradmin.VolumeUnmounting, driveradmin.VolumeUnmounting, driveradmin.VolumePending}))
+
+			close(release)
+			Eventually(finished).Should(Equal(driveradmin.EvacuationFailed))
+
+			Expect(maxParallel.Load()).To(Equal(int32(2)))
+			Expect(fakeVolumes.RemoveCallCount()).To(Equal(3))
+			Expect(status().Volumes).To(Equal([]driveradmin.VolumeEvacuation{
+				{Name: "vol1", Mountpoint: "/var/vcap/data/volumes/nfs/vol1", State: driveradmin.VolumeUnmounted},
+				{Name: "vol2", Mountpoint: "/var/vcap/data/volumes/nfs/vol2", State: driveradmin.VolumeFailed, Err: "Error unmounting volume: device busy"},
+				{Name: "vol3", Mountpoint: "/var/vcap/data/volumes/nfs/vol3", State: driveradmin.VolumeUnmounted},
+			}))
+
+			By("draining what is left afterwards")
+			Expect(fakeVolumes.DrainCallCount()).To(Equal(1))
+			Eventually(fakeProcess.SignalCallCount).Should(Equal(1))
+		})
+
+		Context("when the deadline passes", func() {
+			BeforeEach(func() {
+				driverAdminLocal.SetEvacuationTimeout(100 * time.Millisecond)
+			})
+
+			AfterEach(func() {
+				close(release)
+			})
+
+			It("should give up on the volumes still unmounting and terminate", func() {
+				Eventually(finished).Should(Equal(driveradmin.EvacuationTimedOut))
+				for _, volume := range status().Volumes {
+					Expect(volume.State).To(Equal(driveradmin.VolumeTimedOut))
+					Expect(volume.Err).To(Equal("evacuation deadline exceeded"))
+				}
+				Expect(fakeVolumes.RemoveCallCount()).To(Equal(2))
+				Expect(fakeVolumes.DrainCallCount()).To(Equal(0))
+				Eventually(fakeProcess.SignalCallCount).Should(Equal(1))
+			})
+		})
+	})
+
+	Describe("EvacuationStatus", func() {
+		It("should fail before any evacuation has been started", func() {
+			Expect(driveradminlocal.NewDriverAdminLocal().EvacuationStatus(env).Err).To(Equal("no evacuation has been started"))
+		})
+	})
+})
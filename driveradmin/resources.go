@@ -1,33 +1,338 @@
 package driveradmin
 
 import (
+	"time"
+
 	"code.cloudfoundry.org/dockerdriver"
 	"github.com/tedsuo/rata"
 )
 
 const (
-	EvacuateRoute = "evacuate"
-	PingRoute     = "ping"
+	EvacuateRoute         = "evacuate"
+	EvacuationStatusRoute = "evacuation_status"
+	PingRoute             = "ping"
+	PasswordKeyRoute      = "password_key"
+	CertificatesRoute     = "certificates"
+	LivenessRoute         = "liveness"
+	ReadinessRoute        = "readiness"
+	VolumesRoute          = "volumes"
+	ForceUnmountRoute     = "force_unmount"
+	RemountRoute          = "remount"
+	CordonStatusRoute     = "cordon_status"
+	CordonRoute           = "cordon"
+	UncordonRoute         = "uncordon"
+	LogLevelRoute         = "log_level"
+	SetLogLevelRoute      = "set_log_level"
+	DebugLoggingRoute     = "debug_logging"
+	LoginThrottleRoute    = "login_throttle"
+)
+
+const (
+	DefaultDebugLoggingDuration = 15 * time.Minute
+	MaxDebugLoggingDuration     = 24 * time.Hour
 )
 
 var Routes = rata.Routes{
-	{Path: "/evacuate", Method: "GET", Name: EvacuateRoute},
+	{Path: "/evacuate", Method: "POST", Name: EvacuateRoute},
+	{Path: "/evacuation", Method: "GET", Name: EvacuationStatusRoute},
 	{Path: "/ping", Method: "GET", Name: PingRoute},
+	{Path: "/password-key", Method: "GET", Name: PasswordKeyRoute},
+	{Path: "/certificates", Method: "GET", Name: CertificatesRoute},
+	{Path: "/health/live", Method: "GET", Name: LivenessRoute},
+	{Path: "/health/ready", Method: "GET", Name: ReadinessRoute},
+	{Path: "/volumes", Method: "GET", Name: VolumesRoute},
+	{Path: "/volumes/:name/force-unmount", Method: "POST", Name: ForceUnmountRoute},
+	{Path: "/volumes/:name/remount", Method: "POST", Name: RemountRoute},
+	{Path: "/cordon", Method: "GET", Name: CordonStatusRoute},
+	{Path: "/cordon", Method: "POST", Name: CordonRoute},
+	{Path: "/uncordon", Method: "POST", Name: UncordonRoute},
+	{Path: "/log-level", Method: "GET", Name: LogLevelRoute},
+	{Path: "/log-level", Method: "POST", Name: SetLogLevelRoute},
+	{Path: "/log-level/debug", Method: "POST", Name: DebugLoggingRoute},
+	{Path: "/login-throttle", Method: "GET", Name: LoginThrottleRoute},
 }
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
 //counterfeiter:generate -o ../nfsdriverfakes/fake_driver_admin.go . DriverAdmin
 
 type DriverAdmin interface {
-	Evacuate(env dockerdriver.Env) ErrorResponse
+	// Evacuate starts draining the driver's volumes in the background, or reports the evacuation already running,
+	// which then also terminates the driver if this request asks it to
+	Evacuate(env dockerdriver.Env, evacuateRequest EvacuateRequest) EvacuationResponse
+	EvacuationStatus(env dockerdriver.Env) EvacuationResponse
 	Ping(env dockerdriver.Env) ErrorResponse
+	PasswordKey(env dockerdriver.Env) PasswordKeyResponse
+	Certificates(env dockerdriver.Env) CertificatesResponse
+	// Liveness reports whether the driver is running; Readiness also reports whether it can mount volumes
+	Liveness(env dockerdriver.Env) HealthResponse
+	Readiness(env dockerdriver.Env) HealthResponse
+	Volumes(env dockerdriver.Env, volumesRequest VolumesRequest) VolumesResponse
+	ForceUnmount(env dockerdriver.Env, volumeRequest VolumeRequest) ErrorResponse
+	Remount(env dockerdriver.Env, volumeRequest VolumeRequest) ErrorResponse
+	// Cordon stops new mounts, from every NFS server or from the one in the request, until it is uncordoned
+	Cordon(env dockerdriver.Env, cordonRequest CordonRequest) CordonResponse
+	Uncordon(env dockerdriver.Env, cordonRequest CordonRequest) CordonResponse
+	CordonStatus(env dockerdriver.Env) CordonResponse
+	LogLevel(env dockerdriver.Env) LogLevelResponse
+	SetLogLevel(env dockerdriver.Env, setLogLevelRequest SetLogLevelRequest) LogLevelResponse
+	// EnableDebugLogging logs at debug level for one volume or NFS server until the request's duration has passed
+	EnableDebugLogging(env dockerdriver.Env, debugLoggingRequest DebugLoggingRequest) LogLevelResponse
+	LoginThrottle(env dockerdriver.Env) LoginThrottleResponse
 }
 
 type ErrorResponse struct {
 	Err string
 }
 
+type EvacuateRequest struct {
+	// Exit terminates the driver once its volumes are drained
+	Exit bool
+}
+
+const (
+	EvacuationRunning   = "running"
+	EvacuationSucceeded = "succeeded"
+	EvacuationFailed    = "failed"
+	EvacuationTimedOut  = "timed-out"
+
+	VolumePending    = "pending"
+	VolumeUnmounting = "unmounting"
+	VolumeUnmounted  = "unmounted"
+	VolumeFailed     = "failed"
+	VolumeTimedOut   = "timed-out"
+)
+
+// VolumeEvacuation is the result of unmounting one volume during an evacuation
+type VolumeEvacuation struct {
+	Name       string
+	Mountpoint string
+	State      string
+	Err        string
+}
+
+type EvacuationStatus struct {
+	State      string
+	Exit       bool
+	StartedAt  time.Time
+	Deadline   time.Time
+	FinishedAt time.Time
+	Volumes    []VolumeEvacuation
+}
+
+type EvacuationResponse struct {
+	EvacuationStatus
+	Err string
+}
+
+// PasswordKey is the public key that clients encrypt the 'encrypted_password' bind option to
+type PasswordKey struct {
+	KeyId     string
+	Algorithm string
+	PublicKey string
+}
+
+type PasswordKeyResponse struct {
+	PasswordKey
+	Err string
+}
+
+//counterfeiter:generate -o ../nfsdriverfakes/fake_password_key_source.go . PasswordKeySource
+type PasswordKeySource interface {
+	PasswordKey() PasswordKey
+}
+
+// CertificateStatus describes a certificate that the driver serves
+type CertificateStatus struct {
+	Name            string
+	Subject         string
+	NotAfter        time.Time
+	DaysUntilExpiry int
+}
+
+type CertificatesResponse struct {
+	Certificates []CertificateStatus
+	Err          string
+}
+
+//counterfeiter:generate -o ../nfsdriverfakes/fake_certificate_source.go . CertificateSource
+type CertificateSource interface {
+	CertificateStatus() CertificateStatus
+}
+
+// ComponentHealth describes one thing the driver depends on
+type ComponentHealth struct {
+	Name    string
+	Healthy bool
+	Message string
+	// Details are specific to the component, e.g. a latency or a count
+	Details map[string]interface{}
+}
+
+type HealthResponse struct {
+	Healthy    bool
+	Components []ComponentHealth
+	Err        string
+}
+
+//counterfeiter:generate -o ../nfsdriverfakes/fake_health_check.go . HealthCheck
+
+// HealthCheck reports the health of a component. It should return by the deadline of the env's context.
+type HealthCheck interface {
+	CheckHealth(env dockerdriver.Env) ComponentHealth
+}
+
+type VolumesRequest struct {
+	// Server restricts the volumes to those mounted from one NFS server
+	Server string
+}
+
+// VolumeCheck is the result of the last check that a volume is still mounted
+type VolumeCheck struct {
+	At      time.Time
+	Healthy bool
+}
+
+// VolumeStatus describes a volume as the kernel and the driver see it. Fields the driver cannot find out, e.g. the
+// mount time of a volume mounted before the driver restarted, are left empty.
+type VolumeStatus struct {
+	Name       string
+	Source     string
+	Server     string
+	Mountpoint string
+	MountCount int
+	UidMapping bool
+	Uid        string
+	Gid        string
+	MapfsPid   int
+	MountedAt  time.Time
+	AgeSeconds int64
+	LastCheck  *VolumeCheck
+	// MountOptions are the options of the NFS mount, as the kernel reports them
+	MountOptions string
+}
+
+type VolumesResponse struct {
+	Volumes []VolumeStatus
+	Err     string
+}
+
+//counterfeiter:generate -o ../nfsdriverfakes/fake_volume_inspector.go . VolumeInspector
+type VolumeInspector interface {
+	InspectVolumes(env dockerdriver.Env) ([]VolumeStatus, error)
+}
+
+type VolumeRequest struct {
+	Name string
+}
+
+//counterfeiter:generate -o ../nfsdriverfakes/fake_volume_operator.go . VolumeOperator
+
+// VolumeOperator acts on a single volume without disturbing the others
+type VolumeOperator interface {
+	ForceUnmount(env dockerdriver.Env, name string) error
+	Remount(env dockerdriver.Env, name string) error
+}
+
+type CordonRequest struct {
+	// Server is the NFS server to cordon; empty cordons the whole driver
+	Server string
+}
+
+// CordonStatus lists what is cordoned. Volumes that are already mounted keep working while cordoned.
+type CordonStatus struct {
+	All     bool
+	Servers []string
+}
+
+type CordonResponse struct {
+	CordonStatus
+	Err string
+}
+
+//counterfeiter:generate -o ../nfsdriverfakes/fake_cordoner.go . Cordoner
+
+// Cordoner holds the cordon state and keeps it across restarts
+type Cordoner interface {
+	Cordon(env dockerdriver.Env, server string) (CordonStatus, error)
+	Uncordon(env dockerdriver.Env, server string) (CordonStatus, error)
+	CordonStatus() CordonStatus
+}
+
+type SetLogLevelRequest struct {
+	// Level is one of debug, info, error or fatal
+	Level string
+}
+
+// DebugLoggingRequest names either a volume or an NFS server
+type DebugLoggingRequest struct {
+	Volume   string
+	Server   string
+	Duration time.Duration
+}
+
+// DebugScope is a volume or NFS server logged at debug level until a deadline
+type DebugScope struct {
+	Volume string `json:",omitempty"`
+	Server string `json:",omitempty"`
+	Until  time.Time
+}
+
+type LogLevelStatus struct {
+	Level       string
+	DebugScopes []DebugScope
+}
+
+type LogLevelResponse struct {
+	LogLevelStatus
+	Err string
+}
+
+//counterfeiter:generate -o ../nfsdriverfakes/fake_log_controller.go . LogController
+
+// LogController changes what the driver logs at runtime. Changes last until the driver restarts.
+type LogController interface {
+	LogLevel() LogLevelStatus
+	SetLogLevel(level string) (LogLevelStatus, error)
+	EnableDebugLogging(volume string, server string, duration time.Duration) LogLevelStatus
+}
+
+// LoginThrottleStatus counts the logins rejected since the driver started, and the failures and logins in progress
+// that the next login is judged against
+type LoginThrottleStatus struct {
+	UserThrottled   int
+	GlobalThrottled int
+	RecentFailures  int
+	InFlight        int
+}
+
+type LoginThrottleResponse struct {
+	LoginThrottleStatus
+	Err string
+}
+
+//counterfeiter:generate -o ../nfsdriverfakes/fake_login_throttle.go . LoginThrottle
+type LoginThrottle interface {
+	LoginThrottleStatus() LoginThrottleStatus
+}
+
+//counterfeiter:generate -o ../nfsdriverfakes/fake_admin_token.go . AdminToken
+
+// AdminToken authenticates the bearer tokens presented to the admin API
+type AdminToken interface {
+	Matches(token string) bool
+}
+
 //counterfeiter:generate -o ../nfsdriverfakes/fake_drainable.go . Drainable
 type Drainable interface {
 	Drain(env dockerdriver.Env) error
 }
+
+//counterfeiter:generate -o ../nfsdriverfakes/fake_volume_drainable.go . VolumeDrainable
+
+// VolumeDrainable is a Drainable whose volumes can be removed one at a time, so that an evacuation can unmount them
+// in parallel and report on each before draining what is left
+type VolumeDrainable interface {
+	Drainable
+	List(env dockerdriver.Env) dockerdriver.ListResponse
+	Remove(env dockerdriver.Env, removeRequest dockerdriver.RemoveRequest) dockerdriver.ErrorResponse
+}
@@ -0,0 +1,81 @@
+package nfsv3driver
+
+import (
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/goshims/osshim"
+	"code.cloudfoundry.org/lager/v3"
+	"github.com/tedsuo/ifrit"
+)
+
+type fileVersion struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// NewFilePoller returns a runner that calls onChange whenever the size or modification time of any of the given
+// files changes. Errors from onChange are logged; the next change triggers another attempt.
+func NewFilePoller(logger lager.Logger, os osshim.Os, interval time.Duration, paths []string, onChange func(logger lager.Logger) error) ifrit.Runner {
+	return &filePoller{
+		logger:   logger.Session("file-poller", lager.Data{"paths": paths}),
+		os:       os,
+		interval: interval,
+		paths:    paths,
+		onChange: onChange,
+	}
+}
+
+type filePoller struct {
+	logger   lager.Logger
+	os       osshim.Os
+	interval time.Duration
+	paths    []string
+	onChange func(logger lager.Logger) error
+}
+
+func (p *filePoller) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	versions := p.versions()
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	close(ready)
+
+	for {
+		select {
+		case <-signals:
+			return nil
+		case <-ticker.C:
+			current := p.versions()
+			if changed(versions, current) {
+				p.logger.Info("files-changed")
+				if err := p.onChange(p.logger); err != nil {
+					p.logger.Error("reload-failed", err)
+				}
+				versions = current
+			}
+		}
+	}
+}
+
+func (p *filePoller) versions() []fileVersion {
+	versions := make([]fileVersion, len(p.paths))
+	for i, path := range p.paths {
+		info, err := p.os.Stat(path)
+		if err != nil {
+			continue
+		}
+		versions[i] = fileVersion{exists: true, size: info.Size(), modTime: info.ModTime()}
+	}
+	return versions
+}
+
+func changed(previous []fileVersion, current []fileVersion) bool {
+	for i := range current {
+		if previous[i] != current[i] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package nfsv3driver_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"code.cloudfoundry.org/goshims/osshim"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/tedsuo/ifrit"
+)
+
+var _ = Describe("FilePoller", func() {
+	var (
+		logger    *lagertest.TestLogger
+		dir       string
+		path      string
+		changes   int32
+		reloadErr atomic.Value
+		process   ifrit.Process
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("file-poller")
+		dir = GinkgoT().TempDir()
+		path = filepath.Join(dir, "users")
+		Expect(os.WriteFile(path, []byte("one"), 0600)).To(Succeed())
+		atomic.StoreInt32(&changes, 0)
+		reloadErr.Store(errors.New(""))
+	})
+
+	JustBeforeEach(func() {
+		onChange := func(lager.Logger) error {
+			atomic.AddInt32(&changes, 1)
+			if err := reloadErr.Load().(error); err.Error() != "" {
+				return err
+			}
+			return nil
+		}
+		process = ifrit.Invoke(nfsv3driver.NewFilePoller(logger, &osshim.OsShim{}, 10*time.Millisecond, []string{path}, onChange))
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive(BeNil()))
+	})
+
+	It("does not call onChange while the file is unchanged", func() {
+		Consistently(func() int32 { return atomic.LoadInt32(&changes) }, 100*time.Millisecond).Should(BeZero())
+	})
+
+	It("calls onChange once when the file is rewritten", func() {
+		Expect(os.WriteFile(path, []byte("two and more"), 0600)).To(Succeed())
+		Eventually(func() int32 { return atomic.LoadInt32(&changes) }).Should(Equal(int32(1)))
+		Consistently(func() int32 { return atomic.LoadInt32(&changes) }, 100*time.Millisecond).Should(Equal(int32(1)))
+	})
+
+	It("calls onChange when the file is removed", func() {
+		Expect(os.Remove(path)).To(Succeed())
+		Eventually(func() int32 { return atomic.LoadInt32(&changes) }).Should(Equal(int32(1)))
+	})
+
+	Context("when onChange fails", func() {
+		BeforeEach(func() {
+			reloadErr.Store(errors.New("bad contents"))
+		})
+
+		It("logs the failure and keeps polling", func() {
+			Expect(os.WriteFile(path, []byte("two and more"), 0600)).To(Succeed())
+			Eventually(logger).Should(gbytes.Say("reload-failed.*bad contents"))
+
+			Expect(os.WriteFile(path, []byte("three"), 0600)).To(Succeed())
+			Eventually(func() int32 { return atomic.LoadInt32(&changes) }).Should(Equal(int32(2)))
+		})
+	})
+})
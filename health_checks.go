@@ -0,0 +1,194 @@
+package nfsv3driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/goshims/ioutilshim"
+	"code.cloudfoundry.org/goshims/osshim"
+	"code.cloudfoundry.org/goshims/timeshim"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+	"code.cloudfoundry.org/volumedriver"
+)
+
+//counterfeiter:generate -o nfsdriverfakes/fake_volume_lister.go . VolumeLister
+
+// VolumeLister is the part of the volume driver that health checks inspect
+type VolumeLister interface {
+	List(env dockerdriver.Env) dockerdriver.ListResponse
+}
+
+type healthCheckFunc func(env dockerdriver.Env) driveradmin.ComponentHealth
+
+func (f healthCheckFunc) CheckHealth(env dockerdriver.Env) driveradmin.ComponentHealth {
+	return f(env)
+}
+
+func healthy(details map[string]interface{}) driveradmin.ComponentHealth {
+	return driveradmin.ComponentHealth{Healthy: true, Details: details}
+}
+
+func unhealthy(details map[string]interface{}, format string, args ...interface{}) driveradmin.ComponentHealth {
+	return driveradmin.ComponentHealth{Message: fmt.Sprintf(format, args...), Details: details}
+}
+
+// NewExecutableHealthCheck is healthy when any of paths is an executable file
+func NewExecutableHealthCheck(os osshim.Os, paths ...string) driveradmin.HealthCheck {
+	return healthCheckFunc(func(dockerdriver.Env) driveradmin.ComponentHealth {
+		for _, path := range paths {
+			info, err := os.Stat(path)
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			if info.Mode().Perm()&0111 == 0 {
+				return unhealthy(map[string]interface{}{"path": path}, "%s is not executable", path)
+			}
+			return healthy(map[string]interface{}{"path": path})
+		}
+		return unhealthy(nil, "not found at %v", paths)
+	})
+}
+
+// NewDeviceHealthCheck is healthy when path can be opened for reading and writing, as mapfs opens /dev/fuse
+func NewDeviceHealthCheck(os osshim.Os, path string) driveradmin.HealthCheck {
+	return healthCheckFunc(func(dockerdriver.Env) driveradmin.ComponentHealth {
+		details := map[string]interface{}{"path": path}
+		file, err := os.OpenFile(path, syscall.O_RDWR, 0)
+		if err != nil {
+			return unhealthy(details, "%s", err.Error())
+		}
+		file.Close()
+		return healthy(details)
+	})
+}
+
+// NewWritableDirHealthCheck is healthy when a file can be created in dir
+func NewWritableDirHealthCheck(os osshim.Os, dir string) driveradmin.HealthCheck {
+	return healthCheckFunc(func(dockerdriver.Env) driveradmin.ComponentHealth {
+		details := map[string]interface{}{"path": dir}
+		probe := filepath.Join(dir, fmt.Sprintf(".health-check-%d", os.Getpid()))
+		file, err := os.OpenFile(probe, syscall.O_CREAT|syscall.O_EXCL|syscall.O_WRONLY, 0600)
+		if err != nil {
+			return unhealthy(details, "%s", err.Error())
+		}
+		file.Close()
+		if err := os.Remove(probe); err != nil {
+			return unhealthy(details, "%s", err.Error())
+		}
+		return healthy(details)
+	})
+}
+
+// NewListenerHealthCheck is healthy when address accepts connections
+func NewListenerHealthCheck(network string, address string) driveradmin.HealthCheck {
+	return healthCheckFunc(func(env dockerdriver.Env) driveradmin.ComponentHealth {
+		details := map[string]interface{}{"address": address}
+		conn, err := (&net.Dialer{}).DialContext(env.Context(), network, address)
+		if err != nil {
+			return unhealthy(details, "%s", err.Error())
+		}
+		conn.Close()
+		return healthy(details)
+	})
+}
+
+// NewStateFileHealthCheck is healthy when the state file the driver restores its volumes from after a restart
+// holds the volumes it currently knows. Mounts that are in flight while the check runs can make it fail once.
+func NewStateFileHealthCheck(ioutil ioutilshim.Ioutil, driver VolumeLister, path string) driveradmin.HealthCheck {
+	return healthCheckFunc(func(env dockerdriver.Env) driveradmin.ComponentHealth {
+		details := map[string]interface{}{"path": path}
+		volumes := driver.List(env).Volumes
+
+		contents, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) && len(volumes) == 0 {
+			return healthy(details)
+		}
+		if err != nil {
+			return unhealthy(details, "%s", err.Error())
+		}
+
+		var state map[string]dockerdriver.VolumeInfo
+		if err := json.Unmarshal(contents, &state); err != nil {
+			return unhealthy(details, "invalid state file: %s", err.Error())
+		}
+
+		var mismatched []string
+		for _, volume := range volumes {
+			if saved, ok := state[volume.Name]; !ok || saved.MountCount != volume.MountCount {
+				mismatched = append(mismatched, volume.Name)
+			}
+			delete(state, volume.Name)
+		}
+		for name := range state {
+			mismatched = append(mismatched, name)
+		}
+		if len(mismatched) > 0 {
+			sort.Strings(mismatched)
+			details["mismatched-volumes"] = mismatched
+			return unhealthy(details, "%d volumes differ from the state file", len(mismatched))
+		}
+		return healthy(details)
+	})
+}
+
+// volumeChecksInParallel bounds the mountpoint processes a volume health check starts at once
+const volumeChecksInParallel = 8
+
+// NewVolumeHealthCheck is healthy when every mounted volume is still mounted
+func NewVolumeHealthCheck(driver VolumeLister, mounter volumedriver.Mounter) driveradmin.HealthCheck {
+	return healthCheckFunc(func(env dockerdriver.Env) driveradmin.ComponentHealth {
+		var (
+			lock             sync.Mutex
+			wg               sync.WaitGroup
+			mounted          int
+			unhealthyVolumes = []string{}
+			slots            = make(chan struct{}, volumeChecksInParallel)
+		)
+		for _, volume := range driver.List(env).Volumes {
+			if volume.MountCount < 1 || volume.Mountpoint == "" {
+				continue
+			}
+			mounted++
+
+			wg.Add(1)
+			slots <- struct{}{}
+			go func(volume dockerdriver.VolumeInfo) {
+				defer func() { <-slots; wg.Done() }()
+				if !mounter.Check(env, volume.Name, volume.Mountpoint) {
+					lock.Lock()
+					unhealthyVolumes = append(unhealthyVolumes, volume.Name)
+					lock.Unlock()
+				}
+			}(volume)
+		}
+		wg.Wait()
+
+		sort.Strings(unhealthyVolumes)
+		details := map[string]interface{}{"mounted": mounted, "unhealthy": len(unhealthyVolumes), "unhealthy-volumes": unhealthyVolumes}
+		if len(unhealthyVolumes) > 0 {
+			return unhealthy(details, "%d of %d volumes are not mounted", len(unhealthyVolumes), mounted)
+		}
+		return healthy(details)
+	})
+}
+
+// NewDirectoryHealthCheck is healthy when the id resolver can bind to its directory server, and reports how long
+// that took
+func NewDirectoryHealthCheck(checker ConnectionChecker, time timeshim.Time) driveradmin.HealthCheck {
+	return healthCheckFunc(func(env dockerdriver.Env) driveradmin.ComponentHealth {
+		start := time.Now()
+		err := checker.CheckConnection(env)
+		details := map[string]interface{}{"bind-latency-ms": time.Now().Sub(start).Milliseconds()}
+		if err != nil {
+			return unhealthy(details, "%s", err.Error())
+		}
+		return healthy(details)
+	})
+}
@@ -0,0 +1,274 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/ioutilshim/ioutil_fake"
+	"code.cloudfoundry.org/goshims/osshim"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
+	"code.cloudfoundry.org/volumedriver/volumedriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HealthChecks", func() {
+	var (
+		env    dockerdriver.Env
+		dir    string
+		health driveradmin.ComponentHealth
+	)
+
+	BeforeEach(func() {
+		env = driverhttp.NewHttpDriverEnv(lagertest.NewTestLogger("health-checks"), context.TODO())
+		dir = GinkgoT().TempDir()
+	})
+
+	Describe("ExecutableHealthCheck", func() {
+		var paths []string
+
+		BeforeEach(func() {
+			Expect(os.WriteFile(filepath.Join(dir, "mount.nfs"), []byte("#!/bin/sh\n"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "mapfs"), []byte("#!/bin/sh\n"), 0644)).To(Succeed())
+		})
+
+		JustBeforeEach(func() {
+			health = nfsv3driver.NewExecutableHealthCheck(&osshim.OsShim{}, paths...).CheckHealth(env)
+		})
+
+		Context("when one of the paths is executable", func() {
+			BeforeEach(func() {
+				paths = []string{filepath.Join(dir, "missing"), filepath.Join(dir, "mount.nfs")}
+			})
+
+			It("is healthy", func() {
+				Expect(health.Healthy).To(BeTrue())
+				Expect(health.Details).To(HaveKeyWithValue("path", filepath.Join(dir, "mount.nfs")))
+			})
+		})
+
+		Context("when the file is not executable", func() {
+			BeforeEach(func() {
+				paths = []string{filepath.Join(dir, "mapfs")}
+			})
+
+			It("is unhealthy", func() {
+				Expect(health.Healthy).To(BeFalse())
+				Expect(health.Message).To(Equal(filepath.Join(dir, "mapfs") + " is not executable"))
+			})
+		})
+
+		Context("when none of the paths exist", func() {
+			BeforeEach(func() {
+				paths = []string{filepath.Join(dir, "missing"), dir}
+			})
+
+			It("is unhealthy", func() {
+				Expect(health.Healthy).To(BeFalse())
+				Expect(health.Message).To(HavePrefix("not found at"))
+			})
+		})
+	})
+
+	Describe("DeviceHealthCheck", func() {
+		It("is healthy when the device can be opened", func() {
+			device := filepath.Join(dir, "fuse")
+			Expect(os.WriteFile(device, nil, 0600)).To(Succeed())
+			Expect(nfsv3driver.NewDeviceHealthCheck(&osshim.OsShim{}, device).CheckHealth(env).Healthy).To(BeTrue())
+		})
+
+		It("is unhealthy when the device cannot be opened", func() {
+			health = nfsv3driver.NewDeviceHealthCheck(&osshim.OsShim{}, filepath.Join(dir, "fuse")).CheckHealth(env)
+			Expect(health.Healthy).To(BeFalse())
+			Expect(health.Message).To(ContainSubstring("no such file or directory"))
+		})
+	})
+
+	Describe("WritableDirHealthCheck", func() {
+		It("is healthy when a file can be created in the directory", func() {
+			Expect(nfsv3driver.NewWritableDirHealthCheck(&osshim.OsShim{}, dir).CheckHealth(env).Healthy).To(BeTrue())
+
+			entries, err := os.ReadDir(dir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+		})
+
+		It("is unhealthy when the directory does not exist", func() {
+			health = nfsv3driver.NewWritableDirHealthCheck(&osshim.OsShim{}, filepath.Join(dir, "missing")).CheckHealth(env)
+			Expect(health.Healthy).To(BeFalse())
+		})
+	})
+
+	Describe("ListenerHealthCheck", func() {
+		It("is healthy when the address accepts connections", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			defer listener.Close()
+
+			Expect(nfsv3driver.NewListenerHealthCheck("tcp", listener.Addr().String()).CheckHealth(env).Healthy).To(BeTrue())
+		})
+
+		It("is unhealthy when nothing listens on the address", func() {
+			health = nfsv3driver.NewListenerHealthCheck("unix", filepath.Join(dir, "driver.sock")).CheckHealth(env)
+			Expect(health.Healthy).To(BeFalse())
+			Expect(health.Details).To(HaveKeyWithValue("address", filepath.Join(dir, "driver.sock")))
+		})
+	})
+
+	Describe("StateFileHealthCheck", func() {
+		var (
+			fakeIoutil *ioutil_fake.FakeIoutil
+			fakeDriver *nfsdriverfakes.FakeVolumeLister
+		)
+
+		BeforeEach(func() {
+			fakeIoutil = &ioutil_fake.FakeIoutil{}
+			fakeIoutil.ReadFileReturns([]byte(`{"vol1":{"Name":"vol1","Mountpoint":"/tmp/volumes/vol1","MountCount":2}}`), nil)
+			fakeDriver = &nfsdriverfakes.FakeVolumeLister{}
+			fakeDriver.ListReturns(dockerdriver.ListResponse{Volumes: []dockerdriver.VolumeInfo{{Name: "vol1", Mountpoint: "/tmp/volumes/vol1", MountCount: 2}}})
+		})
+
+		JustBeforeEach(func() {
+			health = nfsv3driver.NewStateFileHealthCheck(fakeIoutil, fakeDriver, "/tmp/volumes/driver-state.json").CheckHealth(env)
+		})
+
+		It("is healthy when the state file matches the driver's volumes", func() {
+			Expect(health.Healthy).To(BeTrue())
+			Expect(fakeIoutil.ReadFileArgsForCall(0)).To(Equal("/tmp/volumes/driver-state.json"))
+		})
+
+		Context("when the volumes differ", func() {
+			BeforeEach(func() {
+				fakeDriver.ListReturns(dockerdriver.ListResponse{Volumes: []dockerdriver.VolumeInfo{
+					{Name: "vol1", MountCount: 1},
+					{Name: "vol2", MountCount: 1},
+				}})
+			})
+
+			It("is unhealthy", func() {
+				Expect(health.Healthy).To(BeFalse())
+				Expect(health.Message).To(Equal("2 volumes differ from the state file"))
+				Expect(health.Details).To(HaveKeyWithValue("mismatched-volumes", []string{"vol1", "vol2"}))
+			})
+		})
+
+		Context("when the state file is invalid", func() {
+			BeforeEach(func() {
+				fakeIoutil.ReadFileReturns([]byte(`{"vol1":`), nil)
+			})
+
+			It("is unhealthy", func() {
+				Expect(health.Healthy).To(BeFalse())
+				Expect(health.Message).To(HavePrefix("invalid state file"))
+			})
+		})
+
+		Context("when there is no state file", func() {
+			BeforeEach(func() {
+				fakeIoutil.ReadFileReturns(nil, os.ErrNotExist)
+			})
+
+			It("is unhealthy while the driver has volumes", func() {
+				Expect(health.Healthy).To(BeFalse())
+			})
+
+			Context("and no volumes", func() {
+				BeforeEach(func() {
+					fakeDriver.ListReturns(dockerdriver.ListResponse{})
+				})
+
+				It("is healthy", func() {
+					Expect(health.Healthy).To(BeTrue())
+				})
+			})
+		})
+	})
+
+	Describe("VolumeHealthCheck", func() {
+		var (
+			fakeDriver  *nfsdriverfakes.FakeVolumeLister
+			fakeMounter *volumedriverfakes.FakeMounter
+		)
+
+		BeforeEach(func() {
+			fakeDriver = &nfsdriverfakes.FakeVolumeLister{}
+			fakeDriver.ListReturns(dockerdriver.ListResponse{Volumes: []dockerdriver.VolumeInfo{
+				{Name: "vol1", Mountpoint: "/tmp/volumes/vol1", MountCount: 1},
+				{Name: "vol2", Mountpoint: "/tmp/volumes/vol2", MountCount: 3},
+				{Name: "created", MountCount: 0},
+			}})
+			fakeMounter = &volumedriverfakes.FakeMounter{}
+			fakeMounter.CheckReturns(true)
+		})
+
+		JustBeforeEach(func() {
+			health = nfsv3driver.NewVolumeHealthCheck(fakeDriver, fakeMounter).CheckHealth(env)
+		})
+
+		It("checks every mounted volume", func() {
+			Expect(health.Healthy).To(BeTrue())
+			Expect(health.Details).To(HaveKeyWithValue("mounted", 2))
+			Expect(health.Details).To(HaveKeyWithValue("unhealthy", 0))
+			Expect(fakeMounter.CheckCallCount()).To(Equal(2))
+		})
+
+		Context("when a volume is no longer mounted", func() {
+			BeforeEach(func() {
+				fakeMounter.CheckStub = func(env dockerdriver.Env, name string, mountPoint string) bool {
+					return name != "vol2"
+				}
+			})
+
+			It("counts it as unhealthy", func() {
+				Expect(health.Healthy).To(BeFalse())
+				Expect(health.Message).To(Equal("1 of 2 volumes are not mounted"))
+				Expect(health.Details).To(HaveKeyWithValue("unhealthy", 1))
+				Expect(health.Details).To(HaveKeyWithValue("unhealthy-volumes", []string{"vol2"}))
+			})
+		})
+	})
+
+	Describe("DirectoryHealthCheck", func() {
+		var (
+			fakeChecker *nfsdriverfakes.FakeConnectionChecker
+			fakeTime    *nfsdriverfakes.FakeTime
+		)
+
+		BeforeEach(func() {
+			fakeChecker = &nfsdriverfakes.FakeConnectionChecker{}
+			fakeTime = &nfsdriverfakes.FakeTime{}
+			start := time.Now()
+			fakeTime.NowReturnsOnCall(0, start)
+			fakeTime.NowReturnsOnCall(1, start.Add(42*time.Millisecond))
+		})
+
+		JustBeforeEach(func() {
+			health = nfsv3driver.NewDirectoryHealthCheck(fakeChecker, fakeTime).CheckHealth(env)
+		})
+
+		It("reports the bind latency", func() {
+			Expect(health.Healthy).To(BeTrue())
+			Expect(health.Details).To(HaveKeyWithValue("bind-latency-ms", int64(42)))
+		})
+
+		Context("when the directory cannot be reached", func() {
+			BeforeEach(func() {
+				fakeChecker.CheckConnectionReturns(errors.New("LDAP server could not be reached"))
+			})
+
+			It("is unhealthy", func() {
+				Expect(health.Healthy).To(BeFalse())
+				Expect(health.Message).To(Equal("LDAP server could not be reached"))
+			})
+		})
+	})
+})
@@ -0,0 +1,114 @@
+package nfsv3driver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const IdNotAllowedErrorMessage = "The requested uid or gid is not permitted on this cell"
+
+type IdRange struct {
+	Min uint32
+	Max uint32
+}
+
+func (r IdRange) contains(id uint32) bool {
+	return id >= r.Min && id <= r.Max
+}
+
+// ParseIdRanges parses a comma separated list of ids and inclusive ranges, e.g. "1000-59999,65534"
+func ParseIdRanges(encoded string) ([]IdRange, error) {
+	var ranges []IdRange
+	for _, field := range strings.Split(encoded, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		bounds := strings.SplitN(field, "-", 2)
+		min, err := strconv.ParseUint(strings.TrimSpace(bounds[0]), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid id range '%s'", field)
+		}
+		max := min
+		if len(bounds) == 2 {
+			max, err = strconv.ParseUint(strings.TrimSpace(bounds[1]), 10, 32)
+			if err != nil || max < min {
+				return nil, fmt.Errorf("invalid id range '%s'", field)
+			}
+		}
+
+		ranges = append(ranges, IdRange{Min: uint32(min), Max: uint32(max)})
+	}
+	return ranges, nil
+}
+
+// IdChecker decides whether volumes may be mapped to a uid and gid
+type IdChecker interface {
+	Check(uid uint32, gid uint32) error
+}
+
+// IdPolicy limits the uids and gids that volumes may be mapped to. An id must fall in one of the allowed ranges, if
+// any are configured, and in none of the denied ones. The zero value allows every id.
+type IdPolicy struct {
+	AllowedUids []IdRange
+	DeniedUids  []IdRange
+	AllowedGids []IdRange
+	DeniedGids  []IdRange
+}
+
+// Check returns a description of the first violation, or nil
+func (p IdPolicy) Check(uid uint32, gid uint32) error {
+	if !idAllowed(uid, p.AllowedUids, p.DeniedUids) {
+		return fmt.Errorf("uid %d is not allowed", uid)
+	}
+	if !idAllowed(gid, p.AllowedGids, p.DeniedGids) {
+		return fmt.Errorf("gid %d is not allowed", gid)
+	}
+	return nil
+}
+
+func idAllowed(id uint32, allowed []IdRange, denied []IdRange) bool {
+	for _, r := range denied {
+		if r.contains(id) {
+			return false
+		}
+	}
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, r := range allowed {
+		if r.contains(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// SwappableIdPolicy is an IdChecker whose policy can be replaced while mounts are in flight, e.g. when the
+// configuration is reloaded
+type SwappableIdPolicy struct {
+	lock   sync.RWMutex
+	policy IdPolicy
+}
+
+func NewSwappableIdPolicy(policy IdPolicy) *SwappableIdPolicy {
+	return &SwappableIdPolicy{policy: policy}
+}
+
+func (p *SwappableIdPolicy) Swap(policy IdPolicy) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.policy = policy
+}
+
+func (p *SwappableIdPolicy) Check(uid uint32, gid uint32) error {
+	p.lock.RLock()
+	policy := p.policy
+	p.lock.RUnlock()
+
+	return policy.Check(uid, gid)
+}
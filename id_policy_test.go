@@ -0,0 +1,53 @@
+package nfsv3driver_test
+
+import (
+	"code.cloudfoundry.org/nfsv3driver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IdPolicy", func() {
+	DescribeTable("parsing id ranges",
+		func(encoded string, expected []nfsv3driver.IdRange, expectedError string) {
+			ranges, err := nfsv3driver.ParseIdRanges(encoded)
+			if expectedError != "" {
+				Expect(err).To(MatchError(expectedError))
+				return
+			}
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ranges).To(Equal(expected))
+		},
+		Entry("empty", "", nil, ""),
+		Entry("single id", "65534", []nfsv3driver.IdRange{{Min: 65534, Max: 65534}}, ""),
+		Entry("ranges and ids", "1000-59999, 65534", []nfsv3driver.IdRange{{Min: 1000, Max: 59999}, {Min: 65534, Max: 65534}}, ""),
+		Entry("not a number", "staff", nil, "invalid id range 'staff'"),
+		Entry("reversed range", "2000-1000", nil, "invalid id range '2000-1000'"),
+		Entry("negative", "-1", nil, "invalid id range '-1'"),
+	)
+
+	DescribeTable("checking ids",
+		func(policy nfsv3driver.IdPolicy, uid, gid uint32, expectedError string) {
+			err := policy.Check(uid, gid)
+			if expectedError == "" {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(expectedError))
+			}
+		},
+		Entry("no policy", nfsv3driver.IdPolicy{}, uint32(1), uint32(1), ""),
+		Entry("allowed uid", nfsv3driver.IdPolicy{AllowedUids: []nfsv3driver.IdRange{{Min: 1000, Max: 1999}}}, uint32(1000), uint32(1), ""),
+		Entry("uid outside the allowed ranges", nfsv3driver.IdPolicy{AllowedUids: []nfsv3driver.IdRange{{Min: 1000, Max: 1999}}}, uint32(2000), uint32(1), "uid 2000 is not allowed"),
+		Entry("denied uid inside an allowed range", nfsv3driver.IdPolicy{AllowedUids: []nfsv3driver.IdRange{{Min: 1000, Max: 1999}}, DeniedUids: []nfsv3driver.IdRange{{Min: 1500, Max: 1500}}}, uint32(1500), uint32(1), "uid 1500 is not allowed"),
+		Entry("denied gid", nfsv3driver.IdPolicy{DeniedGids: []nfsv3driver.IdRange{{Min: 0, Max: 999}}}, uint32(1000), uint32(27), "gid 27 is not allowed"),
+		Entry("gid outside the allowed ranges", nfsv3driver.IdPolicy{AllowedGids: []nfsv3driver.IdRange{{Min: 1000, Max: 1999}}}, uint32(1000), uint32(2000), "gid 2000 is not allowed"),
+	)
+	Describe("SwappableIdPolicy", func() {
+		It("checks ids against the current policy", func() {
+			policy := nfsv3driver.NewSwappableIdPolicy(nfsv3driver.IdPolicy{})
+			Expect(policy.Check(0, 0)).To(Succeed())
+
+			policy.Swap(nfsv3driver.IdPolicy{DeniedUids: []nfsv3driver.IdRange{{Min: 0, Max: 0}}})
+			Expect(policy.Check(0, 0)).To(MatchError("uid 0 is not allowed"))
+		})
+	})
+})
@@ -1,23 +1,31 @@
 package nfsv3driver
 
 import (
-	"crypto/tls"
-	"crypto/x509"
-	"errors"
+	"context"
 	"fmt"
 	"time"
 
 	"code.cloudfoundry.org/dockerdriver"
 	"code.cloudfoundry.org/goshims/ldapshim"
+	"code.cloudfoundry.org/lager/v3"
 	"gopkg.in/ldap.v2"
 )
 
+const UserNotFoundErrorMessage = "User does not exist"
+
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
 //counterfeiter:generate -o nfsdriverfakes/fake_id_resolver.go . IdResolver
 type IdResolver interface {
 	Resolve(env dockerdriver.Env, username string, password string) (uid string, gid string, err error)
 }
 
+//counterfeiter:generate -o nfsdriverfakes/fake_connection_checker.go . ConnectionChecker
+
+// ConnectionChecker is implemented by id resolvers that depend on a directory server
+type ConnectionChecker interface {
+	CheckConnection(env dockerdriver.Env) error
+}
+
 type ldapIdResolver struct {
 	svcUser     string
 	svcPass     string
@@ -28,6 +36,13 @@ type ldapIdResolver struct {
 	ldapCACert  string
 	ldap        ldapshim.Ldap
 	ldapTimeout time.Duration
+	domains     []LdapDomain
+	idMapRange  *IdMapRange
+	groupPolicy *GroupMembershipPolicy
+
+	searchOptions LdapSearchOptions
+
+	credentialSource LdapCredentialSource
 }
 
 func NewLdapIdResolver(
@@ -40,8 +55,9 @@ func NewLdapIdResolver(
 	ldapCACert string,
 	ldap ldapshim.Ldap,
 	ldapTimeout time.Duration,
+	opts ...LdapIdResolverOption,
 ) IdResolver {
-	return &ldapIdResolver{
+	d := &ldapIdResolver{
 		svcUser:     svcUser,
 		svcPass:     svcPass,
 		ldapHost:    ldapHost,
@@ -52,78 +68,170 @@ func NewLdapIdResolver(
 		ldap:        ldap,
 		ldapTimeout: ldapTimeout,
 	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
 }
 
 func (d *ldapIdResolver) Resolve(env dockerdriver.Env, username string, password string) (uid string, gid string, err error) {
-	addr := fmt.Sprintf("%s:%d", d.ldapHost, d.ldapPort)
+	logger := env.Logger().Session("ldap-resolve", lager.Data{"username": username})
+	logger.Info("start")
+	defer logger.Info("end")
 
-	var l ldapshim.LdapConnection
-	if d.ldapCACert != "" {
-		roots := x509.NewCertPool()
-		ok := roots.AppendCertsFromPEM([]byte(d.ldapCACert))
-		if !ok {
-			return "", "", errors.New("Failed to load CA certificate")
-		}
+	return resolveWithContext(logger, env.Context(), func(conn *cancellableConnection) (string, string, error) {
+		return d.resolve(logger, env.Context(), conn, username, password)
+	})
+}
 
-		// #nosec G402
-		l, err = d.ldap.DialTLS(d.ldapProto, addr, &tls.Config{
-			ServerName: d.ldapHost,
-			RootCAs:    roots,
-		})
-	} else {
-		l, err = d.ldap.Dial(d.ldapProto, addr)
-	}
+func (d *ldapIdResolver) resolve(logger lager.Logger, ctx context.Context, conn *cancellableConnection, username string, password string) (uid string, gid string, err error) {
+	credentials := d.credentials()
+	lookup := d.userLookup(logger, username)
+
+	l, err := d.dial(ctx, conn, lookup.host, lookup.port, credentials)
 	if err != nil {
-		return "", "", dockerdriver.SafeError{SafeDescription: "LDAP server could not be reached, please contact your system administrator"}
+		return "", "", err
 	}
-
-	l.SetTimeout(d.ldapTimeout)
 	defer l.Close()
 
 	// First bind with a read only user
-	err = l.Bind(d.svcUser, d.svcPass)
+	err = l.Bind(credentials.SvcUser, credentials.SvcPass)
 	if err != nil {
 		return "", "", err
 	}
 
 	// Search for the given username
 	searchRequest := d.ldap.NewSearchRequest(
-		d.ldapFqdn,
+		lookup.baseDN,
 		ldap.ScopeWholeSubtree,
 		ldap.NeverDerefAliases,
 		0,
 		0,
 		false,
-		fmt.Sprintf("(&(objectClass=User)(cn=%s))", ldap.EscapeFilter(username)),
-		[]string{"dn", "uidNumber", "gidNumber"},
+		lookup.filter,
+		d.userAttributes(),
 		nil,
 	)
 
-	sr, err := l.Search(searchRequest)
+	entries, err := d.searchUser(logger, ctx, conn, l, searchRequest, credentials)
 	if err != nil {
 		return "", "", err
 	}
 
-	if len(sr.Entries) == 0 {
-		return "", "", dockerdriver.SafeError{SafeDescription: "User does not exist"}
+	if len(entries) == 0 {
+		return "", "", dockerdriver.SafeError{SafeDescription: UserNotFoundErrorMessage}
 	}
-	if len(sr.Entries) > 1 {
+	if len(entries) > 1 {
 		return "", "", dockerdriver.SafeError{SafeDescription: "Ambiguous search--too many results"}
 	}
 
-	userdn := sr.Entries[0].DN
+	entry := entries[0]
+	userdn := entry.DN
+
+	err = checkAccountState(logger, entry.Entry, time.Now())
+	if err != nil {
+		return "", "", err
+	}
 
-	uid = sr.Entries[0].GetAttributeValue("uidNumber")
-	gid = sr.Entries[0].GetAttributeValue("gidNumber")
-	if gid == "" {
-		gid = uid
+	// A user found through a referral can only bind on the server that holds it
+	userConn, baseDN := l, lookup.baseDN
+	if entry.referral != nil {
+		logger.Info("user-found-by-referral", lager.Data{"referral": entry.referral.url})
+		userConn, err = d.dialReferral(ctx, conn, entry.referral, credentials)
+		if err != nil {
+			logger.Error("failed-to-follow-referral", err, lager.Data{"referral": entry.referral.url})
+			return "", "", dockerdriver.SafeError{SafeDescription: "LDAP server could not be reached, please contact your system administrator"}
+		}
+		defer userConn.Close()
+		baseDN = entry.referral.baseDN
 	}
 
 	// Bind as the user to verify their password
-	err = l.Bind(userdn, password)
+	err = bindUser(logger, userConn, userdn, password)
+	if err != nil {
+		return "", "", err
+	}
+
+	if d.groupPolicy != nil {
+		// Group searches need the service user's read access
+		err = userConn.Bind(credentials.SvcUser, credentials.SvcPass)
+		if err != nil {
+			return "", "", err
+		}
+
+		err = d.checkGroupMembership(logger, userConn, entry.Entry, baseDN)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	return d.entryIds(logger, entry.Entry)
+}
+
+// CheckConnection binds as the service user on the default server, which every resolution starts with
+func (d *ldapIdResolver) CheckConnection(env dockerdriver.Env) error {
+	logger := env.Logger().Session("ldap-check-connection")
+	logger.Info("start")
+	defer logger.Info("end")
+
+	_, _, err := resolveWithContext(logger, env.Context(), func(conn *cancellableConnection) (string, string, error) {
+		credentials := d.credentials()
+		l, err := d.dial(env.Context(), conn, d.ldapHost, d.ldapPort, credentials)
+		if err != nil {
+			return "", "", err
+		}
+		defer l.Close()
+
+		return "", "", l.Bind(credentials.SvcUser, credentials.SvcPass)
+	})
+	return err
+}
+
+// dial connects to host, over TLS when a CA certificate is configured
+func (d *ldapIdResolver) dial(ctx context.Context, conn *cancellableConnection, host string, port int, credentials LdapCredentials) (ldapshim.LdapConnection, error) {
+	addr := fmt.Sprintf("%s:%d", host, port)
+
+	var l ldapshim.LdapConnection
+	var err error
+	if credentials.CACert != "" {
+		tlsConfig, tlsErr := ldapTLSConfig(host, credentials)
+		if tlsErr != nil {
+			return nil, tlsErr
+		}
+
+		// #nosec G402
+		l, err = d.ldap.DialTLS(d.ldapProto, addr, tlsConfig)
+	} else {
+		l, err = d.ldap.Dial(d.ldapProto, addr)
+	}
 	if err != nil {
-		return "", "", dockerdriver.SafeError{SafeDescription: err.Error()}
+		return nil, dockerdriver.SafeError{SafeDescription: "LDAP server could not be reached, please contact your system administrator"}
+	}
+
+	if !conn.add(l) {
+		return nil, dockerdriver.SafeError{SafeDescription: LdapTimeoutErrorMessage}
 	}
 
-	return uid, gid, nil
+	l.SetTimeout(connectionTimeout(ctx, d.ldapTimeout))
+	return l, nil
+}
+
+func (d *ldapIdResolver) credentials() LdapCredentials {
+	if d.credentialSource != nil {
+		return d.credentialSource.Credentials()
+	}
+	return LdapCredentials{SvcUser: d.svcUser, SvcPass: d.svcPass, CACert: d.ldapCACert}
+}
+
+func (d *ldapIdResolver) userAttributes() []string {
+	attributes := append([]string{"dn", "uidNumber", "gidNumber"}, ldapAccountStateAttributes...)
+	if d.idMapRange != nil {
+		attributes = append(attributes, ldapIdMappingAttributes...)
+	}
+	if d.groupPolicy != nil && d.groupPolicy.Lookup == GroupLookupRecursive {
+		attributes = append(attributes, "memberOf")
+	}
+	return attributes
 }
@@ -10,8 +10,10 @@ import (
 	"code.cloudfoundry.org/goshims/ldapshim/ldap_fake"
 	"code.cloudfoundry.org/lager/v3/lagertest"
 	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 	"gopkg.in/ldap.v2"
 )
 
@@ -26,13 +28,16 @@ var _ = Describe("IdResolverTest", func() {
 	var ldapCACert string
 	var ldapTimeout time.Duration
 	var user string
+	var logger *lagertest.TestLogger
+	var domains []nfsv3driver.LdapDomain
 
 	BeforeEach(func() {
-		logger := lagertest.NewTestLogger("nfs-mounter")
+		logger = lagertest.NewTestLogger("nfs-mounter")
 		testContext := context.TODO()
 		env = driverhttp.NewHttpDriverEnv(logger, testContext)
 
 		user = "user"
+		domains = nil
 	})
 
 	JustBeforeEach(func() {
@@ -46,6 +51,7 @@ var _ = Describe("IdResolverTest", func() {
 			ldapCACert,
 			ldapFake,
 			ldapTimeout,
+			nfsv3driver.WithLdapDomains(domains),
 		)
 		uid, gid, err = ldapIdResolver.Resolve(env, user, "pw")
 	})
@@ -62,25 +68,7 @@ var _ = Describe("IdResolverTest", func() {
 
 		Context("when CA cert is provided", func() {
 			BeforeEach(func() {
-				ldapCACert = `-----BEGIN CERTIFICATE-----
-MIIDGTCCAgGgAwIBAgIRAIlVvSGFPY1EvNayuTpPAScwDQYJKoZIhvcNAQELBQAw
-EjEQMA4GA1UEChMHQWNtZSBDbzAeFw0xODA1MzExNzU5MTBaFw0xOTA1MzExNzU5
-MTBaMBIxEDAOBgNVBAoTB0FjbWUgQ28wggEiMA0GCSqGSIb3DQEBAQUAA4IBDwAw
-ggEKAoIBAQCSf8J68FYrRuE8+NumcleeI10+O5QGibQ3+axX79eFS3RGcQKn5UOr
-OFE/RM/ghc7sUD8urLhlA2QAua+0dZEr+QtNswDxLfWljw08azR4xkPnBejdwYKU
-jHHU9UoJrxEgWqNFwTWWCyHYERUK/RFSrSUJaZLv1fRa9C+wbkD2Wd+aesPU6TZr
-5f6DT1UdL5umykwVoKy9ymA1CUi3iRSPuIxF0iuwwNtgtS0Dswi9+gqICOYp+lGJ
-RM2zRZFas8clubvkIRYlO2YG8hb181uxW9nLAfUfJjjtDt7lp5z/eZqliFwzrl0i
-DG8xWUppHV9654hGRDOL2ow3u8kwNv9/AgMBAAGjajBoMA4GA1UdDwEB/wQEAwIC
-pDATBgNVHSUEDDAKBggrBgEFBQcDATAPBgNVHRMBAf8EBTADAQH/MDAGA1UdEQQp
-MCeCJW5mc3Rlc3RsZGFwc2VydmVyLnNlcnZpY2UuY2YuaW50ZXJuYWwwDQYJKoZI
-hvcNAQELBQADggEBAB/4KT3+G5YqrnCCF+GmYlxZO9ScRA6yPBtwXTQe7WH8Yfz2
-bnUs4jKhK2wh3+RSTsBwV9afF+xm/uVrD9iZveixC1E3NqJwlchHc2bv9NCvC8OY
-VShIx+8Joqpud6VIrzclhus2lo9Dvn55at3Z/5SYDf07fDmSJ5pZuLUVryiJk9AT
-G0GELNbBftMakAJaH6eqGvcNbDRMeFqq7VyjthQJRPWSaWKA6TsfzgiO9lwx1wd1
-1ZtN1nl1NexFqcan26vg0f1SwLM9r9mVXrKII/T60RXKvtcAkMS3XfaebG3ulout
-z6sbK6WkL0AwPEcI/HzUOrsAUBtyY8cfy6yVcuQ=
------END CERTIFICATE-----`
+				ldapCACert = ldapTestCACert
 				ldapFake.DialTLSReturns(ldapConnectionFake, nil)
 			})
 
@@ -134,7 +122,7 @@ z6sbK6WkL0AwPEcI/HzUOrsAUBtyY8cfy6yVcuQ=
 				Expect(timeLimit).To(Equal(0))
 				Expect(typesOnly).To(BeFalse())
 				Expect(filter).To(Equal("(&(objectClass=User)(cn=user))"))
-				Expect(attributes).To(ConsistOf("dn", "uidNumber", "gidNumber"))
+				Expect(attributes).To(ConsistOf("dn", "uidNumber", "gidNumber", "userAccountControl", "msDS-User-Account-Control-Computed", "accountExpires", "pwdLastSet"))
 				Expect(controls).To(BeNil())
 			})
 
@@ -164,11 +152,140 @@ z6sbK6WkL0AwPEcI/HzUOrsAUBtyY8cfy6yVcuQ=
 				It("should find the user and then fail", func() {
 					Expect(err).To(HaveOccurred())
 					Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
-					Expect(err.Error()).To(ContainSubstring("badness"))
+					Expect(err).To(MatchError(nfsv3driver.InvalidCredentialsErrorMessage))
 					Expect(ldapConnectionFake.SearchCallCount()).To(Equal(1))
 					Expect(uid).To(BeEmpty())
 				})
+
+				It("logs the ldap diagnostic without returning it", func() {
+					Expect(err.Error()).NotTo(ContainSubstring("badness"))
+					Expect(logger).To(gbytes.Say("user-bind-failed.*badness"))
+				})
+			})
+
+			DescribeTable("when active directory rejects the user bind",
+				func(diagnostic string, expectedMessage string) {
+					ldapConnectionFake.BindStub = func(u, p string) error {
+						if u == "svcuser" {
+							return nil
+						}
+						return ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New(diagnostic))
+					}
+
+					_, _, err = ldapIdResolver.Resolve(env, user, "pw")
+					Expect(err).To(MatchError(expectedMessage))
+					Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+				},
+				Entry("invalid credentials", "80090308: LdapErr: DSID-0C09044E, comment: AcceptSecurityContext error, data 52e, v2580", nfsv3driver.InvalidCredentialsErrorMessage),
+				Entry("account disabled", "80090308: LdapErr: DSID-0C09044E, comment: AcceptSecurityContext error, data 533, v2580", nfsv3driver.AccountDisabledErrorMessage),
+				Entry("account expired", "80090308: LdapErr: DSID-0C09044E, comment: AcceptSecurityContext error, data 701, v2580", nfsv3driver.AccountExpiredErrorMessage),
+				Entry("password expired", "80090308: LdapErr: DSID-0C09044E, comment: AcceptSecurityContext error, data 532, v2580", nfsv3driver.PasswordExpiredErrorMessage),
+				Entry("password must be reset", "80090308: LdapErr: DSID-0C09044E, comment: AcceptSecurityContext error, data 773, v2580", nfsv3driver.PasswordMustChangeErrorMessage),
+				Entry("account locked", "80090308: LdapErr: DSID-0C09044E, comment: AcceptSecurityContext error, data 775, v2580", nfsv3driver.AccountLockedErrorMessage),
+			)
+
+			Context("when the connection supports bind controls", func() {
+				var extendedConnectionFake *nfsdriverfakes.FakeExtendedLdapConnection
+
+				BeforeEach(func() {
+					extendedConnectionFake = &nfsdriverfakes.FakeExtendedLdapConnection{}
+					extendedConnectionFake.SearchReturns(&ldap.SearchResult{
+						Entries: []*ldap.Entry{{
+							DN: "foo",
+							Attributes: []*ldap.EntryAttribute{
+								{Name: "uidNumber", Values: []string{"100"}},
+								{Name: "gidNumber", Values: []string{"100"}},
+							},
+						}},
+					}, nil)
+					extendedConnectionFake.SimpleBindReturns(&ldap.SimpleBindResult{}, nil)
+					ldapFake.DialReturns(extendedConnectionFake, nil)
+				})
+
+				It("requests the password policy control when binding as the user", func() {
+					Expect(err).NotTo(HaveOccurred())
+					Expect(extendedConnectionFake.BindCallCount()).To(Equal(1))
+					Expect(extendedConnectionFake.SimpleBindCallCount()).To(Equal(1))
+					request := extendedConnectionFake.SimpleBindArgsForCall(0)
+					Expect(request.Username).To(Equal("foo"))
+					Expect(request.Password).To(Equal("pw"))
+					Expect(ldap.FindControl(request.Controls, ldap.ControlTypeBeheraPasswordPolicy)).NotTo(BeNil())
+				})
+
+				DescribeTable("when the password policy control reports an error",
+					func(policyError int, bindFails bool, expectedMessage string) {
+						policy := ldap.NewControlBeheraPasswordPolicy()
+						policy.Error = int8(policyError)
+						var bindErr error
+						if bindFails {
+							bindErr = ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+						}
+						extendedConnectionFake.SimpleBindReturns(&ldap.SimpleBindResult{Controls: []ldap.Control{policy}}, bindErr)
+
+						_, _, err = ldapIdResolver.Resolve(env, user, "pw")
+						Expect(err).To(MatchError(expectedMessage))
+						Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+					},
+					Entry("password expired", 0, true, nfsv3driver.PasswordExpiredErrorMessage),
+					Entry("account locked", 1, true, nfsv3driver.AccountLockedErrorMessage),
+					Entry("change after reset", 2, false, nfsv3driver.PasswordMustChangeErrorMessage),
+				)
+
+				Context("when the password is about to expire", func() {
+					BeforeEach(func() {
+						policy := ldap.NewControlBeheraPasswordPolicy()
+						policy.Expire = 3600
+						extendedConnectionFake.SimpleBindReturns(&ldap.SimpleBindResult{Controls: []ldap.Control{policy}}, nil)
+					})
+
+					It("resolves the user and logs a warning", func() {
+						Expect(err).NotTo(HaveOccurred())
+						Expect(uid).To(Equal("100"))
+						Expect(logger).To(gbytes.Say("password-expiring"))
+					})
+				})
 			})
+
+			DescribeTable("when the directory entry shows the account cannot be used",
+				func(attributes []*ldap.EntryAttribute, expectedMessage string) {
+					ldapConnectionFake.SearchReturns(&ldap.SearchResult{
+						Entries: []*ldap.Entry{{
+							DN:         "foo",
+							Attributes: append([]*ldap.EntryAttribute{{Name: "uidNumber", Values: []string{"100"}}}, attributes...),
+						}},
+					}, nil)
+
+					_, _, err = ldapIdResolver.Resolve(env, user, "pw")
+					Expect(err).To(MatchError(expectedMessage))
+					Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+					lastBindUser, _ := ldapConnectionFake.BindArgsForCall(ldapConnectionFake.BindCallCount() - 1)
+					Expect(lastBindUser).To(Equal("svcuser"))
+				},
+				Entry("disabled", []*ldap.EntryAttribute{{Name: "userAccountControl", Values: []string{"514"}}}, nfsv3driver.AccountDisabledErrorMessage),
+				Entry("locked", []*ldap.EntryAttribute{{Name: "msDS-User-Account-Control-Computed", Values: []string{"16"}}}, nfsv3driver.AccountLockedErrorMessage),
+				Entry("expired", []*ldap.EntryAttribute{{Name: "accountExpires", Values: []string{"131000000000000000"}}}, nfsv3driver.AccountExpiredErrorMessage),
+				Entry("password expired", []*ldap.EntryAttribute{{Name: "msDS-User-Account-Control-Computed", Values: []string{"8388608"}}}, nfsv3driver.PasswordExpiredErrorMessage),
+				Entry("password must change", []*ldap.EntryAttribute{{Name: "pwdLastSet", Values: []string{"0"}}}, nfsv3driver.PasswordMustChangeErrorMessage),
+			)
+
+			DescribeTable("when the directory entry shows a usable account",
+				func(attributes []*ldap.EntryAttribute) {
+					ldapConnectionFake.SearchReturns(&ldap.SearchResult{
+						Entries: []*ldap.Entry{{
+							DN:         "foo",
+							Attributes: append([]*ldap.EntryAttribute{{Name: "uidNumber", Values: []string{"100"}}}, attributes...),
+						}},
+					}, nil)
+
+					uid, _, err = ldapIdResolver.Resolve(env, user, "pw")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(uid).To(Equal("100"))
+				},
+				Entry("normal account", []*ldap.EntryAttribute{{Name: "userAccountControl", Values: []string{"512"}}, {Name: "pwdLastSet", Values: []string{"133000000000000000"}}}),
+				Entry("account never expires", []*ldap.EntryAttribute{{Name: "accountExpires", Values: []string{"9223372036854775807"}}}),
+				Entry("account expires in the future", []*ldap.EntryAttribute{{Name: "accountExpires", Values: []string{"300000000000000000"}}}),
+				Entry("password expired flag with password never expires", []*ldap.EntryAttribute{{Name: "userAccountControl", Values: []string{"8454656"}}}),
+			)
 		})
 
 		Context("when the search uses an invalid username", func() {
@@ -182,6 +299,62 @@ z6sbK6WkL0AwPEcI/HzUOrsAUBtyY8cfy6yVcuQ=
 			})
 		})
 
+		Context("when LDAP domains are mapped", func() {
+			BeforeEach(func() {
+				domains = []nfsv3driver.LdapDomain{
+					{NetbiosName: "CORP", UPNSuffix: "corp.example.com", BaseDN: "ou=People,dc=corp,dc=example,dc=com"},
+					{NetbiosName: "PARTNER", UPNSuffix: "partner.example.com", BaseDN: "dc=partner,dc=example,dc=com", Host: "dc1.partner.example.com", Port: 636},
+				}
+			})
+
+			DescribeTable("searches for the user in the mapped domain",
+				func(username string, expectedAddr string, expectedBaseDN string, expectedFilter string) {
+					_, _, err := nfsv3driver.NewLdapIdResolver(
+						"svcuser", "svcpw", "host", 111, "tcp", "cn=Users,dc=test,dc=com", "", ldapFake, ldapTimeout,
+						nfsv3driver.WithLdapDomains(domains),
+					).Resolve(env, username, "pw")
+					Expect(err).To(MatchError(nfsv3driver.UserNotFoundErrorMessage))
+
+					_, addr := ldapFake.DialArgsForCall(1)
+					Expect(addr).To(Equal(expectedAddr))
+					baseDN, _, _, _, _, _, filter, _, _ := ldapFake.NewSearchRequestArgsForCall(1)
+					Expect(baseDN).To(Equal(expectedBaseDN))
+					Expect(filter).To(Equal(expectedFilter))
+				},
+				Entry("down-level name", `CORP\alice`, "host:111", "ou=People,dc=corp,dc=example,dc=com", "(&(objectClass=User)(sAMAccountName=alice))"),
+				Entry("down-level name in another case", `corp\alice`, "host:111", "ou=People,dc=corp,dc=example,dc=com", "(&(objectClass=User)(sAMAccountName=alice))"),
+				Entry("UPN", "alice@corp.example.com", "host:111", "ou=People,dc=corp,dc=example,dc=com", "(&(objectClass=User)(userPrincipalName=alice@corp.example.com))"),
+				Entry("domain with its own server", `PARTNER\bob`, "dc1.partner.example.com:636", "dc=partner,dc=example,dc=com", "(&(objectClass=User)(sAMAccountName=bob))"),
+				Entry("escaped down-level name", `CORP\a*`, "host:111", "ou=People,dc=corp,dc=example,dc=com", "(&(objectClass=User)(sAMAccountName=a\\2a))"),
+				Entry("plain username", "alice", "host:111", "cn=Users,dc=test,dc=com", "(&(objectClass=User)(cn=alice))"),
+				Entry("unmapped domain", `OTHER\alice`, "host:111", "cn=Users,dc=test,dc=com", "(&(objectClass=User)(sAMAccountName=alice))"),
+			)
+
+			Context("when the domain is not mapped", func() {
+				BeforeEach(func() {
+					user = "alice@unknown.example.com"
+				})
+
+				It("logs that the default base DN is used", func() {
+					Expect(logger).To(gbytes.Say(`domain-not-mapped.*"base-dn":"cn=Users,dc=test,dc=com"`))
+				})
+			})
+
+			Context("when the domain has its own server and a CA cert is provided", func() {
+				BeforeEach(func() {
+					user = `PARTNER\bob`
+					ldapCACert = ldapTestCACert
+					ldapFake.DialTLSReturns(ldapConnectionFake, nil)
+				})
+
+				It("verifies the domain's server name", func() {
+					_, addr, config := ldapFake.DialTLSArgsForCall(0)
+					Expect(addr).To(Equal("dc1.partner.example.com:636"))
+					Expect(config.ServerName).To(Equal("dc1.partner.example.com"))
+				})
+			})
+		})
+
 		Context("when search does not return GID", func() {
 			BeforeEach(func() {
 				entry := &ldap.Entry{
@@ -291,3 +464,75 @@ z6sbK6WkL0AwPEcI/HzUOrsAUBtyY8cfy6yVcuQ=
 		})
 	})
 })
+
+var _ = Describe("IdResolver CheckConnection", func() {
+	var (
+		ldapFake           *ldap_fake.FakeLdap
+		ldapConnectionFake *ldap_fake.FakeLdapConnection
+		env                dockerdriver.Env
+		err                error
+	)
+
+	BeforeEach(func() {
+		env = driverhttp.NewHttpDriverEnv(lagertest.NewTestLogger("ldap-check"), context.TODO())
+		ldapFake = &ldap_fake.FakeLdap{}
+		ldapConnectionFake = &ldap_fake.FakeLdapConnection{}
+		ldapFake.DialReturns(ldapConnectionFake, nil)
+	})
+
+	JustBeforeEach(func() {
+		resolver := nfsv3driver.NewLdapIdResolver("svcuser", "svcpw", "host", 111, "tcp", "cn=Users,dc=test,dc=com", "", ldapFake, time.Minute)
+		err = resolver.(nfsv3driver.ConnectionChecker).CheckConnection(env)
+	})
+
+	It("binds as the service user on the default server", func() {
+		Expect(err).NotTo(HaveOccurred())
+		_, addr := ldapFake.DialArgsForCall(0)
+		Expect(addr).To(Equal("host:111"))
+		user, password := ldapConnectionFake.BindArgsForCall(0)
+		Expect(user).To(Equal("svcuser"))
+		Expect(password).To(Equal("svcpw"))
+		Expect(ldapConnectionFake.SearchCallCount()).To(BeZero())
+		Expect(ldapConnectionFake.CloseCallCount()).To(Equal(1))
+	})
+
+	Context("when the server cannot be reached", func() {
+		BeforeEach(func() {
+			ldapFake.DialReturns(nil, errors.New("connection refused"))
+		})
+
+		It("fails", func() {
+			Expect(err).To(MatchError("LDAP server could not be reached, please contact your system administrator"))
+		})
+	})
+
+	Context("when the service user cannot bind", func() {
+		BeforeEach(func() {
+			ldapConnectionFake.BindReturns(errors.New("LDAP Result Code 49: Invalid Credentials"))
+		})
+
+		It("fails", func() {
+			Expect(err).To(MatchError("LDAP Result Code 49: Invalid Credentials"))
+		})
+	})
+})
+
+const ldapTestCACert = `-----BEGIN CERTIFICATE-----
+MIIDGTCCAgGgAwIBAgIRAIlVvSGFPY1EvNayuTpPAScwDQYJKoZIhvcNAQELBQAw
+EjEQMA4GA1UEChMHQWNtZSBDbzAeFw0xODA1MzExNzU5MTBaFw0xOTA1MzExNzU5
+MTBaMBIxEDAOBgNVBAoTB0FjbWUgQ28wggEiMA0GCSqGSIb3DQEBAQUAA4IBDwAw
+ggEKAoIBAQCSf8J68FYrRuE8+NumcleeI10+O5QGibQ3+axX79eFS3RGcQKn5UOr
+OFE/RM/ghc7sUD8urLhlA2QAua+0dZEr+QtNswDxLfWljw08azR4xkPnBejdwYKU
+jHHU9UoJrxEgWqNFwTWWCyHYERUK/RFSrSUJaZLv1fRa9C+wbkD2Wd+aesPU6TZr
+5f6DT1UdL5umykwVoKy9ymA1CUi3iRSPuIxF0iuwwNtgtS0Dswi9+gqICOYp+lGJ
+RM2zRZFas8clubvkIRYlO2YG8hb181uxW9nLAfUfJjjtDt7lp5z/eZqliFwzrl0i
+DG8xWUppHV9654hGRDOL2ow3u8kwNv9/AgMBAAGjajBoMA4GA1UdDwEB/wQEAwIC
+pDATBgNVHSUEDDAKBggrBgEFBQcDATAPBgNVHRMBAf8EBTADAQH/MDAGA1UdEQQp
+MCeCJW5mc3Rlc3RsZGFwc2VydmVyLnNlcnZpY2UuY2YuaW50ZXJuYWwwDQYJKoZI
+hvcNAQELBQADggEBAB/4KT3+G5YqrnCCF+GmYlxZO9ScRA6yPBtwXTQe7WH8Yfz2
+bnUs4jKhK2wh3+RSTsBwV9afF+xm/uVrD9iZveixC1E3NqJwlchHc2bv9NCvC8OY
+VShIx+8Joqpud6VIrzclhus2lo9Dvn55at3Z/5SYDf07fDmSJ5pZuLUVryiJk9AT
+G0GELNbBftMakAJaH6eqGvcNbDRMeFqq7VyjthQJRPWSaWKA6TsfzgiO9lwx1wd1
+1ZtN1nl1NexFqcan26vg0f1SwLM9r9mVXrKII/T60RXKvtcAkMS3XfaebG3ulout
+z6sbK6WkL0AwPEcI/HzUOrsAUBtyY8cfy6yVcuQ=
+-----END CERTIFICATE-----`
@@ -0,0 +1,188 @@
+package nfsv3driver
+
+import (
+	"crypto/tls"
+	"regexp"
+	"strconv"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/goshims/ldapshim"
+	"code.cloudfoundry.org/lager/v3"
+	"gopkg.in/ldap.v2"
+)
+
+const InvalidCredentialsErrorMessage = "Invalid username or password"
+const AccountDisabledErrorMessage = "LDAP user account is disabled, please contact your system administrator"
+const AccountLockedErrorMessage = "LDAP user account is locked, please contact your system administrator"
+const AccountExpiredErrorMessage = "LDAP user account has expired, please contact your system administrator"
+const PasswordExpiredErrorMessage = "LDAP user password has expired, please change it and update the service binding"
+const PasswordMustChangeErrorMessage = "LDAP user must change their password before it can be used, please change it and update the service binding"
+
+// Active Directory userAccountControl flags, see
+// https://learn.microsoft.com/en-us/troubleshoot/windows-server/active-directory/useraccountcontrol-manipulate-account-properties
+const (
+	adAccountDisable     = 0x0002
+	adLockout            = 0x0010
+	adDontExpirePassword = 0x10000
+	adPasswordExpired    = 0x800000
+)
+
+// AD encodes "never expires" in accountExpires as either 0 or the largest int64
+const adNeverExpires = int64(0x7FFFFFFFFFFFFFFF)
+
+// 100ns intervals between the FILETIME epoch (1601-01-01) and the unix epoch
+const adFileTimeUnixOffset = int64(116444736000000000)
+
+// draft-behera-ldap-password-policy error values
+const (
+	ppolicyPasswordExpired  = 0
+	ppolicyAccountLocked    = 1
+	ppolicyChangeAfterReset = 2
+)
+
+var ldapAccountStateAttributes = []string{"userAccountControl", "msDS-User-Account-Control-Computed", "accountExpires", "pwdLastSet"}
+
+// AD reports the reason for a failed bind as a hex "data" code inside the diagnostic message, e.g.
+// "80090308: LdapErr: DSID-0C09044E, comment: AcceptSecurityContext error, data 775, v2580"
+var adBindDiagnosticPattern = regexp.MustCompile(`data ([0-9a-fA-F]+),`)
+
+var adBindDiagnosticErrors = map[string]string{
+	"52e": InvalidCredentialsErrorMessage,
+	"525": InvalidCredentialsErrorMessage,
+	"530": AccountDisabledErrorMessage,
+	"531": AccountDisabledErrorMessage,
+	"532": PasswordExpiredErrorMessage,
+	"533": AccountDisabledErrorMessage,
+	"701": AccountExpiredErrorMessage,
+	"773": PasswordMustChangeErrorMessage,
+	"775": AccountLockedErrorMessage,
+}
+
+//counterfeiter:generate -o nfsdriverfakes/fake_extended_ldap_connection.go . ExtendedLdapConnection
+
+// ExtendedLdapConnection exposes the parts of *ldap.Conn that are not part of ldapshim.LdapConnection.
+// Connections that do not implement it are bound without request controls and searched without paging.
+type ExtendedLdapConnection interface {
+	ldapshim.LdapConnection
+	SimpleBind(*ldap.SimpleBindRequest) (*ldap.SimpleBindResult, error)
+	SearchWithPaging(*ldap.SearchRequest, uint32) (*ldap.SearchResult, error)
+	StartTLS(*tls.Config) error
+}
+
+// checkAccountState rejects accounts whose directory attributes show that a bind cannot be allowed, before the
+// password is tried against the server. Attributes that are absent (e.g. on non-AD servers) are ignored.
+func checkAccountState(logger lager.Logger, entry *ldap.Entry, now time.Time) error {
+	uac, _ := strconv.ParseInt(entry.GetAttributeValue("userAccountControl"), 10, 64)
+	computed, _ := strconv.ParseInt(entry.GetAttributeValue("msDS-User-Account-Control-Computed"), 10, 64)
+	flags := uac | computed
+
+	if flags&adAccountDisable != 0 {
+		logger.Info("account-disabled", lager.Data{"dn": entry.DN, "userAccountControl": flags})
+		return dockerdriver.SafeError{SafeDescription: AccountDisabledErrorMessage}
+	}
+
+	if flags&adLockout != 0 {
+		logger.Info("account-locked", lager.Data{"dn": entry.DN, "userAccountControl": flags})
+		return dockerdriver.SafeError{SafeDescription: AccountLockedErrorMessage}
+	}
+
+	if expires, err := strconv.ParseInt(entry.GetAttributeValue("accountExpires"), 10, 64); err == nil && expires != 0 && expires != adNeverExpires {
+		expiry := fileTimeToTime(expires)
+		if !now.Before(expiry) {
+			logger.Info("account-expired", lager.Data{"dn": entry.DN, "expired-at": expiry})
+			return dockerdriver.SafeError{SafeDescription: AccountExpiredErrorMessage}
+		}
+	}
+
+	if flags&adPasswordExpired != 0 && flags&adDontExpirePassword == 0 {
+		logger.Info("password-expired", lager.Data{"dn": entry.DN, "userAccountControl": flags})
+		return dockerdriver.SafeError{SafeDescription: PasswordExpiredErrorMessage}
+	}
+
+	if entry.GetAttributeValue("pwdLastSet") == "0" {
+		logger.Info("password-must-change", lager.Data{"dn": entry.DN})
+		return dockerdriver.SafeError{SafeDescription: PasswordMustChangeErrorMessage}
+	}
+
+	return nil
+}
+
+// bindUser verifies the user's password. The raw server diagnostic is logged, and only a SafeError describing the
+// account state is returned to the caller.
+func bindUser(logger lager.Logger, l ldapshim.LdapConnection, userdn string, password string) error {
+	extended, ok := l.(ExtendedLdapConnection)
+	if !ok {
+		err := l.Bind(userdn, password)
+		if err != nil {
+			logger.Error("user-bind-failed", err, lager.Data{"dn": userdn})
+			return bindError(err, nil)
+		}
+		return nil
+	}
+
+	result, err := extended.SimpleBind(ldap.NewSimpleBindRequest(userdn, password, []ldap.Control{ldap.NewControlBeheraPasswordPolicy()}))
+
+	var policy *ldap.ControlBeheraPasswordPolicy
+	if result != nil {
+		policy, _ = ldap.FindControl(result.Controls, ldap.ControlTypeBeheraPasswordPolicy).(*ldap.ControlBeheraPasswordPolicy)
+	}
+
+	if err != nil {
+		logger.Error("user-bind-failed", err, lager.Data{"dn": userdn, "ppolicy": policyDescription(policy)})
+		return bindError(err, policy)
+	}
+
+	if policy != nil {
+		if policy.Error == ppolicyChangeAfterReset {
+			logger.Info("password-must-change", lager.Data{"dn": userdn, "ppolicy": policy.ErrorString})
+			return dockerdriver.SafeError{SafeDescription: PasswordMustChangeErrorMessage}
+		}
+		if policy.Expire >= 0 || policy.Grace >= 0 {
+			logger.Info("password-expiring", lager.Data{"dn": userdn, "seconds-before-expiration": policy.Expire, "grace-binds-remaining": policy.Grace})
+		}
+	}
+
+	return nil
+}
+
+func bindError(err error, policy *ldap.ControlBeheraPasswordPolicy) error {
+	if policy != nil {
+		switch policy.Error {
+		case ppolicyPasswordExpired:
+			return dockerdriver.SafeError{SafeDescription: PasswordExpiredErrorMessage}
+		case ppolicyAccountLocked:
+			return dockerdriver.SafeError{SafeDescription: AccountLockedErrorMessage}
+		case ppolicyChangeAfterReset:
+			return dockerdriver.SafeError{SafeDescription: PasswordMustChangeErrorMessage}
+		}
+	}
+
+	if match := adBindDiagnosticPattern.FindStringSubmatch(err.Error()); match != nil {
+		if message, ok := adBindDiagnosticErrors[lowerHex(match[1])]; ok {
+			return dockerdriver.SafeError{SafeDescription: message}
+		}
+	}
+
+	return dockerdriver.SafeError{SafeDescription: InvalidCredentialsErrorMessage}
+}
+
+func policyDescription(policy *ldap.ControlBeheraPasswordPolicy) string {
+	if policy == nil {
+		return ""
+	}
+	return policy.String()
+}
+
+func fileTimeToTime(fileTime int64) time.Time {
+	intervals := fileTime - adFileTimeUnixOffset
+	return time.Unix(intervals/10000000, (intervals%10000000)*100)
+}
+
+func lowerHex(s string) string {
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return s
+	}
+	return strconv.FormatUint(v, 16)
+}
@@ -0,0 +1,84 @@
+package nfsv3driver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/goshims/ldapshim"
+	"code.cloudfoundry.org/lager/v3"
+)
+
+const LdapTimeoutErrorMessage = "LDAP request timed out or was cancelled, please try again"
+
+// cancellableConnection lets a cancelled request close the connections a resolving goroutine is blocked on, or
+// will receive once its dial returns
+type cancellableConnection struct {
+	lock      sync.Mutex
+	conns     []ldapshim.LdapConnection
+	cancelled bool
+}
+
+// add returns false, closing l, if the request was cancelled while dialing
+func (c *cancellableConnection) add(l ldapshim.LdapConnection) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.cancelled {
+		l.Close()
+		return false
+	}
+	c.conns = append(c.conns, l)
+	return true
+}
+
+func (c *cancellableConnection) cancel() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.cancelled = true
+	for _, conn := range c.conns {
+		conn.Close()
+	}
+}
+
+// resolveWithContext runs resolve until it finishes or ctx is done. ldap.v2 has no context support, so on
+// cancellation the connection is closed to unblock resolve, which finishes in the background.
+func resolveWithContext(logger lager.Logger, ctx context.Context, resolve func(*cancellableConnection) (string, string, error)) (string, string, error) {
+	if err := ctx.Err(); err != nil {
+		logger.Info("cancelled", lager.Data{"reason": err.Error()})
+		return "", "", dockerdriver.SafeError{SafeDescription: LdapTimeoutErrorMessage}
+	}
+
+	type result struct {
+		uid, gid string
+		err      error
+	}
+
+	conn := &cancellableConnection{}
+	done := make(chan result, 1)
+	go func() {
+		uid, gid, err := resolve(conn)
+		done <- result{uid, gid, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.uid, r.gid, r.err
+	case <-ctx.Done():
+		logger.Info("cancelled", lager.Data{"reason": ctx.Err().Error()})
+		conn.cancel()
+		return "", "", dockerdriver.SafeError{SafeDescription: LdapTimeoutErrorMessage}
+	}
+}
+
+// connectionTimeout shortens timeout to the time left before the request's deadline
+func connectionTimeout(ctx context.Context, timeout time.Duration) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); remaining < timeout {
+			return remaining
+		}
+	}
+	return timeout
+}
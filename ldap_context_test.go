@@ -0,0 +1,155 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/ldapshim"
+	"code.cloudfoundry.org/goshims/ldapshim/ldap_fake"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"gopkg.in/ldap.v2"
+)
+
+var _ = Describe("LDAP resolution with a request context", func() {
+	var (
+		logger             *lagertest.TestLogger
+		ctx                context.Context
+		cancel             context.CancelFunc
+		ldapFake           *ldap_fake.FakeLdap
+		ldapConnectionFake *ldap_fake.FakeLdapConnection
+		closed             chan struct{}
+		resolver           nfsv3driver.IdResolver
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("ldap-context")
+		ctx, cancel = context.WithCancel(context.Background())
+
+		ldapFake = &ldap_fake.FakeLdap{}
+		ldapConnectionFake = &ldap_fake.FakeLdapConnection{}
+		ldapFake.DialReturns(ldapConnectionFake, nil)
+		ldapConnectionFake.SearchReturns(&ldap.SearchResult{Entries: []*ldap.Entry{{DN: "foo", Attributes: []*ldap.EntryAttribute{
+			{Name: "uidNumber", Values: []string{"100"}},
+		}}}}, nil)
+
+		// like ldap.Conn, blocked operations fail once the connection is closed. Stubs capture their own
+		// channels because abandoned resolutions may still be running when the next spec starts.
+		connClosed := make(chan struct{})
+		closed = connClosed
+		ldapConnectionFake.CloseStub = func() {
+			select {
+			case <-connClosed:
+			default:
+				close(connClosed)
+			}
+		}
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	JustBeforeEach(func() {
+		resolver = nfsv3driver.NewLdapIdResolver("svcuser", "svcpw", "host", 111, "tcp", "cn=Users,dc=test,dc=com", "", ldapFake, 120*time.Second)
+	})
+
+	resolve := func() (string, string, error) {
+		return resolver.Resolve(driverhttp.NewHttpDriverEnv(logger, ctx), "user", "pw")
+	}
+
+	It("resolves normally while the context is live", func() {
+		uid, _, err := resolve()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(uid).To(Equal("100"))
+	})
+
+	Context("when the context is already cancelled", func() {
+		BeforeEach(func() {
+			cancel()
+		})
+
+		It("does not contact the server", func() {
+			_, _, err := resolve()
+			Expect(err).To(MatchError(nfsv3driver.LdapTimeoutErrorMessage))
+			Expect(ldapFake.DialCallCount()).To(BeZero())
+		})
+	})
+
+	Context("when the request is cancelled during a search", func() {
+		BeforeEach(func() {
+			cancel, connClosed := cancel, closed
+			ldapConnectionFake.SearchStub = func(*ldap.SearchRequest) (*ldap.SearchResult, error) {
+				cancel()
+				<-connClosed
+				return nil, errors.New("connection closed")
+			}
+		})
+
+		It("returns a timeout error and closes the connection", func() {
+			_, _, err := resolve()
+			Expect(err).To(MatchError(nfsv3driver.LdapTimeoutErrorMessage))
+			Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+			Eventually(closed).Should(BeClosed())
+			Expect(logger).To(gbytes.Say(`ldap-resolve.cancelled.*"reason":"context canceled"`))
+		})
+	})
+
+	Context("when the request is cancelled while dialing", func() {
+		var dialed chan struct{}
+
+		BeforeEach(func() {
+			dialed = make(chan struct{})
+			cancel, dialed, conn := cancel, dialed, ldapConnectionFake
+			ldapFake.DialStub = func(string, string) (ldapshim.LdapConnection, error) {
+				cancel()
+				<-dialed
+				return conn, nil
+			}
+		})
+
+		It("closes the connection once the dial completes", func() {
+			_, _, err := resolve()
+			Expect(err).To(MatchError(nfsv3driver.LdapTimeoutErrorMessage))
+
+			close(dialed)
+			Eventually(closed).Should(BeClosed())
+			Consistently(ldapConnectionFake.BindCallCount).Should(BeZero())
+		})
+	})
+
+	Context("when the context has a deadline", func() {
+		BeforeEach(func() {
+			ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+		})
+
+		It("limits the connection timeout to the time remaining", func() {
+			_, _, err := resolve()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ldapConnectionFake.SetTimeoutArgsForCall(0)).To(BeNumerically("<=", 5*time.Second))
+		})
+
+		Context("and the deadline passes during a search", func() {
+			BeforeEach(func() {
+				ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+				connClosed := closed
+				ldapConnectionFake.SearchStub = func(*ldap.SearchRequest) (*ldap.SearchResult, error) {
+					<-connClosed
+					return nil, errors.New("connection closed")
+				}
+			})
+
+			It("returns a timeout error", func() {
+				_, _, err := resolve()
+				Expect(err).To(MatchError(nfsv3driver.LdapTimeoutErrorMessage))
+				Expect(logger).To(gbytes.Say(`"reason":"context deadline exceeded"`))
+			})
+		})
+	})
+})
@@ -0,0 +1,129 @@
+package nfsv3driver
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"sync"
+
+	"code.cloudfoundry.org/goshims/ioutilshim"
+	"code.cloudfoundry.org/lager/v3"
+)
+
+// LdapCredentials are the service account and CA bundle the resolver connects with
+type LdapCredentials struct {
+	SvcUser string
+	SvcPass string
+	CACert  string
+}
+
+type LdapCredentialSource interface {
+	Credentials() LdapCredentials
+}
+
+// WithCredentialSource replaces the credentials given to NewLdapIdResolver. Each resolution reads the source
+// once, so a rotation never mixes old and new credentials within one request.
+func WithCredentialSource(source LdapCredentialSource) LdapIdResolverOption {
+	return func(d *ldapIdResolver) {
+		d.credentialSource = source
+	}
+}
+
+// LdapCredentialFiles are the files holding each credential. An empty path keeps the corresponding default.
+type LdapCredentialFiles struct {
+	SvcUserFile string
+	SvcPassFile string
+	CACertFile  string
+}
+
+func (f LdapCredentialFiles) Paths() []string {
+	var paths []string
+	for _, path := range []string{f.SvcUserFile, f.SvcPassFile, f.CACertFile} {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+type ReloadableLdapCredentialSource interface {
+	LdapCredentialSource
+	Reload(logger lager.Logger) error
+}
+
+type ldapCredentialFileSource struct {
+	ioutil   ioutilshim.Ioutil
+	files    LdapCredentialFiles
+	defaults LdapCredentials
+
+	lock        sync.RWMutex
+	credentials LdapCredentials
+}
+
+func NewLdapCredentialFileSource(logger lager.Logger, ioutil ioutilshim.Ioutil, files LdapCredentialFiles, defaults LdapCredentials) (ReloadableLdapCredentialSource, error) {
+	s := &ldapCredentialFileSource{
+		ioutil:   ioutil,
+		files:    files,
+		defaults: defaults,
+	}
+
+	if err := s.Reload(logger); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *ldapCredentialFileSource) Credentials() LdapCredentials {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.credentials
+}
+
+func (s *ldapCredentialFileSource) Reload(logger lager.Logger) error {
+	logger = logger.Session("ldap-credentials-reload", lager.Data{"files": s.files.Paths()})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	credentials := s.defaults
+	for _, file := range []struct {
+		path  string
+		value *string
+		trim  bool
+	}{
+		{s.files.SvcUserFile, &credentials.SvcUser, true},
+		{s.files.SvcPassFile, &credentials.SvcPass, true},
+		{s.files.CACertFile, &credentials.CACert, false},
+	} {
+		if file.path == "" {
+			continue
+		}
+
+		contents, err := s.ioutil.ReadFile(file.path)
+		if err != nil {
+			logger.Error("failed-to-read", err, lager.Data{"path": file.path})
+			return err
+		}
+
+		*file.value = string(contents)
+		if file.trim {
+			*file.value = strings.TrimRight(*file.value, "\r\n")
+		}
+	}
+
+	if credentials.SvcUser == "" || credentials.SvcPass == "" {
+		return fmt.Errorf("LDAP service user and password must not be empty")
+	}
+	if credentials.CACert != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(credentials.CACert)) {
+		return fmt.Errorf("LDAP CA certificate file does not contain a PEM certificate")
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.credentials = credentials
+	logger.Info("loaded")
+
+	return nil
+}
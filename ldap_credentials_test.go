@@ -0,0 +1,132 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/ioutilshim/ioutil_fake"
+	"code.cloudfoundry.org/goshims/ldapshim/ldap_fake"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/ldap.v2"
+)
+
+var _ = Describe("LdapCredentialFileSource", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeIoutil *ioutil_fake.FakeIoutil
+		files      map[string]string
+		source     nfsv3driver.ReloadableLdapCredentialSource
+		err        error
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("ldap-credentials")
+		fakeIoutil = &ioutil_fake.FakeIoutil{}
+		files = map[string]string{
+			"/secrets/svc-pass": "svcpw\n",
+			"/secrets/ca.pem":   ldapTestCACert,
+		}
+		fakeIoutil.ReadFileStub = func(path string) ([]byte, error) {
+			contents, ok := files[path]
+			if !ok {
+				return nil, os.ErrNotExist
+			}
+			return []byte(contents), nil
+		}
+	})
+
+	JustBeforeEach(func() {
+		source, err = nfsv3driver.NewLdapCredentialFileSource(
+			logger,
+			fakeIoutil,
+			nfsv3driver.LdapCredentialFiles{SvcPassFile: "/secrets/svc-pass", CACertFile: "/secrets/ca.pem"},
+			nfsv3driver.LdapCredentials{SvcUser: "svcuser", SvcPass: "from-env"},
+		)
+	})
+
+	It("reads the configured files and keeps the defaults for the others", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(source.Credentials()).To(Equal(nfsv3driver.LdapCredentials{SvcUser: "svcuser", SvcPass: "svcpw", CACert: ldapTestCACert}))
+	})
+
+	It("picks up rotated credentials on reload", func() {
+		files["/secrets/svc-pass"] = "rotated\r\n"
+		Expect(source.Reload(logger)).To(Succeed())
+		Expect(source.Credentials().SvcPass).To(Equal("rotated"))
+	})
+
+	DescribeTable("keeps the previous credentials when the new files are unusable",
+		func(path string, contents string, expectedError string) {
+			files[path] = contents
+			Expect(source.Reload(logger)).To(MatchError(ContainSubstring(expectedError)))
+			Expect(source.Credentials().SvcPass).To(Equal("svcpw"))
+			Expect(source.Credentials().CACert).To(Equal(ldapTestCACert))
+		},
+		Entry("empty password", "/secrets/svc-pass", "\n", "LDAP service user and password must not be empty"),
+		Entry("invalid CA", "/secrets/ca.pem", "not a certificate", "does not contain a PEM certificate"),
+	)
+
+	It("keeps the previous credentials when a file disappears", func() {
+		delete(files, "/secrets/ca.pem")
+		Expect(source.Reload(logger)).To(MatchError(os.ErrNotExist))
+		Expect(source.Credentials().CACert).To(Equal(ldapTestCACert))
+	})
+
+	It("fails when a file cannot be read at startup", func() {
+		fakeIoutil.ReadFileStub = func(string) ([]byte, error) { return nil, errors.New("permission denied") }
+		_, err := nfsv3driver.NewLdapCredentialFileSource(logger, fakeIoutil, nfsv3driver.LdapCredentialFiles{SvcPassFile: "/secrets/svc-pass"}, nfsv3driver.LdapCredentials{SvcUser: "svcuser"})
+		Expect(err).To(MatchError("permission denied"))
+	})
+
+	It("lists only the configured paths", func() {
+		Expect(nfsv3driver.LdapCredentialFiles{SvcPassFile: "/secrets/svc-pass"}.Paths()).To(Equal([]string{"/secrets/svc-pass"}))
+	})
+
+	Context("when used by the LDAP resolver", func() {
+		var (
+			ldapFake           *ldap_fake.FakeLdap
+			ldapConnectionFake *ldap_fake.FakeLdapConnection
+			resolver           nfsv3driver.IdResolver
+		)
+
+		BeforeEach(func() {
+			ldapFake = &ldap_fake.FakeLdap{}
+			ldapConnectionFake = &ldap_fake.FakeLdapConnection{}
+			ldapFake.DialTLSReturns(ldapConnectionFake, nil)
+			ldapConnectionFake.SearchReturns(&ldap.SearchResult{Entries: []*ldap.Entry{{DN: "foo", Attributes: []*ldap.EntryAttribute{
+				{Name: "uidNumber", Values: []string{"100"}},
+			}}}}, nil)
+		})
+
+		JustBeforeEach(func() {
+			resolver = nfsv3driver.NewLdapIdResolver("ignored", "ignored", "host", 111, "tcp", "cn=Users,dc=test,dc=com", "", ldapFake, 120*time.Second,
+				nfsv3driver.WithCredentialSource(source),
+			)
+		})
+
+		It("binds with the current credentials on each resolution", func() {
+			env := driverhttp.NewHttpDriverEnv(logger, context.TODO())
+
+			_, _, err := resolver.Resolve(env, "user", "pw")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ldapFake.DialTLSCallCount()).To(Equal(1))
+			user, password := ldapConnectionFake.BindArgsForCall(0)
+			Expect(user).To(Equal("svcuser"))
+			Expect(password).To(Equal("svcpw"))
+
+			files["/secrets/svc-pass"] = "rotated"
+			Expect(source.Reload(logger)).To(Succeed())
+
+			_, _, err = resolver.Resolve(env, "user", "pw")
+			Expect(err).NotTo(HaveOccurred())
+			_, password = ldapConnectionFake.BindArgsForCall(2)
+			Expect(password).To(Equal("rotated"))
+		})
+	})
+})
@@ -0,0 +1,110 @@
+package nfsv3driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"code.cloudfoundry.org/lager/v3"
+	"gopkg.in/ldap.v2"
+)
+
+// LdapDomain maps the domain part of a down-level (DOMAIN\user) or UPN (user@suffix) username to the part of the
+// directory that holds its users. Host and Port are optional and default to the resolver's server.
+type LdapDomain struct {
+	NetbiosName string `json:"netbios_name" yaml:"netbios_name"`
+	UPNSuffix   string `json:"upn_suffix" yaml:"upn_suffix"`
+	BaseDN      string `json:"base_dn" yaml:"base_dn"`
+	Host        string `json:"host" yaml:"host"`
+	Port        int    `json:"port" yaml:"port"`
+}
+
+type LdapIdResolverOption func(*ldapIdResolver)
+
+// WithLdapDomains lets users log in as DOMAIN\user or user@suffix. Unmapped domains are searched under the default base DN.
+func WithLdapDomains(domains []LdapDomain) LdapIdResolverOption {
+	return func(d *ldapIdResolver) {
+		d.domains = domains
+	}
+}
+
+// ParseLdapDomains reads the JSON list of domains given in LDAP_DOMAINS
+func ParseLdapDomains(encoded string) ([]LdapDomain, error) {
+	var domains []LdapDomain
+	if err := json.Unmarshal([]byte(encoded), &domains); err != nil {
+		return nil, fmt.Errorf("invalid LDAP domains: %s", err.Error())
+	}
+
+	if err := ValidateLdapDomains(domains); err != nil {
+		return nil, err
+	}
+	return domains, nil
+}
+
+func ValidateLdapDomains(domains []LdapDomain) error {
+	for i, domain := range domains {
+		if domain.NetbiosName == "" && domain.UPNSuffix == "" {
+			return fmt.Errorf("invalid LDAP domain %d: one of netbios_name or upn_suffix is required", i)
+		}
+		if domain.BaseDN == "" {
+			return fmt.Errorf("invalid LDAP domain %d: base_dn is required", i)
+		}
+		if domain.Port < 0 {
+			return fmt.Errorf("invalid LDAP domain %d: port must not be negative", i)
+		}
+	}
+	return nil
+}
+
+// ldapUserLookup describes where and how to search for one username
+type ldapUserLookup struct {
+	host   string
+	port   int
+	baseDN string
+	filter string
+}
+
+func (d *ldapIdResolver) userLookup(logger lager.Logger, username string) ldapUserLookup {
+	lookup := ldapUserLookup{
+		host:   d.ldapHost,
+		port:   d.ldapPort,
+		baseDN: d.ldapFqdn,
+	}
+
+	var domain *LdapDomain
+	if netbios, user, ok := strings.Cut(username, `\`); ok {
+		lookup.filter = fmt.Sprintf("(&(objectClass=User)(sAMAccountName=%s))", ldap.EscapeFilter(user))
+		domain = d.findDomain(func(domain LdapDomain) bool { return strings.EqualFold(domain.NetbiosName, netbios) })
+	} else if at := strings.LastIndex(username, "@"); at > 0 {
+		suffix := username[at+1:]
+		lookup.filter = fmt.Sprintf("(&(objectClass=User)(userPrincipalName=%s))", ldap.EscapeFilter(username))
+		domain = d.findDomain(func(domain LdapDomain) bool { return strings.EqualFold(domain.UPNSuffix, suffix) })
+	} else {
+		lookup.filter = fmt.Sprintf("(&(objectClass=User)(cn=%s))", ldap.EscapeFilter(username))
+		return lookup
+	}
+
+	if domain == nil {
+		logger.Info("domain-not-mapped", lager.Data{"base-dn": lookup.baseDN})
+		return lookup
+	}
+
+	lookup.baseDN = domain.BaseDN
+	if domain.Host != "" {
+		lookup.host = domain.Host
+	}
+	if domain.Port != 0 {
+		lookup.port = domain.Port
+	}
+
+	return lookup
+}
+
+func (d *ldapIdResolver) findDomain(matches func(LdapDomain) bool) *LdapDomain {
+	for i := range d.domains {
+		if matches(d.domains[i]) {
+			return &d.domains[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package nfsv3driver_test
+
+import (
+	"code.cloudfoundry.org/nfsv3driver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseLdapDomains", func() {
+	It("parses a list of domains", func() {
+		domains, err := nfsv3driver.ParseLdapDomains(`[
+			{"netbios_name": "CORP", "upn_suffix": "corp.example.com", "base_dn": "dc=corp,dc=example,dc=com"},
+			{"upn_suffix": "partner.example.com", "base_dn": "dc=partner,dc=example,dc=com", "host": "dc1.partner.example.com", "port": 636}
+		]`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(domains).To(Equal([]nfsv3driver.LdapDomain{
+			{NetbiosName: "CORP", UPNSuffix: "corp.example.com", BaseDN: "dc=corp,dc=example,dc=com"},
+			{UPNSuffix: "partner.example.com", BaseDN: "dc=partner,dc=example,dc=com", Host: "dc1.partner.example.com", Port: 636},
+		}))
+	})
+
+	DescribeTable("rejects invalid domains",
+		func(encoded string, expectedError string) {
+			_, err := nfsv3driver.ParseLdapDomains(encoded)
+			Expect(err).To(MatchError(ContainSubstring(expectedError)))
+		},
+		Entry("malformed json", `[{"netbios_name": }]`, "invalid LDAP domains"),
+		Entry("no domain name", `[{"base_dn": "dc=corp"}]`, "invalid LDAP domain 0: one of netbios_name or upn_suffix is required"),
+		Entry("no base dn", `[{"netbios_name": "CORP"}]`, "invalid LDAP domain 0: base_dn is required"),
+		Entry("negative port", `[{"netbios_name": "CORP", "base_dn": "dc=corp", "port": -1}]`, "invalid LDAP domain 0: port must not be negative"),
+	)
+})
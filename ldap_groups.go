@@ -0,0 +1,184 @@
+package nfsv3driver
+
+import (
+	"fmt"
+	"strings"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/goshims/ldapshim"
+	"code.cloudfoundry.org/lager/v3"
+	"gopkg.in/ldap.v2"
+)
+
+const NotAuthorizedErrorMessage = "User is not a member of a group that may use this driver"
+
+// ldapMatchingRuleInChain is LDAP_MATCHING_RULE_IN_CHAIN, which makes active directory follow nested groups itself
+const ldapMatchingRuleInChain = "1.2.840.113556.1.4.1941"
+
+// maxExpandedGroups bounds recursive expansion on directories with very large or cyclic group graphs
+const maxExpandedGroups = 1000
+
+type GroupLookup string
+
+const (
+	// GroupLookupInChain asks the directory to resolve nested membership (active directory)
+	GroupLookupInChain GroupLookup = "in-chain"
+	// GroupLookupRecursive walks memberOf and member/uniqueMember links one level at a time (other servers)
+	GroupLookupRecursive GroupLookup = "recursive"
+)
+
+// GroupMembershipPolicy restricts the resolver to users that belong, directly or through nested groups, to at
+// least one of RequiredGroups. Group searches run under BaseDN, or under the user search base when it is empty.
+type GroupMembershipPolicy struct {
+	RequiredGroups []string
+	Lookup         GroupLookup
+	BaseDN         string
+}
+
+func (p GroupMembershipPolicy) Validate() error {
+	if p.Lookup != GroupLookupInChain && p.Lookup != GroupLookupRecursive {
+		return fmt.Errorf("unknown group lookup '%s'", p.Lookup)
+	}
+	for _, group := range p.RequiredGroups {
+		if _, err := ldap.ParseDN(group); err != nil {
+			return fmt.Errorf("invalid group DN '%s': %s", group, err.Error())
+		}
+	}
+	return nil
+}
+
+func WithRequiredGroups(policy GroupMembershipPolicy) LdapIdResolverOption {
+	return func(d *ldapIdResolver) {
+		if len(policy.RequiredGroups) > 0 {
+			d.groupPolicy = &policy
+		}
+	}
+}
+
+// checkGroupMembership expects l to be bound as the service user
+func (d *ldapIdResolver) checkGroupMembership(logger lager.Logger, l ldapshim.LdapConnection, entry *ldap.Entry, baseDN string) error {
+	logger = logger.Session("check-group-membership")
+
+	var member bool
+	var err error
+	if d.groupPolicy.Lookup == GroupLookupInChain {
+		member, err = d.memberInChain(l, entry.DN)
+	} else {
+		if d.groupPolicy.BaseDN != "" {
+			baseDN = d.groupPolicy.BaseDN
+		}
+		member, err = d.memberRecursive(logger, l, entry, baseDN)
+	}
+	if err != nil {
+		logger.Error("failed-to-search-groups", err)
+		return err
+	}
+
+	if !member {
+		logger.Info("not-authorized", lager.Data{"required-groups": d.groupPolicy.RequiredGroups})
+		return dockerdriver.SafeError{SafeDescription: NotAuthorizedErrorMessage}
+	}
+	return nil
+}
+
+func (d *ldapIdResolver) memberInChain(l ldapshim.LdapConnection, userdn string) (bool, error) {
+	for _, group := range d.groupPolicy.RequiredGroups {
+		sr, err := d.search(l, d.ldap.NewSearchRequest(
+			userdn,
+			ldap.ScopeBaseObject,
+			ldap.NeverDerefAliases,
+			0,
+			0,
+			false,
+			fmt.Sprintf("(memberOf:%s:=%s)", ldapMatchingRuleInChain, ldap.EscapeFilter(group)),
+			[]string{"dn"},
+			nil,
+		))
+		if err != nil {
+			return false, err
+		}
+		if len(sr.Entries) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (d *ldapIdResolver) memberRecursive(logger lager.Logger, l ldapshim.LdapConnection, entry *ldap.Entry, baseDN string) (bool, error) {
+	required := map[string]bool{}
+	for _, group := range d.groupPolicy.RequiredGroups {
+		required[normalizeDN(group)] = true
+	}
+
+	seen := map[string]bool{}
+	var queue []string
+	enqueue := func(dns []string) bool {
+		for _, dn := range dns {
+			key := normalizeDN(dn)
+			if required[key] {
+				return true
+			}
+			if !seen[key] {
+				seen[key] = true
+				queue = append(queue, dn)
+			}
+		}
+		return false
+	}
+
+	if enqueue(entry.GetAttributeValues("memberOf")) {
+		return true, nil
+	}
+	queue = append(queue, entry.DN)
+
+	for len(queue) > 0 {
+		if len(seen) > maxExpandedGroups {
+			logger.Info("group-expansion-limit-reached", lager.Data{"limit": maxExpandedGroups})
+			return false, nil
+		}
+
+		dn := queue[0]
+		queue = queue[1:]
+
+		sr, err := d.search(l, d.ldap.NewSearchRequest(
+			baseDN,
+			ldap.ScopeWholeSubtree,
+			ldap.NeverDerefAliases,
+			0,
+			0,
+			false,
+			fmt.Sprintf("(|(member=%s)(uniqueMember=%s))", ldap.EscapeFilter(dn), ldap.EscapeFilter(dn)),
+			[]string{"dn", "memberOf"},
+			nil,
+		))
+		if err != nil {
+			return false, err
+		}
+
+		for _, group := range sr.Entries {
+			if enqueue(append([]string{group.DN}, group.GetAttributeValues("memberOf")...)) {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// normalizeDN makes DNs comparable regardless of spacing and case, as directory servers compare them
+func normalizeDN(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil {
+		return strings.ToLower(dn)
+	}
+
+	rdns := make([]string, 0, len(parsed.RDNs))
+	for _, rdn := range parsed.RDNs {
+		attributes := make([]string, 0, len(rdn.Attributes))
+		for _, attribute := range rdn.Attributes {
+			attributes = append(attributes, strings.ToLower(attribute.Type)+"="+strings.ToLower(attribute.Value))
+		}
+		rdns = append(rdns, strings.Join(attributes, "+"))
+	}
+	return strings.Join(rdns, ",")
+}
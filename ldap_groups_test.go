@@ -0,0 +1,244 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/ldapshim/ldap_fake"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"gopkg.in/ldap.v2"
+)
+
+var _ = Describe("Group membership gating", func() {
+	const (
+		userDN     = "cn=alice,cn=Users,dc=test,dc=com"
+		requiredDN = "cn=NFS Users,ou=Groups,dc=test,dc=com"
+	)
+
+	var (
+		logger             *lagertest.TestLogger
+		env                dockerdriver.Env
+		ldapFake           *ldap_fake.FakeLdap
+		ldapConnectionFake *ldap_fake.FakeLdapConnection
+		policy             nfsv3driver.GroupMembershipPolicy
+		userMemberOf       []string
+		parents            map[string][]string
+		searches           []*ldap.SearchRequest
+		uid                string
+		err                error
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("ldap-groups")
+		env = driverhttp.NewHttpDriverEnv(logger, context.TODO())
+		ldapFake = &ldap_fake.FakeLdap{}
+		ldapConnectionFake = &ldap_fake.FakeLdapConnection{}
+		ldapFake.DialReturns(ldapConnectionFake, nil)
+		ldapFake.NewSearchRequestStub = ldap.NewSearchRequest
+
+		policy = nfsv3driver.GroupMembershipPolicy{
+			RequiredGroups: []string{"cn=Admins,ou=Groups,dc=test,dc=com", requiredDN},
+			Lookup:         nfsv3driver.GroupLookupInChain,
+		}
+		userMemberOf = nil
+		parents = map[string][]string{}
+		searches = nil
+
+		ldapConnectionFake.SearchStub = func(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+			searches = append(searches, req)
+
+			switch {
+			case strings.HasPrefix(req.Filter, "(&(objectClass=User)"):
+				return &ldap.SearchResult{Entries: []*ldap.Entry{{DN: userDN, Attributes: []*ldap.EntryAttribute{
+					{Name: "uidNumber", Values: []string{"100"}},
+					{Name: "memberOf", Values: userMemberOf},
+				}}}}, nil
+			case strings.HasPrefix(req.Filter, "(memberOf:1.2.840.113556.1.4.1941:="):
+				if req.BaseDN == userDN && req.Filter == "(memberOf:1.2.840.113556.1.4.1941:="+requiredDN+")" && len(parents[userDN]) > 0 {
+					return &ldap.SearchResult{Entries: []*ldap.Entry{{DN: userDN}}}, nil
+				}
+				return &ldap.SearchResult{}, nil
+			default:
+				member, _, _ := strings.Cut(strings.TrimPrefix(req.Filter, "(|(member="), ")(uniqueMember=")
+				result := &ldap.SearchResult{}
+				for _, parent := range parents[member] {
+					result.Entries = append(result.Entries, &ldap.Entry{DN: parent})
+				}
+				return result, nil
+			}
+		}
+	})
+
+	JustBeforeEach(func() {
+		resolver := nfsv3driver.NewLdapIdResolver("svcuser", "svcpw", "host", 111, "tcp", "cn=Users,dc=test,dc=com", "", ldapFake, 120*time.Second,
+			nfsv3driver.WithRequiredGroups(policy),
+		)
+		uid, _, err = resolver.Resolve(env, "alice", "pw")
+	})
+
+	Context("using LDAP_MATCHING_RULE_IN_CHAIN", func() {
+		Context("when the user is a nested member of a required group", func() {
+			BeforeEach(func() {
+				parents[userDN] = []string{"cn=Team,ou=Groups,dc=test,dc=com"}
+			})
+
+			It("resolves the user", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(uid).To(Equal("100"))
+			})
+
+			It("asks the directory about each required group on the user's entry", func() {
+				Expect(searches).To(HaveLen(3))
+				Expect(searches[1].BaseDN).To(Equal(userDN))
+				Expect(searches[1].Scope).To(Equal(ldap.ScopeBaseObject))
+				Expect(searches[1].Filter).To(Equal("(memberOf:1.2.840.113556.1.4.1941:=cn=Admins,ou=Groups,dc=test,dc=com)"))
+				Expect(searches[2].Filter).To(Equal("(memberOf:1.2.840.113556.1.4.1941:=cn=NFS Users,ou=Groups,dc=test,dc=com)"))
+			})
+
+			It("searches groups as the service user after verifying the password", func() {
+				Expect(ldapConnectionFake.BindCallCount()).To(Equal(3))
+				user, password := ldapConnectionFake.BindArgsForCall(1)
+				Expect(user).To(Equal(userDN))
+				Expect(password).To(Equal("pw"))
+				user, password = ldapConnectionFake.BindArgsForCall(2)
+				Expect(user).To(Equal("svcuser"))
+				Expect(password).To(Equal("svcpw"))
+			})
+		})
+
+		Context("when the user is not a member", func() {
+			It("reports that the user is not authorized", func() {
+				Expect(err).To(MatchError(nfsv3driver.NotAuthorizedErrorMessage))
+				Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+				Expect(logger).To(gbytes.Say("check-group-membership.not-authorized"))
+			})
+		})
+
+		Context("when the password is wrong", func() {
+			BeforeEach(func() {
+				ldapConnectionFake.BindStub = func(user, password string) error {
+					if user == userDN {
+						return ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+					}
+					return nil
+				}
+			})
+
+			It("does not reveal group membership", func() {
+				Expect(err).To(MatchError(nfsv3driver.InvalidCredentialsErrorMessage))
+				Expect(searches).To(HaveLen(1))
+			})
+		})
+	})
+
+	Context("using recursive expansion", func() {
+		BeforeEach(func() {
+			policy.Lookup = nfsv3driver.GroupLookupRecursive
+		})
+
+		Context("when the user's memberOf names a required group", func() {
+			BeforeEach(func() {
+				userMemberOf = []string{"CN=nfs users, OU=groups, DC=test, DC=com"}
+			})
+
+			It("resolves the user without searching groups", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(searches).To(HaveLen(1))
+				Expect(searches[0].Attributes).To(ContainElement("memberOf"))
+			})
+		})
+
+		Context("when the user reaches a required group through nested groups", func() {
+			BeforeEach(func() {
+				parents[userDN] = []string{"cn=Team,ou=Groups,dc=test,dc=com"}
+				parents["cn=Team,ou=Groups,dc=test,dc=com"] = []string{"cn=Department,ou=Groups,dc=test,dc=com"}
+				parents["cn=Department,ou=Groups,dc=test,dc=com"] = []string{requiredDN}
+			})
+
+			It("resolves the user", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(uid).To(Equal("100"))
+			})
+
+			It("looks for groups listing each dn as member", func() {
+				Expect(searches[1].BaseDN).To(Equal("cn=Users,dc=test,dc=com"))
+				Expect(searches[1].Filter).To(Equal("(|(member=" + userDN + ")(uniqueMember=" + userDN + "))"))
+			})
+		})
+
+		Context("when a group base DN is configured", func() {
+			BeforeEach(func() {
+				policy.BaseDN = "ou=Groups,dc=test,dc=com"
+			})
+
+			It("searches groups under it", func() {
+				Expect(searches[1].BaseDN).To(Equal("ou=Groups,dc=test,dc=com"))
+			})
+		})
+
+		Context("when groups are nested in a cycle", func() {
+			BeforeEach(func() {
+				parents[userDN] = []string{"cn=A,ou=Groups,dc=test,dc=com"}
+				parents["cn=A,ou=Groups,dc=test,dc=com"] = []string{"cn=B,ou=Groups,dc=test,dc=com"}
+				parents["cn=B,ou=Groups,dc=test,dc=com"] = []string{"cn=A,ou=Groups,dc=test,dc=com"}
+			})
+
+			It("visits each group once and reports that the user is not authorized", func() {
+				Expect(err).To(MatchError(nfsv3driver.NotAuthorizedErrorMessage))
+				Expect(searches).To(HaveLen(4))
+			})
+		})
+
+		Context("when a group search fails", func() {
+			BeforeEach(func() {
+				search := ldapConnectionFake.SearchStub
+				ldapConnectionFake.SearchStub = func(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+					if strings.HasPrefix(req.Filter, "(|(member=") {
+						return nil, errors.New("size limit exceeded")
+					}
+					return search(req)
+				}
+			})
+
+			It("returns the error", func() {
+				Expect(err).To(MatchError("size limit exceeded"))
+				Expect(logger).To(gbytes.Say("failed-to-search-groups"))
+			})
+		})
+	})
+
+	Context("when no groups are required", func() {
+		BeforeEach(func() {
+			policy.RequiredGroups = nil
+		})
+
+		It("does not search groups", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(searches).To(HaveLen(1))
+			Expect(ldapConnectionFake.BindCallCount()).To(Equal(2))
+		})
+	})
+
+	DescribeTable("validating policies",
+		func(policy nfsv3driver.GroupMembershipPolicy, expectedError string) {
+			err := policy.Validate()
+			if expectedError == "" {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(ContainSubstring(expectedError)))
+			}
+		},
+		Entry("in-chain", nfsv3driver.GroupMembershipPolicy{RequiredGroups: []string{requiredDN}, Lookup: nfsv3driver.GroupLookupInChain}, ""),
+		Entry("recursive", nfsv3driver.GroupMembershipPolicy{RequiredGroups: []string{requiredDN}, Lookup: nfsv3driver.GroupLookupRecursive}, ""),
+		Entry("unknown lookup", nfsv3driver.GroupMembershipPolicy{Lookup: "magic"}, "unknown group lookup 'magic'"),
+		Entry("invalid group", nfsv3driver.GroupMembershipPolicy{RequiredGroups: []string{"not a dn"}, Lookup: nfsv3driver.GroupLookupInChain}, "invalid group DN 'not a dn'"),
+	)
+})
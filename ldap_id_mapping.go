@@ -0,0 +1,164 @@
+package nfsv3driver
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/lager/v3"
+	"gopkg.in/ldap.v2"
+)
+
+const MissingUidErrorMessage = "User has no uid in the directory, please contact your system administrator"
+
+var ldapIdMappingAttributes = []string{"objectSid", "primaryGroupID"}
+
+// IdMapRange carves the id space into slices like sssd's ldap_idmap_range_min, ldap_idmap_range_max and
+// ldap_idmap_range_size, so users get the same uid/gid on hosts running sssd with ldap_id_mapping.
+type IdMapRange struct {
+	Min  uint32
+	Max  uint32
+	Size uint32
+}
+
+var DefaultIdMapRange = IdMapRange{Min: 200000, Max: 2000200000, Size: 200000}
+
+func (r IdMapRange) Validate() error {
+	if r.Size == 0 {
+		return fmt.Errorf("id mapping range size must be positive")
+	}
+	if r.Max <= r.Min || r.Max-r.Min < r.Size {
+		return fmt.Errorf("id mapping range %d-%d cannot hold a slice of %d ids", r.Min, r.Max, r.Size)
+	}
+	return nil
+}
+
+// WithSidIdMapping derives uid and gid from objectSid and primaryGroupID for users without a uidNumber
+func WithSidIdMapping(idMapRange IdMapRange) LdapIdResolverOption {
+	return func(d *ldapIdResolver) {
+		d.idMapRange = &idMapRange
+	}
+}
+
+func (d *ldapIdResolver) entryIds(logger lager.Logger, entry *ldap.Entry) (uid string, gid string, err error) {
+	uid = entry.GetAttributeValue("uidNumber")
+	gid = entry.GetAttributeValue("gidNumber")
+	if uid != "" {
+		if gid == "" {
+			gid = uid
+		}
+		return uid, gid, nil
+	}
+
+	if d.idMapRange == nil {
+		logger.Info("missing-uid-number")
+		return "", "", dockerdriver.SafeError{SafeDescription: MissingUidErrorMessage}
+	}
+
+	domainSid, rid, err := parseSid(entry.GetRawAttributeValue("objectSid"))
+	if err != nil {
+		logger.Error("invalid-object-sid", err)
+		return "", "", dockerdriver.SafeError{SafeDescription: MissingUidErrorMessage}
+	}
+
+	primaryGroupRid := rid
+	if primaryGroupID := entry.GetAttributeValue("primaryGroupID"); primaryGroupID != "" {
+		parsed, err := strconv.ParseUint(primaryGroupID, 10, 32)
+		if err != nil {
+			logger.Error("invalid-primary-group-id", err)
+			return "", "", dockerdriver.SafeError{SafeDescription: MissingUidErrorMessage}
+		}
+		primaryGroupRid = uint32(parsed)
+	}
+
+	base := d.idMapRange.sliceBase(domainSid)
+	if rid >= d.idMapRange.Size || primaryGroupRid >= d.idMapRange.Size {
+		logger.Info("rid-outside-slice", lager.Data{"domain-sid": domainSid, "rid": rid, "primary-group-rid": primaryGroupRid})
+		return "", "", dockerdriver.SafeError{SafeDescription: MissingUidErrorMessage}
+	}
+
+	uid = strconv.FormatUint(uint64(base)+uint64(rid), 10)
+	gid = strconv.FormatUint(uint64(base)+uint64(primaryGroupRid), 10)
+	logger.Info("mapped-ids-from-sid", lager.Data{"domain-sid": domainSid, "uid": uid, "gid": gid})
+	return uid, gid, nil
+}
+
+// sliceBase matches sss_idmap_calculate_range for a domain without collisions
+func (r IdMapRange) sliceBase(domainSid string) uint32 {
+	slices := (r.Max - r.Min) / r.Size
+	slice := murmur3([]byte(domainSid), 0xdeadbeef) % slices
+	return r.Min + slice*r.Size
+}
+
+// parseSid splits a binary SID into its domain part in string form and the trailing RID
+func parseSid(sid []byte) (string, uint32, error) {
+	if len(sid) < 8 {
+		return "", 0, fmt.Errorf("SID is too short")
+	}
+
+	subAuthorityCount := int(sid[1])
+	if subAuthorityCount < 2 || len(sid) != 8+4*subAuthorityCount {
+		return "", 0, fmt.Errorf("SID has an invalid length")
+	}
+
+	var authority uint64
+	for _, b := range sid[2:8] {
+		authority = authority<<8 | uint64(b)
+	}
+
+	parts := []string{"S", strconv.Itoa(int(sid[0])), strconv.FormatUint(authority, 10)}
+	for i := 0; i < subAuthorityCount-1; i++ {
+		parts = append(parts, strconv.FormatUint(uint64(binary.LittleEndian.Uint32(sid[8+4*i:])), 10))
+	}
+
+	return strings.Join(parts, "-"), binary.LittleEndian.Uint32(sid[8+4*(subAuthorityCount-1):]), nil
+}
+
+// murmur3 is MurmurHash3_x86_32, which sssd uses to pick a domain's slice
+func murmur3(data []byte, seed uint32) uint32 {
+	const (
+		c1 = 0xcc9e2d51
+		c2 = 0x1b873593
+	)
+
+	h := seed
+	blocks := len(data) / 4
+	for i := 0; i < blocks; i++ {
+		k := binary.LittleEndian.Uint32(data[4*i:])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+	}
+
+	var k uint32
+	tail := data[4*blocks:]
+	switch len(tail) {
+	case 3:
+		k ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+
+	h ^= uint32(len(data))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
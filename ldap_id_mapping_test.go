@@ -0,0 +1,166 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"encoding/binary"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/ldapshim/ldap_fake"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"gopkg.in/ldap.v2"
+)
+
+// encodeSid builds the binary objectSid for S-1-5-<subAuthorities...>
+func encodeSid(subAuthorities ...uint32) []byte {
+	sid := []byte{1, byte(len(subAuthorities)), 0, 0, 0, 0, 0, 5}
+	for _, subAuthority := range subAuthorities {
+		sid = binary.LittleEndian.AppendUint32(sid, subAuthority)
+	}
+	return sid
+}
+
+var _ = Describe("SID based id mapping", func() {
+	var (
+		logger             *lagertest.TestLogger
+		env                dockerdriver.Env
+		ldapFake           *ldap_fake.FakeLdap
+		ldapConnectionFake *ldap_fake.FakeLdapConnection
+		opts               []nfsv3driver.LdapIdResolverOption
+		attributes         []*ldap.EntryAttribute
+		uid, gid           string
+		err                error
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("ldap-id-mapping")
+		env = driverhttp.NewHttpDriverEnv(logger, context.TODO())
+		ldapFake = &ldap_fake.FakeLdap{}
+		ldapConnectionFake = &ldap_fake.FakeLdapConnection{}
+		ldapFake.DialReturns(ldapConnectionFake, nil)
+
+		opts = []nfsv3driver.LdapIdResolverOption{nfsv3driver.WithSidIdMapping(nfsv3driver.DefaultIdMapRange)}
+		attributes = []*ldap.EntryAttribute{
+			{Name: "objectSid", ByteValues: [][]byte{encodeSid(21, 3623811015, 3361044348, 30300820, 1013)}},
+			{Name: "primaryGroupID", Values: []string{"513"}},
+		}
+	})
+
+	JustBeforeEach(func() {
+		for _, attribute := range attributes {
+			if attribute.Values == nil {
+				for _, value := range attribute.ByteValues {
+					attribute.Values = append(attribute.Values, string(value))
+				}
+			}
+		}
+		ldapConnectionFake.SearchReturns(&ldap.SearchResult{Entries: []*ldap.Entry{{DN: "foo", Attributes: attributes}}}, nil)
+
+		resolver := nfsv3driver.NewLdapIdResolver("svcuser", "svcpw", "host", 111, "tcp", "cn=Users,dc=test,dc=com", "", ldapFake, 120*time.Second, opts...)
+		uid, gid, err = resolver.Resolve(env, "user", "pw")
+	})
+
+	It("requests the SID attributes", func() {
+		_, _, _, _, _, _, _, requested, _ := ldapFake.NewSearchRequestArgsForCall(0)
+		Expect(requested).To(ContainElements("objectSid", "primaryGroupID"))
+	})
+
+	It("derives the ids that sssd would assign", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(uid).To(Equal("674001013"))
+		Expect(gid).To(Equal("674000513"))
+		Expect(logger).To(gbytes.Say(`mapped-ids-from-sid.*"domain-sid":"S-1-5-21-3623811015-3361044348-30300820"`))
+	})
+
+	Context("with a custom range", func() {
+		BeforeEach(func() {
+			opts = []nfsv3driver.LdapIdResolverOption{nfsv3driver.WithSidIdMapping(nfsv3driver.IdMapRange{Min: 100000, Max: 1100000, Size: 100000})}
+		})
+
+		It("places the domain in a slice of that range", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uid).To(Equal("1001013"))
+			Expect(gid).To(Equal("1000513"))
+		})
+	})
+
+	Context("when the user has no primary group", func() {
+		BeforeEach(func() {
+			attributes = attributes[:1]
+		})
+
+		It("uses the uid as gid", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gid).To(Equal(uid))
+		})
+	})
+
+	Context("when the user has POSIX attributes", func() {
+		BeforeEach(func() {
+			attributes = append(attributes, &ldap.EntryAttribute{Name: "uidNumber", Values: []string{"100"}})
+		})
+
+		It("prefers them", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uid).To(Equal("100"))
+			Expect(gid).To(Equal("100"))
+		})
+	})
+
+	Context("when the RID does not fit in a slice", func() {
+		BeforeEach(func() {
+			attributes[0] = &ldap.EntryAttribute{Name: "objectSid", ByteValues: [][]byte{encodeSid(21, 3623811015, 3361044348, 30300820, 250000)}}
+		})
+
+		It("reports that the user has no uid", func() {
+			Expect(err).To(MatchError(nfsv3driver.MissingUidErrorMessage))
+			Expect(logger).To(gbytes.Say("rid-outside-slice"))
+		})
+	})
+
+	Context("when the objectSid is malformed", func() {
+		BeforeEach(func() {
+			attributes[0] = &ldap.EntryAttribute{Name: "objectSid", ByteValues: [][]byte{{1, 5, 0, 0}}}
+		})
+
+		It("reports that the user has no uid", func() {
+			Expect(err).To(MatchError(nfsv3driver.MissingUidErrorMessage))
+			Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+		})
+	})
+
+	Context("when id mapping is not enabled", func() {
+		BeforeEach(func() {
+			opts = nil
+		})
+
+		It("does not request the SID attributes", func() {
+			_, _, _, _, _, _, _, requested, _ := ldapFake.NewSearchRequestArgsForCall(0)
+			Expect(requested).NotTo(ContainElement("objectSid"))
+		})
+
+		It("reports that the user has no uid instead of returning an empty one", func() {
+			Expect(err).To(MatchError(nfsv3driver.MissingUidErrorMessage))
+			Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+		})
+	})
+
+	DescribeTable("validating ranges",
+		func(idMapRange nfsv3driver.IdMapRange, valid bool) {
+			if valid {
+				Expect(idMapRange.Validate()).To(Succeed())
+			} else {
+				Expect(idMapRange.Validate()).NotTo(Succeed())
+			}
+		},
+		Entry("default", nfsv3driver.DefaultIdMapRange, true),
+		Entry("zero size", nfsv3driver.IdMapRange{Min: 1, Max: 10}, false),
+		Entry("inverted", nfsv3driver.IdMapRange{Min: 10, Max: 1, Size: 1}, false),
+		Entry("smaller than a slice", nfsv3driver.IdMapRange{Min: 10, Max: 20, Size: 100}, false),
+	)
+})
@@ -0,0 +1,236 @@
+package nfsv3driver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/goshims/ldapshim"
+	"code.cloudfoundry.org/lager/v3"
+	"gopkg.in/ldap.v2"
+)
+
+type ReferralTLSPolicy string
+
+const (
+	// ReferralTLSRequired follows ldap:// referrals only after upgrading them with StartTLS
+	ReferralTLSRequired ReferralTLSPolicy = "required"
+	// ReferralTLSOptional follows ldap:// referrals in plain text. ldaps:// referrals always use TLS.
+	ReferralTLSOptional ReferralTLSPolicy = "optional"
+)
+
+type LdapSearchOptions struct {
+	// PageSize requests results in pages of this size with the paged results control. 0 disables paging.
+	PageSize uint32
+	// ChaseReferrals follows search continuation references returned by the user search
+	ChaseReferrals  bool
+	MaxReferralHops int
+	ReferralTLS     ReferralTLSPolicy
+}
+
+func (o LdapSearchOptions) Validate() error {
+	if o.MaxReferralHops < 0 {
+		return fmt.Errorf("maximum referral hops must not be negative")
+	}
+	if o.ChaseReferrals && o.ReferralTLS != ReferralTLSRequired && o.ReferralTLS != ReferralTLSOptional {
+		return fmt.Errorf("unknown referral TLS policy '%s'", o.ReferralTLS)
+	}
+	return nil
+}
+
+func WithSearchOptions(options LdapSearchOptions) LdapIdResolverOption {
+	return func(d *ldapIdResolver) {
+		d.searchOptions = options
+	}
+}
+
+// ldapReferral is a parsed ldap:// or ldaps:// URL from a search continuation reference
+type ldapReferral struct {
+	url    string
+	host   string
+	addr   string
+	baseDN string
+	tls    bool
+}
+
+// ldapSearchEntry remembers which referral, if any, an entry was found through, since binding as that user has to
+// happen on the same server
+type ldapSearchEntry struct {
+	*ldap.Entry
+	referral *ldapReferral
+}
+
+// search pages through the results when paging is enabled and the connection supports it
+func (d *ldapIdResolver) search(l ldapshim.LdapConnection, req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	if d.searchOptions.PageSize > 0 {
+		if extended, ok := l.(ExtendedLdapConnection); ok {
+			return extended.SearchWithPaging(req, d.searchOptions.PageSize)
+		}
+	}
+	return l.Search(req)
+}
+
+func (d *ldapIdResolver) searchUser(logger lager.Logger, ctx context.Context, conn *cancellableConnection, l ldapshim.LdapConnection, req *ldap.SearchRequest, credentials LdapCredentials) ([]ldapSearchEntry, error) {
+	sr, err := d.search(l, req)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := searchEntries(sr.Entries, nil)
+	if len(sr.Referrals) > 0 {
+		if !d.searchOptions.ChaseReferrals {
+			logger.Debug("referrals-ignored", lager.Data{"referrals": sr.Referrals})
+			return entries, nil
+		}
+		entries = append(entries, d.chaseReferrals(logger, ctx, conn, req, credentials, sr.Referrals, 1, map[string]bool{})...)
+	}
+
+	return entries, nil
+}
+
+// chaseReferrals repeats req on each referred server. Referrals that cannot be followed are logged and skipped, as
+// directories commonly return references to partitions the service user cannot reach.
+func (d *ldapIdResolver) chaseReferrals(logger lager.Logger, ctx context.Context, conn *cancellableConnection, req *ldap.SearchRequest, credentials LdapCredentials, referrals []string, hop int, visited map[string]bool) []ldapSearchEntry {
+	var entries []ldapSearchEntry
+	for _, ref := range referrals {
+		if visited[ref] {
+			continue
+		}
+		visited[ref] = true
+
+		if hop > d.searchOptions.MaxReferralHops {
+			logger.Info("referral-hop-limit-reached", lager.Data{"referral": ref, "limit": d.searchOptions.MaxReferralHops})
+			continue
+		}
+
+		referral, err := parseReferral(ref, req.BaseDN)
+		if err != nil {
+			logger.Error("invalid-referral", err, lager.Data{"referral": ref})
+			continue
+		}
+
+		l, err := d.dialReferral(ctx, conn, referral, credentials)
+		if err != nil {
+			logger.Error("failed-to-follow-referral", err, lager.Data{"referral": ref})
+			continue
+		}
+
+		referredReq := *req
+		referredReq.BaseDN = referral.baseDN
+		// paging leaves its cookie in the original request's controls
+		referredReq.Controls = nil
+
+		sr, err := d.search(l, &referredReq)
+		l.Close()
+		if err != nil {
+			logger.Error("failed-to-search-referral", err, lager.Data{"referral": ref})
+			continue
+		}
+
+		logger.Info("followed-referral", lager.Data{"referral": ref, "hop": hop, "entries": len(sr.Entries)})
+		entries = append(entries, searchEntries(sr.Entries, referral)...)
+		entries = append(entries, d.chaseReferrals(logger, ctx, conn, &referredReq, credentials, sr.Referrals, hop+1, visited)...)
+	}
+	return entries
+}
+
+// dialReferral connects to a referred server and binds as the service user
+func (d *ldapIdResolver) dialReferral(ctx context.Context, conn *cancellableConnection, referral *ldapReferral, credentials LdapCredentials) (ldapshim.LdapConnection, error) {
+	tlsConfig, err := ldapTLSConfig(referral.host, credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	var l ldapshim.LdapConnection
+	if referral.tls {
+		l, err = d.ldap.DialTLS(d.ldapProto, referral.addr, tlsConfig)
+	} else {
+		l, err = d.ldap.Dial(d.ldapProto, referral.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !conn.add(l) {
+		return nil, dockerdriver.SafeError{SafeDescription: LdapTimeoutErrorMessage}
+	}
+	l.SetTimeout(connectionTimeout(ctx, d.ldapTimeout))
+
+	if !referral.tls && d.searchOptions.ReferralTLS == ReferralTLSRequired {
+		extended, ok := l.(ExtendedLdapConnection)
+		if !ok {
+			l.Close()
+			return nil, fmt.Errorf("cannot start TLS with %s", referral.addr)
+		}
+		if err := extended.StartTLS(tlsConfig); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
+
+	if err := l.Bind(credentials.SvcUser, credentials.SvcPass); err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// ldapTLSConfig verifies servers against the configured CA bundle, or the system roots when there is none
+func ldapTLSConfig(host string, credentials LdapCredentials) (*tls.Config, error) {
+	config := &tls.Config{ServerName: host}
+	if credentials.CACert != "" {
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM([]byte(credentials.CACert)) {
+			return nil, errors.New("Failed to load CA certificate")
+		}
+	}
+	return config, nil
+}
+
+func parseReferral(ref string, defaultBaseDN string) (*ldapReferral, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	referral := &ldapReferral{url: ref, host: u.Hostname(), baseDN: strings.TrimPrefix(u.Path, "/")}
+	port := u.Port()
+	switch u.Scheme {
+	case "ldap":
+		if port == "" {
+			port = "389"
+		}
+	case "ldaps":
+		referral.tls = true
+		if port == "" {
+			port = "636"
+		}
+	default:
+		return nil, fmt.Errorf("unsupported referral scheme '%s'", u.Scheme)
+	}
+
+	if referral.host == "" {
+		return nil, fmt.Errorf("referral has no host")
+	}
+	if referral.baseDN == "" {
+		referral.baseDN = defaultBaseDN
+	}
+	referral.addr = net.JoinHostPort(referral.host, port)
+
+	return referral, nil
+}
+
+func searchEntries(entries []*ldap.Entry, referral *ldapReferral) []ldapSearchEntry {
+	result := make([]ldapSearchEntry, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, ldapSearchEntry{Entry: entry, referral: referral})
+	}
+	return result
+}
@@ -0,0 +1,249 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/ldapshim"
+	"code.cloudfoundry.org/goshims/ldapshim/ldap_fake"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"gopkg.in/ldap.v2"
+)
+
+var _ = Describe("LDAP search paging and referrals", func() {
+	const childReferral = "ldap://child.corp.example.com/DC=child,DC=corp,DC=example,DC=com"
+
+	var (
+		logger   *lagertest.TestLogger
+		env      dockerdriver.Env
+		ldapFake *ldap_fake.FakeLdap
+		servers  map[string]*nfsdriverfakes.FakeExtendedLdapConnection
+		options  nfsv3driver.LdapSearchOptions
+		uid      string
+		err      error
+
+		userEntry = func(dn string) *ldap.Entry {
+			return &ldap.Entry{DN: dn, Attributes: []*ldap.EntryAttribute{{Name: "uidNumber", Values: []string{"100"}}}}
+		}
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("ldap-search")
+		env = driverhttp.NewHttpDriverEnv(logger, context.TODO())
+
+		servers = map[string]*nfsdriverfakes.FakeExtendedLdapConnection{
+			"host:111":                    {},
+			"child.corp.example.com:389":  {},
+			"child.corp.example.com:636":  {},
+			"grandchild.example.com:389":  {},
+			"unreachable.example.com:389": {},
+		}
+		for _, server := range servers {
+			server.SearchReturns(&ldap.SearchResult{}, nil)
+			server.SearchWithPagingReturns(&ldap.SearchResult{}, nil)
+			server.SimpleBindReturns(&ldap.SimpleBindResult{}, nil)
+		}
+
+		ldapFake = &ldap_fake.FakeLdap{}
+		ldapFake.NewSearchRequestStub = ldap.NewSearchRequest
+		ldapFake.DialStub = func(_ string, addr string) (ldapshim.LdapConnection, error) {
+			if addr == "unreachable.example.com:389" {
+				return nil, errors.New("connection refused")
+			}
+			return servers[addr], nil
+		}
+		ldapFake.DialTLSStub = func(_ string, addr string, _ *tls.Config) (ldapshim.LdapConnection, error) {
+			return servers[addr], nil
+		}
+
+		options = nfsv3driver.LdapSearchOptions{}
+	})
+
+	JustBeforeEach(func() {
+		resolver := nfsv3driver.NewLdapIdResolver("svcuser", "svcpw", "host", 111, "tcp", "DC=corp,DC=example,DC=com", "", ldapFake, 120*time.Second,
+			nfsv3driver.WithSearchOptions(options),
+		)
+		uid, _, err = resolver.Resolve(env, "user", "pw")
+	})
+
+	Context("when paging is enabled", func() {
+		BeforeEach(func() {
+			options.PageSize = 500
+			servers["host:111"].SearchWithPagingReturns(&ldap.SearchResult{Entries: []*ldap.Entry{userEntry("cn=user")}}, nil)
+		})
+
+		It("requests pages of the configured size", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(servers["host:111"].SearchWithPagingCallCount()).To(Equal(1))
+			_, pageSize := servers["host:111"].SearchWithPagingArgsForCall(0)
+			Expect(pageSize).To(Equal(uint32(500)))
+			Expect(servers["host:111"].SearchCallCount()).To(BeZero())
+		})
+	})
+
+	Context("when paging is disabled", func() {
+		BeforeEach(func() {
+			servers["host:111"].SearchReturns(&ldap.SearchResult{Entries: []*ldap.Entry{userEntry("cn=user")}}, nil)
+		})
+
+		It("makes a single search", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(servers["host:111"].SearchCallCount()).To(Equal(1))
+			Expect(servers["host:111"].SearchWithPagingCallCount()).To(BeZero())
+		})
+	})
+
+	Context("when the search returns a referral", func() {
+		BeforeEach(func() {
+			servers["host:111"].SearchReturns(&ldap.SearchResult{Referrals: []string{childReferral}}, nil)
+			servers["child.corp.example.com:389"].SearchReturns(&ldap.SearchResult{Entries: []*ldap.Entry{userEntry("CN=user,DC=child,DC=corp,DC=example,DC=com")}}, nil)
+		})
+
+		It("ignores it by default", func() {
+			Expect(err).To(MatchError(nfsv3driver.UserNotFoundErrorMessage))
+			Expect(ldapFake.DialCallCount()).To(Equal(1))
+		})
+
+		Context("and referral chasing is enabled", func() {
+			BeforeEach(func() {
+				options.ChaseReferrals = true
+				options.MaxReferralHops = 2
+				options.ReferralTLS = nfsv3driver.ReferralTLSOptional
+			})
+
+			It("searches the referred server under the referred base DN", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(uid).To(Equal("100"))
+
+				child := servers["child.corp.example.com:389"]
+				user, password := child.BindArgsForCall(0)
+				Expect(user).To(Equal("svcuser"))
+				Expect(password).To(Equal("svcpw"))
+				Expect(child.SearchArgsForCall(0).BaseDN).To(Equal("DC=child,DC=corp,DC=example,DC=com"))
+				Expect(child.SearchArgsForCall(0).Filter).To(Equal("(&(objectClass=User)(cn=user))"))
+			})
+
+			It("verifies the password on the server holding the user", func() {
+				Expect(servers["host:111"].SimpleBindCallCount()).To(BeZero())
+				Expect(servers["child.corp.example.com:389"].SimpleBindCallCount()).To(Equal(1))
+				Expect(servers["child.corp.example.com:389"].SimpleBindArgsForCall(0).Username).To(Equal("CN=user,DC=child,DC=corp,DC=example,DC=com"))
+				Expect(logger).To(gbytes.Say("user-found-by-referral"))
+			})
+
+			Context("when TLS is required", func() {
+				BeforeEach(func() {
+					options.ReferralTLS = nfsv3driver.ReferralTLSRequired
+				})
+
+				It("upgrades plain referrals with StartTLS", func() {
+					Expect(err).NotTo(HaveOccurred())
+					child := servers["child.corp.example.com:389"]
+					Expect(child.StartTLSCallCount()).To(Equal(2))
+					Expect(child.StartTLSArgsForCall(0).ServerName).To(Equal("child.corp.example.com"))
+				})
+
+				Context("and StartTLS fails", func() {
+					BeforeEach(func() {
+						servers["child.corp.example.com:389"].StartTLSReturns(errors.New("unsupported extended operation"))
+					})
+
+					It("does not follow the referral", func() {
+						Expect(err).To(MatchError(nfsv3driver.UserNotFoundErrorMessage))
+						Expect(servers["child.corp.example.com:389"].SearchCallCount()).To(BeZero())
+						Expect(logger).To(gbytes.Say("failed-to-follow-referral.*unsupported extended operation"))
+					})
+				})
+			})
+
+			Context("when the referral uses ldaps", func() {
+				BeforeEach(func() {
+					servers["host:111"].SearchReturns(&ldap.SearchResult{Referrals: []string{"ldaps://child.corp.example.com/DC=child,DC=corp,DC=example,DC=com"}}, nil)
+					servers["child.corp.example.com:636"].SearchReturns(&ldap.SearchResult{Entries: []*ldap.Entry{userEntry("CN=user,DC=child")}}, nil)
+				})
+
+				It("connects with TLS to the default ldaps port", func() {
+					Expect(err).NotTo(HaveOccurred())
+					_, addr, config := ldapFake.DialTLSArgsForCall(0)
+					Expect(addr).To(Equal("child.corp.example.com:636"))
+					Expect(config.ServerName).To(Equal("child.corp.example.com"))
+				})
+			})
+
+			Context("when the user is found on more than one server", func() {
+				BeforeEach(func() {
+					servers["host:111"].SearchReturns(&ldap.SearchResult{Entries: []*ldap.Entry{userEntry("cn=user")}, Referrals: []string{childReferral}}, nil)
+				})
+
+				It("reports an ambiguous search", func() {
+					Expect(err).To(MatchError("Ambiguous search--too many results"))
+				})
+			})
+
+			Context("when referrals lead further than the hop limit", func() {
+				BeforeEach(func() {
+					options.MaxReferralHops = 1
+					servers["child.corp.example.com:389"].SearchReturns(&ldap.SearchResult{Referrals: []string{"ldap://grandchild.example.com/DC=grandchild"}}, nil)
+					servers["grandchild.example.com:389"].SearchReturns(&ldap.SearchResult{Entries: []*ldap.Entry{userEntry("cn=user")}}, nil)
+				})
+
+				It("stops following them", func() {
+					Expect(err).To(MatchError(nfsv3driver.UserNotFoundErrorMessage))
+					Expect(servers["grandchild.example.com:389"].SearchCallCount()).To(BeZero())
+					Expect(logger).To(gbytes.Say(`referral-hop-limit-reached.*"referral":"ldap://grandchild.example.com/DC=grandchild"`))
+				})
+			})
+
+			Context("when referrals form a loop", func() {
+				BeforeEach(func() {
+					options.MaxReferralHops = 10
+					servers["child.corp.example.com:389"].SearchReturns(&ldap.SearchResult{Referrals: []string{childReferral}}, nil)
+				})
+
+				It("follows each referral once", func() {
+					Expect(err).To(MatchError(nfsv3driver.UserNotFoundErrorMessage))
+					Expect(servers["child.corp.example.com:389"].SearchCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("when some referrals cannot be followed", func() {
+				BeforeEach(func() {
+					servers["host:111"].SearchReturns(&ldap.SearchResult{Referrals: []string{
+						"http://child.corp.example.com/",
+						"ldap://unreachable.example.com/DC=unreachable",
+						childReferral,
+					}}, nil)
+				})
+
+				It("skips them and uses the rest", func() {
+					Expect(err).NotTo(HaveOccurred())
+					Expect(logger).To(gbytes.Say(`invalid-referral.*unsupported referral scheme 'http'`))
+					Expect(logger).To(gbytes.Say(`failed-to-follow-referral.*connection refused`))
+				})
+			})
+		})
+	})
+
+	DescribeTable("validating search options",
+		func(options nfsv3driver.LdapSearchOptions, expectedError string) {
+			err := options.Validate()
+			if expectedError == "" {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(expectedError))
+			}
+		},
+		Entry("defaults", nfsv3driver.LdapSearchOptions{}, ""),
+		Entry("chasing with TLS required", nfsv3driver.LdapSearchOptions{ChaseReferrals: true, MaxReferralHops: 3, ReferralTLS: nfsv3driver.ReferralTLSRequired}, ""),
+		Entry("negative hops", nfsv3driver.LdapSearchOptions{MaxReferralHops: -1}, "maximum referral hops must not be negative"),
+		Entry("unknown TLS policy", nfsv3driver.LdapSearchOptions{ChaseReferrals: true, ReferralTLS: "sometimes"}, "unknown referral TLS policy 'sometimes'"),
+	)
+})
@@ -0,0 +1,146 @@
+package nfsv3driver
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/goshims/timeshim"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+// LogController is also a sink for the logger, through which it writes the debug logs of the volumes and NFS
+// servers being debugged while the log level is above debug
+type LogController interface {
+	lager.Sink
+	driveradmin.LogController
+	// SetVolumeServers lets an NFS server's scope cover the logs of its volumes, which rarely name the server
+	SetVolumeServers(servers VolumeServers)
+}
+
+//counterfeiter:generate -o nfsdriverfakes/fake_volume_servers.go . VolumeServers
+
+// VolumeServers names the NFS server that a volume is mounted from, or returns empty if it does not know the volume
+type VolumeServers interface {
+	VolumeServer(volume string) string
+}
+
+type logController struct {
+	sink   lager.Sink
+	levels *lager.ReconfigurableSink
+	time   timeshim.Time
+
+	lock    sync.Mutex
+	scopes  []driveradmin.DebugScope
+	servers VolumeServers
+}
+
+// NewLogController sets the level of levels, and writes matching debug logs to sink, which levels should also write to
+func NewLogController(sink lager.Sink, levels *lager.ReconfigurableSink, time timeshim.Time) LogController {
+	return &logController{sink: sink, levels: levels, time: time}
+}
+
+func (c *logController) SetVolumeServers(servers VolumeServers) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.servers = servers
+}
+
+func (c *logController) Log(log lager.LogFormat) {
+	if log.LogLevel != lager.DEBUG || c.levels.GetMinLevel() <= lager.DEBUG {
+		return
+	}
+
+	if c.debugging(log.Data) {
+		c.sink.Log(log)
+	}
+}
+
+func (c *logController) debugging(data lager.Data) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if len(c.scopes) == 0 {
+		return false
+	}
+	now := c.time.Now()
+	volume, _ := data["volume"].(string)
+	volumeServer := ""
+	for _, scope := range c.scopes {
+		if !now.Before(scope.Until) {
+			continue
+		}
+		if scope.Volume != "" && volume == scope.Volume {
+			return true
+		}
+		if scope.Server == "" {
+			continue
+		}
+		if logsServer(data, scope.Server) {
+			return true
+		}
+		if volumeServer == "" && volume != "" && c.servers != nil {
+			volumeServer = c.servers.VolumeServer(volume)
+		}
+		if volumeServer == scope.Server {
+			return true
+		}
+	}
+	return false
+}
+
+// logsServer matches the data logged about the NFS server itself, which names the server or a source on it
+func logsServer(data lager.Data, server string) bool {
+	if data["server"] == server {
+		return true
+	}
+	source, ok := data["source"].(string)
+	return ok && sourceServer(source) == server
+}
+
+func (c *logController) LogLevel() driveradmin.LogLevelStatus {
+	return driveradmin.LogLevelStatus{Level: c.levels.GetMinLevel().String(), DebugScopes: c.activeScopes()}
+}
+
+func (c *logController) SetLogLevel(level string) (driveradmin.LogLevelStatus, error) {
+	minLevel, err := lager.LogLevelFromString(level)
+	if err != nil {
+		return driveradmin.LogLevelStatus{}, err
+	}
+	c.levels.SetMinLevel(minLevel)
+	return c.LogLevel(), nil
+}
+
+// EnableDebugLogging replaces any scope for the same volume or server, so a scope can be extended or cut short
+func (c *logController) EnableDebugLogging(volume string, server string, duration time.Duration) driveradmin.LogLevelStatus {
+	scope := driveradmin.DebugScope{Volume: volume, Server: server, Until: c.time.Now().Add(duration)}
+
+	c.lock.Lock()
+	scopes := []driveradmin.DebugScope{scope}
+	for _, existing := range c.scopes {
+		if existing.Volume != scope.Volume || existing.Server != scope.Server {
+			scopes = append(scopes, existing)
+		}
+	}
+	c.scopes = scopes
+	c.lock.Unlock()
+
+	return c.LogLevel()
+}
+
+// activeScopes drops the scopes that have run out, which reverts their volumes and servers to the log level
+func (c *logController) activeScopes() []driveradmin.DebugScope {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.time.Now()
+	active := []driveradmin.DebugScope{}
+	for _, scope := range c.scopes {
+		if now.Before(scope.Until) {
+			active = append(active, scope)
+		}
+	}
+	c.scopes = active
+	return active
+}
@@ -0,0 +1,179 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/filepathshim"
+	"code.cloudfoundry.org/goshims/ioutilshim"
+	"code.cloudfoundry.org/goshims/ioutilshim/ioutil_fake"
+	"code.cloudfoundry.org/goshims/osshim"
+	"code.cloudfoundry.org/goshims/osshim/os_fake"
+	"code.cloudfoundry.org/goshims/syscallshim/syscall_fake"
+	"code.cloudfoundry.org/goshims/timeshim"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
+	"code.cloudfoundry.org/volumedriver"
+	"code.cloudfoundry.org/volumedriver/invokerfakes"
+	"code.cloudfoundry.org/volumedriver/oshelper"
+	"code.cloudfoundry.org/volumedriver/volumedriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LogController", func() {
+	var (
+		sink       *lagertest.TestSink
+		levels     *lager.ReconfigurableSink
+		fakeTime   *nfsdriverfakes.FakeTime
+		now        time.Time
+		controller nfsv3driver.LogController
+		logger     lager.Logger
+	)
+
+	BeforeEach(func() {
+		sink = lagertest.NewTestSink()
+		levels = lager.NewReconfigurableSink(sink, lager.INFO)
+		now = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+		fakeTime = &nfsdriverfakes.FakeTime{}
+		fakeTime.NowReturns(now)
+
+		controller = nfsv3driver.NewLogController(sink, levels, fakeTime)
+		logger = lager.NewLogger("log-controller")
+		logger.RegisterSink(levels)
+		logger.RegisterSink(controller)
+	})
+
+	It("reports the log level", func() {
+		Expect(controller.LogLevel()).To(Equal(driveradmin.LogLevelStatus{Level: "info", DebugScopes: []driveradmin.DebugScope{}}))
+	})
+
+	It("changes the log level", func() {
+		status, err := controller.SetLogLevel("debug")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Level).To(Equal("debug"))
+
+		logger.Debug("visible")
+		Expect(sink.LogMessages()).To(Equal([]string{"log-controller.visible"}))
+	})
+
+	It("rejects unknown log levels", func() {
+		_, err := controller.SetLogLevel("verbose")
+		Expect(err).To(HaveOccurred())
+		Expect(levels.GetMinLevel()).To(Equal(lager.INFO))
+	})
+
+	Context("when a volume is being debugged", func() {
+		BeforeEach(func() {
+			status := controller.EnableDebugLogging("vol1", "", 10*time.Minute)
+			Expect(status.DebugScopes).To(Equal([]driveradmin.DebugScope{{Volume: "vol1", Until: now.Add(10 * time.Minute)}}))
+		})
+
+		It("writes its debug logs and no others", func() {
+			logger.Session("mount", lager.Data{"volume": "vol1"}).Debug("for-vol1")
+			logger.Session("mount", lager.Data{"volume": "vol2"}).Debug("for-vol2")
+			logger.Debug("unscoped")
+
+			Expect(sink.LogMessages()).To(Equal([]string{"log-controller.mount.for-vol1"}))
+		})
+
+		It("does not write them twice once the log level is debug", func() {
+			levels.SetMinLevel(lager.DEBUG)
+			logger.Session("mount", lager.Data{"volume": "vol1"}).Debug("for-vol1")
+
+			Expect(sink.LogMessages()).To(HaveLen(1))
+		})
+
+		It("reverts once the duration has passed", func() {
+			fakeTime.NowReturns(now.Add(10 * time.Minute))
+			logger.Session("mount", lager.Data{"volume": "vol1"}).Debug("for-vol1")
+
+			Expect(sink.LogMessages()).To(BeEmpty())
+			Expect(controller.LogLevel().DebugScopes).To(BeEmpty())
+		})
+
+		It("replaces the scope when the volume is debugged again", func() {
+			status := controller.EnableDebugLogging("vol1", "", time.Minute)
+			Expect(status.DebugScopes).To(Equal([]driveradmin.DebugScope{{Volume: "vol1", Until: now.Add(time.Minute)}}))
+		})
+	})
+
+	Context("when an NFS server is being debugged", func() {
+		BeforeEach(func() {
+			controller.EnableDebugLogging("", "nfs1.example.com", 10*time.Minute)
+		})
+
+		It("writes the debug logs of mounts from it", func() {
+			logger.Session("mount", lager.Data{"source": "nfs1.example.com:/export/a"}).Debug("from-source")
+			logger.Session("mount", lager.Data{"source": "nfs://nfs1.example.com/export/b"}).Debug("from-legacy-source")
+			logger.Session("volumes", lager.Data{"server": "nfs1.example.com"}).Debug("from-server")
+			logger.Session("mount", lager.Data{"source": "nfs2.example.com:/export/a"}).Debug("from-other-server")
+
+			Expect(sink.LogMessages()).To(Equal([]string{
+				"log-controller.mount.from-source",
+				"log-controller.mount.from-legacy-source",
+				"log-controller.volumes.from-server",
+			}))
+		})
+
+		Context("when it knows the servers of the volumes", func() {
+			BeforeEach(func() {
+				fakeServers := &nfsdriverfakes.FakeVolumeServers{}
+				fakeServers.VolumeServerStub = func(volume string) string {
+					return map[string]string{"vol1": "nfs1.example.com", "vol2": "nfs2.example.com"}[volume]
+				}
+				controller.SetVolumeServers(fakeServers)
+			})
+
+			It("writes the debug logs of the volumes mounted from it", func() {
+				logger.Session("mount", lager.Data{"volume": "vol1"}).Debug("for-vol1")
+				logger.Session("mount", lager.Data{"volume": "vol2"}).Debug("for-vol2")
+				logger.Session("mount", lager.Data{"volume": "unknown"}).Debug("for-unknown")
+
+				Expect(sink.LogMessages()).To(Equal([]string{"log-controller.mount.for-vol1"}))
+			})
+		})
+
+		Context("when a volume on it is mounted", func() {
+			var driver nfsv3driver.CordoningDriver
+
+			BeforeEach(func() {
+				mask, err := nfsv3driver.NewMapFsVolumeMountMask()
+				Expect(err).NotTo(HaveOccurred())
+				mounter := nfsv3driver.NewMapfsMounter(&invokerfakes.FakeInvoker{}, &os_fake.FakeOs{}, &syscall_fake.FakeSyscall{}, &ioutil_fake.FakeIoutil{}, &volumedriverfakes.FakeMountChecker{}, "nfs", "", nil, nil, nil, nil, nfsv3driver.IdPolicy{}, mask, "/var/vcap/packages/mapfs/bin/mapfs")
+				volumeDriver := volumedriver.NewVolumeDriver(
+					logger,
+					&osshim.OsShim{},
+					&filepathshim.FilepathShim{},
+					&ioutilshim.IoutilShim{},
+					&timeshim.TimeShim{},
+					&volumedriverfakes.FakeMountChecker{},
+					GinkgoT().TempDir(),
+					mounter,
+					oshelper.NewOsHelper(),
+				)
+				driver = nfsv3driver.NewCordoningDriver(logger, volumeDriver, &ioutilshim.IoutilShim{}, filepath.Join(GinkgoT().TempDir(), "cordon-state.json"))
+				controller.SetVolumeServers(driver)
+			})
+
+			It("writes the debug logs of the mount, which only name the volume", func() {
+				env := driverhttp.NewHttpDriverEnv(logger, context.TODO())
+				Expect(driver.Create(env, dockerdriver.CreateRequest{Name: "vol1", Opts: map[string]interface{}{
+					"source": "nfs1.example.com:/export/a",
+					"colour": "blue",
+				}}).Err).To(BeEmpty())
+				// the invalid option stops the mount before anything is run
+				Expect(driver.Mount(env, dockerdriver.MountRequest{Name: "vol1"}).Err).To(ContainSubstring("Not allowed options"))
+
+				Expect(sink.LogMessages()).To(ContainElement("log-controller.mount.persist-state.state-saved"))
+				Expect(sink.LogMessages()).NotTo(ContainElement("log-controller.create.persist-state.state-saved"))
+			})
+		})
+	})
+})
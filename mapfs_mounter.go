@@ -31,16 +31,20 @@ const InvalidUidValueErrorMessage = "Invalid 'uid' option (0, negative, or non-i
 const InvalidGidValueErrorMessage = "Invalid 'gid' option (0, negative, or non-integer)"
 
 type mapfsMounter struct {
-	invoker      invoker.Invoker
-	osshim       osshim.Os
-	syscallshim  syscallshim.Syscall
-	ioutilshim   ioutilshim.Ioutil
-	mountChecker mountchecker.MountChecker
-	fstype       string
-	defaultOpts  string
-	resolver     IdResolver
-	mask         vmo.MountOptsMask
-	mapfsPath    string
+	invoker       invoker.Invoker
+	osshim        osshim.Os
+	syscallshim   syscallshim.Syscall
+	ioutilshim    ioutilshim.Ioutil
+	mountChecker  mountchecker.MountChecker
+	fstype        string
+	defaultOpts   string
+	resolver      IdResolver
+	tokenResolver TokenResolver
+	tokenIds      TokenIdCache
+	decrypter     PasswordDecrypter
+	idPolicy      IdChecker
+	mask          vmo.MountOptsMask
+	mapfsPath     string
 }
 
 var legacyNfsSharePattern *regexp.Regexp
@@ -60,10 +64,14 @@ func NewMapfsMounter(
 	fstype string,
 	defaultOpts string,
 	resolver IdResolver,
+	tokenResolver TokenResolver,
+	tokenIds TokenIdCache,
+	decrypter PasswordDecrypter,
+	idPolicy IdChecker,
 	mask vmo.MountOptsMask,
 	mapfsPath string,
 ) volumedriver.Mounter {
-	return &mapfsMounter{invoker, osshim, syscallshim, ioutilshim, mountChecker, fstype, defaultOpts, resolver, mask, mapfsPath}
+	return &mapfsMounter{invoker, osshim, syscallshim, ioutilshim, mountChecker, fstype, defaultOpts, resolver, tokenResolver, tokenIds, decrypter, idPolicy, mask, mapfsPath}
 }
 
 func (m *mapfsMounter) Mount(env dockerdriver.Env, remote string, target string, opts map[string]interface{}) error {
@@ -71,6 +79,51 @@ func (m *mapfsMounter) Mount(env dockerdriver.Env, remote string, target string,
 	logger.Info("mount-start")
 	defer logger.Info("mount-end")
 
+	opts, secrets, err := ExtractSecretOptions(opts)
+	if err != nil {
+		return err
+	}
+	defer secrets.Zero()
+
+	// keytabs are only kept out of the logs; mapfs cannot use them
+	if _, ok := secrets["keytab"]; ok {
+		return dockerdriver.SafeError{SafeDescription: "Not allowed options: keytab"}
+	}
+
+	if token, ok := secrets["token"]; ok {
+		if len(secrets) > 1 {
+			return dockerdriver.SafeError{SafeDescription: "Not allowed options"}
+		}
+		for _, option := range []string{"uid", "gid", "username"} {
+			if _, found := opts[option]; found {
+				return dockerdriver.SafeError{SafeDescription: "Not allowed options"}
+			}
+		}
+
+		if m.tokenResolver == nil {
+			return dockerdriver.SafeError{SafeDescription: "Identity token is specified but token verification is not configured"}
+		}
+
+		uid, gid, found := "", "", false
+		if m.tokenIds != nil {
+			uid, gid, found = m.tokenIds.TokenIds(target)
+		}
+		if found {
+			logger.Info("reusing-token-ids", lager.Data{"uid": uid, "gid": gid})
+		} else {
+			uid, gid, err = m.tokenResolver.ResolveToken(env, token.Reveal())
+			if err != nil {
+				return err
+			}
+			if m.tokenIds != nil {
+				m.tokenIds.SetTokenIds(target, uid, gid)
+			}
+		}
+
+		opts["uid"] = uid
+		opts["gid"] = gid
+	}
+
 	if username, ok := opts["username"]; ok {
 		if _, found := opts["uid"]; found {
 			return dockerdriver.SafeError{SafeDescription: "Not allowed options"}
@@ -83,12 +136,13 @@ func (m *mapfsMounter) Mount(env dockerdriver.Env, remote string, target string,
 		if m.resolver == nil {
 			return dockerdriver.SafeError{SafeDescription: "LDAP username is specified but LDAP is not configured"}
 		}
-		password, ok := opts["password"]
-		if !ok {
-			return dockerdriver.SafeError{SafeDescription: "LDAP username is specified but LDAP password is missing"}
+		password, err := m.password(logger, secrets)
+		if err != nil {
+			return err
 		}
+		defer password.Zero()
 
-		uid, gid, err := m.resolver.Resolve(env, username.(string), password.(string))
+		uid, gid, err := m.resolver.Resolve(env, uniformData(username), password.Reveal())
 		if err != nil {
 			return err
 		}
@@ -103,6 +157,18 @@ func (m *mapfsMounter) Mount(env dockerdriver.Env, remote string, target string,
 		return dockerdriver.SafeError{SafeDescription: "required 'gid' option is missing"}
 	}
 
+	if uidok {
+		// malformed ids are reported below
+		uid, uidErr := strconv.ParseUint(uniformData(opts["uid"]), 10, 32)
+		gid, gidErr := strconv.ParseUint(uniformData(opts["gid"]), 10, 32)
+		if uidErr == nil && gidErr == nil {
+			if err := m.idPolicy.Check(uint32(uid), uint32(gid)); err != nil {
+				logger.Info("id-not-allowed", lager.Data{"reason": err.Error()})
+				return dockerdriver.SafeError{SafeDescription: IdNotAllowedErrorMessage}
+			}
+		}
+	}
+
 	optsToUse, err := vmo.NewMountOpts(opts, m.mask)
 	if err != nil {
 		logger.Debug("mount-options-failed", lager.Data{
@@ -267,6 +333,31 @@ func (m *mapfsMounter) Mount(env dockerdriver.Env, remote string, target string,
 	return nil
 }
 
+// password returns the LDAP password from either the 'password' or the 'encrypted_password' option
+func (m *mapfsMounter) password(logger lager.Logger, secrets SecretOptions) (*Secret, error) {
+	password, plainOk := secrets["password"]
+	encrypted, encryptedOk := secrets["encrypted_password"]
+
+	switch {
+	case plainOk && encryptedOk:
+		return nil, dockerdriver.SafeError{SafeDescription: "Not allowed options"}
+	case encryptedOk:
+		if m.decrypter == nil {
+			return nil, dockerdriver.SafeError{SafeDescription: "Encrypted password is specified but password encryption is not configured"}
+		}
+		plaintext, err := m.decrypter.DecryptPassword(encrypted.Reveal())
+		if err != nil {
+			logger.Info("failed-to-decrypt-password", lager.Data{"reason": err.Error()})
+			return nil, dockerdriver.SafeError{SafeDescription: InvalidEncryptedPasswordErrorMessage}
+		}
+		return newSecretFromBytes(plaintext), nil
+	case plainOk:
+		return password, nil
+	default:
+		return nil, dockerdriver.SafeError{SafeDescription: "LDAP username is specified but LDAP password is missing"}
+	}
+}
+
 func (m *mapfsMounter) Unmount(env dockerdriver.Env, target string) error {
 	logger := env.Logger().Session("unmount")
 	logger.Info("unmount-start")
@@ -388,7 +479,7 @@ func (m *mapfsMounter) Purge(env dockerdriver.Env, path string) {
 }
 
 func NewMapFsVolumeMountMask() (vmo.MountOptsMask, error) {
-	allowed := []string{"auto_cache", "mount", "source", "experimental", "uid", "gid", "username", "password", "readonly", "version", "cache"}
+	allowed := []string{"auto_cache", "mount", "source", "experimental", "uid", "gid", "username", "password", "encrypted_password", "token", "readonly", "version", "cache"}
 
 	defaultMap := map[string]interface{}{
 		"auto_cache": "true",
@@ -85,7 +85,7 @@ var _ = Describe("MapfsMounter", func() {
 		mask, err = nfsv3driver.NewMapFsVolumeMountMask()
 		Expect(err).NotTo(HaveOccurred())
 
-		subject = nfsv3driver.NewMapfsMounter(fakeInvoker, fakeOs, fakeSyscall, fakeIoutil, fakeMountChecker, "my-fs", "my-mount-options,timeo=600,retrans=2,actimeo=0", nil, mask, mapfsPath)
+		subject = nfsv3driver.NewMapfsMounter(fakeInvoker, fakeOs, fakeSyscall, fakeIoutil, fakeMountChecker, "my-fs", "my-mount-options,timeo=600,retrans=2,actimeo=0", nil, nil, nil, nil, nfsv3driver.IdPolicy{}, mask, mapfsPath)
 	})
 
 	Context("#Mount", func() {
@@ -338,7 +338,7 @@ var _ = Describe("MapfsMounter", func() {
 			DescribeTable("when the mount has a legacy format", func(legacySourceFormat string, expectedShareFormat string) {
 				fakeInvoker = &invokerfakes.FakeInvoker{}
 				fakeInvoker.InvokeReturns(fakeInvokeResult)
-				subject = nfsv3driver.NewMapfsMounter(fakeInvoker, fakeOs, fakeSyscall, fakeIoutil, fakeMountChecker, "my-fs", "my-mount-options,timeo=600,retrans=2,actimeo=0", nil, mask, mapfsPath)
+				subject = nfsv3driver.NewMapfsMounter(fakeInvoker, fakeOs, fakeSyscall, fakeIoutil, fakeMountChecker, "my-fs", "my-mount-options,timeo=600,retrans=2,actimeo=0", nil, nil, nil, nil, nfsv3driver.IdPolicy{}, mask, mapfsPath)
 
 				err = subject.Mount(env, legacySourceFormat, target, opts)
 				Expect(err).NotTo(HaveOccurred())
@@ -601,6 +601,18 @@ var _ = Describe("MapfsMounter", func() {
 			})
 		})
 
+		Context("when a keytab is passed", func() {
+			BeforeEach(func() {
+				opts["keytab"] = "BQIAAAA3AAEAC0VYQU1QTEUuQ09N"
+			})
+
+			It("should error without mounting", func() {
+				Expect(err).To(MatchError("Not allowed options: keytab"))
+				Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+				Expect(fakeInvoker.InvokeCallCount()).To(BeZero())
+			})
+		})
+
 		Context("when mount errors", func() {
 			BeforeEach(func() {
 				fakeInvoker.InvokeReturns(fakeInvokeResult)
@@ -697,11 +709,60 @@ var _ = Describe("MapfsMounter", func() {
 			})
 		})
 
+		Context("when the operator restricts uids and gids", func() {
+			var fakeIdResolver *nfsdriverfakes.FakeIdResolver
+
+			BeforeEach(func() {
+				fakeIdResolver = &nfsdriverfakes.FakeIdResolver{}
+				policy := nfsv3driver.IdPolicy{
+					AllowedUids: []nfsv3driver.IdRange{{Min: 1000, Max: 59999}},
+					DeniedUids:  []nfsv3driver.IdRange{{Min: 5000, Max: 5999}},
+					DeniedGids:  []nfsv3driver.IdRange{{Min: 0, Max: 999}},
+				}
+				subject = nfsv3driver.NewMapfsMounter(fakeInvoker, fakeOs, fakeSyscall, fakeIoutil, fakeMountChecker, "my-fs", "my-mount-options", fakeIdResolver, nil, nil, nil, policy, mask, mapfsPath)
+			})
+
+			It("mounts with an allowed uid and gid", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			DescribeTable("rejecting ids outside the policy before mounting",
+				func(uid, gid string) {
+					opts["uid"] = uid
+					opts["gid"] = gid
+					err = subject.Mount(env, source, target, opts)
+					Expect(err).To(MatchError(nfsv3driver.IdNotAllowedErrorMessage))
+					Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+					Expect(fakeInvoker.InvokeCallCount()).To(Equal(2))
+					Expect(logger).To(gbytes.Say("id-not-allowed"))
+				},
+				Entry("a system uid", "1", "2000"),
+				Entry("a denied uid", "5500", "2000"),
+				Entry("a uid above the allowed range", "60000", "2000"),
+				Entry("a denied gid", "2000", "10"),
+			)
+
+			Context("when a resolved user maps to a denied uid", func() {
+				BeforeEach(func() {
+					delete(opts, "uid")
+					delete(opts, "gid")
+					opts["username"] = "daemon"
+					opts["password"] = "test-pw"
+					fakeIdResolver.ResolveReturns("1", "1", nil)
+				})
+
+				It("should error", func() {
+					Expect(err).To(MatchError(nfsv3driver.IdNotAllowedErrorMessage))
+					Expect(fakeInvoker.InvokeCallCount()).To(BeZero())
+				})
+			})
+		})
+
 		Context("when provided a username to map to a uid", func() {
 			BeforeEach(func() {
 				fakeIdResolver = &nfsdriverfakes.FakeIdResolver{}
 
-				subject = nfsv3driver.NewMapfsMounter(fakeInvoker, fakeOs, fakeSyscall, fakeIoutil, fakeMountChecker, "my-fs", "my-mount-options", fakeIdResolver, mask, mapfsPath)
+				subject = nfsv3driver.NewMapfsMounter(fakeInvoker, fakeOs, fakeSyscall, fakeIoutil, fakeMountChecker, "my-fs", "my-mount-options", fakeIdResolver, nil, nil, nil, nfsv3driver.IdPolicy{}, mask, mapfsPath)
 				fakeIdResolver.ResolveReturns("100", "100", nil)
 
 				delete(opts, "uid")
@@ -724,6 +785,94 @@ var _ = Describe("MapfsMounter", func() {
 				Expect(strings.Join(args, " ")).To(ContainSubstring("-gid 100"))
 			})
 
+			Context("when the password is encrypted", func() {
+				var fakeDecrypter *nfsdriverfakes.FakePasswordDecrypter
+
+				BeforeEach(func() {
+					fakeDecrypter = &nfsdriverfakes.FakePasswordDecrypter{}
+					fakeDecrypter.DecryptPasswordReturns([]byte("decrypted-pw"), nil)
+					subject = nfsv3driver.NewMapfsMounter(fakeInvoker, fakeOs, fakeSyscall, fakeIoutil, fakeMountChecker, "my-fs", "my-mount-options", fakeIdResolver, nil, nil, fakeDecrypter, nfsv3driver.IdPolicy{}, mask, mapfsPath)
+
+					delete(opts, "password")
+					opts["encrypted_password"] = "Y2lwaGVydGV4dA=="
+				})
+
+				It("resolves the user with the decrypted password", func() {
+					Expect(err).NotTo(HaveOccurred())
+					Expect(fakeDecrypter.DecryptPasswordArgsForCall(0)).To(Equal("Y2lwaGVydGV4dA=="))
+					_, username, password := fakeIdResolver.ResolveArgsForCall(0)
+					Expect(username).To(Equal("test-user"))
+					Expect(password).To(Equal("decrypted-pw"))
+				})
+
+				It("does not keep the decrypted password", func() {
+					Expect(opts).NotTo(ContainElement("decrypted-pw"))
+					Expect(logger).NotTo(gbytes.Say("decrypted-pw"))
+				})
+
+				Context("when the password cannot be decrypted", func() {
+					BeforeEach(func() {
+						fakeDecrypter.DecryptPasswordReturns(nil, errors.New("invalid encrypted password"))
+					})
+
+					It("should error", func() {
+						Expect(err).To(MatchError(nfsv3driver.InvalidEncryptedPasswordErrorMessage))
+						Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+						Expect(fakeIdResolver.ResolveCallCount()).To(BeZero())
+					})
+				})
+
+				Context("when a plaintext password is passed as well", func() {
+					BeforeEach(func() {
+						opts["password"] = "test-pw"
+					})
+
+					It("should error", func() {
+						Expect(err).To(MatchError("Not allowed options"))
+					})
+				})
+
+				Context("when password encryption is not configured", func() {
+					BeforeEach(func() {
+						subject = nfsv3driver.NewMapfsMounter(fakeInvoker, fakeOs, fakeSyscall, fakeIoutil, fakeMountChecker, "my-fs", "my-mount-options", fakeIdResolver, nil, nil, nil, nfsv3driver.IdPolicy{}, mask, mapfsPath)
+					})
+
+					It("should error", func() {
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("password encryption is not configured"))
+					})
+				})
+			})
+
+			It("leaves the caller's options untouched", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(opts).To(HaveKeyWithValue("password", "test-pw"))
+				Expect(opts).NotTo(HaveKey("uid"))
+			})
+
+			Context("when another option is invalid", func() {
+				BeforeEach(func() {
+					opts["unknown"] = "value"
+				})
+
+				It("does not log the password", func() {
+					Expect(err).To(HaveOccurred())
+					Expect(logger).To(gbytes.Say("mount-options-failed"))
+					Expect(logger.Buffer().Contents()).NotTo(ContainSubstring("test-pw"))
+				})
+			})
+
+			Context("when the password is not a string", func() {
+				BeforeEach(func() {
+					opts["password"] = 12345
+				})
+
+				It("should error", func() {
+					Expect(err).To(MatchError("Invalid 'password' option"))
+					Expect(fakeIdResolver.ResolveCallCount()).To(BeZero())
+				})
+			})
+
 			Context("when username is passed but password is not passed", func() {
 				BeforeEach(func() {
 					delete(opts, "password")
@@ -797,6 +946,106 @@ var _ = Describe("MapfsMounter", func() {
 				})
 			})
 		})
+
+		Context("when provided an identity token", func() {
+			var fakeTokenResolver *nfsdriverfakes.FakeTokenResolver
+
+			BeforeEach(func() {
+				fakeTokenResolver = &nfsdriverfakes.FakeTokenResolver{}
+				fakeTokenResolver.ResolveTokenReturns("200", "300", nil)
+
+				subject = nfsv3driver.NewMapfsMounter(fakeInvoker, fakeOs, fakeSyscall, fakeIoutil, fakeMountChecker, "my-fs", "my-mount-options", nil, fakeTokenResolver, nil, nil, nfsv3driver.IdPolicy{}, mask, mapfsPath)
+
+				delete(opts, "uid")
+				delete(opts, "gid")
+				opts["token"] = "header.claims.signature"
+			})
+
+			It("resolves the token", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeTokenResolver.ResolveTokenCallCount()).To(Equal(1))
+				_, token := fakeTokenResolver.ResolveTokenArgsForCall(0)
+				Expect(token).To(Equal("header.claims.signature"))
+			})
+
+			It("maps to the token's uid and gid without passing the token on", func() {
+				Expect(err).NotTo(HaveOccurred())
+				_, _, args, _ := fakeInvoker.InvokeArgsForCall(1)
+				Expect(strings.Join(args, " ")).To(ContainSubstring("-uid 200"))
+				Expect(strings.Join(args, " ")).To(ContainSubstring("-gid 300"))
+				Expect(strings.Join(args, " ")).NotTo(ContainSubstring("header.claims.signature"))
+			})
+
+			Context("when the ids of the volume's token are remembered", func() {
+				var fakeTokenIds *nfsdriverfakes.FakeTokenIdCache
+
+				BeforeEach(func() {
+					fakeTokenIds = &nfsdriverfakes.FakeTokenIdCache{}
+					subject = nfsv3driver.NewMapfsMounter(fakeInvoker, fakeOs, fakeSyscall, fakeIoutil, fakeMountChecker, "my-fs", "my-mount-options", nil, fakeTokenResolver, fakeTokenIds, nil, nfsv3driver.IdPolicy{}, mask, mapfsPath)
+				})
+
+				It("remembers the ids the token resolved to", func() {
+					Expect(err).NotTo(HaveOccurred())
+					Expect(fakeTokenIds.SetTokenIdsCallCount()).To(Equal(1))
+					rememberedTarget, uid, gid := fakeTokenIds.SetTokenIdsArgsForCall(0)
+					Expect(rememberedTarget).To(Equal(target))
+					Expect(uid).To(Equal("200"))
+					Expect(gid).To(Equal("300"))
+				})
+
+				Context("when the volume is mounted again", func() {
+					BeforeEach(func() {
+						fakeTokenIds.TokenIdsReturns("200", "300", true)
+					})
+
+					It("maps to the remembered ids without spending the token again", func() {
+						Expect(err).NotTo(HaveOccurred())
+						Expect(fakeTokenResolver.ResolveTokenCallCount()).To(BeZero())
+						Expect(fakeTokenIds.TokenIdsArgsForCall(0)).To(Equal(target))
+						_, _, args, _ := fakeInvoker.InvokeArgsForCall(1)
+						Expect(strings.Join(args, " ")).To(ContainSubstring("-uid 200"))
+						Expect(strings.Join(args, " ")).To(ContainSubstring("-gid 300"))
+					})
+				})
+			})
+
+			DescribeTable("when combined with other identity options",
+				func(option string) {
+					opts[option] = "100"
+					err = subject.Mount(env, source, target, opts)
+					Expect(err).To(MatchError("Not allowed options"))
+					Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+				},
+				Entry("uid", "uid"),
+				Entry("gid", "gid"),
+				Entry("username", "username"),
+				Entry("password", "password"),
+				Entry("encrypted password", "encrypted_password"),
+			)
+
+			Context("when the token is rejected", func() {
+				BeforeEach(func() {
+					fakeTokenResolver.ResolveTokenReturns("", "", dockerdriver.SafeError{SafeDescription: nfsv3driver.InvalidTokenErrorMessage})
+				})
+
+				It("should error", func() {
+					Expect(err).To(MatchError(nfsv3driver.InvalidTokenErrorMessage))
+					Expect(fakeInvoker.InvokeCallCount()).To(BeZero())
+				})
+			})
+
+			Context("when tokens are not configured", func() {
+				BeforeEach(func() {
+					subject = nfsv3driver.NewMapfsMounter(fakeInvoker, fakeOs, fakeSyscall, fakeIoutil, fakeMountChecker, "my-fs", "my-mount-options", nil, nil, nil, nil, nfsv3driver.IdPolicy{}, mask, mapfsPath)
+				})
+
+				It("should error", func() {
+					Expect(err).To(HaveOccurred())
+					Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+					Expect(err.Error()).To(ContainSubstring("token verification is not configured"))
+				})
+			})
+		})
 	})
 
 	Context("#Unmount", func() {
@@ -0,0 +1,95 @@
+package nfsv3driver
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/goshims/timeshim"
+	"code.cloudfoundry.org/volumedriver"
+)
+
+// MountRecord is what the driver remembers about a mount that the kernel does not
+type MountRecord struct {
+	MountedAt time.Time
+	// LastCheckAt is zero until the mount has been checked
+	LastCheckAt      time.Time
+	LastCheckHealthy bool
+}
+
+//counterfeiter:generate -o nfsdriverfakes/fake_mount_tracker.go . MountTracker
+
+// MountTracker is a Mounter that records when targets were mounted and the result of the last check of each. The
+// records live in memory, so mounts made before the driver restarted have none.
+type MountTracker interface {
+	volumedriver.Mounter
+	Record(target string) (MountRecord, bool)
+}
+
+type mountTracker struct {
+	mounter volumedriver.Mounter
+	time    timeshim.Time
+
+	lock    sync.RWMutex
+	records map[string]MountRecord
+}
+
+func NewMountTracker(mounter volumedriver.Mounter, time timeshim.Time) MountTracker {
+	return &mountTracker{mounter: mounter, time: time, records: map[string]MountRecord{}}
+}
+
+func (t *mountTracker) Mount(env dockerdriver.Env, remote string, target string, opts map[string]interface{}) error {
+	if err := t.mounter.Mount(env, remote, target, opts); err != nil {
+		return err
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.records[strings.TrimSuffix(target, "/")] = MountRecord{MountedAt: t.time.Now()}
+	return nil
+}
+
+func (t *mountTracker) Unmount(env dockerdriver.Env, target string) error {
+	if err := t.mounter.Unmount(env, target); err != nil {
+		return err
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.records, strings.TrimSuffix(target, "/"))
+	return nil
+}
+
+func (t *mountTracker) Check(env dockerdriver.Env, name, mountPoint string) bool {
+	healthy := t.mounter.Check(env, name, mountPoint)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	key := strings.TrimSuffix(mountPoint, "/")
+	record := t.records[key]
+	record.LastCheckAt = t.time.Now()
+	record.LastCheckHealthy = healthy
+	t.records[key] = record
+	return healthy
+}
+
+func (t *mountTracker) Purge(env dockerdriver.Env, path string) {
+	t.mounter.Purge(env, path)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for target := range t.records {
+		if strings.HasPrefix(target, path) {
+			delete(t.records, target)
+		}
+	}
+}
+
+func (t *mountTracker) Record(target string) (MountRecord, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	record, ok := t.records[strings.TrimSuffix(target, "/")]
+	return record, ok
+}
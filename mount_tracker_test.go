@@ -0,0 +1,98 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
+	"code.cloudfoundry.org/volumedriver/volumedriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MountTracker", func() {
+	var (
+		env         dockerdriver.Env
+		fakeMounter *volumedriverfakes.FakeMounter
+		fakeTime    *nfsdriverfakes.FakeTime
+		mountedAt   time.Time
+		tracker     nfsv3driver.MountTracker
+	)
+
+	BeforeEach(func() {
+		env = driverhttp.NewHttpDriverEnv(lagertest.NewTestLogger("mount-tracker"), context.TODO())
+		fakeMounter = &volumedriverfakes.FakeMounter{}
+		fakeTime = &nfsdriverfakes.FakeTime{}
+		mountedAt = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+		fakeTime.NowReturns(mountedAt)
+		tracker = nfsv3driver.NewMountTracker(fakeMounter, fakeTime)
+	})
+
+	It("records when a target was mounted", func() {
+		Expect(tracker.Mount(env, "server:/export", "/var/vcap/data/volumes/nfs/vol1/", nil)).To(Succeed())
+
+		_, source, target, _ := fakeMounter.MountArgsForCall(0)
+		Expect(source).To(Equal("server:/export"))
+		Expect(target).To(Equal("/var/vcap/data/volumes/nfs/vol1/"))
+
+		record, ok := tracker.Record("/var/vcap/data/volumes/nfs/vol1")
+		Expect(ok).To(BeTrue())
+		Expect(record.MountedAt).To(Equal(mountedAt))
+		Expect(record.LastCheckAt).To(BeZero())
+	})
+
+	It("records nothing when the mount fails", func() {
+		fakeMounter.MountReturns(errors.New("mount failed"))
+		Expect(tracker.Mount(env, "server:/export", "/var/vcap/data/volumes/nfs/vol1", nil)).To(MatchError("mount failed"))
+
+		_, ok := tracker.Record("/var/vcap/data/volumes/nfs/vol1")
+		Expect(ok).To(BeFalse())
+	})
+
+	Context("when a target is mounted", func() {
+		BeforeEach(func() {
+			Expect(tracker.Mount(env, "server:/export", "/var/vcap/data/volumes/nfs/vol1", nil)).To(Succeed())
+		})
+
+		It("records the result of the last check", func() {
+			checkedAt := mountedAt.Add(time.Minute)
+			fakeTime.NowReturns(checkedAt)
+			fakeMounter.CheckReturns(false)
+
+			Expect(tracker.Check(env, "vol1", "/var/vcap/data/volumes/nfs/vol1")).To(BeFalse())
+
+			record, _ := tracker.Record("/var/vcap/data/volumes/nfs/vol1")
+			Expect(record.MountedAt).To(Equal(mountedAt))
+			Expect(record.LastCheckAt).To(Equal(checkedAt))
+			Expect(record.LastCheckHealthy).To(BeFalse())
+		})
+
+		It("forgets it once unmounted", func() {
+			Expect(tracker.Unmount(env, "/var/vcap/data/volumes/nfs/vol1")).To(Succeed())
+
+			_, ok := tracker.Record("/var/vcap/data/volumes/nfs/vol1")
+			Expect(ok).To(BeFalse())
+		})
+
+		It("remembers it when unmounting fails", func() {
+			fakeMounter.UnmountReturns(errors.New("device busy"))
+			Expect(tracker.Unmount(env, "/var/vcap/data/volumes/nfs/vol1")).To(MatchError("device busy"))
+
+			_, ok := tracker.Record("/var/vcap/data/volumes/nfs/vol1")
+			Expect(ok).To(BeTrue())
+		})
+
+		It("forgets the targets under a purged path", func() {
+			tracker.Purge(env, "/var/vcap/data/volumes/nfs")
+
+			Expect(fakeMounter.PurgeCallCount()).To(Equal(1))
+			_, ok := tracker.Record("/var/vcap/data/volumes/nfs/vol1")
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,111 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+type FakeAdminToken struct {
+	MatchesStub        func(string) bool
+	matchesMutex       sync.RWMutex
+	matchesArgsForCall []struct {
+		arg1 string
+	}
+	matchesReturns struct {
+		result1 bool
+	}
+	matchesReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeAdminToken) Matches(arg1 string) bool {
+	fake.matchesMutex.Lock()
+	ret, specificReturn := fake.matchesReturnsOnCall[len(fake.matchesArgsForCall)]
+	fake.matchesArgsForCall = append(fake.matchesArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.MatchesStub
+	fakeReturns := fake.matchesReturns
+	fake.recordInvocation("Matches", []interface{}{arg1})
+	fake.matchesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeAdminToken) MatchesCallCount() int {
+	fake.matchesMutex.RLock()
+	defer fake.matchesMutex.RUnlock()
+	return len(fake.matchesArgsForCall)
+}
+
+func (fake *FakeAdminToken) MatchesCalls(stub func(string) bool) {
+	fake.matchesMutex.Lock()
+	defer fake.matchesMutex.Unlock()
+	fake.MatchesStub = stub
+}
+
+func (fake *FakeAdminToken) MatchesArgsForCall(i int) string {
+	fake.matchesMutex.RLock()
+	defer fake.matchesMutex.RUnlock()
+	argsForCall := fake.matchesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeAdminToken) MatchesReturns(result1 bool) {
+	fake.matchesMutex.Lock()
+	defer fake.matchesMutex.Unlock()
+	fake.MatchesStub = nil
+	fake.matchesReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeAdminToken) MatchesReturnsOnCall(i int, result1 bool) {
+	fake.matchesMutex.Lock()
+	defer fake.matchesMutex.Unlock()
+	fake.MatchesStub = nil
+	if fake.matchesReturnsOnCall == nil {
+		fake.matchesReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.matchesReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeAdminToken) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.matchesMutex.RLock()
+	defer fake.matchesMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeAdminToken) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ driveradmin.AdminToken = new(FakeAdminToken)
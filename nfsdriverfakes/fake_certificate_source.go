@@ -0,0 +1,102 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+type FakeCertificateSource struct {
+	CertificateStatusStub        func() driveradmin.CertificateStatus
+	certificateStatusMutex       sync.RWMutex
+	certificateStatusArgsForCall []struct {
+	}
+	certificateStatusReturns struct {
+		result1 driveradmin.CertificateStatus
+	}
+	certificateStatusReturnsOnCall map[int]struct {
+		result1 driveradmin.CertificateStatus
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeCertificateSource) CertificateStatus() driveradmin.CertificateStatus {
+	fake.certificateStatusMutex.Lock()
+	ret, specificReturn := fake.certificateStatusReturnsOnCall[len(fake.certificateStatusArgsForCall)]
+	fake.certificateStatusArgsForCall = append(fake.certificateStatusArgsForCall, struct {
+	}{})
+	stub := fake.CertificateStatusStub
+	fakeReturns := fake.certificateStatusReturns
+	fake.recordInvocation("CertificateStatus", []interface{}{})
+	fake.certificateStatusMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeCertificateSource) CertificateStatusCallCount() int {
+	fake.certificateStatusMutex.RLock()
+	defer fake.certificateStatusMutex.RUnlock()
+	return len(fake.certificateStatusArgsForCall)
+}
+
+func (fake *FakeCertificateSource) CertificateStatusCalls(stub func() driveradmin.CertificateStatus) {
+	fake.certificateStatusMutex.Lock()
+	defer fake.certificateStatusMutex.Unlock()
+	fake.CertificateStatusStub = stub
+}
+
+func (fake *FakeCertificateSource) CertificateStatusReturns(result1 driveradmin.CertificateStatus) {
+	fake.certificateStatusMutex.Lock()
+	defer fake.certificateStatusMutex.Unlock()
+	fake.CertificateStatusStub = nil
+	fake.certificateStatusReturns = struct {
+		result1 driveradmin.CertificateStatus
+	}{result1}
+}
+
+func (fake *FakeCertificateSource) CertificateStatusReturnsOnCall(i int, result1 driveradmin.CertificateStatus) {
+	fake.certificateStatusMutex.Lock()
+	defer fake.certificateStatusMutex.Unlock()
+	fake.CertificateStatusStub = nil
+	if fake.certificateStatusReturnsOnCall == nil {
+		fake.certificateStatusReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.CertificateStatus
+		})
+	}
+	fake.certificateStatusReturnsOnCall[i] = struct {
+		result1 driveradmin.CertificateStatus
+	}{result1}
+}
+
+func (fake *FakeCertificateSource) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.certificateStatusMutex.RLock()
+	defer fake.certificateStatusMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeCertificateSource) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ driveradmin.CertificateSource = new(FakeCertificateSource)
@@ -0,0 +1,112 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/nfsv3driver"
+)
+
+type FakeConnectionChecker struct {
+	CheckConnectionStub        func(dockerdriver.Env) error
+	checkConnectionMutex       sync.RWMutex
+	checkConnectionArgsForCall []struct {
+		arg1 dockerdriver.Env
+	}
+	checkConnectionReturns struct {
+		result1 error
+	}
+	checkConnectionReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeConnectionChecker) CheckConnection(arg1 dockerdriver.Env) error {
+	fake.checkConnectionMutex.Lock()
+	ret, specificReturn := fake.checkConnectionReturnsOnCall[len(fake.checkConnectionArgsForCall)]
+	fake.checkConnectionArgsForCall = append(fake.checkConnectionArgsForCall, struct {
+		arg1 dockerdriver.Env
+	}{arg1})
+	stub := fake.CheckConnectionStub
+	fakeReturns := fake.checkConnectionReturns
+	fake.recordInvocation("CheckConnection", []interface{}{arg1})
+	fake.checkConnectionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeConnectionChecker) CheckConnectionCallCount() int {
+	fake.checkConnectionMutex.RLock()
+	defer fake.checkConnectionMutex.RUnlock()
+	return len(fake.checkConnectionArgsForCall)
+}
+
+func (fake *FakeConnectionChecker) CheckConnectionCalls(stub func(dockerdriver.Env) error) {
+	fake.checkConnectionMutex.Lock()
+	defer fake.checkConnectionMutex.Unlock()
+	fake.CheckConnectionStub = stub
+}
+
+func (fake *FakeConnectionChecker) CheckConnectionArgsForCall(i int) dockerdriver.Env {
+	fake.checkConnectionMutex.RLock()
+	defer fake.checkConnectionMutex.RUnlock()
+	argsForCall := fake.checkConnectionArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeConnectionChecker) CheckConnectionReturns(result1 error) {
+	fake.checkConnectionMutex.Lock()
+	defer fake.checkConnectionMutex.Unlock()
+	fake.CheckConnectionStub = nil
+	fake.checkConnectionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnectionChecker) CheckConnectionReturnsOnCall(i int, result1 error) {
+	fake.checkConnectionMutex.Lock()
+	defer fake.checkConnectionMutex.Unlock()
+	fake.CheckConnectionStub = nil
+	if fake.checkConnectionReturnsOnCall == nil {
+		fake.checkConnectionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.checkConnectionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnectionChecker) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.checkConnectionMutex.RLock()
+	defer fake.checkConnectionMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeConnectionChecker) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ nfsv3driver.ConnectionChecker = new(FakeConnectionChecker)
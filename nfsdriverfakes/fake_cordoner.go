@@ -0,0 +1,265 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+type FakeCordoner struct {
+	CordonStub        func(dockerdriver.Env, string) (driveradmin.CordonStatus, error)
+	cordonMutex       sync.RWMutex
+	cordonArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 string
+	}
+	cordonReturns struct {
+		result1 driveradmin.CordonStatus
+		result2 error
+	}
+	cordonReturnsOnCall map[int]struct {
+		result1 driveradmin.CordonStatus
+		result2 error
+	}
+	CordonStatusStub        func() driveradmin.CordonStatus
+	cordonStatusMutex       sync.RWMutex
+	cordonStatusArgsForCall []struct {
+	}
+	cordonStatusReturns struct {
+		result1 driveradmin.CordonStatus
+	}
+	cordonStatusReturnsOnCall map[int]struct {
+		result1 driveradmin.CordonStatus
+	}
+	UncordonStub        func(dockerdriver.Env, string) (driveradmin.CordonStatus, error)
+	uncordonMutex       sync.RWMutex
+	uncordonArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 string
+	}
+	uncordonReturns struct {
+		result1 driveradmin.CordonStatus
+		result2 error
+	}
+	uncordonReturnsOnCall map[int]struct {
+		result1 driveradmin.CordonStatus
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeCordoner) Cordon(arg1 dockerdriver.Env, arg2 string) (driveradmin.CordonStatus, error) {
+	fake.cordonMutex.Lock()
+	ret, specificReturn := fake.cordonReturnsOnCall[len(fake.cordonArgsForCall)]
+	fake.cordonArgsForCall = append(fake.cordonArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.CordonStub
+	fakeReturns := fake.cordonReturns
+	fake.recordInvocation("Cordon", []interface{}{arg1, arg2})
+	fake.cordonMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCordoner) CordonCallCount() int {
+	fake.cordonMutex.RLock()
+	defer fake.cordonMutex.RUnlock()
+	return len(fake.cordonArgsForCall)
+}
+
+func (fake *FakeCordoner) CordonCalls(stub func(dockerdriver.Env, string) (driveradmin.CordonStatus, error)) {
+	fake.cordonMutex.Lock()
+	defer fake.cordonMutex.Unlock()
+	fake.CordonStub = stub
+}
+
+func (fake *FakeCordoner) CordonArgsForCall(i int) (dockerdriver.Env, string) {
+	fake.cordonMutex.RLock()
+	defer fake.cordonMutex.RUnlock()
+	argsForCall := fake.cordonArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeCordoner) CordonReturns(result1 driveradmin.CordonStatus, result2 error) {
+	fake.cordonMutex.Lock()
+	defer fake.cordonMutex.Unlock()
+	fake.CordonStub = nil
+	fake.cordonReturns = struct {
+		result1 driveradmin.CordonStatus
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCordoner) CordonReturnsOnCall(i int, result1 driveradmin.CordonStatus, result2 error) {
+	fake.cordonMutex.Lock()
+	defer fake.cordonMutex.Unlock()
+	fake.CordonStub = nil
+	if fake.cordonReturnsOnCall == nil {
+		fake.cordonReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.CordonStatus
+			result2 error
+		})
+	}
+	fake.cordonReturnsOnCall[i] = struct {
+		result1 driveradmin.CordonStatus
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCordoner) CordonStatus() driveradmin.CordonStatus {
+	fake.cordonStatusMutex.Lock()
+	ret, specificReturn := fake.cordonStatusReturnsOnCall[len(fake.cordonStatusArgsForCall)]
+	fake.cordonStatusArgsForCall = append(fake.cordonStatusArgsForCall, struct {
+	}{})
+	stub := fake.CordonStatusStub
+	fakeReturns := fake.cordonStatusReturns
+	fake.recordInvocation("CordonStatus", []interface{}{})
+	fake.cordonStatusMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeCordoner) CordonStatusCallCount() int {
+	fake.cordonStatusMutex.RLock()
+	defer fake.cordonStatusMutex.RUnlock()
+	return len(fake.cordonStatusArgsForCall)
+}
+
+func (fake *FakeCordoner) CordonStatusCalls(stub func() driveradmin.CordonStatus) {
+	fake.cordonStatusMutex.Lock()
+	defer fake.cordonStatusMutex.Unlock()
+	fake.CordonStatusStub = stub
+}
+
+func (fake *FakeCordoner) CordonStatusReturns(result1 driveradmin.CordonStatus) {
+	fake.cordonStatusMutex.Lock()
+	defer fake.cordonStatusMutex.Unlock()
+	fake.CordonStatusStub = nil
+	fake.cordonStatusReturns = struct {
+		result1 driveradmin.CordonStatus
+	}{result1}
+}
+
+func (fake *FakeCordoner) CordonStatusReturnsOnCall(i int, result1 driveradmin.CordonStatus) {
+	fake.cordonStatusMutex.Lock()
+	defer fake.cordonStatusMutex.Unlock()
+	fake.CordonStatusStub = nil
+	if fake.cordonStatusReturnsOnCall == nil {
+		fake.cordonStatusReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.CordonStatus
+		})
+	}
+	fake.cordonStatusReturnsOnCall[i] = struct {
+		result1 driveradmin.CordonStatus
+	}{result1}
+}
+
+func (fake *FakeCordoner) Uncordon(arg1 dockerdriver.Env, arg2 string) (driveradmin.CordonStatus, error) {
+	fake.uncordonMutex.Lock()
+	ret, specificReturn := fake.uncordonReturnsOnCall[len(fake.uncordonArgsForCall)]
+	fake.uncordonArgsForCall = append(fake.uncordonArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.UncordonStub
+	fakeReturns := fake.uncordonReturns
+	fake.recordInvocation("Uncordon", []interface{}{arg1, arg2})
+	fake.uncordonMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCordoner) UncordonCallCount() int {
+	fake.uncordonMutex.RLock()
+	defer fake.uncordonMutex.RUnlock()
+	return len(fake.uncordonArgsForCall)
+}
+
+func (fake *FakeCordoner) UncordonCalls(stub func(dockerdriver.Env, string) (driveradmin.CordonStatus, error)) {
+	fake.uncordonMutex.Lock()
+	defer fake.uncordonMutex.Unlock()
+	fake.UncordonStub = stub
+}
+
+func (fake *FakeCordoner) UncordonArgsForCall(i int) (dockerdriver.Env, string) {
+	fake.uncordonMutex.RLock()
+	defer fake.uncordonMutex.RUnlock()
+	argsForCall := fake.uncordonArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeCordoner) UncordonReturns(result1 driveradmin.CordonStatus, result2 error) {
+	fake.uncordonMutex.Lock()
+	defer fake.uncordonMutex.Unlock()
+	fake.UncordonStub = nil
+	fake.uncordonReturns = struct {
+		result1 driveradmin.CordonStatus
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCordoner) UncordonReturnsOnCall(i int, result1 driveradmin.CordonStatus, result2 error) {
+	fake.uncordonMutex.Lock()
+	defer fake.uncordonMutex.Unlock()
+	fake.UncordonStub = nil
+	if fake.uncordonReturnsOnCall == nil {
+		fake.uncordonReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.CordonStatus
+			result2 error
+		})
+	}
+	fake.uncordonReturnsOnCall[i] = struct {
+		result1 driveradmin.CordonStatus
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCordoner) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.cordonMutex.RLock()
+	defer fake.cordonMutex.RUnlock()
+	fake.cordonStatusMutex.RLock()
+	defer fake.cordonStatusMutex.RUnlock()
+	fake.uncordonMutex.RLock()
+	defer fake.uncordonMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeCordoner) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ driveradmin.Cordoner = new(FakeCordoner)
@@ -9,17 +9,131 @@ import (
 )
 
 type FakeDriverAdmin struct {
-	EvacuateStub        func(dockerdriver.Env) driveradmin.ErrorResponse
+	CertificatesStub        func(dockerdriver.Env) driveradmin.CertificatesResponse
+	certificatesMutex       sync.RWMutex
+	certificatesArgsForCall []struct {
+		arg1 dockerdriver.Env
+	}
+	certificatesReturns struct {
+		result1 driveradmin.CertificatesResponse
+	}
+	certificatesReturnsOnCall map[int]struct {
+		result1 driveradmin.CertificatesResponse
+	}
+	CordonStub        func(dockerdriver.Env, driveradmin.CordonRequest) driveradmin.CordonResponse
+	cordonMutex       sync.RWMutex
+	cordonArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 driveradmin.CordonRequest
+	}
+	cordonReturns struct {
+		result1 driveradmin.CordonResponse
+	}
+	cordonReturnsOnCall map[int]struct {
+		result1 driveradmin.CordonResponse
+	}
+	CordonStatusStub        func(dockerdriver.Env) driveradmin.CordonResponse
+	cordonStatusMutex       sync.RWMutex
+	cordonStatusArgsForCall []struct {
+		arg1 dockerdriver.Env
+	}
+	cordonStatusReturns struct {
+		result1 driveradmin.CordonResponse
+	}
+	cordonStatusReturnsOnCall map[int]struct {
+		result1 driveradmin.CordonResponse
+	}
+	EnableDebugLoggingStub        func(dockerdriver.Env, driveradmin.DebugLoggingRequest) driveradmin.LogLevelResponse
+	enableDebugLoggingMutex       sync.RWMutex
+	enableDebugLoggingArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 driveradmin.DebugLoggingRequest
+	}
+	enableDebugLoggingReturns struct {
+		result1 driveradmin.LogLevelResponse
+	}
+	enableDebugLoggingReturnsOnCall map[int]struct {
+		result1 driveradmin.LogLevelResponse
+	}
+	EvacuateStub        func(dockerdriver.Env, driveradmin.EvacuateRequest) driveradmin.EvacuationResponse
 	evacuateMutex       sync.RWMutex
 	evacuateArgsForCall []struct {
 		arg1 dockerdriver.Env
+		arg2 driveradmin.EvacuateRequest
 	}
 	evacuateReturns struct {
-		result1 driveradmin.ErrorResponse
+		result1 driveradmin.EvacuationResponse
 	}
 	evacuateReturnsOnCall map[int]struct {
+		result1 driveradmin.EvacuationResponse
+	}
+	EvacuationStatusStub        func(dockerdriver.Env) driveradmin.EvacuationResponse
+	evacuationStatusMutex       sync.RWMutex
+	evacuationStatusArgsForCall []struct {
+		arg1 dockerdriver.Env
+	}
+	evacuationStatusReturns struct {
+		result1 driveradmin.EvacuationResponse
+	}
+	evacuationStatusReturnsOnCall map[int]struct {
+		result1 driveradmin.EvacuationResponse
+	}
+	ForceUnmountStub        func(dockerdriver.Env, driveradmin.VolumeRequest) driveradmin.ErrorResponse
+	forceUnmountMutex       sync.RWMutex
+	forceUnmountArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 driveradmin.VolumeRequest
+	}
+	forceUnmountReturns struct {
 		result1 driveradmin.ErrorResponse
 	}
+	forceUnmountReturnsOnCall map[int]struct {
+		result1 driveradmin.ErrorResponse
+	}
+	LivenessStub        func(dockerdriver.Env) driveradmin.HealthResponse
+	livenessMutex       sync.RWMutex
+	livenessArgsForCall []struct {
+		arg1 dockerdriver.Env
+	}
+	livenessReturns struct {
+		result1 driveradmin.HealthResponse
+	}
+	livenessReturnsOnCall map[int]struct {
+		result1 driveradmin.HealthResponse
+	}
+	LogLevelStub        func(dockerdriver.Env) driveradmin.LogLevelResponse
+	logLevelMutex       sync.RWMutex
+	logLevelArgsForCall []struct {
+		arg1 dockerdriver.Env
+	}
+	logLevelReturns struct {
+		result1 driveradmin.LogLevelResponse
+	}
+	logLevelReturnsOnCall map[int]struct {
+		result1 driveradmin.LogLevelResponse
+	}
+	LoginThrottleStub        func(dockerdriver.Env) driveradmin.LoginThrottleResponse
+	loginThrottleMutex       sync.RWMutex
+	loginThrottleArgsForCall []struct {
+		arg1 dockerdriver.Env
+	}
+	loginThrottleReturns struct {
+		result1 driveradmin.LoginThrottleResponse
+	}
+	loginThrottleReturnsOnCall map[int]struct {
+		result1 driveradmin.LoginThrottleResponse
+	}
+	PasswordKeyStub        func(dockerdriver.Env) driveradmin.PasswordKeyResponse
+	passwordKeyMutex       sync.RWMutex
+	passwordKeyArgsForCall []struct {
+		arg1 dockerdriver.Env
+	}
+	passwordKeyReturns struct {
+		result1 driveradmin.PasswordKeyResponse
+	}
+	passwordKeyReturnsOnCall map[int]struct {
+		result1 driveradmin.PasswordKeyResponse
+	}
 	PingStub        func(dockerdriver.Env) driveradmin.ErrorResponse
 	pingMutex       sync.RWMutex
 	pingArgsForCall []struct {
@@ -31,22 +145,328 @@ type FakeDriverAdmin struct {
 	pingReturnsOnCall map[int]struct {
 		result1 driveradmin.ErrorResponse
 	}
+	ReadinessStub        func(dockerdriver.Env) driveradmin.HealthResponse
+	readinessMutex       sync.RWMutex
+	readinessArgsForCall []struct {
+		arg1 dockerdriver.Env
+	}
+	readinessReturns struct {
+		result1 driveradmin.HealthResponse
+	}
+	readinessReturnsOnCall map[int]struct {
+		result1 driveradmin.HealthResponse
+	}
+	RemountStub        func(dockerdriver.Env, driveradmin.VolumeRequest) driveradmin.ErrorResponse
+	remountMutex       sync.RWMutex
+	remountArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 driveradmin.VolumeRequest
+	}
+	remountReturns struct {
+		result1 driveradmin.ErrorResponse
+	}
+	remountReturnsOnCall map[int]struct {
+		result1 driveradmin.ErrorResponse
+	}
+	SetLogLevelStub        func(dockerdriver.Env, driveradmin.SetLogLevelRequest) driveradmin.LogLevelResponse
+	setLogLevelMutex       sync.RWMutex
+	setLogLevelArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 driveradmin.SetLogLevelRequest
+	}
+	setLogLevelReturns struct {
+		result1 driveradmin.LogLevelResponse
+	}
+	setLogLevelReturnsOnCall map[int]struct {
+		result1 driveradmin.LogLevelResponse
+	}
+	UncordonStub        func(dockerdriver.Env, driveradmin.CordonRequest) driveradmin.CordonResponse
+	uncordonMutex       sync.RWMutex
+	uncordonArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 driveradmin.CordonRequest
+	}
+	uncordonReturns struct {
+		result1 driveradmin.CordonResponse
+	}
+	uncordonReturnsOnCall map[int]struct {
+		result1 driveradmin.CordonResponse
+	}
+	VolumesStub        func(dockerdriver.Env, driveradmin.VolumesRequest) driveradmin.VolumesResponse
+	volumesMutex       sync.RWMutex
+	volumesArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 driveradmin.VolumesRequest
+	}
+	volumesReturns struct {
+		result1 driveradmin.VolumesResponse
+	}
+	volumesReturnsOnCall map[int]struct {
+		result1 driveradmin.VolumesResponse
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeDriverAdmin) Evacuate(arg1 dockerdriver.Env) driveradmin.ErrorResponse {
+func (fake *FakeDriverAdmin) Certificates(arg1 dockerdriver.Env) driveradmin.CertificatesResponse {
+	fake.certificatesMutex.Lock()
+	ret, specificReturn := fake.certificatesReturnsOnCall[len(fake.certificatesArgsForCall)]
+	fake.certificatesArgsForCall = append(fake.certificatesArgsForCall, struct {
+		arg1 dockerdriver.Env
+	}{arg1})
+	stub := fake.CertificatesStub
+	fakeReturns := fake.certificatesReturns
+	fake.recordInvocation("Certificates", []interface{}{arg1})
+	fake.certificatesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDriverAdmin) CertificatesCallCount() int {
+	fake.certificatesMutex.RLock()
+	defer fake.certificatesMutex.RUnlock()
+	return len(fake.certificatesArgsForCall)
+}
+
+func (fake *FakeDriverAdmin) CertificatesCalls(stub func(dockerdriver.Env) driveradmin.CertificatesResponse) {
+	fake.certificatesMutex.Lock()
+	defer fake.certificatesMutex.Unlock()
+	fake.CertificatesStub = stub
+}
+
+func (fake *FakeDriverAdmin) CertificatesArgsForCall(i int) dockerdriver.Env {
+	fake.certificatesMutex.RLock()
+	defer fake.certificatesMutex.RUnlock()
+	argsForCall := fake.certificatesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDriverAdmin) CertificatesReturns(result1 driveradmin.CertificatesResponse) {
+	fake.certificatesMutex.Lock()
+	defer fake.certificatesMutex.Unlock()
+	fake.CertificatesStub = nil
+	fake.certificatesReturns = struct {
+		result1 driveradmin.CertificatesResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) CertificatesReturnsOnCall(i int, result1 driveradmin.CertificatesResponse) {
+	fake.certificatesMutex.Lock()
+	defer fake.certificatesMutex.Unlock()
+	fake.CertificatesStub = nil
+	if fake.certificatesReturnsOnCall == nil {
+		fake.certificatesReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.CertificatesResponse
+		})
+	}
+	fake.certificatesReturnsOnCall[i] = struct {
+		result1 driveradmin.CertificatesResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) Cordon(arg1 dockerdriver.Env, arg2 driveradmin.CordonRequest) driveradmin.CordonResponse {
+	fake.cordonMutex.Lock()
+	ret, specificReturn := fake.cordonReturnsOnCall[len(fake.cordonArgsForCall)]
+	fake.cordonArgsForCall = append(fake.cordonArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 driveradmin.CordonRequest
+	}{arg1, arg2})
+	stub := fake.CordonStub
+	fakeReturns := fake.cordonReturns
+	fake.recordInvocation("Cordon", []interface{}{arg1, arg2})
+	fake.cordonMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDriverAdmin) CordonCallCount() int {
+	fake.cordonMutex.RLock()
+	defer fake.cordonMutex.RUnlock()
+	return len(fake.cordonArgsForCall)
+}
+
+func (fake *FakeDriverAdmin) CordonCalls(stub func(dockerdriver.Env, driveradmin.CordonRequest) driveradmin.CordonResponse) {
+	fake.cordonMutex.Lock()
+	defer fake.cordonMutex.Unlock()
+	fake.CordonStub = stub
+}
+
+func (fake *FakeDriverAdmin) CordonArgsForCall(i int) (dockerdriver.Env, driveradmin.CordonRequest) {
+	fake.cordonMutex.RLock()
+	defer fake.cordonMutex.RUnlock()
+	argsForCall := fake.cordonArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDriverAdmin) CordonReturns(result1 driveradmin.CordonResponse) {
+	fake.cordonMutex.Lock()
+	defer fake.cordonMutex.Unlock()
+	fake.CordonStub = nil
+	fake.cordonReturns = struct {
+		result1 driveradmin.CordonResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) CordonReturnsOnCall(i int, result1 driveradmin.CordonResponse) {
+	fake.cordonMutex.Lock()
+	defer fake.cordonMutex.Unlock()
+	fake.CordonStub = nil
+	if fake.cordonReturnsOnCall == nil {
+		fake.cordonReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.CordonResponse
+		})
+	}
+	fake.cordonReturnsOnCall[i] = struct {
+		result1 driveradmin.CordonResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) CordonStatus(arg1 dockerdriver.Env) driveradmin.CordonResponse {
+	fake.cordonStatusMutex.Lock()
+	ret, specificReturn := fake.cordonStatusReturnsOnCall[len(fake.cordonStatusArgsForCall)]
+	fake.cordonStatusArgsForCall = append(fake.cordonStatusArgsForCall, struct {
+		arg1 dockerdriver.Env
+	}{arg1})
+	stub := fake.CordonStatusStub
+	fakeReturns := fake.cordonStatusReturns
+	fake.recordInvocation("CordonStatus", []interface{}{arg1})
+	fake.cordonStatusMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDriverAdmin) CordonStatusCallCount() int {
+	fake.cordonStatusMutex.RLock()
+	defer fake.cordonStatusMutex.RUnlock()
+	return len(fake.cordonStatusArgsForCall)
+}
+
+func (fake *FakeDriverAdmin) CordonStatusCalls(stub func(dockerdriver.Env) driveradmin.CordonResponse) {
+	fake.cordonStatusMutex.Lock()
+	defer fake.cordonStatusMutex.Unlock()
+	fake.CordonStatusStub = stub
+}
+
+func (fake *FakeDriverAdmin) CordonStatusArgsForCall(i int) dockerdriver.Env {
+	fake.cordonStatusMutex.RLock()
+	defer fake.cordonStatusMutex.RUnlock()
+	argsForCall := fake.cordonStatusArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDriverAdmin) CordonStatusReturns(result1 driveradmin.CordonResponse) {
+	fake.cordonStatusMutex.Lock()
+	defer fake.cordonStatusMutex.Unlock()
+	fake.CordonStatusStub = nil
+	fake.cordonStatusReturns = struct {
+		result1 driveradmin.CordonResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) CordonStatusReturnsOnCall(i int, result1 driveradmin.CordonResponse) {
+	fake.cordonStatusMutex.Lock()
+	defer fake.cordonStatusMutex.Unlock()
+	fake.CordonStatusStub = nil
+	if fake.cordonStatusReturnsOnCall == nil {
+		fake.cordonStatusReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.CordonResponse
+		})
+	}
+	fake.cordonStatusReturnsOnCall[i] = struct {
+		result1 driveradmin.CordonResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) EnableDebugLogging(arg1 dockerdriver.Env, arg2 driveradmin.DebugLoggingRequest) driveradmin.LogLevelResponse {
+	fake.enableDebugLoggingMutex.Lock()
+	ret, specificReturn := fake.enableDebugLoggingReturnsOnCall[len(fake.enableDebugLoggingArgsForCall)]
+	fake.enableDebugLoggingArgsForCall = append(fake.enableDebugLoggingArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 driveradmin.DebugLoggingRequest
+	}{arg1, arg2})
+	stub := fake.EnableDebugLoggingStub
+	fakeReturns := fake.enableDebugLoggingReturns
+	fake.recordInvocation("EnableDebugLogging", []interface{}{arg1, arg2})
+	fake.enableDebugLoggingMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDriverAdmin) EnableDebugLoggingCallCount() int {
+	fake.enableDebugLoggingMutex.RLock()
+	defer fake.enableDebugLoggingMutex.RUnlock()
+	return len(fake.enableDebugLoggingArgsForCall)
+}
+
+func (fake *FakeDriverAdmin) EnableDebugLoggingCalls(stub func(dockerdriver.Env, driveradmin.DebugLoggingRequest) driveradmin.LogLevelResponse) {
+	fake.enableDebugLoggingMutex.Lock()
+	defer fake.enableDebugLoggingMutex.Unlock()
+	fake.EnableDebugLoggingStub = stub
+}
+
+func (fake *FakeDriverAdmin) EnableDebugLoggingArgsForCall(i int) (dockerdriver.Env, driveradmin.DebugLoggingRequest) {
+	fake.enableDebugLoggingMutex.RLock()
+	defer fake.enableDebugLoggingMutex.RUnlock()
+	argsForCall := fake.enableDebugLoggingArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDriverAdmin) EnableDebugLoggingReturns(result1 driveradmin.LogLevelResponse) {
+	fake.enableDebugLoggingMutex.Lock()
+	defer fake.enableDebugLoggingMutex.Unlock()
+	fake.EnableDebugLoggingStub = nil
+	fake.enableDebugLoggingReturns = struct {
+		result1 driveradmin.LogLevelResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) EnableDebugLoggingReturnsOnCall(i int, result1 driveradmin.LogLevelResponse) {
+	fake.enableDebugLoggingMutex.Lock()
+	defer fake.enableDebugLoggingMutex.Unlock()
+	fake.EnableDebugLoggingStub = nil
+	if fake.enableDebugLoggingReturnsOnCall == nil {
+		fake.enableDebugLoggingReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.LogLevelResponse
+		})
+	}
+	fake.enableDebugLoggingReturnsOnCall[i] = struct {
+		result1 driveradmin.LogLevelResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) Evacuate(arg1 dockerdriver.Env, arg2 driveradmin.EvacuateRequest) driveradmin.EvacuationResponse {
 	fake.evacuateMutex.Lock()
 	ret, specificReturn := fake.evacuateReturnsOnCall[len(fake.evacuateArgsForCall)]
 	fake.evacuateArgsForCall = append(fake.evacuateArgsForCall, struct {
 		arg1 dockerdriver.Env
-	}{arg1})
+		arg2 driveradmin.EvacuateRequest
+	}{arg1, arg2})
 	stub := fake.EvacuateStub
 	fakeReturns := fake.evacuateReturns
-	fake.recordInvocation("Evacuate", []interface{}{arg1})
+	fake.recordInvocation("Evacuate", []interface{}{arg1, arg2})
 	fake.evacuateMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
@@ -60,42 +480,409 @@ func (fake *FakeDriverAdmin) EvacuateCallCount() int {
 	return len(fake.evacuateArgsForCall)
 }
 
-func (fake *FakeDriverAdmin) EvacuateCalls(stub func(dockerdriver.Env) driveradmin.ErrorResponse) {
+func (fake *FakeDriverAdmin) EvacuateCalls(stub func(dockerdriver.Env, driveradmin.EvacuateRequest) driveradmin.EvacuationResponse) {
 	fake.evacuateMutex.Lock()
 	defer fake.evacuateMutex.Unlock()
 	fake.EvacuateStub = stub
 }
 
-func (fake *FakeDriverAdmin) EvacuateArgsForCall(i int) dockerdriver.Env {
+func (fake *FakeDriverAdmin) EvacuateArgsForCall(i int) (dockerdriver.Env, driveradmin.EvacuateRequest) {
 	fake.evacuateMutex.RLock()
 	defer fake.evacuateMutex.RUnlock()
 	argsForCall := fake.evacuateArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
-func (fake *FakeDriverAdmin) EvacuateReturns(result1 driveradmin.ErrorResponse) {
+func (fake *FakeDriverAdmin) EvacuateReturns(result1 driveradmin.EvacuationResponse) {
 	fake.evacuateMutex.Lock()
 	defer fake.evacuateMutex.Unlock()
 	fake.EvacuateStub = nil
 	fake.evacuateReturns = struct {
-		result1 driveradmin.ErrorResponse
+		result1 driveradmin.EvacuationResponse
 	}{result1}
 }
 
-func (fake *FakeDriverAdmin) EvacuateReturnsOnCall(i int, result1 driveradmin.ErrorResponse) {
+func (fake *FakeDriverAdmin) EvacuateReturnsOnCall(i int, result1 driveradmin.EvacuationResponse) {
 	fake.evacuateMutex.Lock()
 	defer fake.evacuateMutex.Unlock()
 	fake.EvacuateStub = nil
 	if fake.evacuateReturnsOnCall == nil {
 		fake.evacuateReturnsOnCall = make(map[int]struct {
-			result1 driveradmin.ErrorResponse
+			result1 driveradmin.EvacuationResponse
 		})
 	}
 	fake.evacuateReturnsOnCall[i] = struct {
+		result1 driveradmin.EvacuationResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) EvacuationStatus(arg1 dockerdriver.Env) driveradmin.EvacuationResponse {
+	fake.evacuationStatusMutex.Lock()
+	ret, specificReturn := fake.evacuationStatusReturnsOnCall[len(fake.evacuationStatusArgsForCall)]
+	fake.evacuationStatusArgsForCall = append(fake.evacuationStatusArgsForCall, struct {
+		arg1 dockerdriver.Env
+	}{arg1})
+	stub := fake.EvacuationStatusStub
+	fakeReturns := fake.evacuationStatusReturns
+	fake.recordInvocation("EvacuationStatus", []interface{}{arg1})
+	fake.evacuationStatusMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDriverAdmin) EvacuationStatusCallCount() int {
+	fake.evacuationStatusMutex.RLock()
+	defer fake.evacuationStatusMutex.RUnlock()
+	return len(fake.evacuationStatusArgsForCall)
+}
+
+func (fake *FakeDriverAdmin) EvacuationStatusCalls(stub func(dockerdriver.Env) driveradmin.EvacuationResponse) {
+	fake.evacuationStatusMutex.Lock()
+	defer fake.evacuationStatusMutex.Unlock()
+	fake.EvacuationStatusStub = stub
+}
+
+func (fake *FakeDriverAdmin) EvacuationStatusArgsForCall(i int) dockerdriver.Env {
+	fake.evacuationStatusMutex.RLock()
+	defer fake.evacuationStatusMutex.RUnlock()
+	argsForCall := fake.evacuationStatusArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDriverAdmin) EvacuationStatusReturns(result1 driveradmin.EvacuationResponse) {
+	fake.evacuationStatusMutex.Lock()
+	defer fake.evacuationStatusMutex.Unlock()
+	fake.EvacuationStatusStub = nil
+	fake.evacuationStatusReturns = struct {
+		result1 driveradmin.EvacuationResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) EvacuationStatusReturnsOnCall(i int, result1 driveradmin.EvacuationResponse) {
+	fake.evacuationStatusMutex.Lock()
+	defer fake.evacuationStatusMutex.Unlock()
+	fake.EvacuationStatusStub = nil
+	if fake.evacuationStatusReturnsOnCall == nil {
+		fake.evacuationStatusReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.EvacuationResponse
+		})
+	}
+	fake.evacuationStatusReturnsOnCall[i] = struct {
+		result1 driveradmin.EvacuationResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) ForceUnmount(arg1 dockerdriver.Env, arg2 driveradmin.VolumeRequest) driveradmin.ErrorResponse {
+	fake.forceUnmountMutex.Lock()
+	ret, specificReturn := fake.forceUnmountReturnsOnCall[len(fake.forceUnmountArgsForCall)]
+	fake.forceUnmountArgsForCall = append(fake.forceUnmountArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 driveradmin.VolumeRequest
+	}{arg1, arg2})
+	stub := fake.ForceUnmountStub
+	fakeReturns := fake.forceUnmountReturns
+	fake.recordInvocation("ForceUnmount", []interface{}{arg1, arg2})
+	fake.forceUnmountMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDriverAdmin) ForceUnmountCallCount() int {
+	fake.forceUnmountMutex.RLock()
+	defer fake.forceUnmountMutex.RUnlock()
+	return len(fake.forceUnmountArgsForCall)
+}
+
+func (fake *FakeDriverAdmin) ForceUnmountCalls(stub func(dockerdriver.Env, driveradmin.VolumeRequest) driveradmin.ErrorResponse) {
+	fake.forceUnmountMutex.Lock()
+	defer fake.forceUnmountMutex.Unlock()
+	fake.ForceUnmountStub = stub
+}
+
+func (fake *FakeDriverAdmin) ForceUnmountArgsForCall(i int) (dockerdriver.Env, driveradmin.VolumeRequest) {
+	fake.forceUnmountMutex.RLock()
+	defer fake.forceUnmountMutex.RUnlock()
+	argsForCall := fake.forceUnmountArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDriverAdmin) ForceUnmountReturns(result1 driveradmin.ErrorResponse) {
+	fake.forceUnmountMutex.Lock()
+	defer fake.forceUnmountMutex.Unlock()
+	fake.ForceUnmountStub = nil
+	fake.forceUnmountReturns = struct {
 		result1 driveradmin.ErrorResponse
 	}{result1}
 }
 
+func (fake *FakeDriverAdmin) ForceUnmountReturnsOnCall(i int, result1 driveradmin.ErrorResponse) {
+	fake.forceUnmountMutex.Lock()
+	defer fake.forceUnmountMutex.Unlock()
+	fake.ForceUnmountStub = nil
+	if fake.forceUnmountReturnsOnCall == nil {
+		fake.forceUnmountReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.ErrorResponse
+		})
+	}
+	fake.forceUnmountReturnsOnCall[i] = struct {
+		result1 driveradmin.ErrorResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) Liveness(arg1 dockerdriver.Env) driveradmin.HealthResponse {
+	fake.livenessMutex.Lock()
+	ret, specificReturn := fake.livenessReturnsOnCall[len(fake.livenessArgsForCall)]
+	fake.livenessArgsForCall = append(fake.livenessArgsForCall, struct {
+		arg1 dockerdriver.Env
+	}{arg1})
+	stub := fake.LivenessStub
+	fakeReturns := fake.livenessReturns
+	fake.recordInvocation("Liveness", []interface{}{arg1})
+	fake.livenessMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDriverAdmin) LivenessCallCount() int {
+	fake.livenessMutex.RLock()
+	defer fake.livenessMutex.RUnlock()
+	return len(fake.livenessArgsForCall)
+}
+
+func (fake *FakeDriverAdmin) LivenessCalls(stub func(dockerdriver.Env) driveradmin.HealthResponse) {
+	fake.livenessMutex.Lock()
+	defer fake.livenessMutex.Unlock()
+	fake.LivenessStub = stub
+}
+
+func (fake *FakeDriverAdmin) LivenessArgsForCall(i int) dockerdriver.Env {
+	fake.livenessMutex.RLock()
+	defer fake.livenessMutex.RUnlock()
+	argsForCall := fake.livenessArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDriverAdmin) LivenessReturns(result1 driveradmin.HealthResponse) {
+	fake.livenessMutex.Lock()
+	defer fake.livenessMutex.Unlock()
+	fake.LivenessStub = nil
+	fake.livenessReturns = struct {
+		result1 driveradmin.HealthResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) LivenessReturnsOnCall(i int, result1 driveradmin.HealthResponse) {
+	fake.livenessMutex.Lock()
+	defer fake.livenessMutex.Unlock()
+	fake.LivenessStub = nil
+	if fake.livenessReturnsOnCall == nil {
+		fake.livenessReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.HealthResponse
+		})
+	}
+	fake.livenessReturnsOnCall[i] = struct {
+		result1 driveradmin.HealthResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) LogLevel(arg1 dockerdriver.Env) driveradmin.LogLevelResponse {
+	fake.logLevelMutex.Lock()
+	ret, specificReturn := fake.logLevelReturnsOnCall[len(fake.logLevelArgsForCall)]
+	fake.logLevelArgsForCall = append(fake.logLevelArgsForCall, struct {
+		arg1 dockerdriver.Env
+	}{arg1})
+	stub := fake.LogLevelStub
+	fakeReturns := fake.logLevelReturns
+	fake.recordInvocation("LogLevel", []interface{}{arg1})
+	fake.logLevelMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDriverAdmin) LogLevelCallCount() int {
+	fake.logLevelMutex.RLock()
+	defer fake.logLevelMutex.RUnlock()
+	return len(fake.logLevelArgsForCall)
+}
+
+func (fake *FakeDriverAdmin) LogLevelCalls(stub func(dockerdriver.Env) driveradmin.LogLevelResponse) {
+	fake.logLevelMutex.Lock()
+	defer fake.logLevelMutex.Unlock()
+	fake.LogLevelStub = stub
+}
+
+func (fake *FakeDriverAdmin) LogLevelArgsForCall(i int) dockerdriver.Env {
+	fake.logLevelMutex.RLock()
+	defer fake.logLevelMutex.RUnlock()
+	argsForCall := fake.logLevelArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDriverAdmin) LogLevelReturns(result1 driveradmin.LogLevelResponse) {
+	fake.logLevelMutex.Lock()
+	defer fake.logLevelMutex.Unlock()
+	fake.LogLevelStub = nil
+	fake.logLevelReturns = struct {
+		result1 driveradmin.LogLevelResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) LogLevelReturnsOnCall(i int, result1 driveradmin.LogLevelResponse) {
+	fake.logLevelMutex.Lock()
+	defer fake.logLevelMutex.Unlock()
+	fake.LogLevelStub = nil
+	if fake.logLevelReturnsOnCall == nil {
+		fake.logLevelReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.LogLevelResponse
+		})
+	}
+	fake.logLevelReturnsOnCall[i] = struct {
+		result1 driveradmin.LogLevelResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) LoginThrottle(arg1 dockerdriver.Env) driveradmin.LoginThrottleResponse {
+	fake.loginThrottleMutex.Lock()
+	ret, specificReturn := fake.loginThrottleReturnsOnCall[len(fake.loginThrottleArgsForCall)]
+	fake.loginThrottleArgsForCall = append(fake.loginThrottleArgsForCall, struct {
+		arg1 dockerdriver.Env
+	}{arg1})
+	stub := fake.LoginThrottleStub
+	fakeReturns := fake.loginThrottleReturns
+	fake.recordInvocation("LoginThrottle", []interface{}{arg1})
+	fake.loginThrottleMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDriverAdmin) LoginThrottleCallCount() int {
+	fake.loginThrottleMutex.RLock()
+	defer fake.loginThrottleMutex.RUnlock()
+	return len(fake.loginThrottleArgsForCall)
+}
+
+func (fake *FakeDriverAdmin) LoginThrottleCalls(stub func(dockerdriver.Env) driveradmin.LoginThrottleResponse) {
+	fake.loginThrottleMutex.Lock()
+	defer fake.loginThrottleMutex.Unlock()
+	fake.LoginThrottleStub = stub
+}
+
+func (fake *FakeDriverAdmin) LoginThrottleArgsForCall(i int) dockerdriver.Env {
+	fake.loginThrottleMutex.RLock()
+	defer fake.loginThrottleMutex.RUnlock()
+	argsForCall := fake.loginThrottleArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDriverAdmin) LoginThrottleReturns(result1 driveradmin.LoginThrottleResponse) {
+	fake.loginThrottleMutex.Lock()
+	defer fake.loginThrottleMutex.Unlock()
+	fake.LoginThrottleStub = nil
+	fake.loginThrottleReturns = struct {
+		result1 driveradmin.LoginThrottleResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) LoginThrottleReturnsOnCall(i int, result1 driveradmin.LoginThrottleResponse) {
+	fake.loginThrottleMutex.Lock()
+	defer fake.loginThrottleMutex.Unlock()
+	fake.LoginThrottleStub = nil
+	if fake.loginThrottleReturnsOnCall == nil {
+		fake.loginThrottleReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.LoginThrottleResponse
+		})
+	}
+	fake.loginThrottleReturnsOnCall[i] = struct {
+		result1 driveradmin.LoginThrottleResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) PasswordKey(arg1 dockerdriver.Env) driveradmin.PasswordKeyResponse {
+	fake.passwordKeyMutex.Lock()
+	ret, specificReturn := fake.passwordKeyReturnsOnCall[len(fake.passwordKeyArgsForCall)]
+	fake.passwordKeyArgsForCall = append(fake.passwordKeyArgsForCall, struct {
+		arg1 dockerdriver.Env
+	}{arg1})
+	stub := fake.PasswordKeyStub
+	fakeReturns := fake.passwordKeyReturns
+	fake.recordInvocation("PasswordKey", []interface{}{arg1})
+	fake.passwordKeyMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDriverAdmin) PasswordKeyCallCount() int {
+	fake.passwordKeyMutex.RLock()
+	defer fake.passwordKeyMutex.RUnlock()
+	return len(fake.passwordKeyArgsForCall)
+}
+
+func (fake *FakeDriverAdmin) PasswordKeyCalls(stub func(dockerdriver.Env) driveradmin.PasswordKeyResponse) {
+	fake.passwordKeyMutex.Lock()
+	defer fake.passwordKeyMutex.Unlock()
+	fake.PasswordKeyStub = stub
+}
+
+func (fake *FakeDriverAdmin) PasswordKeyArgsForCall(i int) dockerdriver.Env {
+	fake.passwordKeyMutex.RLock()
+	defer fake.passwordKeyMutex.RUnlock()
+	argsForCall := fake.passwordKeyArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDriverAdmin) PasswordKeyReturns(result1 driveradmin.PasswordKeyResponse) {
+	fake.passwordKeyMutex.Lock()
+	defer fake.passwordKeyMutex.Unlock()
+	fake.PasswordKeyStub = nil
+	fake.passwordKeyReturns = struct {
+		result1 driveradmin.PasswordKeyResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) PasswordKeyReturnsOnCall(i int, result1 driveradmin.PasswordKeyResponse) {
+	fake.passwordKeyMutex.Lock()
+	defer fake.passwordKeyMutex.Unlock()
+	fake.PasswordKeyStub = nil
+	if fake.passwordKeyReturnsOnCall == nil {
+		fake.passwordKeyReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.PasswordKeyResponse
+		})
+	}
+	fake.passwordKeyReturnsOnCall[i] = struct {
+		result1 driveradmin.PasswordKeyResponse
+	}{result1}
+}
+
 func (fake *FakeDriverAdmin) Ping(arg1 dockerdriver.Env) driveradmin.ErrorResponse {
 	fake.pingMutex.Lock()
 	ret, specificReturn := fake.pingReturnsOnCall[len(fake.pingArgsForCall)]
@@ -157,13 +944,352 @@ func (fake *FakeDriverAdmin) PingReturnsOnCall(i int, result1 driveradmin.ErrorR
 	}{result1}
 }
 
+func (fake *FakeDriverAdmin) Readiness(arg1 dockerdriver.Env) driveradmin.HealthResponse {
+	fake.readinessMutex.Lock()
+	ret, specificReturn := fake.readinessReturnsOnCall[len(fake.readinessArgsForCall)]
+	fake.readinessArgsForCall = append(fake.readinessArgsForCall, struct {
+		arg1 dockerdriver.Env
+	}{arg1})
+	stub := fake.ReadinessStub
+	fakeReturns := fake.readinessReturns
+	fake.recordInvocation("Readiness", []interface{}{arg1})
+	fake.readinessMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDriverAdmin) ReadinessCallCount() int {
+	fake.readinessMutex.RLock()
+	defer fake.readinessMutex.RUnlock()
+	return len(fake.readinessArgsForCall)
+}
+
+func (fake *FakeDriverAdmin) ReadinessCalls(stub func(dockerdriver.Env) driveradmin.HealthResponse) {
+	fake.readinessMutex.Lock()
+	defer fake.readinessMutex.Unlock()
+	fake.ReadinessStub = stub
+}
+
+func (fake *FakeDriverAdmin) ReadinessArgsForCall(i int) dockerdriver.Env {
+	fake.readinessMutex.RLock()
+	defer fake.readinessMutex.RUnlock()
+	argsForCall := fake.readinessArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDriverAdmin) ReadinessReturns(result1 driveradmin.HealthResponse) {
+	fake.readinessMutex.Lock()
+	defer fake.readinessMutex.Unlock()
+	fake.ReadinessStub = nil
+	fake.readinessReturns = struct {
+		result1 driveradmin.HealthResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) ReadinessReturnsOnCall(i int, result1 driveradmin.HealthResponse) {
+	fake.readinessMutex.Lock()
+	defer fake.readinessMutex.Unlock()
+	fake.ReadinessStub = nil
+	if fake.readinessReturnsOnCall == nil {
+		fake.readinessReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.HealthResponse
+		})
+	}
+	fake.readinessReturnsOnCall[i] = struct {
+		result1 driveradmin.HealthResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) Remount(arg1 dockerdriver.Env, arg2 driveradmin.VolumeRequest) driveradmin.ErrorResponse {
+	fake.remountMutex.Lock()
+	ret, specificReturn := fake.remountReturnsOnCall[len(fake.remountArgsForCall)]
+	fake.remountArgsForCall = append(fake.remountArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 driveradmin.VolumeRequest
+	}{arg1, arg2})
+	stub := fake.RemountStub
+	fakeReturns := fake.remountReturns
+	fake.recordInvocation("Remount", []interface{}{arg1, arg2})
+	fake.remountMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDriverAdmin) RemountCallCount() int {
+	fake.remountMutex.RLock()
+	defer fake.remountMutex.RUnlock()
+	return len(fake.remountArgsForCall)
+}
+
+func (fake *FakeDriverAdmin) RemountCalls(stub func(dockerdriver.Env, driveradmin.VolumeRequest) driveradmin.ErrorResponse) {
+	fake.remountMutex.Lock()
+	defer fake.remountMutex.Unlock()
+	fake.RemountStub = stub
+}
+
+func (fake *FakeDriverAdmin) RemountArgsForCall(i int) (dockerdriver.Env, driveradmin.VolumeRequest) {
+	fake.remountMutex.RLock()
+	defer fake.remountMutex.RUnlock()
+	argsForCall := fake.remountArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDriverAdmin) RemountReturns(result1 driveradmin.ErrorResponse) {
+	fake.remountMutex.Lock()
+	defer fake.remountMutex.Unlock()
+	fake.RemountStub = nil
+	fake.remountReturns = struct {
+		result1 driveradmin.ErrorResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) RemountReturnsOnCall(i int, result1 driveradmin.ErrorResponse) {
+	fake.remountMutex.Lock()
+	defer fake.remountMutex.Unlock()
+	fake.RemountStub = nil
+	if fake.remountReturnsOnCall == nil {
+		fake.remountReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.ErrorResponse
+		})
+	}
+	fake.remountReturnsOnCall[i] = struct {
+		result1 driveradmin.ErrorResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) SetLogLevel(arg1 dockerdriver.Env, arg2 driveradmin.SetLogLevelRequest) driveradmin.LogLevelResponse {
+	fake.setLogLevelMutex.Lock()
+	ret, specificReturn := fake.setLogLevelReturnsOnCall[len(fake.setLogLevelArgsForCall)]
+	fake.setLogLevelArgsForCall = append(fake.setLogLevelArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 driveradmin.SetLogLevelRequest
+	}{arg1, arg2})
+	stub := fake.SetLogLevelStub
+	fakeReturns := fake.setLogLevelReturns
+	fake.recordInvocation("SetLogLevel", []interface{}{arg1, arg2})
+	fake.setLogLevelMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDriverAdmin) SetLogLevelCallCount() int {
+	fake.setLogLevelMutex.RLock()
+	defer fake.setLogLevelMutex.RUnlock()
+	return len(fake.setLogLevelArgsForCall)
+}
+
+func (fake *FakeDriverAdmin) SetLogLevelCalls(stub func(dockerdriver.Env, driveradmin.SetLogLevelRequest) driveradmin.LogLevelResponse) {
+	fake.setLogLevelMutex.Lock()
+	defer fake.setLogLevelMutex.Unlock()
+	fake.SetLogLevelStub = stub
+}
+
+func (fake *FakeDriverAdmin) SetLogLevelArgsForCall(i int) (dockerdriver.Env, driveradmin.SetLogLevelRequest) {
+	fake.setLogLevelMutex.RLock()
+	defer fake.setLogLevelMutex.RUnlock()
+	argsForCall := fake.setLogLevelArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDriverAdmin) SetLogLevelReturns(result1 driveradmin.LogLevelResponse) {
+	fake.setLogLevelMutex.Lock()
+	defer fake.setLogLevelMutex.Unlock()
+	fake.SetLogLevelStub = nil
+	fake.setLogLevelReturns = struct {
+		result1 driveradmin.LogLevelResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) SetLogLevelReturnsOnCall(i int, result1 driveradmin.LogLevelResponse) {
+	fake.setLogLevelMutex.Lock()
+	defer fake.setLogLevelMutex.Unlock()
+	fake.SetLogLevelStub = nil
+	if fake.setLogLevelReturnsOnCall == nil {
+		fake.setLogLevelReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.LogLevelResponse
+		})
+	}
+	fake.setLogLevelReturnsOnCall[i] = struct {
+		result1 driveradmin.LogLevelResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) Uncordon(arg1 dockerdriver.Env, arg2 driveradmin.CordonRequest) driveradmin.CordonResponse {
+	fake.uncordonMutex.Lock()
+	ret, specificReturn := fake.uncordonReturnsOnCall[len(fake.uncordonArgsForCall)]
+	fake.uncordonArgsForCall = append(fake.uncordonArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 driveradmin.CordonRequest
+	}{arg1, arg2})
+	stub := fake.UncordonStub
+	fakeReturns := fake.uncordonReturns
+	fake.recordInvocation("Uncordon", []interface{}{arg1, arg2})
+	fake.uncordonMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDriverAdmin) UncordonCallCount() int {
+	fake.uncordonMutex.RLock()
+	defer fake.uncordonMutex.RUnlock()
+	return len(fake.uncordonArgsForCall)
+}
+
+func (fake *FakeDriverAdmin) UncordonCalls(stub func(dockerdriver.Env, driveradmin.CordonRequest) driveradmin.CordonResponse) {
+	fake.uncordonMutex.Lock()
+	defer fake.uncordonMutex.Unlock()
+	fake.UncordonStub = stub
+}
+
+func (fake *FakeDriverAdmin) UncordonArgsForCall(i int) (dockerdriver.Env, driveradmin.CordonRequest) {
+	fake.uncordonMutex.RLock()
+	defer fake.uncordonMutex.RUnlock()
+	argsForCall := fake.uncordonArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDriverAdmin) UncordonReturns(result1 driveradmin.CordonResponse) {
+	fake.uncordonMutex.Lock()
+	defer fake.uncordonMutex.Unlock()
+	fake.UncordonStub = nil
+	fake.uncordonReturns = struct {
+		result1 driveradmin.CordonResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) UncordonReturnsOnCall(i int, result1 driveradmin.CordonResponse) {
+	fake.uncordonMutex.Lock()
+	defer fake.uncordonMutex.Unlock()
+	fake.UncordonStub = nil
+	if fake.uncordonReturnsOnCall == nil {
+		fake.uncordonReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.CordonResponse
+		})
+	}
+	fake.uncordonReturnsOnCall[i] = struct {
+		result1 driveradmin.CordonResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) Volumes(arg1 dockerdriver.Env, arg2 driveradmin.VolumesRequest) driveradmin.VolumesResponse {
+	fake.volumesMutex.Lock()
+	ret, specificReturn := fake.volumesReturnsOnCall[len(fake.volumesArgsForCall)]
+	fake.volumesArgsForCall = append(fake.volumesArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 driveradmin.VolumesRequest
+	}{arg1, arg2})
+	stub := fake.VolumesStub
+	fakeReturns := fake.volumesReturns
+	fake.recordInvocation("Volumes", []interface{}{arg1, arg2})
+	fake.volumesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDriverAdmin) VolumesCallCount() int {
+	fake.volumesMutex.RLock()
+	defer fake.volumesMutex.RUnlock()
+	return len(fake.volumesArgsForCall)
+}
+
+func (fake *FakeDriverAdmin) VolumesCalls(stub func(dockerdriver.Env, driveradmin.VolumesRequest) driveradmin.VolumesResponse) {
+	fake.volumesMutex.Lock()
+	defer fake.volumesMutex.Unlock()
+	fake.VolumesStub = stub
+}
+
+func (fake *FakeDriverAdmin) VolumesArgsForCall(i int) (dockerdriver.Env, driveradmin.VolumesRequest) {
+	fake.volumesMutex.RLock()
+	defer fake.volumesMutex.RUnlock()
+	argsForCall := fake.volumesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDriverAdmin) VolumesReturns(result1 driveradmin.VolumesResponse) {
+	fake.volumesMutex.Lock()
+	defer fake.volumesMutex.Unlock()
+	fake.VolumesStub = nil
+	fake.volumesReturns = struct {
+		result1 driveradmin.VolumesResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) VolumesReturnsOnCall(i int, result1 driveradmin.VolumesResponse) {
+	fake.volumesMutex.Lock()
+	defer fake.volumesMutex.Unlock()
+	fake.VolumesStub = nil
+	if fake.volumesReturnsOnCall == nil {
+		fake.volumesReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.VolumesResponse
+		})
+	}
+	fake.volumesReturnsOnCall[i] = struct {
+		result1 driveradmin.VolumesResponse
+	}{result1}
+}
+
 func (fake *FakeDriverAdmin) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.certificatesMutex.RLock()
+	defer fake.certificatesMutex.RUnlock()
+	fake.cordonMutex.RLock()
+	defer fake.cordonMutex.RUnlock()
+	fake.cordonStatusMutex.RLock()
+	defer fake.cordonStatusMutex.RUnlock()
+	fake.enableDebugLoggingMutex.RLock()
+	defer fake.enableDebugLoggingMutex.RUnlock()
 	fake.evacuateMutex.RLock()
 	defer fake.evacuateMutex.RUnlock()
+	fake.evacuationStatusMutex.RLock()
+	defer fake.evacuationStatusMutex.RUnlock()
+	fake.forceUnmountMutex.RLock()
+	defer fake.forceUnmountMutex.RUnlock()
+	fake.livenessMutex.RLock()
+	defer fake.livenessMutex.RUnlock()
+	fake.logLevelMutex.RLock()
+	defer fake.logLevelMutex.RUnlock()
+	fake.loginThrottleMutex.RLock()
+	defer fake.loginThrottleMutex.RUnlock()
+	fake.passwordKeyMutex.RLock()
+	defer fake.passwordKeyMutex.RUnlock()
 	fake.pingMutex.RLock()
 	defer fake.pingMutex.RUnlock()
+	fake.readinessMutex.RLock()
+	defer fake.readinessMutex.RUnlock()
+	fake.remountMutex.RLock()
+	defer fake.remountMutex.RUnlock()
+	fake.setLogLevelMutex.RLock()
+	defer fake.setLogLevelMutex.RUnlock()
+	fake.uncordonMutex.RLock()
+	defer fake.uncordonMutex.RUnlock()
+	fake.volumesMutex.RLock()
+	defer fake.volumesMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -0,0 +1,498 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"crypto/tls"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/nfsv3driver"
+	ldap "gopkg.in/ldap.v2"
+)
+
+type FakeExtendedLdapConnection struct {
+	BindStub        func(string, string) error
+	bindMutex       sync.RWMutex
+	bindArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	bindReturns struct {
+		result1 error
+	}
+	bindReturnsOnCall map[int]struct {
+		result1 error
+	}
+	CloseStub        func()
+	closeMutex       sync.RWMutex
+	closeArgsForCall []struct {
+	}
+	SearchStub        func(*ldap.SearchRequest) (*ldap.SearchResult, error)
+	searchMutex       sync.RWMutex
+	searchArgsForCall []struct {
+		arg1 *ldap.SearchRequest
+	}
+	searchReturns struct {
+		result1 *ldap.SearchResult
+		result2 error
+	}
+	searchReturnsOnCall map[int]struct {
+		result1 *ldap.SearchResult
+		result2 error
+	}
+	SearchWithPagingStub        func(*ldap.SearchRequest, uint32) (*ldap.SearchResult, error)
+	searchWithPagingMutex       sync.RWMutex
+	searchWithPagingArgsForCall []struct {
+		arg1 *ldap.SearchRequest
+		arg2 uint32
+	}
+	searchWithPagingReturns struct {
+		result1 *ldap.SearchResult
+		result2 error
+	}
+	searchWithPagingReturnsOnCall map[int]struct {
+		result1 *ldap.SearchResult
+		result2 error
+	}
+	SetTimeoutStub        func(time.Duration)
+	setTimeoutMutex       sync.RWMutex
+	setTimeoutArgsForCall []struct {
+		arg1 time.Duration
+	}
+	SimpleBindStub        func(*ldap.SimpleBindRequest) (*ldap.SimpleBindResult, error)
+	simpleBindMutex       sync.RWMutex
+	simpleBindArgsForCall []struct {
+		arg1 *ldap.SimpleBindRequest
+	}
+	simpleBindReturns struct {
+		result1 *ldap.SimpleBindResult
+		result2 error
+	}
+	simpleBindReturnsOnCall map[int]struct {
+		result1 *ldap.SimpleBindResult
+		result2 error
+	}
+	StartTLSStub        func(*tls.Config) error
+	startTLSMutex       sync.RWMutex
+	startTLSArgsForCall []struct {
+		arg1 *tls.Config
+	}
+	startTLSReturns struct {
+		result1 error
+	}
+	startTLSReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeExtendedLdapConnection) Bind(arg1 string, arg2 string) error {
+	fake.bindMutex.Lock()
+	ret, specificReturn := fake.bindReturnsOnCall[len(fake.bindArgsForCall)]
+	fake.bindArgsForCall = append(fake.bindArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.BindStub
+	fakeReturns := fake.bindReturns
+	fake.recordInvocation("Bind", []interface{}{arg1, arg2})
+	fake.bindMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeExtendedLdapConnection) BindCallCount() int {
+	fake.bindMutex.RLock()
+	defer fake.bindMutex.RUnlock()
+	return len(fake.bindArgsForCall)
+}
+
+func (fake *FakeExtendedLdapConnection) BindCalls(stub func(string, string) error) {
+	fake.bindMutex.Lock()
+	defer fake.bindMutex.Unlock()
+	fake.BindStub = stub
+}
+
+func (fake *FakeExtendedLdapConnection) BindArgsForCall(i int) (string, string) {
+	fake.bindMutex.RLock()
+	defer fake.bindMutex.RUnlock()
+	argsForCall := fake.bindArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeExtendedLdapConnection) BindReturns(result1 error) {
+	fake.bindMutex.Lock()
+	defer fake.bindMutex.Unlock()
+	fake.BindStub = nil
+	fake.bindReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeExtendedLdapConnection) BindReturnsOnCall(i int, result1 error) {
+	fake.bindMutex.Lock()
+	defer fake.bindMutex.Unlock()
+	fake.BindStub = nil
+	if fake.bindReturnsOnCall == nil {
+		fake.bindReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.bindReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeExtendedLdapConnection) Close() {
+	fake.closeMutex.Lock()
+	fake.closeArgsForCall = append(fake.closeArgsForCall, struct {
+	}{})
+	stub := fake.CloseStub
+	fake.recordInvocation("Close", []interface{}{})
+	fake.closeMutex.Unlock()
+	if stub != nil {
+		fake.CloseStub()
+	}
+}
+
+func (fake *FakeExtendedLdapConnection) CloseCallCount() int {
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
+	return len(fake.closeArgsForCall)
+}
+
+func (fake *FakeExtendedLdapConnection) CloseCalls(stub func()) {
+	fake.closeMutex.Lock()
+	defer fake.closeMutex.Unlock()
+	fake.CloseStub = stub
+}
+
+func (fake *FakeExtendedLdapConnection) Search(arg1 *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	fake.searchMutex.Lock()
+	ret, specificReturn := fake.searchReturnsOnCall[len(fake.searchArgsForCall)]
+	fake.searchArgsForCall = append(fake.searchArgsForCall, struct {
+		arg1 *ldap.SearchRequest
+	}{arg1})
+	stub := fake.SearchStub
+	fakeReturns := fake.searchReturns
+	fake.recordInvocation("Search", []interface{}{arg1})
+	fake.searchMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeExtendedLdapConnection) SearchCallCount() int {
+	fake.searchMutex.RLock()
+	defer fake.searchMutex.RUnlock()
+	return len(fake.searchArgsForCall)
+}
+
+func (fake *FakeExtendedLdapConnection) SearchCalls(stub func(*ldap.SearchRequest) (*ldap.SearchResult, error)) {
+	fake.searchMutex.Lock()
+	defer fake.searchMutex.Unlock()
+	fake.SearchStub = stub
+}
+
+func (fake *FakeExtendedLdapConnection) SearchArgsForCall(i int) *ldap.SearchRequest {
+	fake.searchMutex.RLock()
+	defer fake.searchMutex.RUnlock()
+	argsForCall := fake.searchArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeExtendedLdapConnection) SearchReturns(result1 *ldap.SearchResult, result2 error) {
+	fake.searchMutex.Lock()
+	defer fake.searchMutex.Unlock()
+	fake.SearchStub = nil
+	fake.searchReturns = struct {
+		result1 *ldap.SearchResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeExtendedLdapConnection) SearchReturnsOnCall(i int, result1 *ldap.SearchResult, result2 error) {
+	fake.searchMutex.Lock()
+	defer fake.searchMutex.Unlock()
+	fake.SearchStub = nil
+	if fake.searchReturnsOnCall == nil {
+		fake.searchReturnsOnCall = make(map[int]struct {
+			result1 *ldap.SearchResult
+			result2 error
+		})
+	}
+	fake.searchReturnsOnCall[i] = struct {
+		result1 *ldap.SearchResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeExtendedLdapConnection) SearchWithPaging(arg1 *ldap.SearchRequest, arg2 uint32) (*ldap.SearchResult, error) {
+	fake.searchWithPagingMutex.Lock()
+	ret, specificReturn := fake.searchWithPagingReturnsOnCall[len(fake.searchWithPagingArgsForCall)]
+	fake.searchWithPagingArgsForCall = append(fake.searchWithPagingArgsForCall, struct {
+		arg1 *ldap.SearchRequest
+		arg2 uint32
+	}{arg1, arg2})
+	stub := fake.SearchWithPagingStub
+	fakeReturns := fake.searchWithPagingReturns
+	fake.recordInvocation("SearchWithPaging", []interface{}{arg1, arg2})
+	fake.searchWithPagingMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeExtendedLdapConnection) SearchWithPagingCallCount() int {
+	fake.searchWithPagingMutex.RLock()
+	defer fake.searchWithPagingMutex.RUnlock()
+	return len(fake.searchWithPagingArgsForCall)
+}
+
+func (fake *FakeExtendedLdapConnection) SearchWithPagingCalls(stub func(*ldap.SearchRequest, uint32) (*ldap.SearchResult, error)) {
+	fake.searchWithPagingMutex.Lock()
+	defer fake.searchWithPagingMutex.Unlock()
+	fake.SearchWithPagingStub = stub
+}
+
+func (fake *FakeExtendedLdapConnection) SearchWithPagingArgsForCall(i int) (*ldap.SearchRequest, uint32) {
+	fake.searchWithPagingMutex.RLock()
+	defer fake.searchWithPagingMutex.RUnlock()
+	argsForCall := fake.searchWithPagingArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeExtendedLdapConnection) SearchWithPagingReturns(result1 *ldap.SearchResult, result2 error) {
+	fake.searchWithPagingMutex.Lock()
+	defer fake.searchWithPagingMutex.Unlock()
+	fake.SearchWithPagingStub = nil
+	fake.searchWithPagingReturns = struct {
+		result1 *ldap.SearchResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeExtendedLdapConnection) SearchWithPagingReturnsOnCall(i int, result1 *ldap.SearchResult, result2 error) {
+	fake.searchWithPagingMutex.Lock()
+	defer fake.searchWithPagingMutex.Unlock()
+	fake.SearchWithPagingStub = nil
+	if fake.searchWithPagingReturnsOnCall == nil {
+		fake.searchWithPagingReturnsOnCall = make(map[int]struct {
+			result1 *ldap.SearchResult
+			result2 error
+		})
+	}
+	fake.searchWithPagingReturnsOnCall[i] = struct {
+		result1 *ldap.SearchResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeExtendedLdapConnection) SetTimeout(arg1 time.Duration) {
+	fake.setTimeoutMutex.Lock()
+	fake.setTimeoutArgsForCall = append(fake.setTimeoutArgsForCall, struct {
+		arg1 time.Duration
+	}{arg1})
+	stub := fake.SetTimeoutStub
+	fake.recordInvocation("SetTimeout", []interface{}{arg1})
+	fake.setTimeoutMutex.Unlock()
+	if stub != nil {
+		fake.SetTimeoutStub(arg1)
+	}
+}
+
+func (fake *FakeExtendedLdapConnection) SetTimeoutCallCount() int {
+	fake.setTimeoutMutex.RLock()
+	defer fake.setTimeoutMutex.RUnlock()
+	return len(fake.setTimeoutArgsForCall)
+}
+
+func (fake *FakeExtendedLdapConnection) SetTimeoutCalls(stub func(time.Duration)) {
+	fake.setTimeoutMutex.Lock()
+	defer fake.setTimeoutMutex.Unlock()
+	fake.SetTimeoutStub = stub
+}
+
+func (fake *FakeExtendedLdapConnection) SetTimeoutArgsForCall(i int) time.Duration {
+	fake.setTimeoutMutex.RLock()
+	defer fake.setTimeoutMutex.RUnlock()
+	argsForCall := fake.setTimeoutArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeExtendedLdapConnection) SimpleBind(arg1 *ldap.SimpleBindRequest) (*ldap.SimpleBindResult, error) {
+	fake.simpleBindMutex.Lock()
+	ret, specificReturn := fake.simpleBindReturnsOnCall[len(fake.simpleBindArgsForCall)]
+	fake.simpleBindArgsForCall = append(fake.simpleBindArgsForCall, struct {
+		arg1 *ldap.SimpleBindRequest
+	}{arg1})
+	stub := fake.SimpleBindStub
+	fakeReturns := fake.simpleBindReturns
+	fake.recordInvocation("SimpleBind", []interface{}{arg1})
+	fake.simpleBindMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeExtendedLdapConnection) SimpleBindCallCount() int {
+	fake.simpleBindMutex.RLock()
+	defer fake.simpleBindMutex.RUnlock()
+	return len(fake.simpleBindArgsForCall)
+}
+
+func (fake *FakeExtendedLdapConnection) SimpleBindCalls(stub func(*ldap.SimpleBindRequest) (*ldap.SimpleBindResult, error)) {
+	fake.simpleBindMutex.Lock()
+	defer fake.simpleBindMutex.Unlock()
+	fake.SimpleBindStub = stub
+}
+
+func (fake *FakeExtendedLdapConnection) SimpleBindArgsForCall(i int) *ldap.SimpleBindRequest {
+	fake.simpleBindMutex.RLock()
+	defer fake.simpleBindMutex.RUnlock()
+	argsForCall := fake.simpleBindArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeExtendedLdapConnection) SimpleBindReturns(result1 *ldap.SimpleBindResult, result2 error) {
+	fake.simpleBindMutex.Lock()
+	defer fake.simpleBindMutex.Unlock()
+	fake.SimpleBindStub = nil
+	fake.simpleBindReturns = struct {
+		result1 *ldap.SimpleBindResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeExtendedLdapConnection) SimpleBindReturnsOnCall(i int, result1 *ldap.SimpleBindResult, result2 error) {
+	fake.simpleBindMutex.Lock()
+	defer fake.simpleBindMutex.Unlock()
+	fake.SimpleBindStub = nil
+	if fake.simpleBindReturnsOnCall == nil {
+		fake.simpleBindReturnsOnCall = make(map[int]struct {
+			result1 *ldap.SimpleBindResult
+			result2 error
+		})
+	}
+	fake.simpleBindReturnsOnCall[i] = struct {
+		result1 *ldap.SimpleBindResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeExtendedLdapConnection) StartTLS(arg1 *tls.Config) error {
+	fake.startTLSMutex.Lock()
+	ret, specificReturn := fake.startTLSReturnsOnCall[len(fake.startTLSArgsForCall)]
+	fake.startTLSArgsForCall = append(fake.startTLSArgsForCall, struct {
+		arg1 *tls.Config
+	}{arg1})
+	stub := fake.StartTLSStub
+	fakeReturns := fake.startTLSReturns
+	fake.recordInvocation("StartTLS", []interface{}{arg1})
+	fake.startTLSMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeExtendedLdapConnection) StartTLSCallCount() int {
+	fake.startTLSMutex.RLock()
+	defer fake.startTLSMutex.RUnlock()
+	return len(fake.startTLSArgsForCall)
+}
+
+func (fake *FakeExtendedLdapConnection) StartTLSCalls(stub func(*tls.Config) error) {
+	fake.startTLSMutex.Lock()
+	defer fake.startTLSMutex.Unlock()
+	fake.StartTLSStub = stub
+}
+
+func (fake *FakeExtendedLdapConnection) StartTLSArgsForCall(i int) *tls.Config {
+	fake.startTLSMutex.RLock()
+	defer fake.startTLSMutex.RUnlock()
+	argsForCall := fake.startTLSArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeExtendedLdapConnection) StartTLSReturns(result1 error) {
+	fake.startTLSMutex.Lock()
+	defer fake.startTLSMutex.Unlock()
+	fake.StartTLSStub = nil
+	fake.startTLSReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeExtendedLdapConnection) StartTLSReturnsOnCall(i int, result1 error) {
+	fake.startTLSMutex.Lock()
+	defer fake.startTLSMutex.Unlock()
+	fake.StartTLSStub = nil
+	if fake.startTLSReturnsOnCall == nil {
+		fake.startTLSReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.startTLSReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeExtendedLdapConnection) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.bindMutex.RLock()
+	defer fake.bindMutex.RUnlock()
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
+	fake.searchMutex.RLock()
+	defer fake.searchMutex.RUnlock()
+	fake.searchWithPagingMutex.RLock()
+	defer fake.searchWithPagingMutex.RUnlock()
+	fake.setTimeoutMutex.RLock()
+	defer fake.setTimeoutMutex.RUnlock()
+	fake.simpleBindMutex.RLock()
+	defer fake.simpleBindMutex.RUnlock()
+	fake.startTLSMutex.RLock()
+	defer fake.startTLSMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeExtendedLdapConnection) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ nfsv3driver.ExtendedLdapConnection = new(FakeExtendedLdapConnection)
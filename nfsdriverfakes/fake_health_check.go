@@ -0,0 +1,112 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+type FakeHealthCheck struct {
+	CheckHealthStub        func(dockerdriver.Env) driveradmin.ComponentHealth
+	checkHealthMutex       sync.RWMutex
+	checkHealthArgsForCall []struct {
+		arg1 dockerdriver.Env
+	}
+	checkHealthReturns struct {
+		result1 driveradmin.ComponentHealth
+	}
+	checkHealthReturnsOnCall map[int]struct {
+		result1 driveradmin.ComponentHealth
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeHealthCheck) CheckHealth(arg1 dockerdriver.Env) driveradmin.ComponentHealth {
+	fake.checkHealthMutex.Lock()
+	ret, specificReturn := fake.checkHealthReturnsOnCall[len(fake.checkHealthArgsForCall)]
+	fake.checkHealthArgsForCall = append(fake.checkHealthArgsForCall, struct {
+		arg1 dockerdriver.Env
+	}{arg1})
+	stub := fake.CheckHealthStub
+	fakeReturns := fake.checkHealthReturns
+	fake.recordInvocation("CheckHealth", []interface{}{arg1})
+	fake.checkHealthMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeHealthCheck) CheckHealthCallCount() int {
+	fake.checkHealthMutex.RLock()
+	defer fake.checkHealthMutex.RUnlock()
+	return len(fake.checkHealthArgsForCall)
+}
+
+func (fake *FakeHealthCheck) CheckHealthCalls(stub func(dockerdriver.Env) driveradmin.ComponentHealth) {
+	fake.checkHealthMutex.Lock()
+	defer fake.checkHealthMutex.Unlock()
+	fake.CheckHealthStub = stub
+}
+
+func (fake *FakeHealthCheck) CheckHealthArgsForCall(i int) dockerdriver.Env {
+	fake.checkHealthMutex.RLock()
+	defer fake.checkHealthMutex.RUnlock()
+	argsForCall := fake.checkHealthArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeHealthCheck) CheckHealthReturns(result1 driveradmin.ComponentHealth) {
+	fake.checkHealthMutex.Lock()
+	defer fake.checkHealthMutex.Unlock()
+	fake.CheckHealthStub = nil
+	fake.checkHealthReturns = struct {
+		result1 driveradmin.ComponentHealth
+	}{result1}
+}
+
+func (fake *FakeHealthCheck) CheckHealthReturnsOnCall(i int, result1 driveradmin.ComponentHealth) {
+	fake.checkHealthMutex.Lock()
+	defer fake.checkHealthMutex.Unlock()
+	fake.CheckHealthStub = nil
+	if fake.checkHealthReturnsOnCall == nil {
+		fake.checkHealthReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.ComponentHealth
+		})
+	}
+	fake.checkHealthReturnsOnCall[i] = struct {
+		result1 driveradmin.ComponentHealth
+	}{result1}
+}
+
+func (fake *FakeHealthCheck) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.checkHealthMutex.RLock()
+	defer fake.checkHealthMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeHealthCheck) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ driveradmin.HealthCheck = new(FakeHealthCheck)
@@ -0,0 +1,260 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+type FakeLogController struct {
+	EnableDebugLoggingStub        func(string, string, time.Duration) driveradmin.LogLevelStatus
+	enableDebugLoggingMutex       sync.RWMutex
+	enableDebugLoggingArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 time.Duration
+	}
+	enableDebugLoggingReturns struct {
+		result1 driveradmin.LogLevelStatus
+	}
+	enableDebugLoggingReturnsOnCall map[int]struct {
+		result1 driveradmin.LogLevelStatus
+	}
+	LogLevelStub        func() driveradmin.LogLevelStatus
+	logLevelMutex       sync.RWMutex
+	logLevelArgsForCall []struct {
+	}
+	logLevelReturns struct {
+		result1 driveradmin.LogLevelStatus
+	}
+	logLevelReturnsOnCall map[int]struct {
+		result1 driveradmin.LogLevelStatus
+	}
+	SetLogLevelStub        func(string) (driveradmin.LogLevelStatus, error)
+	setLogLevelMutex       sync.RWMutex
+	setLogLevelArgsForCall []struct {
+		arg1 string
+	}
+	setLogLevelReturns struct {
+		result1 driveradmin.LogLevelStatus
+		result2 error
+	}
+	setLogLevelReturnsOnCall map[int]struct {
+		result1 driveradmin.LogLevelStatus
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeLogController) EnableDebugLogging(arg1 string, arg2 string, arg3 time.Duration) driveradmin.LogLevelStatus {
+	fake.enableDebugLoggingMutex.Lock()
+	ret, specificReturn := fake.enableDebugLoggingReturnsOnCall[len(fake.enableDebugLoggingArgsForCall)]
+	fake.enableDebugLoggingArgsForCall = append(fake.enableDebugLoggingArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 time.Duration
+	}{arg1, arg2, arg3})
+	stub := fake.EnableDebugLoggingStub
+	fakeReturns := fake.enableDebugLoggingReturns
+	fake.recordInvocation("EnableDebugLogging", []interface{}{arg1, arg2, arg3})
+	fake.enableDebugLoggingMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLogController) EnableDebugLoggingCallCount() int {
+	fake.enableDebugLoggingMutex.RLock()
+	defer fake.enableDebugLoggingMutex.RUnlock()
+	return len(fake.enableDebugLoggingArgsForCall)
+}
+
+func (fake *FakeLogController) EnableDebugLoggingCalls(stub func(string, string, time.Duration) driveradmin.LogLevelStatus) {
+	fake.enableDebugLoggingMutex.Lock()
+	defer fake.enableDebugLoggingMutex.Unlock()
+	fake.EnableDebugLoggingStub = stub
+}
+
+func (fake *FakeLogController) EnableDebugLoggingArgsForCall(i int) (string, string, time.Duration) {
+	fake.enableDebugLoggingMutex.RLock()
+	defer fake.enableDebugLoggingMutex.RUnlock()
+	argsForCall := fake.enableDebugLoggingArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeLogController) EnableDebugLoggingReturns(result1 driveradmin.LogLevelStatus) {
+	fake.enableDebugLoggingMutex.Lock()
+	defer fake.enableDebugLoggingMutex.Unlock()
+	fake.EnableDebugLoggingStub = nil
+	fake.enableDebugLoggingReturns = struct {
+		result1 driveradmin.LogLevelStatus
+	}{result1}
+}
+
+func (fake *FakeLogController) EnableDebugLoggingReturnsOnCall(i int, result1 driveradmin.LogLevelStatus) {
+	fake.enableDebugLoggingMutex.Lock()
+	defer fake.enableDebugLoggingMutex.Unlock()
+	fake.EnableDebugLoggingStub = nil
+	if fake.enableDebugLoggingReturnsOnCall == nil {
+		fake.enableDebugLoggingReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.LogLevelStatus
+		})
+	}
+	fake.enableDebugLoggingReturnsOnCall[i] = struct {
+		result1 driveradmin.LogLevelStatus
+	}{result1}
+}
+
+func (fake *FakeLogController) LogLevel() driveradmin.LogLevelStatus {
+	fake.logLevelMutex.Lock()
+	ret, specificReturn := fake.logLevelReturnsOnCall[len(fake.logLevelArgsForCall)]
+	fake.logLevelArgsForCall = append(fake.logLevelArgsForCall, struct {
+	}{})
+	stub := fake.LogLevelStub
+	fakeReturns := fake.logLevelReturns
+	fake.recordInvocation("LogLevel", []interface{}{})
+	fake.logLevelMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLogController) LogLevelCallCount() int {
+	fake.logLevelMutex.RLock()
+	defer fake.logLevelMutex.RUnlock()
+	return len(fake.logLevelArgsForCall)
+}
+
+func (fake *FakeLogController) LogLevelCalls(stub func() driveradmin.LogLevelStatus) {
+	fake.logLevelMutex.Lock()
+	defer fake.logLevelMutex.Unlock()
+	fake.LogLevelStub = stub
+}
+
+func (fake *FakeLogController) LogLevelReturns(result1 driveradmin.LogLevelStatus) {
+	fake.logLevelMutex.Lock()
+	defer fake.logLevelMutex.Unlock()
+	fake.LogLevelStub = nil
+	fake.logLevelReturns = struct {
+		result1 driveradmin.LogLevelStatus
+	}{result1}
+}
+
+func (fake *FakeLogController) LogLevelReturnsOnCall(i int, result1 driveradmin.LogLevelStatus) {
+	fake.logLevelMutex.Lock()
+	defer fake.logLevelMutex.Unlock()
+	fake.LogLevelStub = nil
+	if fake.logLevelReturnsOnCall == nil {
+		fake.logLevelReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.LogLevelStatus
+		})
+	}
+	fake.logLevelReturnsOnCall[i] = struct {
+		result1 driveradmin.LogLevelStatus
+	}{result1}
+}
+
+func (fake *FakeLogController) SetLogLevel(arg1 string) (driveradmin.LogLevelStatus, error) {
+	fake.setLogLevelMutex.Lock()
+	ret, specificReturn := fake.setLogLevelReturnsOnCall[len(fake.setLogLevelArgsForCall)]
+	fake.setLogLevelArgsForCall = append(fake.setLogLevelArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.SetLogLevelStub
+	fakeReturns := fake.setLogLevelReturns
+	fake.recordInvocation("SetLogLevel", []interface{}{arg1})
+	fake.setLogLevelMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeLogController) SetLogLevelCallCount() int {
+	fake.setLogLevelMutex.RLock()
+	defer fake.setLogLevelMutex.RUnlock()
+	return len(fake.setLogLevelArgsForCall)
+}
+
+func (fake *FakeLogController) SetLogLevelCalls(stub func(string) (driveradmin.LogLevelStatus, error)) {
+	fake.setLogLevelMutex.Lock()
+	defer fake.setLogLevelMutex.Unlock()
+	fake.SetLogLevelStub = stub
+}
+
+func (fake *FakeLogController) SetLogLevelArgsForCall(i int) string {
+	fake.setLogLevelMutex.RLock()
+	defer fake.setLogLevelMutex.RUnlock()
+	argsForCall := fake.setLogLevelArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeLogController) SetLogLevelReturns(result1 driveradmin.LogLevelStatus, result2 error) {
+	fake.setLogLevelMutex.Lock()
+	defer fake.setLogLevelMutex.Unlock()
+	fake.SetLogLevelStub = nil
+	fake.setLogLevelReturns = struct {
+		result1 driveradmin.LogLevelStatus
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLogController) SetLogLevelReturnsOnCall(i int, result1 driveradmin.LogLevelStatus, result2 error) {
+	fake.setLogLevelMutex.Lock()
+	defer fake.setLogLevelMutex.Unlock()
+	fake.SetLogLevelStub = nil
+	if fake.setLogLevelReturnsOnCall == nil {
+		fake.setLogLevelReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.LogLevelStatus
+			result2 error
+		})
+	}
+	fake.setLogLevelReturnsOnCall[i] = struct {
+		result1 driveradmin.LogLevelStatus
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLogController) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.enableDebugLoggingMutex.RLock()
+	defer fake.enableDebugLoggingMutex.RUnlock()
+	fake.logLevelMutex.RLock()
+	defer fake.logLevelMutex.RUnlock()
+	fake.setLogLevelMutex.RLock()
+	defer fake.setLogLevelMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeLogController) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ driveradmin.LogController = new(FakeLogController)
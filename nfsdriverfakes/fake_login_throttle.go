@@ -0,0 +1,102 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+type FakeLoginThrottle struct {
+	LoginThrottleStatusStub        func() driveradmin.LoginThrottleStatus
+	loginThrottleStatusMutex       sync.RWMutex
+	loginThrottleStatusArgsForCall []struct {
+	}
+	loginThrottleStatusReturns struct {
+		result1 driveradmin.LoginThrottleStatus
+	}
+	loginThrottleStatusReturnsOnCall map[int]struct {
+		result1 driveradmin.LoginThrottleStatus
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeLoginThrottle) LoginThrottleStatus() driveradmin.LoginThrottleStatus {
+	fake.loginThrottleStatusMutex.Lock()
+	ret, specificReturn := fake.loginThrottleStatusReturnsOnCall[len(fake.loginThrottleStatusArgsForCall)]
+	fake.loginThrottleStatusArgsForCall = append(fake.loginThrottleStatusArgsForCall, struct {
+	}{})
+	stub := fake.LoginThrottleStatusStub
+	fakeReturns := fake.loginThrottleStatusReturns
+	fake.recordInvocation("LoginThrottleStatus", []interface{}{})
+	fake.loginThrottleStatusMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLoginThrottle) LoginThrottleStatusCallCount() int {
+	fake.loginThrottleStatusMutex.RLock()
+	defer fake.loginThrottleStatusMutex.RUnlock()
+	return len(fake.loginThrottleStatusArgsForCall)
+}
+
+func (fake *FakeLoginThrottle) LoginThrottleStatusCalls(stub func() driveradmin.LoginThrottleStatus) {
+	fake.loginThrottleStatusMutex.Lock()
+	defer fake.loginThrottleStatusMutex.Unlock()
+	fake.LoginThrottleStatusStub = stub
+}
+
+func (fake *FakeLoginThrottle) LoginThrottleStatusReturns(result1 driveradmin.LoginThrottleStatus) {
+	fake.loginThrottleStatusMutex.Lock()
+	defer fake.loginThrottleStatusMutex.Unlock()
+	fake.LoginThrottleStatusStub = nil
+	fake.loginThrottleStatusReturns = struct {
+		result1 driveradmin.LoginThrottleStatus
+	}{result1}
+}
+
+func (fake *FakeLoginThrottle) LoginThrottleStatusReturnsOnCall(i int, result1 driveradmin.LoginThrottleStatus) {
+	fake.loginThrottleStatusMutex.Lock()
+	defer fake.loginThrottleStatusMutex.Unlock()
+	fake.LoginThrottleStatusStub = nil
+	if fake.loginThrottleStatusReturnsOnCall == nil {
+		fake.loginThrottleStatusReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.LoginThrottleStatus
+		})
+	}
+	fake.loginThrottleStatusReturnsOnCall[i] = struct {
+		result1 driveradmin.LoginThrottleStatus
+	}{result1}
+}
+
+func (fake *FakeLoginThrottle) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.loginThrottleStatusMutex.RLock()
+	defer fake.loginThrottleStatusMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeLoginThrottle) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ driveradmin.LoginThrottle = new(FakeLoginThrottle)
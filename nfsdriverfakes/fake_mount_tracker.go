@@ -0,0 +1,392 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/nfsv3driver"
+)
+
+type FakeMountTracker struct {
+	CheckStub        func(dockerdriver.Env, string, string) bool
+	checkMutex       sync.RWMutex
+	checkArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 string
+		arg3 string
+	}
+	checkReturns struct {
+		result1 bool
+	}
+	checkReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	MountStub        func(dockerdriver.Env, string, string, map[string]interface{}) error
+	mountMutex       sync.RWMutex
+	mountArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 string
+		arg3 string
+		arg4 map[string]interface{}
+	}
+	mountReturns struct {
+		result1 error
+	}
+	mountReturnsOnCall map[int]struct {
+		result1 error
+	}
+	PurgeStub        func(dockerdriver.Env, string)
+	purgeMutex       sync.RWMutex
+	purgeArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 string
+	}
+	RecordStub        func(string) (nfsv3driver.MountRecord, bool)
+	recordMutex       sync.RWMutex
+	recordArgsForCall []struct {
+		arg1 string
+	}
+	recordReturns struct {
+		result1 nfsv3driver.MountRecord
+		result2 bool
+	}
+	recordReturnsOnCall map[int]struct {
+		result1 nfsv3driver.MountRecord
+		result2 bool
+	}
+	UnmountStub        func(dockerdriver.Env, string) error
+	unmountMutex       sync.RWMutex
+	unmountArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 string
+	}
+	unmountReturns struct {
+		result1 error
+	}
+	unmountReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeMountTracker) Check(arg1 dockerdriver.Env, arg2 string, arg3 string) bool {
+	fake.checkMutex.Lock()
+	ret, specificReturn := fake.checkReturnsOnCall[len(fake.checkArgsForCall)]
+	fake.checkArgsForCall = append(fake.checkArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.CheckStub
+	fakeReturns := fake.checkReturns
+	fake.recordInvocation("Check", []interface{}{arg1, arg2, arg3})
+	fake.checkMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeMountTracker) CheckCallCount() int {
+	fake.checkMutex.RLock()
+	defer fake.checkMutex.RUnlock()
+	return len(fake.checkArgsForCall)
+}
+
+func (fake *FakeMountTracker) CheckCalls(stub func(dockerdriver.Env, string, string) bool) {
+	fake.checkMutex.Lock()
+	defer fake.checkMutex.Unlock()
+	fake.CheckStub = stub
+}
+
+func (fake *FakeMountTracker) CheckArgsForCall(i int) (dockerdriver.Env, string, string) {
+	fake.checkMutex.RLock()
+	defer fake.checkMutex.RUnlock()
+	argsForCall := fake.checkArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeMountTracker) CheckReturns(result1 bool) {
+	fake.checkMutex.Lock()
+	defer fake.checkMutex.Unlock()
+	fake.CheckStub = nil
+	fake.checkReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeMountTracker) CheckReturnsOnCall(i int, result1 bool) {
+	fake.checkMutex.Lock()
+	defer fake.checkMutex.Unlock()
+	fake.CheckStub = nil
+	if fake.checkReturnsOnCall == nil {
+		fake.checkReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.checkReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeMountTracker) Mount(arg1 dockerdriver.Env, arg2 string, arg3 string, arg4 map[string]interface{}) error {
+	fake.mountMutex.Lock()
+	ret, specificReturn := fake.mountReturnsOnCall[len(fake.mountArgsForCall)]
+	fake.mountArgsForCall = append(fake.mountArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 string
+		arg3 string
+		arg4 map[string]interface{}
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.MountStub
+	fakeReturns := fake.mountReturns
+	fake.recordInvocation("Mount", []interface{}{arg1, arg2, arg3, arg4})
+	fake.mountMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeMountTracker) MountCallCount() int {
+	fake.mountMutex.RLock()
+	defer fake.mountMutex.RUnlock()
+	return len(fake.mountArgsForCall)
+}
+
+func (fake *FakeMountTracker) MountCalls(stub func(dockerdriver.Env, string, string, map[string]interface{}) error) {
+	fake.mountMutex.Lock()
+	defer fake.mountMutex.Unlock()
+	fake.MountStub = stub
+}
+
+func (fake *FakeMountTracker) MountArgsForCall(i int) (dockerdriver.Env, string, string, map[string]interface{}) {
+	fake.mountMutex.RLock()
+	defer fake.mountMutex.RUnlock()
+	argsForCall := fake.mountArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeMountTracker) MountReturns(result1 error) {
+	fake.mountMutex.Lock()
+	defer fake.mountMutex.Unlock()
+	fake.MountStub = nil
+	fake.mountReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMountTracker) MountReturnsOnCall(i int, result1 error) {
+	fake.mountMutex.Lock()
+	defer fake.mountMutex.Unlock()
+	fake.MountStub = nil
+	if fake.mountReturnsOnCall == nil {
+		fake.mountReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.mountReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMountTracker) Purge(arg1 dockerdriver.Env, arg2 string) {
+	fake.purgeMutex.Lock()
+	fake.purgeArgsForCall = append(fake.purgeArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.PurgeStub
+	fake.recordInvocation("Purge", []interface{}{arg1, arg2})
+	fake.purgeMutex.Unlock()
+	if stub != nil {
+		fake.PurgeStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeMountTracker) PurgeCallCount() int {
+	fake.purgeMutex.RLock()
+	defer fake.purgeMutex.RUnlock()
+	return len(fake.purgeArgsForCall)
+}
+
+func (fake *FakeMountTracker) PurgeCalls(stub func(dockerdriver.Env, string)) {
+	fake.purgeMutex.Lock()
+	defer fake.purgeMutex.Unlock()
+	fake.PurgeStub = stub
+}
+
+func (fake *FakeMountTracker) PurgeArgsForCall(i int) (dockerdriver.Env, string) {
+	fake.purgeMutex.RLock()
+	defer fake.purgeMutex.RUnlock()
+	argsForCall := fake.purgeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeMountTracker) Record(arg1 string) (nfsv3driver.MountRecord, bool) {
+	fake.recordMutex.Lock()
+	ret, specificReturn := fake.recordReturnsOnCall[len(fake.recordArgsForCall)]
+	fake.recordArgsForCall = append(fake.recordArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.RecordStub
+	fakeReturns := fake.recordReturns
+	fake.recordInvocation("Record", []interface{}{arg1})
+	fake.recordMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeMountTracker) RecordCallCount() int {
+	fake.recordMutex.RLock()
+	defer fake.recordMutex.RUnlock()
+	return len(fake.recordArgsForCall)
+}
+
+func (fake *FakeMountTracker) RecordCalls(stub func(string) (nfsv3driver.MountRecord, bool)) {
+	fake.recordMutex.Lock()
+	defer fake.recordMutex.Unlock()
+	fake.RecordStub = stub
+}
+
+func (fake *FakeMountTracker) RecordArgsForCall(i int) string {
+	fake.recordMutex.RLock()
+	defer fake.recordMutex.RUnlock()
+	argsForCall := fake.recordArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeMountTracker) RecordReturns(result1 nfsv3driver.MountRecord, result2 bool) {
+	fake.recordMutex.Lock()
+	defer fake.recordMutex.Unlock()
+	fake.RecordStub = nil
+	fake.recordReturns = struct {
+		result1 nfsv3driver.MountRecord
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeMountTracker) RecordReturnsOnCall(i int, result1 nfsv3driver.MountRecord, result2 bool) {
+	fake.recordMutex.Lock()
+	defer fake.recordMutex.Unlock()
+	fake.RecordStub = nil
+	if fake.recordReturnsOnCall == nil {
+		fake.recordReturnsOnCall = make(map[int]struct {
+			result1 nfsv3driver.MountRecord
+			result2 bool
+		})
+	}
+	fake.recordReturnsOnCall[i] = struct {
+		result1 nfsv3driver.MountRecord
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeMountTracker) Unmount(arg1 dockerdriver.Env, arg2 string) error {
+	fake.unmountMutex.Lock()
+	ret, specificReturn := fake.unmountReturnsOnCall[len(fake.unmountArgsForCall)]
+	fake.unmountArgsForCall = append(fake.unmountArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.UnmountStub
+	fakeReturns := fake.unmountReturns
+	fake.recordInvocation("Unmount", []interface{}{arg1, arg2})
+	fake.unmountMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeMountTracker) UnmountCallCount() int {
+	fake.unmountMutex.RLock()
+	defer fake.unmountMutex.RUnlock()
+	return len(fake.unmountArgsForCall)
+}
+
+func (fake *FakeMountTracker) UnmountCalls(stub func(dockerdriver.Env, string) error) {
+	fake.unmountMutex.Lock()
+	defer fake.unmountMutex.Unlock()
+	fake.UnmountStub = stub
+}
+
+func (fake *FakeMountTracker) UnmountArgsForCall(i int) (dockerdriver.Env, string) {
+	fake.unmountMutex.RLock()
+	defer fake.unmountMutex.RUnlock()
+	argsForCall := fake.unmountArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeMountTracker) UnmountReturns(result1 error) {
+	fake.unmountMutex.Lock()
+	defer fake.unmountMutex.Unlock()
+	fake.UnmountStub = nil
+	fake.unmountReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMountTracker) UnmountReturnsOnCall(i int, result1 error) {
+	fake.unmountMutex.Lock()
+	defer fake.unmountMutex.Unlock()
+	fake.UnmountStub = nil
+	if fake.unmountReturnsOnCall == nil {
+		fake.unmountReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.unmountReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMountTracker) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.checkMutex.RLock()
+	defer fake.checkMutex.RUnlock()
+	fake.mountMutex.RLock()
+	defer fake.mountMutex.RUnlock()
+	fake.purgeMutex.RLock()
+	defer fake.purgeMutex.RUnlock()
+	fake.recordMutex.RLock()
+	defer fake.recordMutex.RUnlock()
+	fake.unmountMutex.RLock()
+	defer fake.unmountMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeMountTracker) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ nfsv3driver.MountTracker = new(FakeMountTracker)
@@ -0,0 +1,116 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/nfsv3driver"
+)
+
+type FakePasswordDecrypter struct {
+	DecryptPasswordStub        func(string) ([]byte, error)
+	decryptPasswordMutex       sync.RWMutex
+	decryptPasswordArgsForCall []struct {
+		arg1 string
+	}
+	decryptPasswordReturns struct {
+		result1 []byte
+		result2 error
+	}
+	decryptPasswordReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakePasswordDecrypter) DecryptPassword(arg1 string) ([]byte, error) {
+	fake.decryptPasswordMutex.Lock()
+	ret, specificReturn := fake.decryptPasswordReturnsOnCall[len(fake.decryptPasswordArgsForCall)]
+	fake.decryptPasswordArgsForCall = append(fake.decryptPasswordArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.DecryptPasswordStub
+	fakeReturns := fake.decryptPasswordReturns
+	fake.recordInvocation("DecryptPassword", []interface{}{arg1})
+	fake.decryptPasswordMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakePasswordDecrypter) DecryptPasswordCallCount() int {
+	fake.decryptPasswordMutex.RLock()
+	defer fake.decryptPasswordMutex.RUnlock()
+	return len(fake.decryptPasswordArgsForCall)
+}
+
+func (fake *FakePasswordDecrypter) DecryptPasswordCalls(stub func(string) ([]byte, error)) {
+	fake.decryptPasswordMutex.Lock()
+	defer fake.decryptPasswordMutex.Unlock()
+	fake.DecryptPasswordStub = stub
+}
+
+func (fake *FakePasswordDecrypter) DecryptPasswordArgsForCall(i int) string {
+	fake.decryptPasswordMutex.RLock()
+	defer fake.decryptPasswordMutex.RUnlock()
+	argsForCall := fake.decryptPasswordArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakePasswordDecrypter) DecryptPasswordReturns(result1 []byte, result2 error) {
+	fake.decryptPasswordMutex.Lock()
+	defer fake.decryptPasswordMutex.Unlock()
+	fake.DecryptPasswordStub = nil
+	fake.decryptPasswordReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakePasswordDecrypter) DecryptPasswordReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.decryptPasswordMutex.Lock()
+	defer fake.decryptPasswordMutex.Unlock()
+	fake.DecryptPasswordStub = nil
+	if fake.decryptPasswordReturnsOnCall == nil {
+		fake.decryptPasswordReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.decryptPasswordReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakePasswordDecrypter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.decryptPasswordMutex.RLock()
+	defer fake.decryptPasswordMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakePasswordDecrypter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ nfsv3driver.PasswordDecrypter = new(FakePasswordDecrypter)
@@ -0,0 +1,102 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+type FakePasswordKeySource struct {
+	PasswordKeyStub        func() driveradmin.PasswordKey
+	passwordKeyMutex       sync.RWMutex
+	passwordKeyArgsForCall []struct {
+	}
+	passwordKeyReturns struct {
+		result1 driveradmin.PasswordKey
+	}
+	passwordKeyReturnsOnCall map[int]struct {
+		result1 driveradmin.PasswordKey
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakePasswordKeySource) PasswordKey() driveradmin.PasswordKey {
+	fake.passwordKeyMutex.Lock()
+	ret, specificReturn := fake.passwordKeyReturnsOnCall[len(fake.passwordKeyArgsForCall)]
+	fake.passwordKeyArgsForCall = append(fake.passwordKeyArgsForCall, struct {
+	}{})
+	stub := fake.PasswordKeyStub
+	fakeReturns := fake.passwordKeyReturns
+	fake.recordInvocation("PasswordKey", []interface{}{})
+	fake.passwordKeyMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakePasswordKeySource) PasswordKeyCallCount() int {
+	fake.passwordKeyMutex.RLock()
+	defer fake.passwordKeyMutex.RUnlock()
+	return len(fake.passwordKeyArgsForCall)
+}
+
+func (fake *FakePasswordKeySource) PasswordKeyCalls(stub func() driveradmin.PasswordKey) {
+	fake.passwordKeyMutex.Lock()
+	defer fake.passwordKeyMutex.Unlock()
+	fake.PasswordKeyStub = stub
+}
+
+func (fake *FakePasswordKeySource) PasswordKeyReturns(result1 driveradmin.PasswordKey) {
+	fake.passwordKeyMutex.Lock()
+	defer fake.passwordKeyMutex.Unlock()
+	fake.PasswordKeyStub = nil
+	fake.passwordKeyReturns = struct {
+		result1 driveradmin.PasswordKey
+	}{result1}
+}
+
+func (fake *FakePasswordKeySource) PasswordKeyReturnsOnCall(i int, result1 driveradmin.PasswordKey) {
+	fake.passwordKeyMutex.Lock()
+	defer fake.passwordKeyMutex.Unlock()
+	fake.PasswordKeyStub = nil
+	if fake.passwordKeyReturnsOnCall == nil {
+		fake.passwordKeyReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.PasswordKey
+		})
+	}
+	fake.passwordKeyReturnsOnCall[i] = struct {
+		result1 driveradmin.PasswordKey
+	}{result1}
+}
+
+func (fake *FakePasswordKeySource) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.passwordKeyMutex.RLock()
+	defer fake.passwordKeyMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakePasswordKeySource) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ driveradmin.PasswordKeySource = new(FakePasswordKeySource)
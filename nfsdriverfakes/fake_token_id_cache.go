@@ -0,0 +1,164 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/nfsv3driver"
+)
+
+type FakeTokenIdCache struct {
+	SetTokenIdsStub        func(string, string, string)
+	setTokenIdsMutex       sync.RWMutex
+	setTokenIdsArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}
+	TokenIdsStub        func(string) (string, string, bool)
+	tokenIdsMutex       sync.RWMutex
+	tokenIdsArgsForCall []struct {
+		arg1 string
+	}
+	tokenIdsReturns struct {
+		result1 string
+		result2 string
+		result3 bool
+	}
+	tokenIdsReturnsOnCall map[int]struct {
+		result1 string
+		result2 string
+		result3 bool
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeTokenIdCache) SetTokenIds(arg1 string, arg2 string, arg3 string) {
+	fake.setTokenIdsMutex.Lock()
+	fake.setTokenIdsArgsForCall = append(fake.setTokenIdsArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.SetTokenIdsStub
+	fake.recordInvocation("SetTokenIds", []interface{}{arg1, arg2, arg3})
+	fake.setTokenIdsMutex.Unlock()
+	if stub != nil {
+		fake.SetTokenIdsStub(arg1, arg2, arg3)
+	}
+}
+
+func (fake *FakeTokenIdCache) SetTokenIdsCallCount() int {
+	fake.setTokenIdsMutex.RLock()
+	defer fake.setTokenIdsMutex.RUnlock()
+	return len(fake.setTokenIdsArgsForCall)
+}
+
+func (fake *FakeTokenIdCache) SetTokenIdsCalls(stub func(string, string, string)) {
+	fake.setTokenIdsMutex.Lock()
+	defer fake.setTokenIdsMutex.Unlock()
+	fake.SetTokenIdsStub = stub
+}
+
+func (fake *FakeTokenIdCache) SetTokenIdsArgsForCall(i int) (string, string, string) {
+	fake.setTokenIdsMutex.RLock()
+	defer fake.setTokenIdsMutex.RUnlock()
+	argsForCall := fake.setTokenIdsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeTokenIdCache) TokenIds(arg1 string) (string, string, bool) {
+	fake.tokenIdsMutex.Lock()
+	ret, specificReturn := fake.tokenIdsReturnsOnCall[len(fake.tokenIdsArgsForCall)]
+	fake.tokenIdsArgsForCall = append(fake.tokenIdsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.TokenIdsStub
+	fakeReturns := fake.tokenIdsReturns
+	fake.recordInvocation("TokenIds", []interface{}{arg1})
+	fake.tokenIdsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeTokenIdCache) TokenIdsCallCount() int {
+	fake.tokenIdsMutex.RLock()
+	defer fake.tokenIdsMutex.RUnlock()
+	return len(fake.tokenIdsArgsForCall)
+}
+
+func (fake *FakeTokenIdCache) TokenIdsCalls(stub func(string) (string, string, bool)) {
+	fake.tokenIdsMutex.Lock()
+	defer fake.tokenIdsMutex.Unlock()
+	fake.TokenIdsStub = stub
+}
+
+func (fake *FakeTokenIdCache) TokenIdsArgsForCall(i int) string {
+	fake.tokenIdsMutex.RLock()
+	defer fake.tokenIdsMutex.RUnlock()
+	argsForCall := fake.tokenIdsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeTokenIdCache) TokenIdsReturns(result1 string, result2 string, result3 bool) {
+	fake.tokenIdsMutex.Lock()
+	defer fake.tokenIdsMutex.Unlock()
+	fake.TokenIdsStub = nil
+	fake.tokenIdsReturns = struct {
+		result1 string
+		result2 string
+		result3 bool
+	}{result1, result2, result3}
+}
+
+func (fake *FakeTokenIdCache) TokenIdsReturnsOnCall(i int, result1 string, result2 string, result3 bool) {
+	fake.tokenIdsMutex.Lock()
+	defer fake.tokenIdsMutex.Unlock()
+	fake.TokenIdsStub = nil
+	if fake.tokenIdsReturnsOnCall == nil {
+		fake.tokenIdsReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 string
+			result3 bool
+		})
+	}
+	fake.tokenIdsReturnsOnCall[i] = struct {
+		result1 string
+		result2 string
+		result3 bool
+	}{result1, result2, result3}
+}
+
+func (fake *FakeTokenIdCache) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.setTokenIdsMutex.RLock()
+	defer fake.setTokenIdsMutex.RUnlock()
+	fake.tokenIdsMutex.RLock()
+	defer fake.tokenIdsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeTokenIdCache) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ nfsv3driver.TokenIdCache = new(FakeTokenIdCache)
@@ -0,0 +1,124 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/nfsv3driver"
+)
+
+type FakeTokenResolver struct {
+	ResolveTokenStub        func(dockerdriver.Env, string) (string, string, error)
+	resolveTokenMutex       sync.RWMutex
+	resolveTokenArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 string
+	}
+	resolveTokenReturns struct {
+		result1 string
+		result2 string
+		result3 error
+	}
+	resolveTokenReturnsOnCall map[int]struct {
+		result1 string
+		result2 string
+		result3 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeTokenResolver) ResolveToken(arg1 dockerdriver.Env, arg2 string) (string, string, error) {
+	fake.resolveTokenMutex.Lock()
+	ret, specificReturn := fake.resolveTokenReturnsOnCall[len(fake.resolveTokenArgsForCall)]
+	fake.resolveTokenArgsForCall = append(fake.resolveTokenArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.ResolveTokenStub
+	fakeReturns := fake.resolveTokenReturns
+	fake.recordInvocation("ResolveToken", []interface{}{arg1, arg2})
+	fake.resolveTokenMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeTokenResolver) ResolveTokenCallCount() int {
+	fake.resolveTokenMutex.RLock()
+	defer fake.resolveTokenMutex.RUnlock()
+	return len(fake.resolveTokenArgsForCall)
+}
+
+func (fake *FakeTokenResolver) ResolveTokenCalls(stub func(dockerdriver.Env, string) (string, string, error)) {
+	fake.resolveTokenMutex.Lock()
+	defer fake.resolveTokenMutex.Unlock()
+	fake.ResolveTokenStub = stub
+}
+
+func (fake *FakeTokenResolver) ResolveTokenArgsForCall(i int) (dockerdriver.Env, string) {
+	fake.resolveTokenMutex.RLock()
+	defer fake.resolveTokenMutex.RUnlock()
+	argsForCall := fake.resolveTokenArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeTokenResolver) ResolveTokenReturns(result1 string, result2 string, result3 error) {
+	fake.resolveTokenMutex.Lock()
+	defer fake.resolveTokenMutex.Unlock()
+	fake.ResolveTokenStub = nil
+	fake.resolveTokenReturns = struct {
+		result1 string
+		result2 string
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeTokenResolver) ResolveTokenReturnsOnCall(i int, result1 string, result2 string, result3 error) {
+	fake.resolveTokenMutex.Lock()
+	defer fake.resolveTokenMutex.Unlock()
+	fake.ResolveTokenStub = nil
+	if fake.resolveTokenReturnsOnCall == nil {
+		fake.resolveTokenReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 string
+			result3 error
+		})
+	}
+	fake.resolveTokenReturnsOnCall[i] = struct {
+		result1 string
+		result2 string
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeTokenResolver) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.resolveTokenMutex.RLock()
+	defer fake.resolveTokenMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeTokenResolver) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ nfsv3driver.TokenResolver = new(FakeTokenResolver)
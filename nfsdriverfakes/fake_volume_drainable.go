@@ -0,0 +1,262 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+type FakeVolumeDrainable struct {
+	DrainStub        func(dockerdriver.Env) error
+	drainMutex       sync.RWMutex
+	drainArgsForCall []struct {
+		arg1 dockerdriver.Env
+	}
+	drainReturns struct {
+		result1 error
+	}
+	drainReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ListStub        func(dockerdriver.Env) dockerdriver.ListResponse
+	listMutex       sync.RWMutex
+	listArgsForCall []struct {
+		arg1 dockerdriver.Env
+	}
+	listReturns struct {
+		result1 dockerdriver.ListResponse
+	}
+	listReturnsOnCall map[int]struct {
+		result1 dockerdriver.ListResponse
+	}
+	RemoveStub        func(dockerdriver.Env, dockerdriver.RemoveRequest) dockerdriver.ErrorResponse
+	removeMutex       sync.RWMutex
+	removeArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 dockerdriver.RemoveRequest
+	}
+	removeReturns struct {
+		result1 dockerdriver.ErrorResponse
+	}
+	removeReturnsOnCall map[int]struct {
+		result1 dockerdriver.ErrorResponse
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeVolumeDrainable) Drain(arg1 dockerdriver.Env) error {
+	fake.drainMutex.Lock()
+	ret, specificReturn := fake.drainReturnsOnCall[len(fake.drainArgsForCall)]
+	fake.drainArgsForCall = append(fake.drainArgsForCall, struct {
+		arg1 dockerdriver.Env
+	}{arg1})
+	stub := fake.DrainStub
+	fakeReturns := fake.drainReturns
+	fake.recordInvocation("Drain", []interface{}{arg1})
+	fake.drainMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeVolumeDrainable) DrainCallCount() int {
+	fake.drainMutex.RLock()
+	defer fake.drainMutex.RUnlock()
+	return len(fake.drainArgsForCall)
+}
+
+func (fake *FakeVolumeDrainable) DrainCalls(stub func(dockerdriver.Env) error) {
+	fake.drainMutex.Lock()
+	defer fake.drainMutex.Unlock()
+	fake.DrainStub = stub
+}
+
+func (fake *FakeVolumeDrainable) DrainArgsForCall(i int) dockerdriver.Env {
+	fake.drainMutex.RLock()
+	defer fake.drainMutex.RUnlock()
+	argsForCall := fake.drainArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeVolumeDrainable) DrainReturns(result1 error) {
+	fake.drainMutex.Lock()
+	defer fake.drainMutex.Unlock()
+	fake.DrainStub = nil
+	fake.drainReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeVolumeDrainable) DrainReturnsOnCall(i int, result1 error) {
+	fake.drainMutex.Lock()
+	defer fake.drainMutex.Unlock()
+	fake.DrainStub = nil
+	if fake.drainReturnsOnCall == nil {
+		fake.drainReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.drainReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeVolumeDrainable) List(arg1 dockerdriver.Env) dockerdriver.ListResponse {
+	fake.listMutex.Lock()
+	ret, specificReturn := fake.listReturnsOnCall[len(fake.listArgsForCall)]
+	fake.listArgsForCall = append(fake.listArgsForCall, struct {
+		arg1 dockerdriver.Env
+	}{arg1})
+	stub := fake.ListStub
+	fakeReturns := fake.listReturns
+	fake.recordInvocation("List", []interface{}{arg1})
+	fake.listMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeVolumeDrainable) ListCallCount() int {
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	return len(fake.listArgsForCall)
+}
+
+func (fake *FakeVolumeDrainable) ListCalls(stub func(dockerdriver.Env) dockerdriver.ListResponse) {
+	fake.listMutex.Lock()
+	defer fake.listMutex.Unlock()
+	fake.ListStub = stub
+}
+
+func (fake *FakeVolumeDrainable) ListArgsForCall(i int) dockerdriver.Env {
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	argsForCall := fake.listArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeVolumeDrainable) ListReturns(result1 dockerdriver.ListResponse) {
+	fake.listMutex.Lock()
+	defer fake.listMutex.Unlock()
+	fake.ListStub = nil
+	fake.listReturns = struct {
+		result1 dockerdriver.ListResponse
+	}{result1}
+}
+
+func (fake *FakeVolumeDrainable) ListReturnsOnCall(i int, result1 dockerdriver.ListResponse) {
+	fake.listMutex.Lock()
+	defer fake.listMutex.Unlock()
+	fake.ListStub = nil
+	if fake.listReturnsOnCall == nil {
+		fake.listReturnsOnCall = make(map[int]struct {
+			result1 dockerdriver.ListResponse
+		})
+	}
+	fake.listReturnsOnCall[i] = struct {
+		result1 dockerdriver.ListResponse
+	}{result1}
+}
+
+func (fake *FakeVolumeDrainable) Remove(arg1 dockerdriver.Env, arg2 dockerdriver.RemoveRequest) dockerdriver.ErrorResponse {
+	fake.removeMutex.Lock()
+	ret, specificReturn := fake.removeReturnsOnCall[len(fake.removeArgsForCall)]
+	fake.removeArgsForCall = append(fake.removeArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 dockerdriver.RemoveRequest
+	}{arg1, arg2})
+	stub := fake.RemoveStub
+	fakeReturns := fake.removeReturns
+	fake.recordInvocation("Remove", []interface{}{arg1, arg2})
+	fake.removeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeVolumeDrainable) RemoveCallCount() int {
+	fake.removeMutex.RLock()
+	defer fake.removeMutex.RUnlock()
+	return len(fake.removeArgsForCall)
+}
+
+func (fake *FakeVolumeDrainable) RemoveCalls(stub func(dockerdriver.Env, dockerdriver.RemoveRequest) dockerdriver.ErrorResponse) {
+	fake.removeMutex.Lock()
+	defer fake.removeMutex.Unlock()
+	fake.RemoveStub = stub
+}
+
+func (fake *FakeVolumeDrainable) RemoveArgsForCall(i int) (dockerdriver.Env, dockerdriver.RemoveRequest) {
+	fake.removeMutex.RLock()
+	defer fake.removeMutex.RUnlock()
+	argsForCall := fake.removeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeVolumeDrainable) RemoveReturns(result1 dockerdriver.ErrorResponse) {
+	fake.removeMutex.Lock()
+	defer fake.removeMutex.Unlock()
+	fake.RemoveStub = nil
+	fake.removeReturns = struct {
+		result1 dockerdriver.ErrorResponse
+	}{result1}
+}
+
+func (fake *FakeVolumeDrainable) RemoveReturnsOnCall(i int, result1 dockerdriver.ErrorResponse) {
+	fake.removeMutex.Lock()
+	defer fake.removeMutex.Unlock()
+	fake.RemoveStub = nil
+	if fake.removeReturnsOnCall == nil {
+		fake.removeReturnsOnCall = make(map[int]struct {
+			result1 dockerdriver.ErrorResponse
+		})
+	}
+	fake.removeReturnsOnCall[i] = struct {
+		result1 dockerdriver.ErrorResponse
+	}{result1}
+}
+
+func (fake *FakeVolumeDrainable) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.drainMutex.RLock()
+	defer fake.drainMutex.RUnlock()
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	fake.removeMutex.RLock()
+	defer fake.removeMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeVolumeDrainable) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ driveradmin.VolumeDrainable = new(FakeVolumeDrainable)
@@ -0,0 +1,117 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+type FakeVolumeInspector struct {
+	InspectVolumesStub        func(dockerdriver.Env) ([]driveradmin.VolumeStatus, error)
+	inspectVolumesMutex       sync.RWMutex
+	inspectVolumesArgsForCall []struct {
+		arg1 dockerdriver.Env
+	}
+	inspectVolumesReturns struct {
+		result1 []driveradmin.VolumeStatus
+		result2 error
+	}
+	inspectVolumesReturnsOnCall map[int]struct {
+		result1 []driveradmin.VolumeStatus
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeVolumeInspector) InspectVolumes(arg1 dockerdriver.Env) ([]driveradmin.VolumeStatus, error) {
+	fake.inspectVolumesMutex.Lock()
+	ret, specificReturn := fake.inspectVolumesReturnsOnCall[len(fake.inspectVolumesArgsForCall)]
+	fake.inspectVolumesArgsForCall = append(fake.inspectVolumesArgsForCall, struct {
+		arg1 dockerdriver.Env
+	}{arg1})
+	stub := fake.InspectVolumesStub
+	fakeReturns := fake.inspectVolumesReturns
+	fake.recordInvocation("InspectVolumes", []interface{}{arg1})
+	fake.inspectVolumesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeVolumeInspector) InspectVolumesCallCount() int {
+	fake.inspectVolumesMutex.RLock()
+	defer fake.inspectVolumesMutex.RUnlock()
+	return len(fake.inspectVolumesArgsForCall)
+}
+
+func (fake *FakeVolumeInspector) InspectVolumesCalls(stub func(dockerdriver.Env) ([]driveradmin.VolumeStatus, error)) {
+	fake.inspectVolumesMutex.Lock()
+	defer fake.inspectVolumesMutex.Unlock()
+	fake.InspectVolumesStub = stub
+}
+
+func (fake *FakeVolumeInspector) InspectVolumesArgsForCall(i int) dockerdriver.Env {
+	fake.inspectVolumesMutex.RLock()
+	defer fake.inspectVolumesMutex.RUnlock()
+	argsForCall := fake.inspectVolumesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeVolumeInspector) InspectVolumesReturns(result1 []driveradmin.VolumeStatus, result2 error) {
+	fake.inspectVolumesMutex.Lock()
+	defer fake.inspectVolumesMutex.Unlock()
+	fake.InspectVolumesStub = nil
+	fake.inspectVolumesReturns = struct {
+		result1 []driveradmin.VolumeStatus
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeVolumeInspector) InspectVolumesReturnsOnCall(i int, result1 []driveradmin.VolumeStatus, result2 error) {
+	fake.inspectVolumesMutex.Lock()
+	defer fake.inspectVolumesMutex.Unlock()
+	fake.InspectVolumesStub = nil
+	if fake.inspectVolumesReturnsOnCall == nil {
+		fake.inspectVolumesReturnsOnCall = make(map[int]struct {
+			result1 []driveradmin.VolumeStatus
+			result2 error
+		})
+	}
+	fake.inspectVolumesReturnsOnCall[i] = struct {
+		result1 []driveradmin.VolumeStatus
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeVolumeInspector) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.inspectVolumesMutex.RLock()
+	defer fake.inspectVolumesMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeVolumeInspector) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ driveradmin.VolumeInspector = new(FakeVolumeInspector)
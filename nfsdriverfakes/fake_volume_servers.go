@@ -0,0 +1,111 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/nfsv3driver"
+)
+
+type FakeVolumeServers struct {
+	VolumeServerStub        func(string) string
+	volumeServerMutex       sync.RWMutex
+	volumeServerArgsForCall []struct {
+		arg1 string
+	}
+	volumeServerReturns struct {
+		result1 string
+	}
+	volumeServerReturnsOnCall map[int]struct {
+		result1 string
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeVolumeServers) VolumeServer(arg1 string) string {
+	fake.volumeServerMutex.Lock()
+	ret, specificReturn := fake.volumeServerReturnsOnCall[len(fake.volumeServerArgsForCall)]
+	fake.volumeServerArgsForCall = append(fake.volumeServerArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.VolumeServerStub
+	fakeReturns := fake.volumeServerReturns
+	fake.recordInvocation("VolumeServer", []interface{}{arg1})
+	fake.volumeServerMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeVolumeServers) VolumeServerCallCount() int {
+	fake.volumeServerMutex.RLock()
+	defer fake.volumeServerMutex.RUnlock()
+	return len(fake.volumeServerArgsForCall)
+}
+
+func (fake *FakeVolumeServers) VolumeServerCalls(stub func(string) string) {
+	fake.volumeServerMutex.Lock()
+	defer fake.volumeServerMutex.Unlock()
+	fake.VolumeServerStub = stub
+}
+
+func (fake *FakeVolumeServers) VolumeServerArgsForCall(i int) string {
+	fake.volumeServerMutex.RLock()
+	defer fake.volumeServerMutex.RUnlock()
+	argsForCall := fake.volumeServerArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeVolumeServers) VolumeServerReturns(result1 string) {
+	fake.volumeServerMutex.Lock()
+	defer fake.volumeServerMutex.Unlock()
+	fake.VolumeServerStub = nil
+	fake.volumeServerReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeVolumeServers) VolumeServerReturnsOnCall(i int, result1 string) {
+	fake.volumeServerMutex.Lock()
+	defer fake.volumeServerMutex.Unlock()
+	fake.VolumeServerStub = nil
+	if fake.volumeServerReturnsOnCall == nil {
+		fake.volumeServerReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.volumeServerReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeVolumeServers) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.volumeServerMutex.RLock()
+	defer fake.volumeServerMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeVolumeServers) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ nfsv3driver.VolumeServers = new(FakeVolumeServers)
@@ -1,6 +1,7 @@
 package nfsv3driver_test
 
 import (
+	"os"
 	"testing"
 	"time"
 
@@ -10,6 +11,12 @@ import (
 )
 
 func TestNfsV3Driver(t *testing.T) {
+	// the resolver helper specs run this binary as their helper process
+	if os.Getenv(resolverHelperTestEnv) != "" {
+		serveTestResolverHelper()
+		return
+	}
+
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "NFS V3 Driver Suite")
 }
@@ -0,0 +1,155 @@
+package nfsv3driver
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"sync"
+
+	"code.cloudfoundry.org/goshims/ioutilshim"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+const InvalidEncryptedPasswordErrorMessage = "Unable to decrypt the 'encrypted_password' option, please re-encrypt it with the driver's current public key"
+
+// PasswordEncryptionAlgorithm is RSAES-OAEP with SHA-256 and MGF1-SHA-256, without a label
+const PasswordEncryptionAlgorithm = "RSA-OAEP-256"
+
+//counterfeiter:generate -o nfsdriverfakes/fake_password_decrypter.go . PasswordDecrypter
+
+// PasswordDecrypter decrypts the base64 encoded 'encrypted_password' bind option
+type PasswordDecrypter interface {
+	DecryptPassword(ciphertext string) ([]byte, error)
+}
+
+type ReloadablePasswordDecrypter interface {
+	PasswordDecrypter
+	driveradmin.PasswordKeySource
+	Reload(logger lager.Logger) error
+}
+
+type rsaPasswordDecrypter struct {
+	ioutil ioutilshim.Ioutil
+	path   string
+
+	lock      sync.RWMutex
+	key       *rsa.PrivateKey
+	publicKey driveradmin.PasswordKey
+}
+
+// NewRsaPasswordDecrypter loads a PEM encoded RSA private key (PKCS #1 or PKCS #8) of at least 2048 bits
+func NewRsaPasswordDecrypter(logger lager.Logger, ioutil ioutilshim.Ioutil, path string) (ReloadablePasswordDecrypter, error) {
+	d := &rsaPasswordDecrypter{
+		ioutil: ioutil,
+		path:   path,
+	}
+
+	if err := d.Reload(logger); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (d *rsaPasswordDecrypter) Reload(logger lager.Logger) error {
+	logger = logger.Session("password-key-reload", lager.Data{"path": d.path})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	contents, err := d.ioutil.ReadFile(d.path)
+	if err != nil {
+		logger.Error("failed-to-read", err)
+		return err
+	}
+
+	key, err := parseRsaPrivateKey(contents)
+	if err != nil {
+		logger.Error("failed-to-parse", err)
+		return err
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		logger.Error("failed-to-marshal-public-key", err)
+		return err
+	}
+	fingerprint := sha256.Sum256(der)
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.key = key
+	d.publicKey = driveradmin.PasswordKey{
+		KeyId:     hex.EncodeToString(fingerprint[:8]),
+		Algorithm: PasswordEncryptionAlgorithm,
+		PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+	}
+	logger.Info("loaded", lager.Data{"key-id": d.publicKey.KeyId})
+
+	return nil
+}
+
+func (d *rsaPasswordDecrypter) PasswordKey() driveradmin.PasswordKey {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	return d.publicKey
+}
+
+// DecryptPassword deliberately reports every failure the same way, so it cannot be used as a padding oracle
+func (d *rsaPasswordDecrypter) DecryptPassword(ciphertext string) ([]byte, error) {
+	d.lock.RLock()
+	key := d.key
+	d.lock.RUnlock()
+
+	decoded, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, errors.New("invalid encrypted password")
+	}
+
+	plaintext, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, key, decoded, nil)
+	if err != nil {
+		return nil, errors.New("invalid encrypted password")
+	}
+	return plaintext, nil
+}
+
+func parseRsaPrivateKey(contents []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(contents)
+	if block == nil {
+		return nil, errors.New("password key is not PEM encoded")
+	}
+
+	var key *rsa.PrivateKey
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key = parsed
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("password key is not an RSA key")
+		}
+		key = rsaKey
+	default:
+		return nil, errors.New("password key is not an RSA private key")
+	}
+
+	if key.N.BitLen() < 2048 {
+		return nil, errors.New("password key must be at least 2048 bits")
+	}
+	return key, nil
+}
@@ -0,0 +1,159 @@
+package nfsv3driver_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+
+	"code.cloudfoundry.org/goshims/ioutilshim/ioutil_fake"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("RsaPasswordDecrypter", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeIoutil *ioutil_fake.FakeIoutil
+		key        *rsa.PrivateKey
+		keyPem     []byte
+		subject    nfsv3driver.ReloadablePasswordDecrypter
+		err        error
+	)
+
+	encrypt := func(publicKey *rsa.PublicKey, password string) string {
+		ciphertext, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, []byte(password), nil)
+		Expect(err).NotTo(HaveOccurred())
+		return base64.StdEncoding.EncodeToString(ciphertext)
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("password-decrypter")
+		fakeIoutil = &ioutil_fake.FakeIoutil{}
+
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		keyPem = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	})
+
+	JustBeforeEach(func() {
+		fakeIoutil.ReadFileReturns(keyPem, nil)
+		subject, err = nfsv3driver.NewRsaPasswordDecrypter(logger, fakeIoutil, "/var/vcap/jobs/nfsv3driver/config/password_key.pem")
+	})
+
+	It("reads the key file", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeIoutil.ReadFileArgsForCall(0)).To(Equal("/var/vcap/jobs/nfsv3driver/config/password_key.pem"))
+	})
+
+	It("decrypts passwords encrypted to its public key", func() {
+		password, err := subject.DecryptPassword(encrypt(&key.PublicKey, "secret"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(password)).To(Equal("secret"))
+	})
+
+	It("publishes its public key", func() {
+		publicKey := subject.PasswordKey()
+		Expect(publicKey.Algorithm).To(Equal("RSA-OAEP-256"))
+		Expect(publicKey.KeyId).To(HaveLen(16))
+		Expect(logger).To(gbytes.Say(`password-key-reload.loaded.*"key-id":"` + publicKey.KeyId))
+
+		block, _ := pem.Decode([]byte(publicKey.PublicKey))
+		Expect(block).NotTo(BeNil())
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed).To(Equal(&key.PublicKey))
+	})
+
+	It("does not publish the private key", func() {
+		Expect(subject.PasswordKey().PublicKey).NotTo(ContainSubstring("PRIVATE"))
+	})
+
+	DescribeTable("rejecting invalid ciphertexts",
+		func(ciphertext func() string) {
+			_, err := subject.DecryptPassword(ciphertext())
+			Expect(err).To(MatchError("invalid encrypted password"))
+		},
+		Entry("not base64", func() string { return "not base64!" }),
+		Entry("not encrypted", func() string { return base64.StdEncoding.EncodeToString([]byte("secret")) }),
+		Entry("encrypted to another key", func() string {
+			other, err := rsa.GenerateKey(rand.Reader, 2048)
+			Expect(err).NotTo(HaveOccurred())
+			return encrypt(&other.PublicKey, "secret")
+		}),
+		Entry("encrypted with PKCS #1 v1.5 padding", func() string {
+			ciphertext, err := rsa.EncryptPKCS1v15(rand.Reader, &key.PublicKey, []byte("secret"))
+			Expect(err).NotTo(HaveOccurred())
+			return base64.StdEncoding.EncodeToString(ciphertext)
+		}),
+	)
+
+	Context("when the key is PKCS #8 encoded", func() {
+		BeforeEach(func() {
+			der, err := x509.MarshalPKCS8PrivateKey(key)
+			Expect(err).NotTo(HaveOccurred())
+			keyPem = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+		})
+
+		It("loads it", func() {
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("when the key is rotated", func() {
+		It("decrypts with the new key and publishes it", func() {
+			oldKeyId := subject.PasswordKey().KeyId
+
+			newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+			Expect(err).NotTo(HaveOccurred())
+			fakeIoutil.ReadFileReturns(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(newKey)}), nil)
+			Expect(subject.Reload(logger)).To(Succeed())
+
+			Expect(subject.PasswordKey().KeyId).NotTo(Equal(oldKeyId))
+			_, err = subject.DecryptPassword(encrypt(&key.PublicKey, "secret"))
+			Expect(err).To(HaveOccurred())
+			_, err = subject.DecryptPassword(encrypt(&newKey.PublicKey, "secret"))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("keeps the previous key when the new one cannot be read", func() {
+			fakeIoutil.ReadFileReturns(nil, errors.New("permission denied"))
+			Expect(subject.Reload(logger)).To(MatchError("permission denied"))
+
+			_, err := subject.DecryptPassword(encrypt(&key.PublicKey, "secret"))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	DescribeTable("when the key file is invalid",
+		func(contents func() []byte, expectedError string) {
+			fakeIoutil.ReadFileReturns(contents(), nil)
+			_, err := nfsv3driver.NewRsaPasswordDecrypter(logger, fakeIoutil, "key.pem")
+			Expect(err).To(MatchError(expectedError))
+		},
+		Entry("not PEM", func() []byte { return []byte("key") }, "password key is not PEM encoded"),
+		Entry("a public key", func() []byte {
+			return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)})
+		}, "password key is not an RSA private key"),
+		Entry("an EC key", func() []byte {
+			ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+			der, err := x509.MarshalPKCS8PrivateKey(ecKey)
+			Expect(err).NotTo(HaveOccurred())
+			return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+		}, "password key is not an RSA key"),
+		Entry("a short key", func() []byte {
+			short, err := rsa.GenerateKey(rand.Reader, 1024)
+			Expect(err).NotTo(HaveOccurred())
+			return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(short)})
+		}, "password key must be at least 2048 bits"),
+	)
+})
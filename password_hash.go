@@ -0,0 +1,161 @@
+package nfsv3driver
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// pbkdf2Schemes are the PBKDF2 modular crypt formats accepted in the static users file, in passlib's
+// "$pbkdf2-<digest>$<rounds>$<salt>$<checksum>" layout with salt and checksum in adapted base64
+var pbkdf2Schemes = map[string]func() hash.Hash{
+	"pbkdf2":        sha1.New,
+	"pbkdf2-sha256": sha256.New,
+	"pbkdf2-sha512": sha512.New,
+}
+
+// used to spend the same effort on unknown users as on known ones
+const dummyPasswordHash = "$2y$10$cVl2ga6IbAZbuTO0ABT75erZsRlYwkYWC08hP7O04JxDW680b1yUO"
+
+// passwordHash is a password hash from the static users file: bcrypt ($2a$, $2b$ or $2y$, as written by
+// htpasswd -B), argon2id in the PHC string format ($argon2id$v=19$m=<KiB>,t=<passes>,p=<lanes>$<salt>$<hash>), or
+// PBKDF2 in one of the pbkdf2Schemes
+type passwordHash interface {
+	matches(password string) bool
+}
+
+func parsePasswordHash(encoded string) (passwordHash, error) {
+	switch {
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		if _, err := bcrypt.Cost([]byte(encoded)); err != nil {
+			return nil, fmt.Errorf("invalid bcrypt password hash: %s", err.Error())
+		}
+		return bcryptHash(encoded), nil
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return parseArgon2idHash(encoded)
+	case strings.HasPrefix(encoded, "$pbkdf2"):
+		return parsePbkdf2Hash(encoded)
+	}
+
+	if fields := strings.Split(encoded, "$"); len(fields) > 2 && fields[0] == "" {
+		return nil, fmt.Errorf("unsupported password hash scheme '%s'", fields[1])
+	}
+	return nil, fmt.Errorf("unrecognized password hash format")
+}
+
+type bcryptHash string
+
+func (h bcryptHash) matches(password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(h), []byte(password)) == nil
+}
+
+type argon2idHash struct {
+	memory  uint32
+	passes  uint32
+	lanes   uint8
+	salt    []byte
+	derived []byte
+}
+
+func parseArgon2idHash(encoded string) (passwordHash, error) {
+	fields := strings.Split(encoded, "$")
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("invalid argon2id password hash")
+	}
+	if fields[2] != fmt.Sprintf("v=%d", argon2.Version) {
+		return nil, fmt.Errorf("unsupported argon2id version '%s'", fields[2])
+	}
+
+	h := argon2idHash{}
+	var memory, passes, lanes uint64
+	for _, param := range strings.Split(fields[3], ",") {
+		name, value, _ := strings.Cut(param, "=")
+		var err error
+		switch name {
+		case "m":
+			memory, err = strconv.ParseUint(value, 10, 32)
+		case "t":
+			passes, err = strconv.ParseUint(value, 10, 32)
+		case "p":
+			lanes, err = strconv.ParseUint(value, 10, 8)
+		default:
+			err = fmt.Errorf("unknown parameter")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid argon2id parameter '%s'", param)
+		}
+	}
+	if passes == 0 || lanes == 0 || memory < 8*lanes {
+		return nil, fmt.Errorf("invalid argon2id parameters '%s'", fields[3])
+	}
+	h.memory, h.passes, h.lanes = uint32(memory), uint32(passes), uint8(lanes)
+
+	var err error
+	if h.salt, err = base64.RawStdEncoding.DecodeString(fields[4]); err != nil {
+		return nil, fmt.Errorf("invalid argon2id password hash salt: %s", err.Error())
+	}
+	if h.derived, err = base64.RawStdEncoding.DecodeString(fields[5]); err != nil || len(h.derived) == 0 {
+		return nil, fmt.Errorf("invalid argon2id password hash checksum")
+	}
+	return h, nil
+}
+
+func (h argon2idHash) matches(password string) bool {
+	derived := argon2.IDKey([]byte(password), h.salt, h.passes, h.memory, h.lanes, uint32(len(h.derived)))
+	return subtle.ConstantTimeCompare(derived, h.derived) == 1
+}
+
+type pbkdf2Hash struct {
+	digest   func() hash.Hash
+	rounds   int
+	salt     []byte
+	checksum []byte
+}
+
+func parsePbkdf2Hash(encoded string) (passwordHash, error) {
+	fields := strings.Split(encoded, "$")
+	if len(fields) != 5 || fields[0] != "" {
+		return nil, fmt.Errorf("unrecognized password hash format")
+	}
+
+	digest, ok := pbkdf2Schemes[fields[1]]
+	if !ok {
+		return nil, fmt.Errorf("unsupported password hash scheme '%s'", fields[1])
+	}
+
+	rounds, err := strconv.Atoi(fields[2])
+	if err != nil || rounds <= 0 {
+		return nil, fmt.Errorf("invalid password hash rounds '%s'", fields[2])
+	}
+
+	salt, err := decodeAdaptedBase64(fields[3])
+	if err != nil {
+		return nil, fmt.Errorf("invalid password hash salt: %s", err.Error())
+	}
+
+	checksum, err := decodeAdaptedBase64(fields[4])
+	if err != nil || len(checksum) == 0 {
+		return nil, fmt.Errorf("invalid password hash checksum")
+	}
+
+	return pbkdf2Hash{digest: digest, rounds: rounds, salt: salt, checksum: checksum}, nil
+}
+
+func (h pbkdf2Hash) matches(password string) bool {
+	derived := pbkdf2.Key([]byte(password), h.salt, h.rounds, len(h.checksum), h.digest)
+	return subtle.ConstantTimeCompare(derived, h.checksum) == 1
+}
+
+func decodeAdaptedBase64(s string) ([]byte, error) {
+	return base64.RawStdEncoding.DecodeString(strings.ReplaceAll(strings.TrimRight(s, "="), ".", "+"))
+}
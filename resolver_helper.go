@@ -0,0 +1,302 @@
+package nfsv3driver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/rpc"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/lager/v3"
+	"github.com/tedsuo/ifrit"
+)
+
+const ResolverHelperUnavailableErrorMessage = "Identity resolution is temporarily unavailable, please try again"
+
+// ResolverHelperFd is the descriptor on which the helper process finds its end of the socketpair
+const ResolverHelperFd = 3
+
+// ResolveRequest and ResolveResponse are the messages exchanged with the resolver helper
+type ResolveRequest struct {
+	Username string
+	Password string
+	// Deadline is the zero time when the mount request has none
+	Deadline time.Time
+}
+
+type ResolveResponse struct {
+	Uid string
+	Gid string
+	// SafeError carries a dockerdriver.SafeError, which may be shown to app developers; Error anything else
+	SafeError string
+	Error     string
+}
+
+type CheckConnectionRequest struct {
+	Deadline time.Time
+}
+
+type CheckConnectionResponse struct {
+	Error string
+}
+
+type resolverHelperService struct {
+	logger   lager.Logger
+	resolver IdResolver
+}
+
+func (s *resolverHelperService) Resolve(req ResolveRequest, resp *ResolveResponse) error {
+	ctx := context.Background()
+	if !req.Deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, req.Deadline)
+		defer cancel()
+	}
+
+	uid, gid, err := s.resolver.Resolve(driverhttp.NewHttpDriverEnv(s.logger, ctx), req.Username, req.Password)
+	var safeErr dockerdriver.SafeError
+	switch {
+	case errors.As(err, &safeErr):
+		resp.SafeError = safeErr.SafeDescription
+	case err != nil:
+		resp.Error = err.Error()
+	default:
+		resp.Uid, resp.Gid = uid, gid
+	}
+	return nil
+}
+
+func (s *resolverHelperService) CheckConnection(req CheckConnectionRequest, resp *CheckConnectionResponse) error {
+	checker, ok := s.resolver.(ConnectionChecker)
+	if !ok {
+		return nil
+	}
+
+	ctx := context.Background()
+	if !req.Deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, req.Deadline)
+		defer cancel()
+	}
+
+	if err := checker.CheckConnection(driverhttp.NewHttpDriverEnv(s.logger, ctx)); err != nil {
+		resp.Error = err.Error()
+	}
+	return nil
+}
+
+// ServeResolverHelper runs inside the helper process, answering requests from the driver on conn until the
+// driver closes its end
+func ServeResolverHelper(logger lager.Logger, resolver IdResolver, conn io.ReadWriteCloser) error {
+	logger = logger.Session("resolver-helper")
+	logger.Info("start")
+	defer logger.Info("end")
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("IdResolver", &resolverHelperService{logger: logger, resolver: resolver}); err != nil {
+		return err
+	}
+	server.ServeConn(conn)
+	return nil
+}
+
+type ResolverHelper interface {
+	IdResolver
+	ConnectionChecker
+	ifrit.Runner
+	// Reload sends SIGHUP to the running helper, which reloads its own configuration
+	Reload() error
+}
+
+// NewResolverHelper returns an IdResolver that forwards requests to a helper process, so that untrusted directory
+// responses are parsed outside the root process. Its runner starts the helper with command, passing it its end of
+// a socketpair as descriptor ResolverHelperFd, and restarts it restartInterval after it exits.
+func NewResolverHelper(logger lager.Logger, command func() *exec.Cmd, restartInterval time.Duration) ResolverHelper {
+	return &resolverHelper{
+		logger:          logger.Session("resolver-helper"),
+		command:         command,
+		restartInterval: restartInterval,
+	}
+}
+
+type resolverHelper struct {
+	logger          lager.Logger
+	command         func() *exec.Cmd
+	restartInterval time.Duration
+
+	lock    sync.RWMutex
+	client  *rpc.Client
+	process *os.Process
+}
+
+func (h *resolverHelper) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	started := false
+	for {
+		cmd, client, err := h.start()
+		if err != nil {
+			h.logger.Error("failed-to-start", err)
+			if !started {
+				return err
+			}
+		} else {
+			h.logger.Info("started", lager.Data{"pid": cmd.Process.Pid})
+			h.setClient(client, cmd.Process)
+			if !started {
+				started = true
+				close(ready)
+			}
+
+			exited := make(chan error, 1)
+			go func() {
+				exited <- cmd.Wait()
+			}()
+
+			select {
+			case <-signals:
+				h.setClient(nil, nil)
+				client.Close()
+				_ = cmd.Process.Kill()
+				<-exited
+				return nil
+			case err := <-exited:
+				h.logger.Error("exited", err)
+				h.setClient(nil, nil)
+				client.Close()
+			}
+		}
+
+		select {
+		case <-signals:
+			return nil
+		case <-time.After(h.restartInterval):
+		}
+	}
+}
+
+func (h *resolverHelper) start() (*exec.Cmd, *rpc.Client, error) {
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	driverEnd := os.NewFile(uintptr(fds[0]), "resolver-helper")
+	helperEnd := os.NewFile(uintptr(fds[1]), "resolver-helper-peer")
+	defer driverEnd.Close()
+	defer helperEnd.Close()
+
+	cmd := h.command()
+	cmd.ExtraFiles = []*os.File{helperEnd}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, err
+	}
+
+	conn, err := net.FileConn(driverEnd)
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, nil, err
+	}
+
+	return cmd, rpc.NewClient(conn), nil
+}
+
+func (h *resolverHelper) setClient(client *rpc.Client, process *os.Process) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.client = client
+	h.process = process
+}
+
+func (h *resolverHelper) Reload() error {
+	h.lock.RLock()
+	process := h.process
+	h.lock.RUnlock()
+
+	if process == nil {
+		return errors.New("resolver helper is not running")
+	}
+	h.logger.Info("reload", lager.Data{"pid": process.Pid})
+	return process.Signal(syscall.SIGHUP)
+}
+
+func (h *resolverHelper) Resolve(env dockerdriver.Env, username string, password string) (string, string, error) {
+	logger := env.Logger().Session("resolver-helper-resolve")
+
+	h.lock.RLock()
+	client := h.client
+	h.lock.RUnlock()
+
+	if client == nil {
+		logger.Info("helper-unavailable")
+		return "", "", dockerdriver.SafeError{SafeDescription: ResolverHelperUnavailableErrorMessage}
+	}
+
+	req := ResolveRequest{Username: username, Password: password}
+	if deadline, ok := env.Context().Deadline(); ok {
+		req.Deadline = deadline
+	}
+
+	var resp ResolveResponse
+	if err := h.call(logger, env, client, "IdResolver.Resolve", req, &resp); err != nil {
+		return "", "", err
+	}
+	if resp.SafeError != "" {
+		return "", "", dockerdriver.SafeError{SafeDescription: resp.SafeError}
+	}
+	if resp.Error != "" {
+		return "", "", errors.New(resp.Error)
+	}
+	return resp.Uid, resp.Gid, nil
+}
+
+// CheckConnection asks the helper to check its resolver's directory server
+func (h *resolverHelper) CheckConnection(env dockerdriver.Env) error {
+	logger := env.Logger().Session("resolver-helper-check-connection")
+
+	h.lock.RLock()
+	client := h.client
+	h.lock.RUnlock()
+
+	if client == nil {
+		logger.Info("helper-unavailable")
+		return dockerdriver.SafeError{SafeDescription: ResolverHelperUnavailableErrorMessage}
+	}
+
+	var req CheckConnectionRequest
+	if deadline, ok := env.Context().Deadline(); ok {
+		req.Deadline = deadline
+	}
+
+	var resp CheckConnectionResponse
+	if err := h.call(logger, env, client, "IdResolver.CheckConnection", req, &resp); err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
+
+// call gives up waiting for the helper when the request is cancelled
+func (h *resolverHelper) call(logger lager.Logger, env dockerdriver.Env, client *rpc.Client, method string, req interface{}, resp interface{}) error {
+	call := client.Go(method, req, resp, make(chan *rpc.Call, 1))
+	select {
+	case <-call.Done:
+	case <-env.Context().Done():
+		logger.Info("cancelled", lager.Data{"reason": env.Context().Err().Error()})
+		return dockerdriver.SafeError{SafeDescription: LdapTimeoutErrorMessage}
+	}
+
+	if call.Error != nil {
+		logger.Error("call-failed", call.Error)
+		return dockerdriver.SafeError{SafeDescription: ResolverHelperUnavailableErrorMessage}
+	}
+	return nil
+}
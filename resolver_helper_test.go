@@ -0,0 +1,223 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/tedsuo/ifrit"
+)
+
+const resolverHelperTestEnv = "NFSV3DRIVER_TEST_RESOLVER_HELPER"
+
+// serveTestResolverHelper is the helper process started by these specs
+func serveTestResolverHelper() {
+	var reloads atomic.Int32
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			reloads.Add(1)
+		}
+	}()
+
+	resolver := &nfsdriverfakes.FakeIdResolver{}
+	resolver.ResolveStub = func(env dockerdriver.Env, username string, password string) (string, string, error) {
+		switch username {
+		case "alice":
+			if password != "secret" {
+				return "", "", dockerdriver.SafeError{SafeDescription: nfsv3driver.InvalidCredentialsErrorMessage}
+			}
+			return "1001", "2001", nil
+		case "slow":
+			<-env.Context().Done()
+			return "", "", dockerdriver.SafeError{SafeDescription: nfsv3driver.LdapTimeoutErrorMessage}
+		case "crash":
+			os.Exit(1)
+		case "broken":
+			return "", "", errors.New("LDAP Result Code 200: connection closed")
+		case "reloads":
+			return strconv.Itoa(int(reloads.Load())), "0", nil
+		}
+		return "", "", dockerdriver.SafeError{SafeDescription: nfsv3driver.UserNotFoundErrorMessage}
+	}
+
+	checker := &nfsdriverfakes.FakeConnectionChecker{}
+	checker.CheckConnectionStub = func(env dockerdriver.Env) error {
+		if _, ok := env.Context().Deadline(); !ok {
+			return errors.New("LDAP Result Code 49: Invalid Credentials")
+		}
+		return nil
+	}
+
+	logger := lager.NewLogger("test-resolver-helper")
+	_ = nfsv3driver.ServeResolverHelper(logger, struct {
+		nfsv3driver.IdResolver
+		nfsv3driver.ConnectionChecker
+	}{resolver, checker}, os.NewFile(nfsv3driver.ResolverHelperFd, "resolver-helper"))
+	os.Exit(0)
+}
+
+var _ = Describe("ResolverHelper", func() {
+	var (
+		logger  *lagertest.TestLogger
+		env     dockerdriver.Env
+		command func() *exec.Cmd
+		helper  nfsv3driver.ResolverHelper
+		process ifrit.Process
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("resolver-helper")
+		env = driverhttp.NewHttpDriverEnv(logger, context.TODO())
+		command = func() *exec.Cmd {
+			cmd := exec.Command(os.Args[0], "-test.run=^TestNfsV3Driver$")
+			cmd.Env = append(os.Environ(), resolverHelperTestEnv+"=1")
+			return cmd
+		}
+	})
+
+	JustBeforeEach(func() {
+		helper = nfsv3driver.NewResolverHelper(logger, command, 10*time.Millisecond)
+		process = ifrit.Background(helper)
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive())
+	})
+
+	Context("when the helper is running", func() {
+		JustBeforeEach(func() {
+			Eventually(process.Ready()).Should(BeClosed())
+		})
+
+		It("resolves users in the helper", func() {
+			uid, gid, err := helper.Resolve(env, "alice", "secret")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uid).To(Equal("1001"))
+			Expect(gid).To(Equal("2001"))
+		})
+
+		It("passes on errors that are safe to show", func() {
+			_, _, err := helper.Resolve(env, "alice", "wrong")
+			Expect(err).To(MatchError(nfsv3driver.InvalidCredentialsErrorMessage))
+			Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+		})
+
+		It("keeps other errors unsafe", func() {
+			_, _, err := helper.Resolve(env, "broken", "secret")
+			Expect(err).To(MatchError("LDAP Result Code 200: connection closed"))
+			Expect(err).NotTo(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+		})
+
+		It("passes the request deadline to the helper", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			_, _, err := helper.Resolve(driverhttp.NewHttpDriverEnv(logger, ctx), "slow", "secret")
+			Expect(err).To(MatchError(nfsv3driver.LdapTimeoutErrorMessage))
+		})
+
+		Context("when the helper crashes", func() {
+			It("fails the request and restarts the helper", func() {
+				_, _, err := helper.Resolve(env, "crash", "secret")
+				Expect(err).To(MatchError(nfsv3driver.ResolverHelperUnavailableErrorMessage))
+				Eventually(logger).Should(gbytes.Say("resolver-helper.exited"))
+
+				Eventually(func() error {
+					_, _, err := helper.Resolve(env, "alice", "secret")
+					return err
+				}).Should(Succeed())
+			})
+		})
+
+		It("checks the helper's connection, passing the request deadline", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			Expect(helper.CheckConnection(driverhttp.NewHttpDriverEnv(logger, ctx))).To(Succeed())
+		})
+
+		It("passes on connection failures", func() {
+			Expect(helper.CheckConnection(env)).To(MatchError("LDAP Result Code 49: Invalid Credentials"))
+		})
+
+		It("asks the helper to reload", func() {
+			// the helper only handles SIGHUP once it is serving
+			_, _, err := helper.Resolve(env, "alice", "secret")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(helper.Reload()).To(Succeed())
+			Expect(logger).To(gbytes.Say("resolver-helper.reload"))
+
+			Eventually(func() string {
+				uid, _, _ := helper.Resolve(env, "reloads", "")
+				return uid
+			}).Should(Equal("1"))
+		})
+
+		It("stops the helper when signalled", func() {
+			Expect(logger).To(gbytes.Say(`resolver-helper.started.*"pid":`))
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive(BeNil()))
+
+			_, _, err := helper.Resolve(env, "alice", "secret")
+			Expect(err).To(MatchError(nfsv3driver.ResolverHelperUnavailableErrorMessage))
+		})
+	})
+
+	Context("when the helper cannot be started", func() {
+		BeforeEach(func() {
+			command = func() *exec.Cmd {
+				return exec.Command("/nonexistent/nfsv3driver")
+			}
+		})
+
+		It("fails to start", func() {
+			Eventually(process.Wait()).Should(Receive(MatchError(ContainSubstring("no such file or directory"))))
+		})
+
+		It("reports the resolver as unavailable", func() {
+			_, _, err := helper.Resolve(env, "alice", "secret")
+			Expect(err).To(MatchError(nfsv3driver.ResolverHelperUnavailableErrorMessage))
+		})
+
+		It("reports the connection as unavailable", func() {
+			Expect(helper.CheckConnection(env)).To(MatchError(nfsv3driver.ResolverHelperUnavailableErrorMessage))
+		})
+
+		It("cannot be reloaded", func() {
+			Eventually(process.Wait()).Should(Receive())
+			Expect(helper.Reload()).To(MatchError("resolver helper is not running"))
+		})
+	})
+
+	It("gives the helper its end of the socketpair only", func() {
+		var helperCmd *exec.Cmd
+		command = func() *exec.Cmd {
+			helperCmd = exec.Command("sleep", "10")
+			return helperCmd
+		}
+		helper = nfsv3driver.NewResolverHelper(logger, command, time.Second)
+		process := ifrit.Invoke(helper)
+		defer process.Signal(os.Interrupt)
+
+		Expect(helperCmd.ExtraFiles).To(HaveLen(1))
+		Expect(helperCmd.ExtraFiles[0].Fd()).NotTo(Equal(uintptr(syscall.Stdin)))
+	})
+})
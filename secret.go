@@ -0,0 +1,88 @@
+package nfsv3driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"code.cloudfoundry.org/dockerdriver"
+)
+
+const redactedSecret = "[REDACTED]"
+
+// secretOptionNames are the bind options that carry credentials
+var secretOptionNames = []string{"password", "encrypted_password", "token", "keytab"}
+
+// Secret holds a credential. It prints and marshals as [REDACTED], so it is safe to pass to lager.Data, and Zero
+// overwrites its buffer once it is no longer needed.
+type Secret struct {
+	value []byte
+}
+
+func NewSecret(value string) *Secret {
+	return &Secret{value: []byte(value)}
+}
+
+// newSecretFromBytes takes ownership of value, which is zeroed with the secret
+func newSecretFromBytes(value []byte) *Secret {
+	return &Secret{value: value}
+}
+
+// Reveal returns the secret for APIs that only accept strings. Go strings cannot be zeroed, so keep the result no
+// longer than the call that needs it.
+func (s *Secret) Reveal() string {
+	return string(s.value)
+}
+
+func (s *Secret) Zero() {
+	clear(s.value)
+	s.value = nil
+}
+
+func (s *Secret) String() string {
+	return redactedSecret
+}
+
+func (s *Secret) Format(f fmt.State, verb rune) {
+	_, _ = io.WriteString(f, redactedSecret)
+}
+
+func (s *Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(redactedSecret)
+}
+
+// SecretOptions are the secret bind options removed from a mount's options
+type SecretOptions map[string]*Secret
+
+// ExtractSecretOptions splits opts into the options that are safe to log and the secrets. opts itself is not
+// modified, since the volume driver reuses it to remount.
+func ExtractSecretOptions(opts map[string]interface{}) (map[string]interface{}, SecretOptions, error) {
+	remaining := make(map[string]interface{}, len(opts))
+	for name, value := range opts {
+		remaining[name] = value
+	}
+
+	secrets := SecretOptions{}
+	for _, name := range secretOptionNames {
+		value, ok := remaining[name]
+		if !ok {
+			continue
+		}
+		delete(remaining, name)
+
+		str, ok := value.(string)
+		if !ok {
+			secrets.Zero()
+			return nil, nil, dockerdriver.SafeError{SafeDescription: fmt.Sprintf("Invalid '%s' option", name)}
+		}
+		secrets[name] = NewSecret(str)
+	}
+
+	return remaining, secrets, nil
+}
+
+func (s SecretOptions) Zero() {
+	for _, secret := range s {
+		secret.Zero()
+	}
+}
@@ -0,0 +1,149 @@
+package nfsv3driver
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sync"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/volumedriver"
+)
+
+type keptSecrets struct {
+	options SecretOptions
+	// uid and gid are what the volume's token resolved to when it was first mounted
+	uid string
+	gid string
+}
+
+// VolumeSecrets holds the secret bind options of each volume in memory only, so that the volume driver neither
+// logs nor stores them. It also remembers the ids that each volume's token resolved to until the volume is removed
+// or created again.
+type VolumeSecrets struct {
+	lock    sync.Mutex
+	volumes map[string]*keptSecrets
+}
+
+func NewVolumeSecrets() *VolumeSecrets {
+	return &VolumeSecrets{volumes: map[string]*keptSecrets{}}
+}
+
+func (s *VolumeSecrets) put(name string, secrets SecretOptions) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if kept, ok := s.volumes[name]; ok {
+		kept.options.Zero()
+	}
+	if len(secrets) == 0 {
+		delete(s.volumes, name)
+		return
+	}
+	s.volumes[name] = &keptSecrets{options: secrets}
+}
+
+func (s *VolumeSecrets) delete(name string) {
+	s.put(name, nil)
+}
+
+// reveal adds the secrets of volume name to a copy of opts
+func (s *VolumeSecrets) reveal(name string, opts map[string]interface{}) map[string]interface{} {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	kept, ok := s.volumes[name]
+	if !ok {
+		return opts
+	}
+
+	revealed := make(map[string]interface{}, len(opts)+len(kept.options))
+	for option, value := range opts {
+		revealed[option] = value
+	}
+	for option, secret := range kept.options {
+		revealed[option] = secret.Reveal()
+	}
+	return revealed
+}
+
+func (s *VolumeSecrets) TokenIds(target string) (string, string, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	kept, ok := s.volumes[filepath.Base(target)]
+	if !ok || kept.uid == "" {
+		return "", "", false
+	}
+	return kept.uid, kept.gid, true
+}
+
+// SetTokenIds only remembers the ids of volumes whose token is kept here
+func (s *VolumeSecrets) SetTokenIds(target string, uid string, gid string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if kept, ok := s.volumes[filepath.Base(target)]; ok {
+		kept.uid = uid
+		kept.gid = gid
+	}
+}
+
+type secretKeepingDriver struct {
+	DrainableDriver
+	secrets *VolumeSecrets
+}
+
+// NewSecretKeepingDriver removes the secret options from the volumes created through driver and keeps them in
+// secrets, from where the mounter returned by NewSecretInjectingMounter adds them back whenever driver mounts the
+// volume. The secrets of a volume are zeroed when it is removed.
+func NewSecretKeepingDriver(driver DrainableDriver, secrets *VolumeSecrets) DrainableDriver {
+	return &secretKeepingDriver{DrainableDriver: driver, secrets: secrets}
+}
+
+func (d *secretKeepingDriver) Create(env dockerdriver.Env, createRequest dockerdriver.CreateRequest) dockerdriver.ErrorResponse {
+	opts, secrets, err := ExtractSecretOptions(createRequest.Opts)
+	if err != nil {
+		logger := env.Logger().Session("create", lager.Data{"volume": createRequest.Name})
+		logger.Info("invalid-secret-option", lager.Data{"reason": err.Error()})
+
+		errBytes, mErr := json.Marshal(err)
+		if mErr != nil {
+			return dockerdriver.ErrorResponse{Err: err.Error()}
+		}
+		return dockerdriver.ErrorResponse{Err: string(errBytes)}
+	}
+
+	createRequest.Opts = opts
+	response := d.DrainableDriver.Create(env, createRequest)
+	if response.Err != "" {
+		secrets.Zero()
+		return response
+	}
+
+	d.secrets.put(createRequest.Name, secrets)
+	return response
+}
+
+func (d *secretKeepingDriver) Remove(env dockerdriver.Env, removeRequest dockerdriver.RemoveRequest) dockerdriver.ErrorResponse {
+	response := d.DrainableDriver.Remove(env, removeRequest)
+	if response.Err == "" {
+		d.secrets.delete(removeRequest.Name)
+	}
+	return response
+}
+
+type secretInjectingMounter struct {
+	volumedriver.Mounter
+	secrets *VolumeSecrets
+}
+
+// NewSecretInjectingMounter adds the secrets kept for a volume to its options before mounting it. The volume
+// driver mounts each volume at a target named after the volume.
+func NewSecretInjectingMounter(mounter volumedriver.Mounter, secrets *VolumeSecrets) volumedriver.Mounter {
+	return &secretInjectingMounter{Mounter: mounter, secrets: secrets}
+}
+
+func (m *secretInjectingMounter) Mount(env dockerdriver.Env, source string, target string, opts map[string]interface{}) error {
+	return m.Mounter.Mount(env, source, target, m.secrets.reveal(filepath.Base(target), opts))
+}
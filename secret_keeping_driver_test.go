@@ -0,0 +1,191 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/filepathshim"
+	"code.cloudfoundry.org/goshims/ioutilshim"
+	"code.cloudfoundry.org/goshims/osshim"
+	"code.cloudfoundry.org/goshims/timeshim"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
+	"code.cloudfoundry.org/volumedriver"
+	"code.cloudfoundry.org/volumedriver/oshelper"
+	"code.cloudfoundry.org/volumedriver/volumedriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SecretKeepingDriver", func() {
+	var (
+		logger      *lagertest.TestLogger
+		env         dockerdriver.Env
+		secrets     *nfsv3driver.VolumeSecrets
+		fakeMounter *volumedriverfakes.FakeMounter
+		mounter     volumedriver.Mounter
+		opts        map[string]interface{}
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("secret-keeping-driver")
+		env = driverhttp.NewHttpDriverEnv(logger, context.TODO())
+		secrets = nfsv3driver.NewVolumeSecrets()
+		fakeMounter = &volumedriverfakes.FakeMounter{}
+		mounter = nfsv3driver.NewSecretInjectingMounter(fakeMounter, secrets)
+		opts = map[string]interface{}{
+			"source":   "nfs://server/export",
+			"username": "alice",
+			"password": "hunter2",
+		}
+	})
+
+	Context("with a fake driver", func() {
+		var (
+			fakeDriver *nfsdriverfakes.FakeDrainableDriver
+			driver     nfsv3driver.DrainableDriver
+		)
+
+		BeforeEach(func() {
+			fakeDriver = &nfsdriverfakes.FakeDrainableDriver{}
+			driver = nfsv3driver.NewSecretKeepingDriver(fakeDriver, secrets)
+		})
+
+		It("creates the volume without its secrets", func() {
+			Expect(driver.Create(env, dockerdriver.CreateRequest{Name: "vol1", Opts: opts}).Err).To(BeEmpty())
+
+			_, createRequest := fakeDriver.CreateArgsForCall(0)
+			Expect(createRequest.Name).To(Equal("vol1"))
+			Expect(createRequest.Opts).To(Equal(map[string]interface{}{"source": "nfs://server/export", "username": "alice"}))
+			Expect(opts).To(HaveKeyWithValue("password", "hunter2"))
+		})
+
+		It("adds the secrets back when the volume is mounted", func() {
+			Expect(driver.Create(env, dockerdriver.CreateRequest{Name: "vol1", Opts: opts}).Err).To(BeEmpty())
+
+			Expect(mounter.Mount(env, "nfs://server/export", "/mnt/vol1", map[string]interface{}{"username": "alice"})).To(Succeed())
+			_, _, target, mountOpts := fakeMounter.MountArgsForCall(0)
+			Expect(target).To(Equal("/mnt/vol1"))
+			Expect(mountOpts).To(Equal(map[string]interface{}{"username": "alice", "password": "hunter2"}))
+		})
+
+		It("passes the options of other volumes through", func() {
+			Expect(driver.Create(env, dockerdriver.CreateRequest{Name: "vol1", Opts: opts}).Err).To(BeEmpty())
+
+			Expect(mounter.Mount(env, "nfs://server/export", "/mnt/vol2", map[string]interface{}{"uid": "100"})).To(Succeed())
+			_, _, _, mountOpts := fakeMounter.MountArgsForCall(0)
+			Expect(mountOpts).To(Equal(map[string]interface{}{"uid": "100"}))
+		})
+
+		It("forgets the secrets once the volume is removed", func() {
+			Expect(driver.Create(env, dockerdriver.CreateRequest{Name: "vol1", Opts: opts}).Err).To(BeEmpty())
+			Expect(driver.Remove(env, dockerdriver.RemoveRequest{Name: "vol1"}).Err).To(BeEmpty())
+
+			Expect(mounter.Mount(env, "nfs://server/export", "/mnt/vol1", map[string]interface{}{"username": "alice"})).To(Succeed())
+			_, _, _, mountOpts := fakeMounter.MountArgsForCall(0)
+			Expect(mountOpts).NotTo(HaveKey("password"))
+		})
+
+		It("replaces the secrets when the volume is created again", func() {
+			Expect(driver.Create(env, dockerdriver.CreateRequest{Name: "vol1", Opts: opts}).Err).To(BeEmpty())
+			opts["password"] = "correct horse"
+			Expect(driver.Create(env, dockerdriver.CreateRequest{Name: "vol1", Opts: opts}).Err).To(BeEmpty())
+
+			Expect(mounter.Mount(env, "nfs://server/export", "/mnt/vol1", map[string]interface{}{})).To(Succeed())
+			_, _, _, mountOpts := fakeMounter.MountArgsForCall(0)
+			Expect(mountOpts).To(HaveKeyWithValue("password", "correct horse"))
+		})
+
+		It("remembers the ids of the volume's token until the volume is created again", func() {
+			opts["token"] = "header.claims.signature"
+			Expect(driver.Create(env, dockerdriver.CreateRequest{Name: "vol1", Opts: opts}).Err).To(BeEmpty())
+
+			secrets.SetTokenIds("/mnt/vol1", "1001", "2001")
+			uid, gid, ok := secrets.TokenIds("/mnt/vol1")
+			Expect(ok).To(BeTrue())
+			Expect(uid).To(Equal("1001"))
+			Expect(gid).To(Equal("2001"))
+			_, _, ok = secrets.TokenIds("/mnt/vol2")
+			Expect(ok).To(BeFalse())
+
+			Expect(driver.Create(env, dockerdriver.CreateRequest{Name: "vol1", Opts: opts}).Err).To(BeEmpty())
+			_, _, ok = secrets.TokenIds("/mnt/vol1")
+			Expect(ok).To(BeFalse())
+		})
+
+		Context("when the driver fails to create the volume", func() {
+			BeforeEach(func() {
+				fakeDriver.CreateReturns(dockerdriver.ErrorResponse{Err: "persist state failed"})
+			})
+
+			It("does not keep the secrets", func() {
+				Expect(driver.Create(env, dockerdriver.CreateRequest{Name: "vol1", Opts: opts}).Err).To(Equal("persist state failed"))
+
+				Expect(mounter.Mount(env, "nfs://server/export", "/mnt/vol1", map[string]interface{}{})).To(Succeed())
+				_, _, _, mountOpts := fakeMounter.MountArgsForCall(0)
+				Expect(mountOpts).To(BeEmpty())
+			})
+		})
+
+		Context("when a secret is not a string", func() {
+			BeforeEach(func() {
+				opts["password"] = 12345
+			})
+
+			It("rejects the volume", func() {
+				response := driver.Create(env, dockerdriver.CreateRequest{Name: "vol1", Opts: opts})
+				Expect(response.Err).To(MatchJSON(`{"SafeDescription":"Invalid 'password' option"}`))
+				Expect(fakeDriver.CreateCallCount()).To(BeZero())
+			})
+		})
+	})
+
+	Context("with the volume driver", func() {
+		var (
+			mountDir string
+			driver   nfsv3driver.DrainableDriver
+		)
+
+		BeforeEach(func() {
+			mountDir = GinkgoT().TempDir()
+			opts["token"] = "header.claims.signature"
+			delete(opts, "username")
+
+			volumeDriver := volumedriver.NewVolumeDriver(
+				logger,
+				&osshim.OsShim{},
+				&filepathshim.FilepathShim{},
+				&ioutilshim.IoutilShim{},
+				&timeshim.TimeShim{},
+				&volumedriverfakes.FakeMountChecker{},
+				mountDir,
+				mounter,
+				oshelper.NewOsHelper(),
+			)
+			driver = nfsv3driver.NewSecretKeepingDriver(volumeDriver, secrets)
+		})
+
+		It("neither logs nor stores the secrets, but mounts with them", func() {
+			Expect(driver.Create(env, dockerdriver.CreateRequest{Name: "vol1", Opts: opts}).Err).To(BeEmpty())
+			Expect(driver.Mount(env, dockerdriver.MountRequest{Name: "vol1"}).Err).To(BeEmpty())
+
+			state, err := os.ReadFile(filepath.Join(mountDir, "driver-state.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(state)).To(ContainSubstring("vol1"))
+			Expect(string(state)).NotTo(ContainSubstring("hunter2"))
+			Expect(string(state)).NotTo(ContainSubstring("header.claims.signature"))
+			Expect(string(logger.Buffer().Contents())).To(ContainSubstring("with-opts"))
+			Expect(string(logger.Buffer().Contents())).NotTo(ContainSubstring("hunter2"))
+			Expect(string(logger.Buffer().Contents())).NotTo(ContainSubstring("header.claims.signature"))
+
+			_, _, target, mountOpts := fakeMounter.MountArgsForCall(0)
+			Expect(target).To(Equal(filepath.Join(mountDir, "vol1")))
+			Expect(mountOpts).To(HaveKeyWithValue("password", "hunter2"))
+			Expect(mountOpts).To(HaveKeyWithValue("token", "header.claims.signature"))
+		})
+	})
+})
@@ -0,0 +1,102 @@
+package nfsv3driver_test
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Secret", func() {
+	var secret *nfsv3driver.Secret
+
+	BeforeEach(func() {
+		secret = nfsv3driver.NewSecret("hunter2")
+	})
+
+	It("reveals its value", func() {
+		Expect(secret.Reveal()).To(Equal("hunter2"))
+	})
+
+	DescribeTable("redacting itself when formatted",
+		func(format string) {
+			Expect(fmt.Sprintf(format, secret)).To(Equal("[REDACTED]"))
+		},
+		Entry("%s", "%s"),
+		Entry("%v", "%v"),
+		Entry("%+v", "%+v"),
+		Entry("%#v", "%#v"),
+		Entry("%x", "%x"),
+		Entry("%q", "%q"),
+	)
+
+	It("redacts itself in JSON", func() {
+		data, err := json.Marshal(map[string]interface{}{"password": secret})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(MatchJSON(`{"password":"[REDACTED]"}`))
+	})
+
+	It("redacts itself in lager data", func() {
+		logger := lagertest.NewTestLogger("secret")
+		logger.Info("credentials", lager.Data{"password": secret})
+		Expect(logger.Buffer().Contents()).NotTo(ContainSubstring("hunter2"))
+		Expect(logger.Buffer().Contents()).To(ContainSubstring(`"password":"[REDACTED]"`))
+	})
+
+	It("forgets its value when zeroed", func() {
+		secret.Zero()
+		Expect(secret.Reveal()).To(BeEmpty())
+	})
+
+	Describe("ExtractSecretOptions", func() {
+		var opts map[string]interface{}
+
+		BeforeEach(func() {
+			opts = map[string]interface{}{
+				"source":             "nfs://server/export",
+				"username":           "alice",
+				"password":           "hunter2",
+				"encrypted_password": "Y2lwaGVydGV4dA==",
+				"token":              "header.claims.signature",
+				"keytab":             "BQIAAAA3AAEAC0VYQU1QTEUuQ09N",
+			}
+		})
+
+		It("separates the secrets from the other options", func() {
+			remaining, secrets, err := nfsv3driver.ExtractSecretOptions(opts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(remaining).To(Equal(map[string]interface{}{"source": "nfs://server/export", "username": "alice"}))
+			Expect(secrets).To(HaveLen(4))
+			Expect(secrets["password"].Reveal()).To(Equal("hunter2"))
+			Expect(secrets["encrypted_password"].Reveal()).To(Equal("Y2lwaGVydGV4dA=="))
+			Expect(secrets["token"].Reveal()).To(Equal("header.claims.signature"))
+			Expect(secrets["keytab"].Reveal()).To(Equal("BQIAAAA3AAEAC0VYQU1QTEUuQ09N"))
+		})
+
+		It("leaves the original options untouched", func() {
+			_, _, err := nfsv3driver.ExtractSecretOptions(opts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(opts).To(HaveKeyWithValue("password", "hunter2"))
+		})
+
+		It("zeroes all secrets together", func() {
+			_, secrets, err := nfsv3driver.ExtractSecretOptions(opts)
+			Expect(err).NotTo(HaveOccurred())
+			secrets.Zero()
+			Expect(secrets["password"].Reveal()).To(BeEmpty())
+			Expect(secrets["token"].Reveal()).To(BeEmpty())
+		})
+
+		It("rejects secrets that are not strings", func() {
+			opts["password"] = 12345
+			_, _, err := nfsv3driver.ExtractSecretOptions(opts)
+			Expect(err).To(MatchError("Invalid 'password' option"))
+			Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+		})
+	})
+})
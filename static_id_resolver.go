@@ -0,0 +1,176 @@
+package nfsv3driver
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/goshims/ioutilshim"
+	"code.cloudfoundry.org/lager/v3"
+)
+
+// ReloadableIdResolver is an IdResolver whose backing data can be re-read while the driver is running. A failed
+// reload leaves the previously loaded data in place.
+type ReloadableIdResolver interface {
+	IdResolver
+	Reload(logger lager.Logger) error
+}
+
+type staticUser struct {
+	hash   passwordHash
+	uid    string
+	gid    string
+	groups []string
+}
+
+type staticIdResolver struct {
+	ioutil ioutilshim.Ioutil
+	path   string
+
+	lock  sync.RWMutex
+	users map[string]staticUser
+}
+
+// NewStaticIdResolver resolves users from a file with one entry per line in the form
+//
+//	username:password-hash:uid:gid[:group,...]
+//
+// Blank lines and lines starting with '#' are ignored. See passwordHash for the accepted hash formats.
+// Supplementary groups are validated but not applied, because mapfs can only map a single uid and gid.
+func NewStaticIdResolver(logger lager.Logger, ioutil ioutilshim.Ioutil, path string) (ReloadableIdResolver, error) {
+	r := &staticIdResolver{
+		ioutil: ioutil,
+		path:   path,
+	}
+
+	if err := r.Reload(logger); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *staticIdResolver) Reload(logger lager.Logger) error {
+	logger = logger.Session("static-users-reload", lager.Data{"path": r.path})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	contents, err := r.ioutil.ReadFile(r.path)
+	if err != nil {
+		logger.Error("read-failed", err)
+		return err
+	}
+
+	users, err := parseStaticUsers(contents)
+	if err != nil {
+		logger.Error("parse-failed", err)
+		return err
+	}
+
+	r.lock.Lock()
+	r.users = users
+	r.lock.Unlock()
+
+	withGroups := 0
+	for _, user := range users {
+		if len(user.groups) > 0 {
+			withGroups++
+		}
+	}
+	if withGroups > 0 {
+		logger.Info("supplementary-groups-ignored", lager.Data{"users": withGroups})
+	}
+
+	logger.Info("loaded", lager.Data{"users": len(users)})
+	return nil
+}
+
+func (r *staticIdResolver) Resolve(env dockerdriver.Env, username string, password string) (uid string, gid string, err error) {
+	logger := env.Logger().Session("static-resolve", lager.Data{"username": username})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	r.lock.RLock()
+	user, ok := r.users[username]
+	r.lock.RUnlock()
+
+	if !ok {
+		dummy, _ := parsePasswordHash(dummyPasswordHash)
+		dummy.matches(password)
+		return "", "", dockerdriver.SafeError{SafeDescription: UserNotFoundErrorMessage}
+	}
+
+	if !user.hash.matches(password) {
+		logger.Info("password-mismatch")
+		return "", "", dockerdriver.SafeError{SafeDescription: InvalidCredentialsErrorMessage}
+	}
+
+	logger.Info("resolved", lager.Data{"uid": user.uid, "gid": user.gid})
+	return user.uid, user.gid, nil
+}
+
+func parseStaticUsers(contents []byte) (map[string]staticUser, error) {
+	users := map[string]staticUser{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, ":")
+		if len(fields) != 4 && len(fields) != 5 {
+			return nil, fmt.Errorf("line %d: expected username:password-hash:uid:gid[:group,...]", lineNumber)
+		}
+
+		username := fields[0]
+		if username == "" {
+			return nil, fmt.Errorf("line %d: empty username", lineNumber)
+		}
+		if _, found := users[username]; found {
+			return nil, fmt.Errorf("line %d: duplicate user '%s'", lineNumber, username)
+		}
+
+		hash, err := parsePasswordHash(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", lineNumber, err.Error())
+		}
+
+		if !isPositiveId(fields[2]) {
+			return nil, fmt.Errorf("line %d: %s", lineNumber, InvalidUidValueErrorMessage)
+		}
+		if !isPositiveId(fields[3]) {
+			return nil, fmt.Errorf("line %d: %s", lineNumber, InvalidGidValueErrorMessage)
+		}
+
+		var groups []string
+		if len(fields) == 5 && fields[4] != "" {
+			groups = strings.Split(fields[4], ",")
+			for _, group := range groups {
+				if !isPositiveId(group) {
+					return nil, fmt.Errorf("line %d: invalid supplementary group '%s'", lineNumber, group)
+				}
+			}
+		}
+
+		users[username] = staticUser{hash: hash, uid: fields[2], gid: fields[3], groups: groups}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func isPositiveId(id string) bool {
+	value, err := strconv.ParseUint(id, 10, 32)
+	return err == nil && value > 0
+}
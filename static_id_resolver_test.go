@@ -0,0 +1,152 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"errors"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/ioutilshim/ioutil_fake"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+const staticUsers = `# username:password-hash:uid:gid
+alice:$pbkdf2-sha256$1000$c2FsdHNhbHRzYWx0c2FsdA$dClvKSmj66n6MdMWNv3Go4mvH1Ym2WIGiJvquqa.mfE:1001:2001
+
+bob:$pbkdf2-sha512$1000$c2FsdHNhbHRzYWx0c2FsdA$2IVLzDcUS76iY9.wBooZzkS5Z0mI6ikZPfMieFfR1eV/GJiiRzyvvz4ne7tBn8OJ1hxZyCz95QPNeLP3ykzMiQ:1002:2002
+carol:$pbkdf2$1000$c2FsdHNhbHRzYWx0c2FsdA$Gl3hdYiKRqzegI7vLGvFG.A89N0:1003:2003
+dave:$2y$04$Q8vQ0oXz1v7v6Yp0m3Lr1u/hWxqK1gLBqj.d9yrJtBoLMCWNzMT7C:1004:2004
+erin:$2b$04$abcdefghijklmnopqrstuu2r9OfJnfCsdneAXAGHnS4UpFFP8WIrW:1005:2005
+frank:$argon2id$v=19$m=64,t=1,p=1$c29tZXNhbHRzb21lc2FsdA$pclxcsa4X0bZ5lu7Vy5h3p7EU7VbuW7pGRWETxHuU0c:1006:2006
+`
+
+var _ = Describe("StaticIdResolver", func() {
+	var (
+		logger     *lagertest.TestLogger
+		env        dockerdriver.Env
+		fakeIoutil *ioutil_fake.FakeIoutil
+		subject    nfsv3driver.ReloadableIdResolver
+		err        error
+		contents   string
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("static-id-resolver")
+		env = driverhttp.NewHttpDriverEnv(logger, context.TODO())
+		fakeIoutil = &ioutil_fake.FakeIoutil{}
+		contents = staticUsers
+	})
+
+	JustBeforeEach(func() {
+		fakeIoutil.ReadFileReturns([]byte(contents), nil)
+		subject, err = nfsv3driver.NewStaticIdResolver(logger, fakeIoutil, "/var/vcap/jobs/nfsv3driver/config/users")
+	})
+
+	It("reads the users file", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeIoutil.ReadFileArgsForCall(0)).To(Equal("/var/vcap/jobs/nfsv3driver/config/users"))
+	})
+
+	DescribeTable("resolves users with the correct password",
+		func(username string, expectedUid string, expectedGid string) {
+			uid, gid, err := subject.Resolve(env, username, "secret")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uid).To(Equal(expectedUid))
+			Expect(gid).To(Equal(expectedGid))
+		},
+		Entry("pbkdf2-sha256", "alice", "1001", "2001"),
+		Entry("pbkdf2-sha512", "bob", "1002", "2002"),
+		Entry("pbkdf2-sha1", "carol", "1003", "2003"),
+		Entry("bcrypt $2y$", "dave", "1004", "2004"),
+		Entry("bcrypt $2b$", "erin", "1005", "2005"),
+		Entry("argon2id", "frank", "1006", "2006"),
+	)
+
+	DescribeTable("rejects a wrong password",
+		func(username string) {
+			_, _, err := subject.Resolve(env, username, "wrong")
+			Expect(err).To(MatchError(nfsv3driver.InvalidCredentialsErrorMessage))
+			Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+		},
+		Entry("pbkdf2", "alice"),
+		Entry("bcrypt", "dave"),
+		Entry("argon2id", "frank"),
+	)
+
+	It("reports unknown users", func() {
+		_, _, err := subject.Resolve(env, "mallory", "secret")
+		Expect(err).To(MatchError(nfsv3driver.UserNotFoundErrorMessage))
+		Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+	})
+
+	DescribeTable("when the users file is invalid",
+		func(line string, expectedError string) {
+			fakeIoutil.ReadFileReturns([]byte(line), nil)
+			_, err := nfsv3driver.NewStaticIdResolver(logger, fakeIoutil, "users")
+			Expect(err).To(MatchError(ContainSubstring(expectedError)))
+		},
+		Entry("too few fields", "alice:$pbkdf2$1000$c2FsdA$Gl3h:1001", "line 1: expected username:password-hash:uid:gid"),
+		Entry("empty username", ":$pbkdf2$1000$c2FsdA$Gl3h:1001:1001", "line 1: empty username"),
+		Entry("unrecognized hash", "alice:secret:1001:1001", "line 1: unrecognized password hash format"),
+		Entry("truncated bcrypt hash", "alice:$2b$12$abcdefghijklmnopqrstuv:1001:1001", "line 1: invalid bcrypt password hash"),
+		Entry("unsupported argon2 version", "alice:$argon2id$v=16$m=64,t=1,p=1$c29tZXNhbHQ$cGFzc3dvcmQ:1001:1001", "line 1: unsupported argon2id version 'v=16'"),
+		Entry("invalid argon2 parameter", "alice:$argon2id$v=19$m=64,t=1,x=1$c29tZXNhbHQ$cGFzc3dvcmQ:1001:1001", "line 1: invalid argon2id parameter 'x=1'"),
+		Entry("argon2i", "alice:$argon2i$v=19$m=64,t=1,p=1$c29tZXNhbHQ$cGFzc3dvcmQ:1001:1001", "line 1: unsupported password hash scheme 'argon2i'"),
+		Entry("unknown scheme", "alice:$md5$1$c2FsdA$Gl3h:1001:1001", "line 1: unsupported password hash scheme 'md5'"),
+		Entry("invalid rounds", "alice:$pbkdf2$many$c2FsdA$Gl3h:1001:1001", "line 1: invalid password hash rounds 'many'"),
+		Entry("zero uid", "alice:$pbkdf2$1000$c2FsdA$Gl3h:0:1001", nfsv3driver.InvalidUidValueErrorMessage),
+		Entry("non-numeric gid", "alice:$pbkdf2$1000$c2FsdA$Gl3h:1001:staff", nfsv3driver.InvalidGidValueErrorMessage),
+		Entry("invalid supplementary group", "alice:$pbkdf2$1000$c2FsdA$Gl3h:1001:1001:3001,staff", "line 1: invalid supplementary group 'staff'"),
+		Entry("too many fields", "alice:$pbkdf2$1000$c2FsdA$Gl3h:1001:1001:3001:4001", "line 1: expected username:password-hash:uid:gid[:group,...]"),
+		Entry("duplicate user", "alice:$pbkdf2$1000$c2FsdA$Gl3h:1001:1001\nalice:$pbkdf2$1000$c2FsdA$Gl3h:1002:1002", "line 2: duplicate user 'alice'"),
+	)
+
+	Context("when users have supplementary groups", func() {
+		BeforeEach(func() {
+			contents = "alice:$pbkdf2-sha256$1000$c2FsdHNhbHRzYWx0c2FsdA$dClvKSmj66n6MdMWNv3Go4mvH1Ym2WIGiJvquqa.mfE:1001:2001:3001,3002\n" +
+				"bob:$pbkdf2-sha256$1000$c2FsdHNhbHRzYWx0c2FsdA$dClvKSmj66n6MdMWNv3Go4mvH1Ym2WIGiJvquqa.mfE:1002:2002:\n"
+		})
+
+		It("loads them, logging that only the primary gid is mapped", func() {
+			Expect(err).NotTo(HaveOccurred())
+			uid, gid, err := subject.Resolve(env, "alice", "secret")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uid).To(Equal("1001"))
+			Expect(gid).To(Equal("2001"))
+			Expect(logger.Buffer()).To(gbytes.Say(`supplementary-groups-ignored.*"users":1`))
+		})
+	})
+
+	It("fails when the users file cannot be read", func() {
+		fakeIoutil.ReadFileReturns(nil, errors.New("permission denied"))
+		_, err := nfsv3driver.NewStaticIdResolver(logger, fakeIoutil, "users")
+		Expect(err).To(MatchError("permission denied"))
+	})
+
+	Describe("Reload", func() {
+		It("picks up changed entries", func() {
+			fakeIoutil.ReadFileReturns([]byte("gina:$pbkdf2$1000$c2FsdHNhbHRzYWx0c2FsdA$Gl3hdYiKRqzegI7vLGvFG.A89N0:1007:2007"), nil)
+			Expect(subject.Reload(logger)).To(Succeed())
+
+			uid, _, err := subject.Resolve(env, "gina", "secret")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uid).To(Equal("1007"))
+
+			_, _, err = subject.Resolve(env, "alice", "secret")
+			Expect(err).To(MatchError(nfsv3driver.UserNotFoundErrorMessage))
+		})
+
+		It("keeps the previous entries when the new file is invalid", func() {
+			fakeIoutil.ReadFileReturns([]byte("garbage"), nil)
+			Expect(subject.Reload(logger)).NotTo(Succeed())
+
+			uid, _, err := subject.Resolve(env, "alice", "secret")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uid).To(Equal("1001"))
+		})
+	})
+})
@@ -0,0 +1,45 @@
+package nfsv3driver
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/dockerdriver"
+)
+
+// SwappableIdResolver forwards to a resolver that can be replaced while mounts are in flight, e.g. when the
+// configuration is reloaded. Resolutions already running finish against the resolver they started with.
+type SwappableIdResolver struct {
+	lock     sync.RWMutex
+	resolver IdResolver
+}
+
+func NewSwappableIdResolver(resolver IdResolver) *SwappableIdResolver {
+	return &SwappableIdResolver{resolver: resolver}
+}
+
+func (s *SwappableIdResolver) Swap(resolver IdResolver) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.resolver = resolver
+}
+
+func (s *SwappableIdResolver) Resolve(env dockerdriver.Env, username string, password string) (string, string, error) {
+	s.lock.RLock()
+	resolver := s.resolver
+	s.lock.RUnlock()
+
+	return resolver.Resolve(env, username, password)
+}
+
+// CheckConnection checks the current resolver, when it depends on a directory server
+func (s *SwappableIdResolver) CheckConnection(env dockerdriver.Env) error {
+	s.lock.RLock()
+	resolver := s.resolver
+	s.lock.RUnlock()
+
+	if checker, ok := resolver.(ConnectionChecker); ok {
+		return checker.CheckConnection(env)
+	}
+	return nil
+}
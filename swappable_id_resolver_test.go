@@ -0,0 +1,70 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"errors"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SwappableIdResolver", func() {
+	var (
+		env         dockerdriver.Env
+		oldResolver *nfsdriverfakes.FakeIdResolver
+		newResolver *nfsdriverfakes.FakeIdResolver
+		subject     *nfsv3driver.SwappableIdResolver
+	)
+
+	BeforeEach(func() {
+		env = driverhttp.NewHttpDriverEnv(lagertest.NewTestLogger("swappable-id-resolver"), context.TODO())
+		oldResolver = &nfsdriverfakes.FakeIdResolver{}
+		oldResolver.ResolveReturns("1001", "2001", nil)
+		newResolver = &nfsdriverfakes.FakeIdResolver{}
+		newResolver.ResolveReturns("1002", "2002", nil)
+		subject = nfsv3driver.NewSwappableIdResolver(oldResolver)
+	})
+
+	It("forwards to the resolver it was created with", func() {
+		uid, gid, err := subject.Resolve(env, "alice", "secret")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(uid).To(Equal("1001"))
+		Expect(gid).To(Equal("2001"))
+
+		_, username, password := oldResolver.ResolveArgsForCall(0)
+		Expect(username).To(Equal("alice"))
+		Expect(password).To(Equal("secret"))
+	})
+
+	It("forwards to the new resolver once swapped", func() {
+		subject.Swap(newResolver)
+
+		uid, gid, err := subject.Resolve(env, "alice", "secret")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(uid).To(Equal("1002"))
+		Expect(gid).To(Equal("2002"))
+		Expect(oldResolver.ResolveCallCount()).To(BeZero())
+	})
+
+	Describe("CheckConnection", func() {
+		It("succeeds when the resolver has no directory server", func() {
+			Expect(subject.CheckConnection(env)).To(Succeed())
+		})
+
+		It("checks the current resolver", func() {
+			checker := &nfsdriverfakes.FakeConnectionChecker{}
+			checker.CheckConnectionReturns(errors.New("LDAP server could not be reached"))
+			subject.Swap(struct {
+				nfsv3driver.IdResolver
+				nfsv3driver.ConnectionChecker
+			}{newResolver, checker})
+
+			Expect(subject.CheckConnection(env)).To(MatchError("LDAP server could not be reached"))
+		})
+	})
+})
@@ -0,0 +1,276 @@
+package nfsv3driver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/goshims/timeshim"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+//counterfeiter:generate -o nfsdriverfakes/fake_time.go code.cloudfoundry.org/goshims/timeshim.Time
+
+const UserThrottledErrorMessage = "Too many failed login attempts for this user, please try again later"
+const GlobalThrottledErrorMessage = "Too many failed login attempts, please try again later"
+
+type ThrottleConfig struct {
+	// MaxUserFailures is the number of consecutive failures for one username before backoff starts. 0 disables it.
+	MaxUserFailures int
+	// BaseBackoff is doubled for every failure past MaxUserFailures, up to MaxBackoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+
+	// MaxGlobalFailures is the number of failures across all usernames within GlobalWindow after which every
+	// resolution is rejected until the window has passed. 0 disables it.
+	MaxGlobalFailures int
+	GlobalWindow      time.Duration
+
+	// MaxInFlight is the number of resolutions across all usernames run at once; further attempts wait for a
+	// slot. It also bounds how far parallel failures can overshoot MaxGlobalFailures. 0 disables it.
+	MaxInFlight int
+
+	// FailureCacheTTL is how long a failed username/password pair is answered from memory. 0 disables it.
+	FailureCacheTTL time.Duration
+}
+
+type userFailures struct {
+	count       int
+	lastFailure time.Time
+}
+
+type cachedFailure struct {
+	err     error
+	expires time.Time
+}
+
+type throttlingIdResolver struct {
+	resolver IdResolver
+	time     timeshim.Time
+	config   ThrottleConfig
+
+	lock           sync.Mutex
+	users          map[string]*userFailures
+	globalFailures []time.Time
+	failureCache   map[string]cachedFailure
+	// inFlight holds a channel per username with a resolution in progress, closed when it finishes. Attempts for
+	// the same username wait for it, so that they see its outcome before reaching the directory.
+	inFlight map[string]chan struct{}
+	// userThrottled and globalThrottled count the rejected resolutions since the driver started
+	userThrottled   int
+	globalThrottled int
+}
+
+// ThrottlingIdResolver reports how many logins it rejected on the admin API
+type ThrottlingIdResolver interface {
+	IdResolver
+	driveradmin.LoginThrottle
+}
+
+func NewThrottlingIdResolver(resolver IdResolver, time timeshim.Time, config ThrottleConfig) ThrottlingIdResolver {
+	return &throttlingIdResolver{
+		resolver:     resolver,
+		time:         time,
+		config:       config,
+		users:        map[string]*userFailures{},
+		failureCache: map[string]cachedFailure{},
+		inFlight:     map[string]chan struct{}{},
+	}
+}
+
+func (t *throttlingIdResolver) Resolve(env dockerdriver.Env, username string, password string) (uid string, gid string, err error) {
+	logger := env.Logger().Session("throttled-resolve", lager.Data{"username": username})
+
+	cacheKey := failureCacheKey(username, password)
+	if err := t.admit(env, logger, username, cacheKey); err != nil {
+		return "", "", err
+	}
+
+	uid, gid, err = t.resolver.Resolve(env, username, password)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	defer t.release(username)
+
+	if err == nil {
+		delete(t.users, username)
+		return uid, gid, nil
+	}
+
+	if !isAuthenticationFailure(err) {
+		return "", "", err
+	}
+
+	now := t.time.Now()
+	t.prune(now)
+
+	failures, ok := t.users[username]
+	if !ok {
+		failures = &userFailures{}
+		t.users[username] = failures
+	}
+	failures.count++
+	failures.lastFailure = now
+
+	t.globalFailures = append(t.globalFailures, now)
+
+	if t.config.FailureCacheTTL > 0 {
+		t.failureCache[cacheKey] = cachedFailure{err: err, expires: now.Add(t.config.FailureCacheTTL)}
+	}
+
+	logger.Info("authentication-failed", lager.Data{"consecutive-failures": failures.count, "global-failures": len(t.globalFailures)})
+
+	return "", "", err
+}
+
+// admit waits for any resolution in flight for username and for a free in-flight slot, then either rejects the
+// attempt or reserves the slot for it, which the caller must release
+func (t *throttlingIdResolver) admit(env dockerdriver.Env, logger lager.Logger, username string, cacheKey string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for {
+		done, ok := t.inFlight[username]
+		if !ok && t.config.MaxInFlight > 0 && len(t.inFlight) >= t.config.MaxInFlight {
+			for _, done = range t.inFlight {
+				break
+			}
+			ok = true
+		}
+		if !ok {
+			break
+		}
+
+		t.lock.Unlock()
+		select {
+		case <-done:
+		case <-env.Context().Done():
+			t.lock.Lock()
+			return env.Context().Err()
+		}
+		t.lock.Lock()
+	}
+
+	now := t.time.Now()
+	t.prune(now)
+
+	if cached, ok := t.failureCache[cacheKey]; ok {
+		logger.Info("failure-cache-hit", lager.Data{"expires": cached.expires})
+		return cached.err
+	}
+
+	if t.config.MaxGlobalFailures > 0 && len(t.globalFailures) >= t.config.MaxGlobalFailures {
+		t.globalThrottled++
+		logger.Info("global-throttled", lager.Data{
+			"failures":  len(t.globalFailures),
+			"window":    t.config.GlobalWindow.String(),
+			"throttled": t.globalThrottled,
+		})
+		return dockerdriver.SafeError{SafeDescription: GlobalThrottledErrorMessage}
+	}
+
+	if failures, ok := t.users[username]; ok {
+		if backoff := t.backoff(failures.count); backoff > 0 && now.Before(failures.lastFailure.Add(backoff)) {
+			t.userThrottled++
+			logger.Info("user-throttled", lager.Data{
+				"consecutive-failures": failures.count,
+				"retry-after":          failures.lastFailure.Add(backoff),
+				"throttled":            t.userThrottled,
+			})
+			return dockerdriver.SafeError{SafeDescription: UserThrottledErrorMessage}
+		}
+	}
+
+	t.inFlight[username] = make(chan struct{})
+	return nil
+}
+
+func (t *throttlingIdResolver) LoginThrottleStatus() driveradmin.LoginThrottleStatus {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.prune(t.time.Now())
+	return driveradmin.LoginThrottleStatus{
+		UserThrottled:   t.userThrottled,
+		GlobalThrottled: t.globalThrottled,
+		RecentFailures:  len(t.globalFailures),
+		InFlight:        len(t.inFlight),
+	}
+}
+
+// release frees the in-flight slot of username. It must be called with the lock held.
+func (t *throttlingIdResolver) release(username string) {
+	if done, ok := t.inFlight[username]; ok {
+		close(done)
+		delete(t.inFlight, username)
+	}
+}
+
+func (t *throttlingIdResolver) backoff(failures int) time.Duration {
+	if t.config.MaxUserFailures <= 0 || failures < t.config.MaxUserFailures {
+		return 0
+	}
+
+	backoff := t.config.BaseBackoff
+	for i := t.config.MaxUserFailures; i < failures; i++ {
+		backoff *= 2
+		if backoff >= t.config.MaxBackoff {
+			return t.config.MaxBackoff
+		}
+	}
+
+	if backoff > t.config.MaxBackoff {
+		return t.config.MaxBackoff
+	}
+	return backoff
+}
+
+// prune drops state that can no longer affect a decision. It must be called with the lock held.
+func (t *throttlingIdResolver) prune(now time.Time) {
+	i := 0
+	for i < len(t.globalFailures) && !now.Before(t.globalFailures[i].Add(t.config.GlobalWindow)) {
+		i++
+	}
+	t.globalFailures = t.globalFailures[i:]
+
+	for key, cached := range t.failureCache {
+		if !now.Before(cached.expires) {
+			delete(t.failureCache, key)
+		}
+	}
+
+	// a user who stays quiet for twice the longest backoff starts over
+	for username, failures := range t.users {
+		if !now.Before(failures.lastFailure.Add(2 * t.config.MaxBackoff)) {
+			delete(t.users, username)
+		}
+	}
+}
+
+// the password is only kept as a digest so that cached failures do not retain credentials in memory
+func failureCacheKey(username string, password string) string {
+	sum := sha256.Sum256([]byte(username + "\x00" + password))
+	return hex.EncodeToString(sum[:])
+}
+
+func isAuthenticationFailure(err error) bool {
+	safeErr, ok := err.(dockerdriver.SafeError)
+	if !ok {
+		return false
+	}
+
+	switch safeErr.SafeDescription {
+	case InvalidCredentialsErrorMessage,
+		AccountDisabledErrorMessage,
+		AccountLockedErrorMessage,
+		AccountExpiredErrorMessage,
+		PasswordExpiredErrorMessage,
+		PasswordMustChangeErrorMessage,
+		UserNotFoundErrorMessage:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,339 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("ThrottlingIdResolver", func() {
+	var (
+		logger       *lagertest.TestLogger
+		env          dockerdriver.Env
+		fakeResolver *nfsdriverfakes.FakeIdResolver
+		fakeTime     *nfsdriverfakes.FakeTime
+		now          time.Time
+		config       nfsv3driver.ThrottleConfig
+		subject      nfsv3driver.ThrottlingIdResolver
+		badPassword  error
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("throttling-id-resolver")
+		env = driverhttp.NewHttpDriverEnv(logger, context.TODO())
+
+		fakeResolver = &nfsdriverfakes.FakeIdResolver{}
+		fakeResolver.ResolveReturns("100", "200", nil)
+
+		now = time.Unix(1000000, 0)
+		fakeTime = &nfsdriverfakes.FakeTime{}
+		fakeTime.NowStub = func() time.Time { return now }
+
+		config = nfsv3driver.ThrottleConfig{
+			MaxUserFailures:   3,
+			BaseBackoff:       time.Second,
+			MaxBackoff:        10 * time.Second,
+			MaxGlobalFailures: 0,
+			GlobalWindow:      time.Minute,
+			FailureCacheTTL:   0,
+		}
+
+		badPassword = dockerdriver.SafeError{SafeDescription: nfsv3driver.InvalidCredentialsErrorMessage}
+	})
+
+	JustBeforeEach(func() {
+		subject = nfsv3driver.NewThrottlingIdResolver(fakeResolver, fakeTime, config)
+	})
+
+	failFor := func(username string, times int) {
+		for i := 0; i < times; i++ {
+			_, _, err := subject.Resolve(env, username, "wrong")
+			Expect(err).To(HaveOccurred())
+		}
+	}
+
+	It("passes successful resolutions through", func() {
+		uid, gid, err := subject.Resolve(env, "alice", "secret")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(uid).To(Equal("100"))
+		Expect(gid).To(Equal("200"))
+
+		_, username, password := fakeResolver.ResolveArgsForCall(0)
+		Expect(username).To(Equal("alice"))
+		Expect(password).To(Equal("secret"))
+	})
+
+	Context("when a user repeatedly fails to authenticate", func() {
+		BeforeEach(func() {
+			fakeResolver.ResolveReturns("", "", badPassword)
+		})
+
+		It("does not throttle before the threshold", func() {
+			failFor("alice", 2)
+			_, _, err := subject.Resolve(env, "alice", "wrong")
+			Expect(err).To(Equal(badPassword))
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(3))
+		})
+
+		It("rejects further attempts without contacting the directory", func() {
+			failFor("alice", 3)
+
+			_, _, err := subject.Resolve(env, "alice", "wrong")
+			Expect(err).To(MatchError(nfsv3driver.UserThrottledErrorMessage))
+			Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(3))
+			Expect(logger).To(gbytes.Say("user-throttled"))
+		})
+
+		It("does not throttle other users", func() {
+			failFor("alice", 3)
+
+			fakeResolver.ResolveReturns("100", "200", nil)
+			_, _, err := subject.Resolve(env, "bob", "secret")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("backs off exponentially", func() {
+			failFor("alice", 3)
+
+			now = now.Add(time.Second)
+			failFor("alice", 1)
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(4))
+
+			now = now.Add(time.Second)
+			_, _, err := subject.Resolve(env, "alice", "wrong")
+			Expect(err).To(MatchError(nfsv3driver.UserThrottledErrorMessage))
+
+			now = now.Add(time.Second)
+			failFor("alice", 1)
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(5))
+		})
+
+		It("caps the backoff", func() {
+			failFor("alice", 3)
+			for i := 0; i < 6; i++ {
+				now = now.Add(10 * time.Second)
+				failFor("alice", 1)
+			}
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(9))
+		})
+
+		It("resets after a successful resolution", func() {
+			failFor("alice", 2)
+
+			fakeResolver.ResolveReturns("100", "200", nil)
+			_, _, err := subject.Resolve(env, "alice", "secret")
+			Expect(err).NotTo(HaveOccurred())
+
+			fakeResolver.ResolveReturns("", "", badPassword)
+			failFor("alice", 2)
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(5))
+		})
+
+		It("forgets users who have been quiet long enough", func() {
+			failFor("alice", 3)
+
+			now = now.Add(20 * time.Second)
+			failFor("alice", 2)
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(5))
+		})
+	})
+
+	Context("when the resolver fails for reasons other than authentication", func() {
+		BeforeEach(func() {
+			fakeResolver.ResolveReturns("", "", errors.New("connection reset"))
+		})
+
+		It("does not count the failures", func() {
+			failFor("alice", 5)
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(5))
+		})
+	})
+
+	Context("when the global limit is configured", func() {
+		BeforeEach(func() {
+			config.MaxUserFailures = 0
+			config.MaxGlobalFailures = 4
+			fakeResolver.ResolveReturns("", "", badPassword)
+		})
+
+		It("rejects every user once the limit is reached within the window", func() {
+			failFor("alice", 2)
+			failFor("bob", 2)
+
+			fakeResolver.ResolveReturns("100", "200", nil)
+			_, _, err := subject.Resolve(env, "carol", "secret")
+			Expect(err).To(MatchError(nfsv3driver.GlobalThrottledErrorMessage))
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(4))
+			Expect(logger).To(gbytes.Say(`global-throttled.*"log_level":1`))
+		})
+
+		It("reports the rejections and the failures within the window", func() {
+			failFor("alice", 4)
+			failFor("carol", 2)
+
+			Expect(subject.LoginThrottleStatus()).To(Equal(driveradmin.LoginThrottleStatus{
+				GlobalThrottled: 2,
+				RecentFailures:  4,
+			}))
+		})
+
+		It("admits users again once the window has passed", func() {
+			failFor("alice", 4)
+
+			now = now.Add(time.Minute)
+			fakeResolver.ResolveReturns("100", "200", nil)
+			_, _, err := subject.Resolve(env, "carol", "secret")
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("when the failure cache is configured", func() {
+		BeforeEach(func() {
+			config.MaxUserFailures = 0
+			config.FailureCacheTTL = 5 * time.Second
+			fakeResolver.ResolveReturns("", "", badPassword)
+		})
+
+		It("replays the failure for the same credentials", func() {
+			failFor("alice", 1)
+			_, _, err := subject.Resolve(env, "alice", "wrong")
+			Expect(err).To(Equal(badPassword))
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(1))
+			Expect(logger).To(gbytes.Say("failure-cache-hit"))
+		})
+
+		It("contacts the directory for different credentials", func() {
+			failFor("alice", 1)
+
+			fakeResolver.ResolveReturns("100", "200", nil)
+			_, _, err := subject.Resolve(env, "alice", "right")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(2))
+		})
+
+		It("expires cached failures", func() {
+			failFor("alice", 1)
+
+			now = now.Add(5 * time.Second)
+			failFor("alice", 1)
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(2))
+		})
+	})
+
+	Context("when attempts arrive in parallel", func() {
+		var (
+			unblock chan struct{}
+			results chan error
+		)
+
+		BeforeEach(func() {
+			unblock = make(chan struct{})
+			results = make(chan error, 10)
+			fakeResolver.ResolveStub = func(dockerdriver.Env, string, string) (string, string, error) {
+				<-unblock
+				return "", "", badPassword
+			}
+		})
+
+		resolveInBackground := func(username string) {
+			go func() {
+				defer GinkgoRecover()
+				_, _, err := subject.Resolve(env, username, "wrong")
+				results <- err
+			}()
+		}
+
+		Context("for the same user", func() {
+			BeforeEach(func() {
+				config.MaxUserFailures = 1
+			})
+
+			It("lets one attempt through at a time, so the others see its failure", func() {
+				for i := 0; i < 5; i++ {
+					resolveInBackground("alice")
+				}
+				Eventually(fakeResolver.ResolveCallCount).Should(Equal(1))
+				Consistently(fakeResolver.ResolveCallCount, 100*time.Millisecond).Should(Equal(1))
+
+				close(unblock)
+				var errs []error
+				for i := 0; i < 5; i++ {
+					errs = append(errs, <-results)
+				}
+				Expect(errs).To(ContainElement(badPassword))
+				Expect(errs).To(HaveEach(Or(Equal(badPassword), Equal(dockerdriver.SafeError{SafeDescription: nfsv3driver.UserThrottledErrorMessage}))))
+				Expect(fakeResolver.ResolveCallCount()).To(Equal(1))
+				Expect(logger).To(gbytes.Say(`user-throttled.*"throttled":1`))
+			})
+
+			It("stops waiting when the request is cancelled", func() {
+				resolveInBackground("alice")
+				Eventually(fakeResolver.ResolveCallCount).Should(Equal(1))
+
+				ctx, cancel := context.WithCancel(context.TODO())
+				cancel()
+				_, _, err := subject.Resolve(driverhttp.NewHttpDriverEnv(logger, ctx), "alice", "wrong")
+				Expect(err).To(MatchError(context.Canceled))
+
+				close(unblock)
+				Eventually(results).Should(Receive())
+			})
+		})
+
+		Context("for different users", func() {
+			BeforeEach(func() {
+				config.MaxUserFailures = 0
+				config.MaxGlobalFailures = 2
+			})
+
+			It("does not count the attempts in flight against the global limit", func() {
+				resolveInBackground("alice")
+				resolveInBackground("bob")
+				resolveInBackground("carol")
+				Eventually(fakeResolver.ResolveCallCount).Should(Equal(3))
+				Expect(subject.LoginThrottleStatus().InFlight).To(Equal(3))
+
+				close(unblock)
+				for i := 0; i < 3; i++ {
+					Eventually(results).Should(Receive(Equal(badPassword)))
+				}
+
+				_, _, err := subject.Resolve(env, "dave", "wrong")
+				Expect(err).To(Equal(dockerdriver.SafeError{SafeDescription: nfsv3driver.GlobalThrottledErrorMessage}))
+				Expect(fakeResolver.ResolveCallCount()).To(Equal(3))
+			})
+
+			Context("when the attempts in flight are limited", func() {
+				BeforeEach(func() {
+					config.MaxGlobalFailures = 0
+					config.MaxInFlight = 2
+				})
+
+				It("holds further attempts until a slot is free", func() {
+					resolveInBackground("alice")
+					resolveInBackground("bob")
+					resolveInBackground("carol")
+					Eventually(fakeResolver.ResolveCallCount).Should(Equal(2))
+					Consistently(fakeResolver.ResolveCallCount, 100*time.Millisecond).Should(Equal(2))
+
+					close(unblock)
+					for i := 0; i < 3; i++ {
+						Eventually(results).Should(Receive(Equal(badPassword)))
+					}
+					Expect(fakeResolver.ResolveCallCount()).To(Equal(3))
+				})
+			})
+		})
+	})
+})
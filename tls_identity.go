@@ -0,0 +1,148 @@
+package nfsv3driver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/goshims/ioutilshim"
+	"code.cloudfoundry.org/goshims/timeshim"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+	"code.cloudfoundry.org/tlsconfig"
+)
+
+type TLSFiles struct {
+	CertFile string
+	KeyFile  string
+	// CAFile holds the authorities that client certificates are verified against
+	CAFile string
+}
+
+func (f TLSFiles) Paths() []string {
+	return []string{f.CertFile, f.KeyFile, f.CAFile}
+}
+
+// ReloadableTLSIdentity is a server certificate and client CA pool that Reload replaces together
+type ReloadableTLSIdentity interface {
+	driveradmin.CertificateSource
+	Reload(logger lager.Logger) error
+	// ServerConfig picks up the current certificate and CA pool for every new connection. Established connections
+	// keep the ones they were made with.
+	ServerConfig() *tls.Config
+}
+
+type tlsIdentity struct {
+	ioutil ioutilshim.Ioutil
+	time   timeshim.Time
+	name   string
+	files  TLSFiles
+
+	lock   sync.RWMutex
+	config *tls.Config
+	leaf   *x509.Certificate
+}
+
+// NewTLSIdentity loads a PEM encoded certificate, key and client CA bundle. name identifies the identity in logs
+// and on the admin API.
+func NewTLSIdentity(logger lager.Logger, ioutil ioutilshim.Ioutil, time timeshim.Time, name string, files TLSFiles) (ReloadableTLSIdentity, error) {
+	i := &tlsIdentity{
+		ioutil: ioutil,
+		time:   time,
+		name:   name,
+		files:  files,
+	}
+
+	if err := i.Reload(logger); err != nil {
+		return nil, err
+	}
+
+	return i, nil
+}
+
+func (i *tlsIdentity) Reload(logger lager.Logger) error {
+	logger = logger.Session("tls-reload", lager.Data{"name": i.name, "cert-file": i.files.CertFile})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	config, leaf, err := i.load()
+	if err != nil {
+		logger.Error("failed-to-load", err)
+		return err
+	}
+
+	i.lock.Lock()
+	i.config = config
+	i.leaf = leaf
+	i.lock.Unlock()
+
+	status := i.CertificateStatus()
+	logger.Info("loaded", lager.Data{"subject": status.Subject, "not-after": status.NotAfter, "days-until-expiry": status.DaysUntilExpiry})
+
+	return nil
+}
+
+func (i *tlsIdentity) load() (*tls.Config, *x509.Certificate, error) {
+	certPem, err := i.ioutil.ReadFile(i.files.CertFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPem, err := i.ioutil.ReadFile(i.files.KeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	caPem, err := i.ioutil.ReadFile(i.files.CAFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cert, err := tls.X509KeyPair(certPem, keyPem)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load keypair: %s", err.Error())
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load keypair: %s", err.Error())
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPem) {
+		return nil, nil, fmt.Errorf("no CA certificates found in %s", i.files.CAFile)
+	}
+
+	config, err := tlsconfig.Build(
+		tlsconfig.WithIdentity(cert),
+		tlsconfig.WithInternalServiceDefaults(),
+	).Server(tlsconfig.WithClientAuthentication(pool))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return config, leaf, nil
+}
+
+func (i *tlsIdentity) ServerConfig() *tls.Config {
+	return &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			i.lock.RLock()
+			defer i.lock.RUnlock()
+
+			return i.config, nil
+		},
+	}
+}
+
+func (i *tlsIdentity) CertificateStatus() driveradmin.CertificateStatus {
+	i.lock.RLock()
+	leaf := i.leaf
+	i.lock.RUnlock()
+
+	return driveradmin.CertificateStatus{
+		Name:            i.name,
+		Subject:         leaf.Subject.String(),
+		NotAfter:        leaf.NotAfter,
+		DaysUntilExpiry: int(leaf.NotAfter.Sub(i.time.Now()) / (24 * time.Hour)),
+	}
+}
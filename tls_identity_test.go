@@ -0,0 +1,222 @@
+package nfsv3driver_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"time"
+
+	"code.cloudfoundry.org/goshims/ioutilshim/ioutil_fake"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+type testCertificate struct {
+	cert    *x509.Certificate
+	key     *rsa.PrivateKey
+	certPem []byte
+	keyPem  []byte
+}
+
+// newTestCertificate issues a certificate signed by issuer, or a self-signed CA when issuer is nil. The keys are
+// RSA, since tlsconfig's internal service defaults only allow RSA cipher suites.
+func newTestCertificate(commonName string, notAfter time.Time, issuer *testCertificate) *testCertificate {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Expect(err).NotTo(HaveOccurred())
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:     []string{commonName},
+	}
+
+	parent, signer := template, key
+	if issuer == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		parent, signer = issuer.cert, issuer.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	Expect(err).NotTo(HaveOccurred())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+	keyDer := x509.MarshalPKCS1PrivateKey(key)
+
+	return &testCertificate{
+		cert:    cert,
+		key:     key,
+		certPem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPem:  pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: keyDer}),
+	}
+}
+
+func (c *testCertificate) tlsCertificate() tls.Certificate {
+	cert, err := tls.X509KeyPair(c.certPem, c.keyPem)
+	Expect(err).NotTo(HaveOccurred())
+	return cert
+}
+
+var _ = Describe("TLSIdentity", func() {
+	var (
+		logger     *lagertest.TestLogger
+		fakeIoutil *ioutil_fake.FakeIoutil
+		fakeTime   *nfsdriverfakes.FakeTime
+		files      map[string][]byte
+		ca         *testCertificate
+		server     *testCertificate
+		client     *testCertificate
+		subject    nfsv3driver.ReloadableTLSIdentity
+		err        error
+	)
+
+	// handshake connects a client trusting ca over a pipe and returns the certificate the server presented
+	handshake := func(clientCert *testCertificate) (*x509.Certificate, error) {
+		serverConn, clientConn := net.Pipe()
+		defer clientConn.Close()
+
+		go func() {
+			defer serverConn.Close()
+			_ = tls.Server(serverConn, subject.ServerConfig()).Handshake()
+		}()
+
+		roots := x509.NewCertPool()
+		roots.AddCert(ca.cert)
+		tlsConn := tls.Client(clientConn, &tls.Config{
+			RootCAs:      roots,
+			ServerName:   "nfsv3driver",
+			Certificates: []tls.Certificate{clientCert.tlsCertificate()},
+			MaxVersion:   tls.VersionTLS12,
+		})
+		if err := tlsConn.Handshake(); err != nil {
+			return nil, err
+		}
+		return tlsConn.ConnectionState().PeerCertificates[0], nil
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("tls-identity")
+		fakeTime = &nfsdriverfakes.FakeTime{}
+		fakeTime.NowReturns(time.Now())
+
+		ca = newTestCertificate("ca", time.Now().Add(365*24*time.Hour), nil)
+		server = newTestCertificate("nfsv3driver", time.Now().Add(30*24*time.Hour+time.Hour), ca)
+		client = newTestCertificate("rep", time.Now().Add(30*24*time.Hour), ca)
+
+		files = map[string][]byte{"cert.pem": server.certPem, "key.pem": server.keyPem, "ca.pem": ca.certPem}
+		fakeIoutil = &ioutil_fake.FakeIoutil{}
+		fakeIoutil.ReadFileStub = func(path string) ([]byte, error) {
+			contents, ok := files[path]
+			if !ok {
+				return nil, errors.New("no such file or directory")
+			}
+			return contents, nil
+		}
+	})
+
+	JustBeforeEach(func() {
+		subject, err = nfsv3driver.NewTLSIdentity(logger, fakeIoutil, fakeTime, "driver", nfsv3driver.TLSFiles{CertFile: "cert.pem", KeyFile: "key.pem", CAFile: "ca.pem"})
+	})
+
+	It("serves its certificate to clients with a trusted certificate", func() {
+		Expect(err).NotTo(HaveOccurred())
+
+		presented, err := handshake(client)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(presented.Equal(server.cert)).To(BeTrue())
+	})
+
+	It("rejects clients with an untrusted certificate", func() {
+		otherCa := newTestCertificate("other-ca", time.Now().Add(time.Hour), nil)
+		_, err := handshake(newTestCertificate("rep", time.Now().Add(time.Hour), otherCa))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("reports the certificate's expiry", func() {
+		status := subject.CertificateStatus()
+		Expect(status.Name).To(Equal("driver"))
+		Expect(status.Subject).To(Equal("CN=nfsv3driver"))
+		Expect(status.NotAfter).To(BeTemporally("==", server.cert.NotAfter))
+		Expect(status.DaysUntilExpiry).To(Equal(30))
+		Expect(logger).To(gbytes.Say(`tls-reload.loaded.*"days-until-expiry":30`))
+	})
+
+	It("counts down the days until expiry", func() {
+		fakeTime.NowReturns(time.Now().Add(10 * 24 * time.Hour))
+		Expect(subject.CertificateStatus().DaysUntilExpiry).To(Equal(20))
+	})
+
+	Context("when the certificates are rotated", func() {
+		var newCa, newServer *testCertificate
+
+		BeforeEach(func() {
+			newCa = newTestCertificate("new-ca", time.Now().Add(365*24*time.Hour), nil)
+			newServer = newTestCertificate("nfsv3driver", time.Now().Add(90*24*time.Hour+time.Hour), ca)
+		})
+
+		It("serves the new certificate to new connections", func() {
+			files["cert.pem"], files["key.pem"] = newServer.certPem, newServer.keyPem
+			Expect(subject.Reload(logger)).To(Succeed())
+
+			presented, err := handshake(client)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(presented.Equal(newServer.cert)).To(BeTrue())
+			Expect(subject.CertificateStatus().DaysUntilExpiry).To(Equal(90))
+		})
+
+		It("verifies clients against the new CA", func() {
+			files["ca.pem"] = newCa.certPem
+			Expect(subject.Reload(logger)).To(Succeed())
+
+			_, err := handshake(client)
+			Expect(err).To(HaveOccurred())
+			_, err = handshake(newTestCertificate("rep", time.Now().Add(time.Hour), newCa))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("keeps the previous identity when the new key does not match", func() {
+			files["cert.pem"] = newServer.certPem
+			Expect(subject.Reload(logger)).To(MatchError(ContainSubstring("failed to load keypair")))
+
+			presented, err := handshake(client)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(presented.Equal(server.cert)).To(BeTrue())
+		})
+
+		It("keeps the previous identity when the new certificate has expired", func() {
+			expired := newTestCertificate("nfsv3driver", time.Now().Add(-time.Minute), ca)
+			files["cert.pem"], files["key.pem"] = expired.certPem, expired.keyPem
+			Expect(subject.Reload(logger)).To(MatchError(ContainSubstring("certificate has expired")))
+			Expect(subject.CertificateStatus().NotAfter).To(BeTemporally("==", server.cert.NotAfter))
+		})
+	})
+
+	DescribeTable("when the files are invalid",
+		func(path string, contents []byte, expectedError string) {
+			files[path] = contents
+			_, err := nfsv3driver.NewTLSIdentity(logger, fakeIoutil, fakeTime, "driver", nfsv3driver.TLSFiles{CertFile: "cert.pem", KeyFile: "key.pem", CAFile: "ca.pem"})
+			Expect(err).To(MatchError(ContainSubstring(expectedError)))
+		},
+		Entry("no certificate", "cert.pem", []byte("nothing"), "failed to load keypair"),
+		Entry("no key", "key.pem", []byte("nothing"), "failed to load keypair"),
+		Entry("no CA", "ca.pem", []byte("nothing"), "no CA certificates found in ca.pem"),
+	)
+})
@@ -0,0 +1,503 @@
+package nfsv3driver
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/goshims/ioutilshim"
+	"code.cloudfoundry.org/goshims/timeshim"
+	"code.cloudfoundry.org/lager/v3"
+)
+
+const InvalidTokenErrorMessage = "Invalid or expired identity token"
+
+//counterfeiter:generate -o nfsdriverfakes/fake_token_resolver.go . TokenResolver
+
+// TokenResolver maps a signed identity token, passed in the 'token' bind option, to a uid and gid
+type TokenResolver interface {
+	ResolveToken(env dockerdriver.Env, token string) (uid string, gid string, err error)
+}
+
+type ReloadableTokenResolver interface {
+	TokenResolver
+	Reload(logger lager.Logger) error
+}
+
+//counterfeiter:generate -o nfsdriverfakes/fake_token_id_cache.go . TokenIdCache
+
+// TokenIdCache keeps the uid and gid that the token of the volume mounted at target resolved to, so that the volume
+// can be mounted again after its single-use token has been spent or has expired
+type TokenIdCache interface {
+	TokenIds(target string) (uid string, gid string, ok bool)
+	SetTokenIds(target string, uid string, gid string)
+}
+
+type TokenConfig struct {
+	// Audience must appear in the token's aud claim
+	Audience string
+	// Issuer, when set, must equal the token's iss claim
+	Issuer string
+	// MaxAge rejects tokens issued (iat) longer ago than this, however far away their expiry is
+	MaxAge time.Duration
+	// Leeway allows for clock skew between the issuer and this host
+	Leeway time.Duration
+	// ReplayCacheSize, when positive, enables replay protection: tokens must have a jti claim, and each jti is
+	// accepted once while its token is within MaxAge. Tokens are rejected while the cache is full.
+	ReplayCacheSize int
+}
+
+type tokenKey struct {
+	alg string
+	key crypto.PublicKey
+}
+
+type jwsTokenResolver struct {
+	ioutil ioutilshim.Ioutil
+	path   string
+	time   timeshim.Time
+	config TokenConfig
+
+	lock sync.RWMutex
+	keys map[string]tokenKey
+
+	usedLock sync.Mutex
+	// used maps the issuer and jti of accepted tokens to when they stop being accepted anyway
+	used map[string]time.Time
+}
+
+// NewJwsTokenResolver verifies JWS compact tokens against the public keys in a JSON Web Key Set file. RSA
+// (RS*/PS*), ECDSA (ES*) and Ed25519 (EdDSA) signatures are accepted; symmetric and unsigned tokens are not. The
+// token's claims must include sub, uid, gid, aud, exp and iat, and jti when replay protection is enabled.
+func NewJwsTokenResolver(logger lager.Logger, ioutil ioutilshim.Ioutil, path string, time timeshim.Time, config TokenConfig) (ReloadableTokenResolver, error) {
+	r := &jwsTokenResolver{
+		ioutil: ioutil,
+		path:   path,
+		time:   time,
+		config: config,
+	}
+
+	if err := r.Reload(logger); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *jwsTokenResolver) Reload(logger lager.Logger) error {
+	logger = logger.Session("token-keys-reload", lager.Data{"path": r.path})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	contents, err := r.ioutil.ReadFile(r.path)
+	if err != nil {
+		logger.Error("failed-to-read", err)
+		return err
+	}
+
+	keys, err := parseJwks(contents)
+	if err != nil {
+		logger.Error("failed-to-parse", err)
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.keys = keys
+	logger.Info("loaded", lager.Data{"keys": len(keys)})
+
+	return nil
+}
+
+func (r *jwsTokenResolver) ResolveToken(env dockerdriver.Env, token string) (uid string, gid string, err error) {
+	logger := env.Logger().Session("token-resolve")
+	logger.Info("start")
+	defer logger.Info("end")
+
+	claims, err := r.verify(token)
+	if err != nil {
+		logger.Info("token-rejected", lager.Data{"reason": err.Error()})
+		return "", "", dockerdriver.SafeError{SafeDescription: InvalidTokenErrorMessage}
+	}
+
+	logger.Info("resolved", lager.Data{"username": claims.Subject, "uid": claims.Uid, "gid": claims.Gid})
+	return claims.Uid, claims.Gid, nil
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type tokenClaims struct {
+	Subject   string   `json:"sub"`
+	Uid       string   `json:"uid"`
+	Gid       string   `json:"gid"`
+	Audience  audience `json:"aud"`
+	Issuer    string   `json:"iss"`
+	ExpiresAt *int64   `json:"exp"`
+	NotBefore *int64   `json:"nbf"`
+	IssuedAt  *int64   `json:"iat"`
+	Id        string   `json:"jti"`
+}
+
+// audience accepts both forms of the aud claim: a single string or a list of strings
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(a))
+}
+
+func (r *jwsTokenResolver) verify(token string) (*tokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("token is not in JWS compact form")
+	}
+
+	var header tokenHeader
+	if err := decodeTokenSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("invalid header: %s", err.Error())
+	}
+
+	key, err := r.findKey(header)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature encoding: %s", err.Error())
+	}
+	if err := verifySignature(header.Alg, key.key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	var claims tokenClaims
+	if err := decodeTokenSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("invalid claims: %s", err.Error())
+	}
+	if err := r.checkClaims(&claims); err != nil {
+		return nil, err
+	}
+	if err := r.checkReplay(&claims); err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+func (r *jwsTokenResolver) findKey(header tokenHeader) (tokenKey, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if header.Kid == "" && len(r.keys) != 1 {
+		return tokenKey{}, errors.New("token has no key id")
+	}
+
+	for kid, key := range r.keys {
+		if header.Kid != "" && kid != header.Kid {
+			continue
+		}
+		if key.alg != "" && key.alg != header.Alg {
+			return tokenKey{}, fmt.Errorf("key '%s' does not allow algorithm '%s'", kid, header.Alg)
+		}
+		return key, nil
+	}
+
+	return tokenKey{}, fmt.Errorf("unknown key id '%s'", header.Kid)
+}
+
+func (r *jwsTokenResolver) checkClaims(claims *tokenClaims) error {
+	now := r.time.Now()
+
+	if claims.Subject == "" {
+		return errors.New("token has no subject")
+	}
+	for _, id := range []string{claims.Uid, claims.Gid} {
+		if value, err := strconv.ParseUint(id, 10, 32); err != nil || value == 0 {
+			return fmt.Errorf("invalid uid or gid '%s'", id)
+		}
+	}
+
+	if !containsString(claims.Audience, r.config.Audience) {
+		return fmt.Errorf("audience %v does not include '%s'", []string(claims.Audience), r.config.Audience)
+	}
+	if r.config.Issuer != "" && claims.Issuer != r.config.Issuer {
+		return fmt.Errorf("unexpected issuer '%s'", claims.Issuer)
+	}
+
+	if claims.ExpiresAt == nil || claims.IssuedAt == nil {
+		return errors.New("token must have exp and iat claims")
+	}
+	if !now.Before(time.Unix(*claims.ExpiresAt, 0).Add(r.config.Leeway)) {
+		return errors.New("token has expired")
+	}
+	if claims.NotBefore != nil && now.Before(time.Unix(*claims.NotBefore, 0).Add(-r.config.Leeway)) {
+		return errors.New("token is not valid yet")
+	}
+
+	issuedAt := time.Unix(*claims.IssuedAt, 0)
+	if issuedAt.After(now.Add(r.config.Leeway)) {
+		return errors.New("token was issued in the future")
+	}
+	if now.Sub(issuedAt) > r.config.MaxAge+r.config.Leeway {
+		return fmt.Errorf("token was issued more than %s ago", r.config.MaxAge)
+	}
+
+	return nil
+}
+
+// checkReplay accepts each jti once, and remembers it until its token is too old to be accepted again
+func (r *jwsTokenResolver) checkReplay(claims *tokenClaims) error {
+	if r.config.ReplayCacheSize <= 0 {
+		return nil
+	}
+	if claims.Id == "" {
+		return errors.New("token has no jti claim")
+	}
+
+	now := r.time.Now()
+	key := claims.Issuer + " " + claims.Id
+
+	r.usedLock.Lock()
+	defer r.usedLock.Unlock()
+
+	if r.used == nil {
+		r.used = map[string]time.Time{}
+	}
+	if expiry, found := r.used[key]; found && now.Before(expiry) {
+		return fmt.Errorf("token '%s' has already been used", claims.Id)
+	}
+	if len(r.used) >= r.config.ReplayCacheSize {
+		for usedKey, expiry := range r.used {
+			if !now.Before(expiry) {
+				delete(r.used, usedKey)
+			}
+		}
+		if len(r.used) >= r.config.ReplayCacheSize {
+			return fmt.Errorf("more than %d tokens were used within %s", r.config.ReplayCacheSize, r.config.MaxAge)
+		}
+	}
+
+	r.used[key] = time.Unix(*claims.IssuedAt, 0).Add(r.config.MaxAge + r.config.Leeway)
+	return nil
+}
+
+// UnmarshalJSON accepts uid and gid claims given either as JSON numbers or as strings
+func (c *tokenClaims) UnmarshalJSON(data []byte) error {
+	type plainClaims tokenClaims
+	var raw struct {
+		plainClaims
+		Uid json.Number `json:"uid"`
+		Gid json.Number `json:"gid"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*c = tokenClaims(raw.plainClaims)
+	c.Uid = raw.Uid.String()
+	c.Gid = raw.Gid.String()
+	return nil
+}
+
+func decodeTokenSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed []byte, signature []byte) error {
+	hashes := map[string]crypto.Hash{"256": crypto.SHA256, "384": crypto.SHA384, "512": crypto.SHA512}
+
+	if alg == "EdDSA" {
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok || !ed25519.Verify(pub, signed, signature) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}
+
+	if len(alg) != 5 {
+		return fmt.Errorf("unsupported algorithm '%s'", alg)
+	}
+	hash, ok := hashes[alg[2:]]
+	if !ok {
+		return fmt.Errorf("unsupported algorithm '%s'", alg)
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch alg[:2] {
+	case "RS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPKCS1v15(pub, hash, digest, signature) != nil {
+			return errors.New("invalid signature")
+		}
+	case "PS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPSS(pub, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) != nil {
+			return errors.New("invalid signature")
+		}
+	case "ES":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || pub.Params().BitSize != map[string]int{"256": 256, "384": 384, "512": 521}[alg[2:]] {
+			return errors.New("invalid signature")
+		}
+		size := (pub.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid signature")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return errors.New("invalid signature")
+		}
+	default:
+		return fmt.Errorf("unsupported algorithm '%s'", alg)
+	}
+
+	return nil
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func parseJwks(contents []byte) (map[string]tokenKey, error) {
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(contents, &jwks); err != nil {
+		return nil, fmt.Errorf("invalid key set: %s", err.Error())
+	}
+	if len(jwks.Keys) == 0 {
+		return nil, errors.New("key set has no keys")
+	}
+
+	keys := map[string]tokenKey{}
+	for i, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if _, found := keys[jwk.Kid]; found {
+			return nil, fmt.Errorf("key %d: duplicate key id '%s'", i, jwk.Kid)
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %s", i, err.Error())
+		}
+		keys[jwk.Kid] = tokenKey{alg: jwk.Alg, key: key}
+	}
+
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if len(n) < 256 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("RSA keys must have a modulus of at least 2048 bits and a valid exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+
+	case "EC":
+		curves := map[string]struct {
+			ecdh  ecdh.Curve
+			curve elliptic.Curve
+		}{
+			"P-256": {ecdh.P256(), elliptic.P256()},
+			"P-384": {ecdh.P384(), elliptic.P384()},
+			"P-521": {ecdh.P521(), elliptic.P521()},
+		}
+		curve, ok := curves[k.Crv]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve '%s'", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		size := (curve.curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, errors.New("invalid EC point")
+		}
+		// crypto/ecdh checks that the point is on the curve
+		if _, err := curve.ecdh.NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve.curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve '%s'", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type '%s'", k.Kty)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
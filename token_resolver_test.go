@@ -0,0 +1,336 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/ioutilshim/ioutil_fake"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("JwsTokenResolver", func() {
+	var (
+		logger     *lagertest.TestLogger
+		env        dockerdriver.Env
+		fakeIoutil *ioutil_fake.FakeIoutil
+		fakeTime   *nfsdriverfakes.FakeTime
+		now        time.Time
+		config     nfsv3driver.TokenConfig
+		subject    nfsv3driver.ReloadableTokenResolver
+		err        error
+
+		rsaKey     *rsa.PrivateKey
+		ecKey      *ecdsa.PrivateKey
+		edKey      ed25519.PrivateKey
+		otherKey   ed25519.PrivateKey
+		jwks       map[string]interface{}
+		claims     map[string]interface{}
+		token      string
+		uid, gid   string
+		resolveErr error
+	)
+
+	encode := func(data []byte) string {
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	sign := func(header map[string]interface{}, claims map[string]interface{}, key crypto.Signer) string {
+		headerJson, _ := json.Marshal(header)
+		claimsJson, _ := json.Marshal(claims)
+		signed := encode(headerJson) + "." + encode(claimsJson)
+
+		var signature []byte
+		switch k := key.(type) {
+		case ed25519.PrivateKey:
+			signature = ed25519.Sign(k, []byte(signed))
+		case *ecdsa.PrivateKey:
+			digest := sha256.Sum256([]byte(signed))
+			r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+			Expect(err).NotTo(HaveOccurred())
+			signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		case *rsa.PrivateKey:
+			digest := sha256.Sum256([]byte(signed))
+			var err error
+			if header["alg"] == "PS256" {
+				signature, err = rsa.SignPSS(rand.Reader, k, crypto.SHA256, digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+			} else {
+				signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+			}
+			Expect(err).NotTo(HaveOccurred())
+		}
+		return signed + "." + encode(signature)
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("token-resolver")
+		env = driverhttp.NewHttpDriverEnv(logger, context.TODO())
+		fakeIoutil = &ioutil_fake.FakeIoutil{}
+
+		now = time.Unix(1700000000, 0)
+		fakeTime = &nfsdriverfakes.FakeTime{}
+		fakeTime.NowStub = func() time.Time { return now }
+
+		config = nfsv3driver.TokenConfig{Audience: "nfsv3driver", MaxAge: 5 * time.Minute, Leeway: 30 * time.Second}
+
+		rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		_, edKey, err = ed25519.GenerateKey(rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		_, otherKey, err = ed25519.GenerateKey(rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+
+		jwks = map[string]interface{}{"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa", "n": encode(rsaKey.N.Bytes()), "e": encode(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kty": "EC", "kid": "ec", "crv": "P-256", "x": encode(ecKey.X.FillBytes(make([]byte, 32))), "y": encode(ecKey.Y.FillBytes(make([]byte, 32)))},
+			{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "alg": "EdDSA", "x": encode(edKey.Public().(ed25519.PublicKey))},
+		}}
+
+		claims = map[string]interface{}{
+			"sub": "alice",
+			"uid": 1001,
+			"gid": "2001",
+			"aud": []string{"other", "nfsv3driver"},
+			"iat": now.Add(-time.Minute).Unix(),
+			"exp": now.Add(time.Hour).Unix(),
+		}
+		token = ""
+	})
+
+	JustBeforeEach(func() {
+		contents, _ := json.Marshal(jwks)
+		fakeIoutil.ReadFileReturns(contents, nil)
+		subject, err = nfsv3driver.NewJwsTokenResolver(logger, fakeIoutil, "/var/vcap/jobs/nfsv3driver/config/token_keys.json", fakeTime, config)
+		Expect(err).NotTo(HaveOccurred())
+
+		if token == "" {
+			token = sign(map[string]interface{}{"alg": "EdDSA", "kid": "ed"}, claims, edKey)
+		}
+		uid, gid, resolveErr = subject.ResolveToken(env, token)
+	})
+
+	It("reads the key set", func() {
+		Expect(fakeIoutil.ReadFileArgsForCall(0)).To(Equal("/var/vcap/jobs/nfsv3driver/config/token_keys.json"))
+		Expect(logger).To(gbytes.Say(`token-keys-reload.loaded.*"keys":3`))
+	})
+
+	It("maps the token to its uid and gid", func() {
+		Expect(resolveErr).NotTo(HaveOccurred())
+		Expect(uid).To(Equal("1001"))
+		Expect(gid).To(Equal("2001"))
+		Expect(logger).To(gbytes.Say(`token-resolve.resolved.*"username":"alice"`))
+	})
+
+	DescribeTable("verifying signatures",
+		func(alg string, kid string, key func() crypto.Signer) {
+			_, _, err := subject.ResolveToken(env, sign(map[string]interface{}{"alg": alg, "kid": kid}, claims, key()))
+			Expect(err).NotTo(HaveOccurred())
+		},
+		Entry("RS256", "RS256", "rsa", func() crypto.Signer { return rsaKey }),
+		Entry("PS256", "PS256", "rsa", func() crypto.Signer { return rsaKey }),
+		Entry("ES256", "ES256", "ec", func() crypto.Signer { return ecKey }),
+		Entry("EdDSA", "EdDSA", "ed", func() crypto.Signer { return edKey }),
+	)
+
+	DescribeTable("rejecting invalid tokens",
+		func(tokenFor func() string, reason string) {
+			_, _, err := subject.ResolveToken(env, tokenFor())
+			Expect(err).To(MatchError(nfsv3driver.InvalidTokenErrorMessage))
+			Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+			Expect(logger).To(gbytes.Say("token-rejected.*" + reason))
+		},
+		Entry("malformed", func() string { return "not-a-token" }, "not in JWS compact form"),
+		Entry("signed by an unknown key", func() string {
+			return sign(map[string]interface{}{"alg": "EdDSA", "kid": "ed"}, claims, otherKey)
+		}, "invalid signature"),
+		Entry("unknown key id", func() string {
+			return sign(map[string]interface{}{"alg": "EdDSA", "kid": "unknown"}, claims, edKey)
+		}, "unknown key id 'unknown'"),
+		Entry("no key id with more than one key", func() string {
+			return sign(map[string]interface{}{"alg": "EdDSA"}, claims, edKey)
+		}, "token has no key id"),
+		Entry("algorithm not allowed for the key", func() string {
+			return sign(map[string]interface{}{"alg": "ES256", "kid": "ed"}, claims, ecKey)
+		}, "key 'ed' does not allow algorithm 'ES256'"),
+		Entry("algorithm does not match the key type", func() string {
+			return sign(map[string]interface{}{"alg": "RS256", "kid": "ec"}, claims, ecKey)
+		}, "invalid signature"),
+		Entry("unsigned", func() string {
+			headerJson, _ := json.Marshal(map[string]string{"alg": "none", "kid": "rsa"})
+			claimsJson, _ := json.Marshal(claims)
+			return encode(headerJson) + "." + encode(claimsJson) + "."
+		}, "unsupported algorithm 'none'"),
+		Entry("another audience", func() string {
+			claims["aud"] = "other"
+			return sign(map[string]interface{}{"alg": "EdDSA", "kid": "ed"}, claims, edKey)
+		}, "audience"),
+		Entry("expired", func() string {
+			claims["exp"] = now.Add(-time.Minute).Unix()
+			return sign(map[string]interface{}{"alg": "EdDSA", "kid": "ed"}, claims, edKey)
+		}, "token has expired"),
+		Entry("not valid yet", func() string {
+			claims["nbf"] = now.Add(time.Minute).Unix()
+			return sign(map[string]interface{}{"alg": "EdDSA", "kid": "ed"}, claims, edKey)
+		}, "token is not valid yet"),
+		Entry("issued longer ago than the maximum age", func() string {
+			claims["iat"] = now.Add(-10 * time.Minute).Unix()
+			return sign(map[string]interface{}{"alg": "EdDSA", "kid": "ed"}, claims, edKey)
+		}, "token was issued more than 5m0s ago"),
+		Entry("no issue time", func() string {
+			delete(claims, "iat")
+			return sign(map[string]interface{}{"alg": "EdDSA", "kid": "ed"}, claims, edKey)
+		}, "token must have exp and iat claims"),
+		Entry("no subject", func() string {
+			delete(claims, "sub")
+			return sign(map[string]interface{}{"alg": "EdDSA", "kid": "ed"}, claims, edKey)
+		}, "token has no subject"),
+		Entry("zero uid", func() string {
+			claims["uid"] = 0
+			return sign(map[string]interface{}{"alg": "EdDSA", "kid": "ed"}, claims, edKey)
+		}, "invalid uid or gid '0'"),
+		Entry("no gid", func() string {
+			delete(claims, "gid")
+			return sign(map[string]interface{}{"alg": "EdDSA", "kid": "ed"}, claims, edKey)
+		}, "invalid uid or gid ''"),
+	)
+
+	Context("when timestamps are within the leeway", func() {
+		BeforeEach(func() {
+			claims["exp"] = now.Add(-10 * time.Second).Unix()
+			claims["nbf"] = now.Add(10 * time.Second).Unix()
+		})
+
+		It("accepts the token", func() {
+			Expect(resolveErr).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("when an issuer is configured", func() {
+		BeforeEach(func() {
+			config.Issuer = "https://uaa.example.com/oauth/token"
+			claims["iss"] = "https://evil.example.com"
+		})
+
+		It("rejects tokens from other issuers", func() {
+			Expect(resolveErr).To(MatchError(nfsv3driver.InvalidTokenErrorMessage))
+			Expect(logger).To(gbytes.Say("unexpected issuer"))
+		})
+	})
+
+	Context("when replay protection is enabled", func() {
+		BeforeEach(func() {
+			config.ReplayCacheSize = 2
+			claims["jti"] = "token-1"
+		})
+
+		It("rejects the token when it is presented again", func() {
+			Expect(resolveErr).NotTo(HaveOccurred())
+
+			_, _, err := subject.ResolveToken(env, token)
+			Expect(err).To(MatchError(nfsv3driver.InvalidTokenErrorMessage))
+			Expect(logger).To(gbytes.Say("token-rejected.*token 'token-1' has already been used"))
+		})
+
+		It("accepts tokens with other ids", func() {
+			claims["jti"] = "token-2"
+			_, _, err := subject.ResolveToken(env, sign(map[string]interface{}{"alg": "EdDSA", "kid": "ed"}, claims, edKey))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects tokens while the cache is full", func() {
+			claims["jti"] = "token-2"
+			_, _, err := subject.ResolveToken(env, sign(map[string]interface{}{"alg": "EdDSA", "kid": "ed"}, claims, edKey))
+			Expect(err).NotTo(HaveOccurred())
+
+			claims["jti"] = "token-3"
+			_, _, err = subject.ResolveToken(env, sign(map[string]interface{}{"alg": "EdDSA", "kid": "ed"}, claims, edKey))
+			Expect(err).To(MatchError(nfsv3driver.InvalidTokenErrorMessage))
+			Expect(logger).To(gbytes.Say("token-rejected.*more than 2 tokens were used within 5m0s"))
+		})
+
+		It("forgets ids once their tokens are older than the maximum age", func() {
+			claims["jti"] = "token-2"
+			_, _, err := subject.ResolveToken(env, sign(map[string]interface{}{"alg": "EdDSA", "kid": "ed"}, claims, edKey))
+			Expect(err).NotTo(HaveOccurred())
+
+			now = now.Add(5 * time.Minute)
+			claims["jti"] = "token-3"
+			claims["iat"] = now.Unix()
+			_, _, err = subject.ResolveToken(env, sign(map[string]interface{}{"alg": "EdDSA", "kid": "ed"}, claims, edKey))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Context("when the token has no jti claim", func() {
+			BeforeEach(func() {
+				delete(claims, "jti")
+			})
+
+			It("rejects it", func() {
+				Expect(resolveErr).To(MatchError(nfsv3driver.InvalidTokenErrorMessage))
+				Expect(logger).To(gbytes.Say("token-rejected.*token has no jti claim"))
+			})
+		})
+	})
+
+	Context("when the keys are rotated", func() {
+		It("verifies tokens with the reloaded keys", func() {
+			jwks["keys"] = []map[string]string{{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": encode(otherKey.Public().(ed25519.PublicKey))}}
+			contents, _ := json.Marshal(jwks)
+			fakeIoutil.ReadFileReturns(contents, nil)
+			Expect(subject.Reload(logger)).To(Succeed())
+
+			_, _, err := subject.ResolveToken(env, token)
+			Expect(err).To(MatchError(nfsv3driver.InvalidTokenErrorMessage))
+
+			_, _, err = subject.ResolveToken(env, sign(map[string]interface{}{"alg": "EdDSA"}, claims, otherKey))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("keeps the previous keys when the new key set is invalid", func() {
+			fakeIoutil.ReadFileReturns([]byte(`{"keys":[]}`), nil)
+			Expect(subject.Reload(logger)).To(MatchError("key set has no keys"))
+
+			_, _, err := subject.ResolveToken(env, token)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	DescribeTable("when the key set is invalid",
+		func(contents string, expectedError string) {
+			fakeIoutil.ReadFileReturns([]byte(contents), nil)
+			_, err := nfsv3driver.NewJwsTokenResolver(logger, fakeIoutil, "keys", fakeTime, config)
+			Expect(err).To(MatchError(ContainSubstring(expectedError)))
+		},
+		Entry("not JSON", "keys", "invalid key set"),
+		Entry("no keys", `{"keys":[]}`, "key set has no keys"),
+		Entry("symmetric key", `{"keys":[{"kty":"oct","k":"c2VjcmV0"}]}`, "key 0: unsupported key type 'oct'"),
+		Entry("short RSA key", `{"keys":[{"kty":"RSA","n":"AQAB","e":"AQAB"}]}`, "at least 2048 bits"),
+		Entry("point not on the curve", `{"keys":[{"kty":"EC","crv":"P-256","x":"`+strings.Repeat("A", 43)+`","y":"`+strings.Repeat("B", 43)+`"}]}`, "key 0: "),
+		Entry("duplicate key ids", `{"keys":[{"kty":"OKP","crv":"Ed25519","x":"`+strings.Repeat("A", 43)+`"},{"kty":"OKP","crv":"Ed25519","x":"`+strings.Repeat("A", 43)+`"}]}`, "key 1: duplicate key id ''"),
+	)
+
+	It("reports a key set that cannot be read", func() {
+		fakeIoutil.ReadFileReturns(nil, errors.New("permission denied"))
+		Expect(subject.Reload(logger)).To(MatchError("permission denied"))
+	})
+})
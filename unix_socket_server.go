@@ -0,0 +1,176 @@
+package nfsv3driver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3"
+	"github.com/tedsuo/ifrit"
+)
+
+type UnixSocketConfig struct {
+	Mode os.FileMode
+	// Uid and Gid own the socket; -1 leaves the driver's own
+	Uid int
+	Gid int
+	// AllowedPeerUids restricts connections to processes running as one of these uids, checked with SO_PEERCRED.
+	// When empty every process that can open the socket is served.
+	AllowedPeerUids []IdRange
+}
+
+// NewUnixSocketServer serves handler on a unix socket at path. A stale socket left behind by a previous run is
+// removed; one that still accepts connections is not. The socket only appears at path once its mode and owner
+// are set, and is removed again when the server stops.
+func NewUnixSocketServer(logger lager.Logger, path string, handler http.Handler, config UnixSocketConfig) ifrit.Runner {
+	return &unixSocketServer{
+		logger:  logger.Session("unix-socket-server", lager.Data{"path": path}),
+		path:    path,
+		handler: handler,
+		config:  config,
+	}
+}
+
+type unixSocketServer struct {
+	logger  lager.Logger
+	path    string
+	handler http.Handler
+	config  UnixSocketConfig
+}
+
+func (s *unixSocketServer) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	if err := s.removeStaleSocket(); err != nil {
+		s.logger.Error("stale-socket", err)
+		return err
+	}
+
+	listener, err := s.listen()
+	if err != nil {
+		s.logger.Error("failed-to-listen", err)
+		return err
+	}
+	defer os.Remove(s.path)
+
+	server := http.Server{Handler: s.handler}
+	serverErrChan := make(chan error, 1)
+	go func() {
+		serverErrChan <- server.Serve(&peerCheckingListener{UnixListener: listener, logger: s.logger, allowed: s.config.AllowedPeerUids})
+	}()
+
+	s.logger.Info("listening", lager.Data{"mode": fmt.Sprintf("%#o", s.config.Mode), "uid": s.config.Uid, "gid": s.config.Gid})
+	close(ready)
+
+	select {
+	case err := <-serverErrChan:
+		return err
+	case <-signals:
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		return server.Shutdown(ctx)
+	}
+}
+
+func (s *unixSocketServer) removeStaleSocket() error {
+	info, err := os.Lstat(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", s.path)
+	}
+
+	if conn, err := net.DialTimeout("unix", s.path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("%s is in use by another process", s.path)
+	}
+
+	s.logger.Info("removing-stale-socket")
+	return os.Remove(s.path)
+}
+
+// listen binds the socket in a private directory next to path and renames it into place, so that clients never
+// see it with the default mode and owner: until then only the driver's user can reach it
+func (s *unixSocketServer) listen() (*net.UnixListener, error) {
+	tmpDir, err := os.MkdirTemp(filepath.Dir(s.path), ".sock-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+	tmpPath := filepath.Join(tmpDir, "s")
+
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmpPath, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	listener.SetUnlinkOnClose(false)
+
+	err = os.Chmod(tmpPath, s.config.Mode)
+	if err == nil {
+		err = os.Lchown(tmpPath, s.config.Uid, s.config.Gid)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, s.path)
+	}
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	return listener, nil
+}
+
+type peerCheckingListener struct {
+	*net.UnixListener
+	logger  lager.Logger
+	allowed []IdRange
+}
+
+func (l *peerCheckingListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.AcceptUnix()
+		if err != nil {
+			return nil, err
+		}
+		if len(l.allowed) == 0 {
+			return conn, nil
+		}
+
+		cred, err := peerCredentials(conn)
+		if err != nil {
+			l.logger.Error("failed-to-read-peer-credentials", err)
+			conn.Close()
+			continue
+		}
+		if !idAllowed(cred.Uid, l.allowed, nil) {
+			l.logger.Info("peer-rejected", lager.Data{"uid": cred.Uid, "pid": cred.Pid})
+			conn.Close()
+			continue
+		}
+		return conn, nil
+	}
+}
+
+func peerCredentials(conn *net.UnixConn) (*syscall.Ucred, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+
+	var cred *syscall.Ucred
+	var credErr error
+	err = raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cred, credErr
+}
@@ -0,0 +1,178 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/tedsuo/ifrit"
+)
+
+var _ = Describe("UnixSocketServer", func() {
+	var (
+		logger     *lagertest.TestLogger
+		socketPath string
+		config     nfsv3driver.UnixSocketConfig
+		process    ifrit.Process
+	)
+
+	get := func() (string, error) {
+		client := &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+			},
+		}}
+		resp, err := client.Get("http://nfsv3driver/")
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("unix-socket-server")
+		socketPath = filepath.Join(GinkgoT().TempDir(), "nfsv3driver.sock")
+		config = nfsv3driver.UnixSocketConfig{Mode: 0660, Uid: -1, Gid: -1}
+	})
+
+	JustBeforeEach(func() {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("pong"))
+		})
+		process = ifrit.Background(nfsv3driver.NewUnixSocketServer(logger, socketPath, handler, config))
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive())
+	})
+
+	Context("when it starts", func() {
+		JustBeforeEach(func() {
+			Eventually(process.Ready()).Should(BeClosed())
+		})
+
+		It("serves the handler", func() {
+			Expect(get()).To(Equal("pong"))
+		})
+
+		It("sets the socket mode", func() {
+			info, err := os.Stat(socketPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode() & os.ModeSocket).NotTo(BeZero())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0660)))
+		})
+
+		It("does not leave the temporary directory behind", func() {
+			entries, err := os.ReadDir(filepath.Dir(socketPath))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Name()).To(Equal("nfsv3driver.sock"))
+		})
+
+		It("removes the socket when it stops", func() {
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive(BeNil()))
+
+			_, err := os.Lstat(socketPath)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		Context("with an owner", func() {
+			BeforeEach(func() {
+				if os.Geteuid() != 0 {
+					Skip("changing the owner requires root")
+				}
+				config.Uid, config.Gid = 65534, 65534
+			})
+
+			It("changes the socket owner", func() {
+				info, err := os.Stat(socketPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(info.Sys().(*syscall.Stat_t).Uid).To(Equal(uint32(65534)))
+				Expect(info.Sys().(*syscall.Stat_t).Gid).To(Equal(uint32(65534)))
+			})
+		})
+
+		Context("when peers are restricted to the current uid", func() {
+			BeforeEach(func() {
+				uid := uint32(os.Getuid())
+				config.AllowedPeerUids = []nfsv3driver.IdRange{{Min: uid, Max: uid}}
+			})
+
+			It("serves them", func() {
+				Expect(get()).To(Equal("pong"))
+			})
+		})
+
+		Context("when peers are restricted to other uids", func() {
+			BeforeEach(func() {
+				uid := uint32(os.Getuid())
+				config.AllowedPeerUids = []nfsv3driver.IdRange{{Min: uid + 1, Max: uid + 1}}
+			})
+
+			It("closes their connections", func() {
+				_, err := get()
+				Expect(err).To(HaveOccurred())
+				Expect(logger).To(gbytes.Say(`unix-socket-server.peer-rejected.*"uid":`))
+			})
+		})
+	})
+
+	Context("when a stale socket is left behind", func() {
+		BeforeEach(func() {
+			listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: socketPath, Net: "unix"})
+			Expect(err).NotTo(HaveOccurred())
+			listener.SetUnlinkOnClose(false)
+			Expect(listener.Close()).To(Succeed())
+		})
+
+		It("replaces it", func() {
+			Eventually(process.Ready()).Should(BeClosed())
+			Expect(logger).To(gbytes.Say("removing-stale-socket"))
+			Expect(get()).To(Equal("pong"))
+		})
+	})
+
+	Context("when another process is listening on the socket", func() {
+		var listener net.Listener
+
+		BeforeEach(func() {
+			var err error
+			listener, err = net.Listen("unix", socketPath)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			listener.Close()
+		})
+
+		It("fails without removing it", func() {
+			Eventually(process.Wait()).Should(Receive(MatchError(socketPath + " is in use by another process")))
+			_, err := os.Lstat(socketPath)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("when the path is not a socket", func() {
+		BeforeEach(func() {
+			Expect(os.WriteFile(socketPath, []byte("data"), 0600)).To(Succeed())
+		})
+
+		It("fails without removing it", func() {
+			Eventually(process.Wait()).Should(Receive(MatchError(socketPath + " exists and is not a socket")))
+			Expect(os.ReadFile(socketPath)).To(Equal([]byte("data")))
+		})
+	})
+})
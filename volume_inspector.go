@@ -0,0 +1,181 @@
+package nfsv3driver
+
+import (
+	"bytes"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/goshims/ioutilshim"
+	"code.cloudfoundry.org/goshims/timeshim"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+type mountEntry struct {
+	source  string
+	options string
+}
+
+type mapfsProcess struct {
+	pid int
+	uid string
+	gid string
+}
+
+type volumeInspector struct {
+	driver   VolumeLister
+	tracker  MountTracker
+	ioutil   ioutilshim.Ioutil
+	time     timeshim.Time
+	procPath string
+}
+
+// NewVolumeInspector describes the driver's volumes from the kernel's mount table and the mapfs processes under
+// procPath, usually /proc, together with what tracker remembers about them
+func NewVolumeInspector(driver VolumeLister, tracker MountTracker, ioutil ioutilshim.Ioutil, time timeshim.Time, procPath string) driveradmin.VolumeInspector {
+	return &volumeInspector{driver: driver, tracker: tracker, ioutil: ioutil, time: time, procPath: procPath}
+}
+
+func (v *volumeInspector) InspectVolumes(env dockerdriver.Env) ([]driveradmin.VolumeStatus, error) {
+	logger := env.Logger().Session("inspect-volumes")
+	logger.Info("start")
+	defer logger.Info("end")
+
+	mounts, err := v.mounts()
+	if err != nil {
+		logger.Error("failed-reading-mounts", err)
+		return nil, err
+	}
+	processes := findMapfsProcesses(logger, v.ioutil, v.procPath)
+	now := v.time.Now()
+
+	volumes := []driveradmin.VolumeStatus{}
+	for _, volume := range v.driver.List(env).Volumes {
+		status := driveradmin.VolumeStatus{Name: volume.Name, Mountpoint: volume.Mountpoint, MountCount: volume.MountCount}
+		if volume.Mountpoint != "" {
+			target := strings.TrimSuffix(volume.Mountpoint, "/")
+
+			nfsMount, mapped := mounts[target+MapfsDirectorySuffix]
+			if !mapped {
+				nfsMount = mounts[target]
+			}
+			status.UidMapping = mapped
+			status.Source = nfsMount.source
+			status.Server = nfsServer(nfsMount.source)
+			status.MountOptions = nfsMount.options
+
+			if process, ok := processes[target]; ok {
+				status.UidMapping = true
+				status.MapfsPid = process.pid
+				status.Uid = process.uid
+				status.Gid = process.gid
+			}
+
+			if record, ok := v.tracker.Record(target); ok {
+				status.MountedAt = record.MountedAt
+				if !record.MountedAt.IsZero() {
+					status.AgeSeconds = int64(now.Sub(record.MountedAt).Seconds())
+				}
+				if !record.LastCheckAt.IsZero() {
+					status.LastCheck = &driveradmin.VolumeCheck{At: record.LastCheckAt, Healthy: record.LastCheckHealthy}
+				}
+			}
+		}
+		volumes = append(volumes, status)
+	}
+
+	sort.Slice(volumes, func(i, j int) bool { return volumes[i].Name < volumes[j].Name })
+	return volumes, nil
+}
+
+// mounts reads the mount table, keyed by mount point
+func (v *volumeInspector) mounts() (map[string]mountEntry, error) {
+	contents, err := v.ioutil.ReadFile(filepath.Join(v.procPath, "mounts"))
+	if err != nil {
+		return nil, err
+	}
+
+	mounts := map[string]mountEntry{}
+	for _, line := range strings.Split(string(contents), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		mounts[unescapeMountField(fields[1])] = mountEntry{source: unescapeMountField(fields[0]), options: fields[3]}
+	}
+	return mounts, nil
+}
+
+// findMapfsProcesses finds the mapfs processes under procPath by their last two arguments, the mount point and the
+// intermediate NFS mount, keyed by mount point. Processes that exit while they are read are skipped.
+func findMapfsProcesses(logger lager.Logger, ioutil ioutilshim.Ioutil, procPath string) map[string]mapfsProcess {
+	processes := map[string]mapfsProcess{}
+
+	entries, err := ioutil.ReadDir(procPath)
+	if err != nil {
+		logger.Error("failed-listing-processes", err)
+		return processes
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		cmdline, err := ioutil.ReadFile(filepath.Join(procPath, entry.Name(), "cmdline"))
+		if err != nil {
+			continue
+		}
+
+		args := strings.Split(string(bytes.TrimRight(cmdline, "\x00")), "\x00")
+		if len(args) < 3 || filepath.Base(args[0]) != "mapfs" {
+			continue
+		}
+		target, intermediate := args[len(args)-2], args[len(args)-1]
+		if intermediate != target+MapfsDirectorySuffix {
+			continue
+		}
+
+		process := mapfsProcess{pid: pid}
+		for i := 1; i+1 < len(args)-2; i++ {
+			switch args[i] {
+			case "-uid":
+				process.uid = args[i+1]
+			case "-gid":
+				process.gid = args[i+1]
+			}
+		}
+		processes[target] = process
+	}
+	return processes
+}
+
+// nfsServer is the host of an NFS source such as 'server:/export'
+func nfsServer(source string) string {
+	if i := strings.Index(source, ":/"); i > 0 {
+		return strings.TrimSuffix(strings.TrimPrefix(source[:i], "["), "]")
+	}
+	return ""
+}
+
+// unescapeMountField undoes the octal escaping of spaces, tabs, newlines and backslashes in the mount table
+func unescapeMountField(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+
+	var unescaped strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+4 <= len(field) {
+			if code, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				unescaped.WriteByte(byte(code))
+				i += 3
+				continue
+			}
+		}
+		unescaped.WriteByte(field[i])
+	}
+	return unescaped.String()
+}
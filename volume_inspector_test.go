@@ -0,0 +1,118 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/ioutilshim"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("VolumeInspector", func() {
+	var (
+		env         dockerdriver.Env
+		procPath    string
+		fakeDriver  *nfsdriverfakes.FakeVolumeLister
+		fakeTracker *nfsdriverfakes.FakeMountTracker
+		fakeTime    *nfsdriverfakes.FakeTime
+		now         time.Time
+		volumes     []driveradmin.VolumeStatus
+		err         error
+	)
+
+	writeProcess := func(pid string, args ...string) {
+		Expect(os.MkdirAll(filepath.Join(procPath, pid), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(procPath, pid, "cmdline"), []byte(strings.Join(args, "\x00")+"\x00"), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		env = driverhttp.NewHttpDriverEnv(lagertest.NewTestLogger("volume-inspector"), context.TODO())
+		procPath = GinkgoT().TempDir()
+
+		Expect(os.WriteFile(filepath.Join(procPath, "mounts"), []byte(strings.Join([]string{
+			"/dev/sda1 / ext4 rw,relatime 0 0",
+			"nfs1.example.com:/export/a /var/vcap/data/volumes/nfs/vol1_mapfs nfs rw,relatime,vers=3,rsize=1048576,hard,proto=tcp 0 0",
+			"mapfs /var/vcap/data/volumes/nfs/vol1 fuse.mapfs rw,nosuid,nodev,relatime,user_id=0,group_id=0 0 0",
+			`nfs2.example.com:/export/with\040space /var/vcap/data/volumes/nfs/vol2 nfs ro,relatime,vers=3,actimeo=0 0 0`,
+			"",
+		}, "\n")), 0644)).To(Succeed())
+
+		writeProcess("1", "/sbin/init")
+		writeProcess("4242", "/var/vcap/packages/mapfs/bin/mapfs", "-uid", "1001", "-gid", "2001", "/var/vcap/data/volumes/nfs/vol1", "/var/vcap/data/volumes/nfs/vol1_mapfs")
+		Expect(os.MkdirAll(filepath.Join(procPath, "self"), 0755)).To(Succeed())
+
+		fakeDriver = &nfsdriverfakes.FakeVolumeLister{}
+		fakeDriver.ListReturns(dockerdriver.ListResponse{Volumes: []dockerdriver.VolumeInfo{
+			{Name: "vol2", Mountpoint: "/var/vcap/data/volumes/nfs/vol2", MountCount: 1},
+			{Name: "vol1", Mountpoint: "/var/vcap/data/volumes/nfs/vol1", MountCount: 2},
+			{Name: "created"},
+		}})
+
+		now = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+		fakeTime = &nfsdriverfakes.FakeTime{}
+		fakeTime.NowReturns(now)
+
+		fakeTracker = &nfsdriverfakes.FakeMountTracker{}
+		fakeTracker.RecordStub = func(target string) (nfsv3driver.MountRecord, bool) {
+			if target == "/var/vcap/data/volumes/nfs/vol1" {
+				return nfsv3driver.MountRecord{MountedAt: now.Add(-time.Hour), LastCheckAt: now.Add(-time.Minute), LastCheckHealthy: true}, true
+			}
+			return nfsv3driver.MountRecord{}, false
+		}
+	})
+
+	JustBeforeEach(func() {
+		inspector := nfsv3driver.NewVolumeInspector(fakeDriver, fakeTracker, &ioutilshim.IoutilShim{}, fakeTime, procPath)
+		volumes, err = inspector.InspectVolumes(env)
+	})
+
+	It("describes each volume, ordered by name", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(volumes).To(Equal([]driveradmin.VolumeStatus{
+			{Name: "created"},
+			{
+				Name:         "vol1",
+				Source:       "nfs1.example.com:/export/a",
+				Server:       "nfs1.example.com",
+				Mountpoint:   "/var/vcap/data/volumes/nfs/vol1",
+				MountCount:   2,
+				UidMapping:   true,
+				Uid:          "1001",
+				Gid:          "2001",
+				MapfsPid:     4242,
+				MountedAt:    now.Add(-time.Hour),
+				AgeSeconds:   3600,
+				LastCheck:    &driveradmin.VolumeCheck{At: now.Add(-time.Minute), Healthy: true},
+				MountOptions: "rw,relatime,vers=3,rsize=1048576,hard,proto=tcp",
+			},
+			{
+				Name:         "vol2",
+				Source:       "nfs2.example.com:/export/with space",
+				Server:       "nfs2.example.com",
+				Mountpoint:   "/var/vcap/data/volumes/nfs/vol2",
+				MountCount:   1,
+				MountOptions: "ro,relatime,vers=3,actimeo=0",
+			},
+		}))
+	})
+
+	Context("when the mount table cannot be read", func() {
+		BeforeEach(func() {
+			Expect(os.Remove(filepath.Join(procPath, "mounts"))).To(Succeed())
+		})
+
+		It("fails", func() {
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,85 @@
+package nfsv3driver
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/dockerdriver"
+)
+
+//counterfeiter:generate -o nfsdriverfakes/fake_drainable_driver.go . DrainableDriver
+
+// DrainableDriver is a volume driver that can unmount all of its volumes, as volumedriver.VolumeDriver does
+type DrainableDriver interface {
+	dockerdriver.Driver
+	Drain(env dockerdriver.Env) error
+}
+
+type volumeLock struct {
+	sync.Mutex
+	users int
+}
+
+// VolumeLocks serialise the changes to each volume. A volume's lock is forgotten once nobody holds or waits for it.
+type VolumeLocks struct {
+	lock    sync.Mutex
+	volumes map[string]*volumeLock
+}
+
+func NewVolumeLocks() *VolumeLocks {
+	return &VolumeLocks{volumes: map[string]*volumeLock{}}
+}
+
+// Lock blocks until name is free and returns the function that frees it again
+func (l *VolumeLocks) Lock(name string) func() {
+	l.lock.Lock()
+	volume, ok := l.volumes[name]
+	if !ok {
+		volume = &volumeLock{}
+		l.volumes[name] = volume
+	}
+	volume.users++
+	l.lock.Unlock()
+
+	volume.Lock()
+	return func() {
+		volume.Unlock()
+
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		volume.users--
+		if volume.users == 0 {
+			delete(l.volumes, name)
+		}
+	}
+}
+
+type volumeLockingDriver struct {
+	DrainableDriver
+	locks *VolumeLocks
+}
+
+// NewVolumeLockingDriver holds a volume's lock while it is created, mounted, unmounted or removed, so that admin
+// operations that take the same lock do not interleave with requests from the rep
+func NewVolumeLockingDriver(driver DrainableDriver, locks *VolumeLocks) DrainableDriver {
+	return &volumeLockingDriver{DrainableDriver: driver, locks: locks}
+}
+
+func (d *volumeLockingDriver) Create(env dockerdriver.Env, createRequest dockerdriver.CreateRequest) dockerdriver.ErrorResponse {
+	defer d.locks.Lock(createRequest.Name)()
+	return d.DrainableDriver.Create(env, createRequest)
+}
+
+func (d *volumeLockingDriver) Mount(env dockerdriver.Env, mountRequest dockerdriver.MountRequest) dockerdriver.MountResponse {
+	defer d.locks.Lock(mountRequest.Name)()
+	return d.DrainableDriver.Mount(env, mountRequest)
+}
+
+func (d *volumeLockingDriver) Unmount(env dockerdriver.Env, unmountRequest dockerdriver.UnmountRequest) dockerdriver.ErrorResponse {
+	defer d.locks.Lock(unmountRequest.Name)()
+	return d.DrainableDriver.Unmount(env, unmountRequest)
+}
+
+func (d *volumeLockingDriver) Remove(env dockerdriver.Env, removeRequest dockerdriver.RemoveRequest) dockerdriver.ErrorResponse {
+	defer d.locks.Lock(removeRequest.Name)()
+	return d.DrainableDriver.Remove(env, removeRequest)
+}
@@ -0,0 +1,103 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("VolumeLocks", func() {
+	var locks *nfsv3driver.VolumeLocks
+
+	BeforeEach(func() {
+		locks = nfsv3driver.NewVolumeLocks()
+	})
+
+	It("keeps a volume locked until it is unlocked", func() {
+		unlock := locks.Lock("vol1")
+
+		locked := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			locks.Lock("vol1")()
+			close(locked)
+		}()
+		Consistently(locked, 100*time.Millisecond).ShouldNot(BeClosed())
+
+		unlock()
+		Eventually(locked).Should(BeClosed())
+	})
+
+	It("does not hold up other volumes", func() {
+		defer locks.Lock("vol1")()
+
+		locked := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			locks.Lock("vol2")()
+			close(locked)
+		}()
+		Eventually(locked).Should(BeClosed())
+	})
+
+	Describe("VolumeLockingDriver", func() {
+		var (
+			env        dockerdriver.Env
+			fakeDriver *nfsdriverfakes.FakeDrainableDriver
+			driver     nfsv3driver.DrainableDriver
+		)
+
+		BeforeEach(func() {
+			env = driverhttp.NewHttpDriverEnv(lagertest.NewTestLogger("volume-locking-driver"), context.TODO())
+			fakeDriver = &nfsdriverfakes.FakeDrainableDriver{}
+			driver = nfsv3driver.NewVolumeLockingDriver(fakeDriver, locks)
+		})
+
+		It("mounts a volume while holding its lock", func() {
+			unlock := locks.Lock("vol1")
+
+			mounted := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				driver.Mount(env, dockerdriver.MountRequest{Name: "vol1"})
+				close(mounted)
+			}()
+			Consistently(fakeDriver.MountCallCount, 100*time.Millisecond).Should(Equal(0))
+
+			unlock()
+			Eventually(mounted).Should(BeClosed())
+			Expect(fakeDriver.MountCallCount()).To(Equal(1))
+		})
+
+		It("unmounts a volume while holding its lock", func() {
+			unlock := locks.Lock("vol1")
+
+			unmounted := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				driver.Unmount(env, dockerdriver.UnmountRequest{Name: "vol1"})
+				close(unmounted)
+			}()
+			Consistently(fakeDriver.UnmountCallCount, 100*time.Millisecond).Should(Equal(0))
+
+			unlock()
+			Eventually(unmounted).Should(BeClosed())
+		})
+
+		It("passes other calls straight through", func() {
+			defer locks.Lock("vol1")()
+
+			driver.List(env)
+			Expect(driver.Drain(env)).To(Succeed())
+			Expect(fakeDriver.ListCallCount()).To(Equal(1))
+			Expect(fakeDriver.DrainCallCount()).To(Equal(1))
+		})
+	})
+})
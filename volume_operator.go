@@ -0,0 +1,114 @@
+package nfsv3driver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"syscall"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/ioutilshim"
+	"code.cloudfoundry.org/goshims/syscallshim"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+	"code.cloudfoundry.org/volumedriver"
+)
+
+type volumeOperator struct {
+	driver   dockerdriver.Driver
+	locks    *VolumeLocks
+	mounter  volumedriver.Mounter
+	ioutil   ioutilshim.Ioutil
+	syscall  syscallshim.Syscall
+	procPath string
+}
+
+// NewVolumeOperator acts on single volumes of driver while holding their locks, so driver must not take the same
+// locks itself
+func NewVolumeOperator(driver dockerdriver.Driver, locks *VolumeLocks, mounter volumedriver.Mounter, ioutil ioutilshim.Ioutil, syscall syscallshim.Syscall, procPath string) driveradmin.VolumeOperator {
+	return &volumeOperator{driver: driver, locks: locks, mounter: mounter, ioutil: ioutil, syscall: syscall, procPath: procPath}
+}
+
+// ForceUnmount kills the volume's mapfs and removes the volume, which lazily detaches its mounts
+func (o *volumeOperator) ForceUnmount(env dockerdriver.Env, name string) error {
+	logger := env.Logger().Session("force-unmount", lager.Data{"volume": name})
+	logger.Info("start")
+	defer logger.Info("end")
+	env = driverhttp.EnvWithLogger(logger, env)
+
+	defer o.locks.Lock(name)()
+
+	volume, err := o.mountedVolume(env, name)
+	if err != nil {
+		return err
+	}
+	o.killMapfs(logger, volume.Mountpoint)
+
+	if response := o.driver.Remove(env, dockerdriver.RemoveRequest{Name: name}); response.Err != "" {
+		logger.Error("failed-removing", errors.New(response.Err))
+		return errors.New(response.Err)
+	}
+	return nil
+}
+
+// Remount detaches the volume and mounts it again in place with the options the driver stored for it. The volume
+// keeps its reference count: the driver remounts a volume that is no longer mounted when it is mounted again, so
+// the volume is mounted and then unmounted once.
+func (o *volumeOperator) Remount(env dockerdriver.Env, name string) error {
+	logger := env.Logger().Session("remount", lager.Data{"volume": name})
+	logger.Info("start")
+	defer logger.Info("end")
+	env = driverhttp.EnvWithLogger(logger, env)
+
+	defer o.locks.Lock(name)()
+
+	volume, err := o.mountedVolume(env, name)
+	if err != nil {
+		return err
+	}
+	o.killMapfs(logger, volume.Mountpoint)
+
+	// a share that has gone bad may already be detached
+	if err := o.mounter.Unmount(env, volume.Mountpoint); err != nil {
+		logger.Info("detach-failed", lager.Data{"err": err.Error()})
+	}
+
+	mountResponse := o.driver.Mount(env, dockerdriver.MountRequest{Name: name})
+	unmountResponse := o.driver.Unmount(env, dockerdriver.UnmountRequest{Name: name})
+	if mountResponse.Err != "" {
+		logger.Error("failed-mounting", errors.New(mountResponse.Err))
+		return errors.New(mountResponse.Err)
+	}
+	if unmountResponse.Err != "" {
+		logger.Error("failed-restoring-mount-count", errors.New(unmountResponse.Err))
+		return errors.New(unmountResponse.Err)
+	}
+	return nil
+}
+
+func (o *volumeOperator) mountedVolume(env dockerdriver.Env, name string) (dockerdriver.VolumeInfo, error) {
+	// Get does not report the mount count
+	for _, volume := range o.driver.List(env).Volumes {
+		if volume.Name != name {
+			continue
+		}
+		if volume.Mountpoint == "" || volume.MountCount < 1 {
+			return dockerdriver.VolumeInfo{}, fmt.Errorf("volume %s is not mounted", name)
+		}
+		return volume, nil
+	}
+	return dockerdriver.VolumeInfo{}, fmt.Errorf("volume %s not found", name)
+}
+
+func (o *volumeOperator) killMapfs(logger lager.Logger, mountpoint string) {
+	process, ok := findMapfsProcesses(logger, o.ioutil, o.procPath)[strings.TrimSuffix(mountpoint, "/")]
+	if !ok {
+		return
+	}
+
+	logger.Info("killing-mapfs", lager.Data{"pid": process.pid})
+	if err := o.syscall.Kill(process.pid, syscall.SIGKILL); err != nil {
+		logger.Error("failed-killing-mapfs", err, lager.Data{"pid": process.pid})
+	}
+}
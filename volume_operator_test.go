@@ -0,0 +1,203 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/filepathshim"
+	"code.cloudfoundry.org/goshims/ioutilshim"
+	"code.cloudfoundry.org/goshims/osshim"
+	"code.cloudfoundry.org/goshims/osshim/os_fake"
+	"code.cloudfoundry.org/goshims/syscallshim/syscall_fake"
+	"code.cloudfoundry.org/goshims/timeshim"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
+	"code.cloudfoundry.org/volumedriver"
+	"code.cloudfoundry.org/volumedriver/invoker"
+	"code.cloudfoundry.org/volumedriver/invokerfakes"
+	"code.cloudfoundry.org/volumedriver/oshelper"
+	"code.cloudfoundry.org/volumedriver/volumedriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("VolumeOperator", func() {
+	var (
+		env         dockerdriver.Env
+		procPath    string
+		fakeDriver  *nfsdriverfakes.FakeDrainableDriver
+		fakeMounter *volumedriverfakes.FakeMounter
+		fakeSyscall *syscall_fake.FakeSyscall
+		operator    driveradmin.VolumeOperator
+	)
+
+	BeforeEach(func() {
+		env = driverhttp.NewHttpDriverEnv(lagertest.NewTestLogger("volume-operator"), context.TODO())
+
+		procPath = GinkgoT().TempDir()
+		Expect(os.MkdirAll(filepath.Join(procPath, "4242"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(procPath, "4242", "cmdline"), []byte(strings.Join([]string{
+			"/var/vcap/packages/mapfs/bin/mapfs", "-uid", "1001", "-gid", "2001", "/var/vcap/data/volumes/nfs/vol1", "/var/vcap/data/volumes/nfs/vol1_mapfs",
+		}, "\x00")+"\x00"), 0644)).To(Succeed())
+
+		fakeDriver = &nfsdriverfakes.FakeDrainableDriver{}
+		fakeDriver.ListReturns(dockerdriver.ListResponse{Volumes: []dockerdriver.VolumeInfo{
+			{Name: "vol1", Mountpoint: "/var/vcap/data/volumes/nfs/vol1", MountCount: 2},
+			{Name: "created"},
+		}})
+		fakeMounter = &volumedriverfakes.FakeMounter{}
+		fakeSyscall = &syscall_fake.FakeSyscall{}
+
+		operator = nfsv3driver.NewVolumeOperator(fakeDriver, nfsv3driver.NewVolumeLocks(), fakeMounter, &ioutilshim.IoutilShim{}, fakeSyscall, procPath)
+	})
+
+	Describe("Remount", func() {
+		It("kills mapfs, detaches the volume and mounts it again without changing its mount count", func() {
+			Expect(operator.Remount(env, "vol1")).To(Succeed())
+
+			Expect(fakeSyscall.KillCallCount()).To(Equal(1))
+			pid, signal := fakeSyscall.KillArgsForCall(0)
+			Expect(pid).To(Equal(4242))
+			Expect(signal).To(Equal(syscall.SIGKILL))
+
+			_, target := fakeMounter.UnmountArgsForCall(0)
+			Expect(target).To(Equal("/var/vcap/data/volumes/nfs/vol1"))
+
+			Expect(fakeDriver.MountCallCount()).To(Equal(1))
+			_, mountRequest := fakeDriver.MountArgsForCall(0)
+			Expect(mountRequest.Name).To(Equal("vol1"))
+			Expect(fakeDriver.UnmountCallCount()).To(Equal(1))
+		})
+
+		It("mounts again when the volume was already detached", func() {
+			fakeMounter.UnmountReturns(errors.New("not mounted"))
+			Expect(operator.Remount(env, "vol1")).To(Succeed())
+			Expect(fakeDriver.MountCallCount()).To(Equal(1))
+		})
+
+		Context("when mounting again fails", func() {
+			BeforeEach(func() {
+				fakeDriver.MountReturns(dockerdriver.MountResponse{Err: "mount failed"})
+			})
+
+			It("fails", func() {
+				Expect(operator.Remount(env, "vol1")).To(MatchError("mount failed"))
+			})
+		})
+
+		Context("when the volume is mapped by an identity token", func() {
+			var (
+				fakeTokenResolver *nfsdriverfakes.FakeTokenResolver
+				fakeInvoker       *invokerfakes.FakeInvoker
+			)
+
+			BeforeEach(func() {
+				// like the replay check, the resolver accepts the token only once
+				fakeTokenResolver = &nfsdriverfakes.FakeTokenResolver{}
+				fakeTokenResolver.ResolveTokenReturnsOnCall(0, "1001", "2001", nil)
+				fakeTokenResolver.ResolveTokenReturns("", "", dockerdriver.SafeError{SafeDescription: nfsv3driver.InvalidTokenErrorMessage})
+
+				fakeInvoker = &invokerfakes.FakeInvoker{}
+				fakeInvoker.InvokeStub = func(_ dockerdriver.Env, cmd string, _ []string, _ ...string) invoker.InvokeResult {
+					result := &invokerfakes.FakeInvokeResult{}
+					if cmd == "mountpoint" {
+						// the detached volume is no longer a mountpoint
+						result.WaitReturns(errors.New("exit status 32"))
+					}
+					return result
+				}
+				fakeOs := &os_fake.FakeOs{}
+				fakeOs.StatReturns(nil, errors.New("not found"))
+				fakeSyscall.StatReturns(errors.New("permission denied"))
+				mask, err := nfsv3driver.NewMapFsVolumeMountMask()
+				Expect(err).NotTo(HaveOccurred())
+
+				secrets := nfsv3driver.NewVolumeSecrets()
+				mapfsMounter := nfsv3driver.NewMapfsMounter(fakeInvoker, fakeOs, fakeSyscall, &ioutilshim.IoutilShim{}, &volumedriverfakes.FakeMountChecker{}, "nfs", "vers=3", nil, fakeTokenResolver, secrets, nil, nfsv3driver.IdPolicy{}, mask, "/var/vcap/packages/mapfs/bin/mapfs")
+				mounter := nfsv3driver.NewSecretInjectingMounter(mapfsMounter, secrets)
+				driver := nfsv3driver.NewSecretKeepingDriver(volumedriver.NewVolumeDriver(
+					lagertest.NewTestLogger("volume-driver"),
+					&osshim.OsShim{},
+					&filepathshim.FilepathShim{},
+					&ioutilshim.IoutilShim{},
+					&timeshim.TimeShim{},
+					&volumedriverfakes.FakeMountChecker{},
+					GinkgoT().TempDir(),
+					mounter,
+					oshelper.NewOsHelper(),
+				), secrets)
+				operator = nfsv3driver.NewVolumeOperator(driver, nfsv3driver.NewVolumeLocks(), mounter, &ioutilshim.IoutilShim{}, fakeSyscall, procPath)
+
+				Expect(driver.Create(env, dockerdriver.CreateRequest{Name: "vol1", Opts: map[string]interface{}{
+					"source": "nfs://nfs.example.com/export",
+					"token":  "header.claims.signature",
+				}}).Err).To(BeEmpty())
+				Expect(driver.Mount(env, dockerdriver.MountRequest{Name: "vol1"}).Err).To(BeEmpty())
+			})
+
+			It("mounts it again with the ids its token resolved to", func() {
+				Expect(operator.Remount(env, "vol1")).To(Succeed())
+				Expect(fakeTokenResolver.ResolveTokenCallCount()).To(Equal(1))
+
+				var mapfsArgs []string
+				for i := 0; i < fakeInvoker.InvokeCallCount(); i++ {
+					_, cmd, args, _ := fakeInvoker.InvokeArgsForCall(i)
+					if cmd == "/var/vcap/packages/mapfs/bin/mapfs" {
+						mapfsArgs = append(mapfsArgs, strings.Join(args, " "))
+					}
+				}
+				Expect(mapfsArgs).To(HaveLen(2))
+				Expect(mapfsArgs[1]).To(ContainSubstring("-uid 1001 -gid 2001"))
+			})
+		})
+
+		Context("when the volume is not mounted", func() {
+			It("fails without touching it", func() {
+				Expect(operator.Remount(env, "created")).To(MatchError("volume created is not mounted"))
+				Expect(fakeMounter.UnmountCallCount()).To(Equal(0))
+				Expect(fakeDriver.MountCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the volume does not exist", func() {
+			It("fails", func() {
+				Expect(operator.Remount(env, "missing")).To(MatchError("volume missing not found"))
+			})
+		})
+	})
+
+	Describe("ForceUnmount", func() {
+		It("kills mapfs and removes the volume", func() {
+			Expect(operator.ForceUnmount(env, "vol1")).To(Succeed())
+
+			Expect(fakeSyscall.KillCallCount()).To(Equal(1))
+			_, removeRequest := fakeDriver.RemoveArgsForCall(0)
+			Expect(removeRequest.Name).To(Equal("vol1"))
+		})
+
+		Context("when removing the volume fails", func() {
+			BeforeEach(func() {
+				fakeDriver.RemoveReturns(dockerdriver.ErrorResponse{Err: "device busy"})
+			})
+
+			It("fails", func() {
+				Expect(operator.ForceUnmount(env, "vol1")).To(MatchError("device busy"))
+			})
+		})
+
+		Context("when the volume does not exist", func() {
+			It("fails without removing anything", func() {
+				Expect(operator.ForceUnmount(env, "missing")).To(MatchError("volume missing not found"))
+				Expect(fakeDriver.RemoveCallCount()).To(Equal(0))
+			})
+		})
+	})
+})